@@ -2,37 +2,279 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
 
+	"github.com/rubiojr/dsg/internal/log"
+	storage "github.com/rubiojr/dsg/internal/storage/sqlite"
 	"github.com/sashabaranov/go-openai"
 )
 
-func sendOpenAIRequest(client *openai.Client, model, prompt string) (string, error) {
-	ctx := context.Background()
+// generationRequest contains everything sent to the model to generate datasets
+type generationRequest struct {
+	Model        string
+	SystemPrompt string
+	Examples     []fewShotExample
+	Prompt       string
+	// Seed makes generations (best-effort) deterministic when set
+	Seed *int
+	// MaxRepairs is the number of times the model is asked to fix an invalid JSON response
+	MaxRepairs int
+	// StrictSchema constrains the response to the datasets JSON Schema
+	StrictSchema bool
+	// Out receives the warnings, stderr if nil
+	Out io.Writer
+}
+
+// output returns the writer of the request warnings
+func (gr generationRequest) output() io.Writer {
+	if gr.Out == nil {
+		return os.Stderr
+	}
+	return gr.Out
+}
+
+// maxCompletionTokens is the maximum number of tokens the model may generate
+const maxCompletionTokens = 8192
+
+// modelContextWindows are the context window sizes, in tokens, of well known
+// models. Versioned model names match their base name prefix.
+var modelContextWindows = map[string]int{
+	"gpt-4.1":       1047576,
+	"gpt-4o":        128000,
+	"gpt-4-turbo":   128000,
+	"gpt-4":         8192,
+	"gpt-3.5-turbo": 16385,
+	"o1":            200000,
+	"o3":            200000,
+	"o4-mini":       200000,
+}
+
+// contextWindow returns the context window of model, or 0 if unknown
+func contextWindow(model string) int {
+	// Longest prefix wins, so gpt-4o isn't mistaken for gpt-4
+	var best string
+	for name := range modelContextWindows {
+		if strings.HasPrefix(model, name) && len(name) > len(best) {
+			best = name
+		}
+	}
+	return modelContextWindows[best]
+}
+
+// checkContextWindow estimates the tokens sent in gr and fails if they
+// don't fit in window. It warns if there may be no room left for the
+// response. A window of 0 disables the check.
+func checkContextWindow(gr generationRequest, window int) error {
+	if window <= 0 {
+		return nil
+	}
+
+	var tokens int
+	for _, m := range buildMessages(gr) {
+		tokens += estimateTokens(m.Content)
+	}
+
+	if tokens > window {
+		return usagef("the prompt is ~%d tokens, more than the %d tokens context window of %s: shorten the input, use fewer --few-shot examples or a model with a larger context", tokens, window, gr.Model)
+	}
+	if tokens+maxCompletionTokens > window {
+		fmt.Fprintf(gr.output(), "Warning: the prompt is ~%d tokens, the response may not fit in the %d tokens context window of %s\n", tokens, window, gr.Model)
+	}
+	return nil
+}
+
+// fewShotExample is a previous prompt/response pair sent as an example
+type fewShotExample struct {
+	Prompt   string
+	Response string
+}
+
+// sendOpenAIRequest sends the generation request and returns the JSON array
+// generated by the model and the number of tokens used. If the response isn't
+// a valid JSON array, the model is asked to correct it up to gr.MaxRepairs times.
+func sendOpenAIRequest(ctx context.Context, client *openai.Client, gr generationRequest) (string, int, error) {
+	var format *openai.ChatCompletionResponseFormat
+	if gr.StrictSchema {
+		format = datasetsResponseFormat()
+	}
+	complete := func(messages []openai.ChatCompletionMessage) (string, int, error) {
+		content, tokens, err := createChatCompletion(ctx, client, gr.Model, gr.Seed, format, messages)
+		if format != nil && isResponseFormatUnsupported(err) {
+			fmt.Fprintf(gr.output(), "Warning: model %s doesn't support structured outputs (%v), generating without the schema\n", gr.Model, err)
+			format = nil
+			return createChatCompletion(ctx, client, gr.Model, gr.Seed, nil, messages)
+		}
+		return content, tokens, err
+	}
+	return generateJSON(complete, gr)
+}
+
+// generateJSON drives the generation and JSON repair loop using complete
+func generateJSON(complete func([]openai.ChatCompletionMessage) (string, int, error), gr generationRequest) (string, int, error) {
+	messages := buildMessages(gr)
+
+	var tokens int
+	for attempt := 0; ; attempt++ {
+		content, used, err := complete(messages)
+		if err != nil {
+			return "", tokens, err
+		}
+		tokens += used
+		if gr.StrictSchema {
+			content = unwrapDatasets(content)
+		}
+
+		// Models sometimes wrap the JSON in code fences or prose
+		extracted, err := extractJSON(content)
+		if err == nil {
+			var entities []json.RawMessage
+			err = json.Unmarshal([]byte(extracted), &entities)
+			if err == nil {
+				if extracted != content {
+					log.Debug("Extracted the JSON from the model response")
+				}
+				return extracted, tokens, nil
+			}
+		}
+
+		if attempt >= gr.MaxRepairs {
+			return "", tokens, fmt.Errorf("invalid JSON response after %d repair attempts: %w", attempt, err)
+		}
 
+		log.Debugf("Invalid JSON response (%v), asking the model to repair it (attempt %d)\n", err, attempt+1)
+		messages = append(messages,
+			openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleAssistant,
+				Content: content,
+			},
+			openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleUser,
+				Content: fmt.Sprintf("Your previous output was invalid JSON: %v. Return the corrected JSON array only.", err),
+			},
+		)
+	}
+}
+
+func buildMessages(gr generationRequest) []openai.ChatCompletionMessage {
+	var messages []openai.ChatCompletionMessage
+	if gr.SystemPrompt != "" {
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: gr.SystemPrompt,
+		})
+	}
+	for _, ex := range gr.Examples {
+		messages = append(messages,
+			openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleUser,
+				Content: ex.Prompt,
+			},
+			openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleAssistant,
+				Content: ex.Response,
+			},
+		)
+	}
+	messages = append(messages, openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleUser,
+		Content: gr.Prompt,
+	})
+
+	return messages
+}
+
+// createChatCompletion returns the content of the first choice and the
+// total number of tokens used by the request
+func createChatCompletion(ctx context.Context, client *openai.Client, model string, seed *int, format *openai.ChatCompletionResponseFormat, messages []openai.ChatCompletionMessage) (string, int, error) {
 	// Create chat completion request
 	resp, err := client.CreateChatCompletion(
 		ctx,
 		openai.ChatCompletionRequest{
-			Model: model,
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: prompt,
-				},
-			},
-			Temperature: 0.2, // Lower temperature for more deterministic output
-			MaxTokens:   8192,
+			Model:          model,
+			Messages:       messages,
+			Temperature:    0.2, // Lower temperature for more deterministic output
+			MaxTokens:      maxCompletionTokens,
+			Seed:           seed,
+			ResponseFormat: format,
 		},
 	)
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 
 	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("no response choices from OpenAI")
+		return "", resp.Usage.TotalTokens, fmt.Errorf("no response choices from OpenAI")
 	}
 
 	// Extract the response content
-	return resp.Choices[0].Message.Content, nil
+	return resp.Choices[0].Message.Content, resp.Usage.TotalTokens, nil
+}
+
+// modelUnavailableStatuses are the OpenAI API statuses telling that the
+// model can't serve the request, so another model may be tried
+var modelUnavailableStatuses = []int{
+	http.StatusNotFound,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+}
+
+// isModelUnavailable returns true if err tells that the model doesn't exist,
+// is overloaded or rate limited
+func isModelUnavailable(err error) bool {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == "model_not_found" || slices.Contains(modelUnavailableStatuses, apiErr.HTTPStatusCode)
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return slices.Contains(modelUnavailableStatuses, reqErr.HTTPStatusCode)
+	}
+	return false
+}
+
+// estimateTokens roughly estimates the number of tokens in s
+func estimateTokens(s string) int {
+	// ~4 characters per token for English text and JSON
+	return (len(s) + 3) / 4
+}
+
+// selectFewShotExamples picks up to n posted responses as examples, skipping
+// the ones that would make the examples exceed maxTokens. Examples pair the
+// description the user wrote with the response, not the RenderedPrompt: the
+// rendered prompt repeats the system prompt and the reference schema, which
+// would use most of the budget, and the new request already carries the
+// current template, prefix and suffix.
+func selectFewShotExamples(responses []*storage.Response, n, maxTokens int) []fewShotExample {
+	var examples []fewShotExample
+	budget := maxTokens
+	for _, resp := range responses {
+		if len(examples) >= n {
+			break
+		}
+		if resp.Status != storage.StatusPosted {
+			continue
+		}
+		tokens := estimateTokens(resp.Prompt) + estimateTokens(resp.Response)
+		if tokens > budget {
+			continue
+		}
+		budget -= tokens
+		examples = append(examples, fewShotExample{Prompt: resp.Prompt, Response: resp.Response})
+	}
+
+	// Oldest first, so the most recent example is the closest to the request
+	for i, j := 0, len(examples)-1; i < j; i, j = i+1, j-1 {
+		examples[i], examples[j] = examples[j], examples[i]
+	}
+
+	return examples
 }
@@ -0,0 +1,410 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	storage "github.com/rubiojr/dsg/internal/storage/sqlite"
+	"github.com/sashabaranov/go-openai"
+)
+
+// openAIStub stubs the OpenAI chat completions endpoint, answering every
+// request with the content returned by reply. It returns the API base URL
+// and the requests received.
+func openAIStub(t *testing.T, reply func(req openai.ChatCompletionRequest) string) (string, *[]openai.ChatCompletionRequest) {
+	var mu sync.Mutex
+	var requests []openai.ChatCompletionRequest
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("error decoding the chat completion request: %v", err)
+			return
+		}
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+
+		json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{
+				Message: openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: reply(req)},
+			}},
+			Usage: openai.Usage{TotalTokens: 10},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv.URL + "/v1", &requests
+}
+
+// openAIServer returns a client of an openAIStub
+func openAIServer(t *testing.T, reply func(req openai.ChatCompletionRequest) string) (*openai.Client, *[]openai.ChatCompletionRequest) {
+	apiBase, requests := openAIStub(t, reply)
+	config := openai.DefaultConfig("test")
+	config.BaseURL = apiBase
+	return openai.NewClientWithConfig(config), requests
+}
+
+// lastMessage returns the content of the last message of req
+func lastMessage(req openai.ChatCompletionRequest) string {
+	return req.Messages[len(req.Messages)-1].Content
+}
+
+func TestSystemAndUserMessages(t *testing.T) {
+	client, requests := openAIServer(t, func(openai.ChatCompletionRequest) string { return `[{"urn":"x"}]` })
+
+	content, tokens, err := sendOpenAIRequest(context.Background(), client, generationRequest{
+		Model:        "m",
+		SystemPrompt: "system instructions",
+		Prompt:       "user request",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != `[{"urn":"x"}]` || tokens != 10 {
+		t.Errorf("content = %q, tokens = %d", content, tokens)
+	}
+
+	if len(*requests) != 1 {
+		t.Fatalf("sent %d requests, want 1", len(*requests))
+	}
+	messages := (*requests)[0].Messages
+	if len(messages) != 2 {
+		t.Fatalf("sent %d messages, want 2", len(messages))
+	}
+	if messages[0].Role != openai.ChatMessageRoleSystem || messages[0].Content != "system instructions" {
+		t.Errorf("first message = %+v", messages[0])
+	}
+	if messages[1].Role != openai.ChatMessageRoleUser || messages[1].Content != "user request" {
+		t.Errorf("second message = %+v", messages[1])
+	}
+}
+
+func TestCombinedPrompt(t *testing.T) {
+	if got := combinedPrompt("system", "user"); got != "system\n\nuser" {
+		t.Errorf("combinedPrompt = %q", got)
+	}
+	if got := combinedPrompt("", "user"); got != "user" {
+		t.Errorf("combinedPrompt without system prompt = %q", got)
+	}
+}
+
+func TestSelectFewShotExamples(t *testing.T) {
+	// Newest first, as listed by the history store
+	responses := []*storage.Response{
+		{ID: 6, Prompt: "p6", Response: "r6", Status: storage.StatusPosted},
+		{ID: 5, Prompt: "p5", Response: "r5", Status: storage.StatusGenerated},
+		{ID: 4, Prompt: "p4", Response: strings.Repeat("x", 400), Status: storage.StatusPosted},
+		{ID: 3, Prompt: "p3", Response: "r3", Status: storage.StatusFailed},
+		{ID: 2, Prompt: "p2", Response: "r2", Status: storage.StatusPosted},
+		{ID: 1, Prompt: "p1", Response: "r1", Status: storage.StatusPosted},
+	}
+
+	prompts := func(examples []fewShotExample) []string {
+		var p []string
+		for _, ex := range examples {
+			p = append(p, ex.Prompt)
+		}
+		return p
+	}
+
+	tests := []struct {
+		n, maxTokens int
+		want         []string
+	}{
+		// Only posted responses, oldest first
+		{n: 10, maxTokens: 1000, want: []string{"p1", "p2", "p4", "p6"}},
+		// Up to n examples
+		{n: 2, maxTokens: 1000, want: []string{"p4", "p6"}},
+		// Examples exceeding the budget are skipped
+		{n: 3, maxTokens: 50, want: []string{"p1", "p2", "p6"}},
+		{n: 3, maxTokens: 4, want: []string{"p2", "p6"}},
+		{n: 0, maxTokens: 1000, want: nil},
+	}
+	for _, tt := range tests {
+		got := prompts(selectFewShotExamples(responses, tt.n, tt.maxTokens))
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("selectFewShotExamples(n=%d, maxTokens=%d) = %v, want %v", tt.n, tt.maxTokens, got, tt.want)
+		}
+	}
+
+	examples := selectFewShotExamples(responses, 1, 1000)
+	if len(examples) != 1 || examples[0].Response != "r6" {
+		t.Errorf("examples = %+v", examples)
+	}
+}
+
+func TestFewShotMessages(t *testing.T) {
+	messages := buildMessages(generationRequest{
+		SystemPrompt: "system",
+		Examples:     []fewShotExample{{Prompt: "p1", Response: "r1"}},
+		Prompt:       "request",
+	})
+
+	want := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: "system"},
+		{Role: openai.ChatMessageRoleUser, Content: "p1"},
+		{Role: openai.ChatMessageRoleAssistant, Content: "r1"},
+		{Role: openai.ChatMessageRoleUser, Content: "request"},
+	}
+	if !slices.EqualFunc(messages, want, func(a, b openai.ChatCompletionMessage) bool {
+		return a.Role == b.Role && a.Content == b.Content
+	}) {
+		t.Errorf("messages = %+v", messages)
+	}
+}
+
+func TestGenerateJSONRepair(t *testing.T) {
+	replies := []string{`[{"urn": "x",}]`, "not json", `[{"urn":"x"}]`}
+	var sent [][]openai.ChatCompletionMessage
+	complete := func(messages []openai.ChatCompletionMessage) (string, int, error) {
+		sent = append(sent, messages)
+		return replies[len(sent)-1], 5, nil
+	}
+
+	content, tokens, err := generateJSON(complete, generationRequest{Prompt: "request", MaxRepairs: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != `[{"urn":"x"}]` || tokens != 15 {
+		t.Errorf("content = %q, tokens = %d", content, tokens)
+	}
+	if len(sent) != 3 {
+		t.Fatalf("sent %d completions, want 3", len(sent))
+	}
+
+	// The invalid output and the repair instructions follow the request
+	repair := sent[1]
+	if len(repair) != 3 || repair[1].Role != openai.ChatMessageRoleAssistant || repair[1].Content != replies[0] {
+		t.Fatalf("repair messages = %+v", repair)
+	}
+	if repair[2].Role != openai.ChatMessageRoleUser || !strings.Contains(repair[2].Content, "invalid JSON") {
+		t.Errorf("repair request = %+v", repair[2])
+	}
+	if len(sent[2]) != 5 {
+		t.Errorf("second repair sent %d messages, want 5", len(sent[2]))
+	}
+}
+
+func TestGenerateJSONRepairLimit(t *testing.T) {
+	calls := 0
+	complete := func([]openai.ChatCompletionMessage) (string, int, error) {
+		calls++
+		return "not json", 1, nil
+	}
+
+	if _, _, err := generateJSON(complete, generationRequest{Prompt: "request", MaxRepairs: 1}); err == nil {
+		t.Fatal("expected an error for an invalid response")
+	}
+	if calls != 2 {
+		t.Errorf("sent %d completions, want 2", calls)
+	}
+}
+
+func TestSeed(t *testing.T) {
+	client, requests := openAIServer(t, func(openai.ChatCompletionRequest) string { return `[]` })
+
+	seed := 42
+	for _, s := range []*int{&seed, nil} {
+		if _, _, err := sendOpenAIRequest(context.Background(), client, generationRequest{Model: "m", Prompt: "p", Seed: s}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := (*requests)[0].Seed; got == nil || *got != 42 {
+		t.Errorf("seed = %v, want 42", got)
+	}
+	if got := (*requests)[1].Seed; got != nil {
+		t.Errorf("seed = %d, want none", *got)
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	tests := map[string]int{
+		"":                        0,
+		"a":                       1,
+		"four":                    1,
+		"users":                   2,
+		"a users table":           4,
+		`{"urn": "x"}`:            3,
+		strings.Repeat("x", 4000): 1000,
+	}
+	for s, want := range tests {
+		if got := estimateTokens(s); got != want {
+			t.Errorf("estimateTokens(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
+
+func TestContextWindow(t *testing.T) {
+	tests := map[string]int{
+		"gpt-4o":            128000,
+		"gpt-4o-2024-08-06": 128000,
+		"gpt-4o-mini":       128000,
+		"gpt-4":             8192,
+		"gpt-4-0613":        8192,
+		"gpt-4-turbo":       128000,
+		"gpt-4.1-mini":      1047576,
+		"o3-mini":           200000,
+		"llama3":            0,
+	}
+	for model, want := range tests {
+		if got := contextWindow(model); got != want {
+			t.Errorf("contextWindow(%q) = %d, want %d", model, got, want)
+		}
+	}
+}
+
+func TestCheckContextWindow(t *testing.T) {
+	var out strings.Builder
+	// 400 tokens
+	gr := generationRequest{Model: "m", SystemPrompt: strings.Repeat("x", 800), Prompt: strings.Repeat("x", 800), Out: &out}
+
+	if err := checkContextWindow(gr, 399); exitCode(err) != exitUsage || !strings.Contains(err.Error(), "~400 tokens, more than the 399 tokens context window of m") {
+		t.Errorf("err = %v", err)
+	}
+
+	if err := checkContextWindow(gr, 400+maxCompletionTokens-1); err != nil || !strings.Contains(out.String(), "Warning: the prompt is ~400 tokens") {
+		t.Errorf("err = %v, output = %q", err, out.String())
+	}
+
+	out.Reset()
+	for _, window := range []int{400 + maxCompletionTokens, 0} {
+		if err := checkContextWindow(gr, window); err != nil || out.Len() > 0 {
+			t.Errorf("window %d: err = %v, output = %q", window, err, out.String())
+		}
+	}
+}
+
+func TestGenerateContextWindow(t *testing.T) {
+	testDataDir(t)
+	apiBase, requests := openAIStub(t, func(req openai.ChatCompletionRequest) string { return datasetJSON("alpha") })
+	withStdin(t, "alpha\n")
+
+	_, err := runApp(t, "generate", "--context-window", "100", "--api-key", "test", "--api-base", apiBase, "--model", "m")
+	if exitCode(err) != exitUsage || !strings.Contains(err.Error(), "context window") {
+		t.Errorf("err = %v, want a context window usage error", err)
+	}
+	if len(*requests) != 0 {
+		t.Errorf("sent %d requests with a prompt too long", len(*requests))
+	}
+}
+
+func TestIsModelUnavailable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&openai.APIError{Code: "model_not_found", HTTPStatusCode: http.StatusBadRequest}, true},
+		{&openai.APIError{HTTPStatusCode: http.StatusNotFound}, true},
+		{fmt.Errorf("wrapped: %w", &openai.APIError{HTTPStatusCode: http.StatusTooManyRequests}), true},
+		{&openai.APIError{HTTPStatusCode: http.StatusServiceUnavailable}, true},
+		{&openai.RequestError{HTTPStatusCode: http.StatusBadGateway}, true},
+		{&openai.APIError{HTTPStatusCode: http.StatusBadRequest}, false},
+		{&openai.APIError{HTTPStatusCode: http.StatusUnauthorized}, false},
+		{&openai.RequestError{HTTPStatusCode: http.StatusForbidden}, false},
+		{errors.New("connection refused"), false},
+	}
+	for _, tt := range tests {
+		if got := isModelUnavailable(tt.err); got != tt.want {
+			t.Errorf("isModelUnavailable(%v) = %t, want %t", tt.err, got, tt.want)
+		}
+	}
+}
+
+// fallbackServer answers the chat completions of the models in available
+// and fails the rest with status. It returns the API base URL and the
+// models requested.
+func fallbackServer(t *testing.T, status int, available ...string) (string, *[]string) {
+	var mu sync.Mutex
+	var models []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		models = append(models, req.Model)
+		mu.Unlock()
+
+		if !slices.Contains(available, req.Model) {
+			w.WriteHeader(status)
+			fmt.Fprintf(w, `{"error": {"message": "The model %s is not available", "type": "invalid_request_error", "code": "unavailable"}}`, req.Model)
+			return
+		}
+		json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{
+				Message: openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: datasetJSON(userInput(req))},
+			}},
+			Usage: openai.Usage{TotalTokens: 10},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL + "/v1", &models
+}
+
+func TestGenerateModelFallback(t *testing.T) {
+	dataDir := testDataDir(t)
+	dh := newDataHubStub(t)
+	apiBase, models := fallbackServer(t, http.StatusNotFound, "fallback")
+	withStdin(t, "alpha\n")
+
+	_, err := runApp(t, "generate",
+		"--api-key", "test",
+		"--api-base", apiBase,
+		"--model", "primary",
+		"--model-fallback", "missing,fallback,unused",
+		"--datahub-gms-url", dh.URL,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"primary", "missing", "fallback"}; !slices.Equal(*models, want) {
+		t.Errorf("requested models %v, want %v", *models, want)
+	}
+	if urns := dh.postedURNs(); !slices.Equal(urns, []string{datasetURN("alpha")}) {
+		t.Errorf("posted %v", urns)
+	}
+
+	db, err := storage.NewSQLiteStorage(storage.WithDataDir(dataDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	history, err := db.ListResponses(1, 0)
+	if err != nil || len(history) != 1 {
+		t.Fatalf("history = %v, err = %v", history, err)
+	}
+	if history[0].Model != "fallback" {
+		t.Errorf("history model = %q, want fallback", history[0].Model)
+	}
+}
+
+func TestGenerateModelFallbackErrors(t *testing.T) {
+	testDataDir(t)
+
+	// Only unavailable models fall back
+	apiBase, models := fallbackServer(t, http.StatusBadRequest, "fallback")
+	withStdin(t, "alpha\n")
+	_, err := runApp(t, "generate", "--api-key", "test", "--api-base", apiBase, "--model", "primary", "--model-fallback", "fallback")
+	if err == nil || !slices.Equal(*models, []string{"primary"}) {
+		t.Errorf("err = %v, requested models %v", err, *models)
+	}
+
+	// The last model error is returned
+	apiBase, models = fallbackServer(t, http.StatusNotFound)
+	withStdin(t, "alpha\n")
+	_, err = runApp(t, "generate", "--api-key", "test", "--api-base", apiBase, "--model", "primary", "--model-fallback", "fallback")
+	if err == nil || !strings.Contains(err.Error(), "The model fallback is not available") {
+		t.Errorf("err = %v", err)
+	}
+	if !slices.Equal(*models, []string{"primary", "fallback"}) {
+		t.Errorf("requested models %v", *models)
+	}
+}
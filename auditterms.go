@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+	"github.com/urfave/cli/v2"
+)
+
+// orphanedDataset is a dataset associated with glossary terms that don't
+// exist anymore
+type orphanedDataset struct {
+	URN   string
+	Terms []string
+}
+
+func runAuditTerms(c *cli.Context) error {
+	dh, err := newClientFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Every term is only checked once
+	exists := map[string]bool{}
+	var orphaned []orphanedDataset
+	scanned := 0
+	err = dh.GetDatasets(func(datasets []*datahub.Dataset) error {
+		for _, ds := range datasets {
+			scanned++
+			var missing []string
+			for _, urn := range datahub.ReferencedTerms([]datahub.Dataset{*ds}) {
+				found, checked := exists[urn]
+				if !checked {
+					if found, err = dh.GlossaryTermExists(urn); err != nil {
+						return err
+					}
+					exists[urn] = found
+				}
+				if !found {
+					missing = append(missing, urn)
+				}
+			}
+			if len(missing) > 0 {
+				orphaned = append(orphaned, orphanedDataset{URN: ds.URN, Terms: missing})
+			}
+		}
+		return nil
+	}, &datahub.ListOptions{Platform: platformURN(c.String("platform"))})
+	if err != nil {
+		return fmt.Errorf("error listing datasets: %w", err)
+	}
+
+	orphans := map[string]bool{}
+	for urn, found := range exists {
+		if !found {
+			orphans[urn] = true
+		}
+	}
+
+	fix := c.Bool("fix")
+	removed := 0
+	for _, ds := range orphaned {
+		for _, term := range ds.Terms {
+			fmt.Printf("%s: %s does not exist\n", ds.URN, term)
+		}
+		if !fix {
+			continue
+		}
+		n, err := dh.RemoveTermAssociations(ds.URN, orphans)
+		removed += n
+		if err != nil {
+			return fmt.Errorf("error removing the orphaned terms of %s: %w", ds.URN, err)
+		}
+		fmt.Printf("%s: %d associations removed\n", ds.URN, n)
+	}
+
+	fmt.Printf("\n%d datasets scanned, %d reference %d missing glossary terms\n", scanned, len(orphaned), len(orphans))
+	if fix {
+		fmt.Printf("%d associations removed\n", removed)
+	} else if len(orphaned) > 0 {
+		fmt.Println("Run with --fix to remove the associations")
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAuditTermsCommand(t *testing.T) {
+	users := datasetURN("users")
+	orders := datasetURN("orders")
+	usersEntity := `{
+  "urn": "` + users + `",
+  "glossaryTerms": {"value": {"terms": [{"urn": "urn:li:glossaryTerm:Email"}, {"urn": "urn:li:glossaryTerm:Deleted"}]}},
+  "schemaMetadata": {"value": {"schemaName": "users", "fields": [
+    {"fieldPath": "ssn", "nativeDataType": "varchar", "glossaryTerms": {"terms": [{"urn": "urn:li:glossaryTerm:Gone"}]}}
+  ]}}
+}`
+	ordersEntity := `{
+  "urn": "` + orders + `",
+  "glossaryTerms": {"value": {"terms": [{"urn": "urn:li:glossaryTerm:Email"}]}}
+}`
+
+	dh := newDataHubStub(t)
+	// The dataset listing is a GET of /openapi/v3/entity/dataset
+	dh.entities["dataset"] = `{"entities": [` + usersEntity + `,` + ordersEntity + `]}`
+	dh.entities[users] = usersEntity
+	dh.entities[orders] = ordersEntity
+	dh.entities["urn:li:glossaryTerm:Email"] = `{"urn": "urn:li:glossaryTerm:Email", "glossaryTermInfo": {"value": {"name": "Email"}}}`
+
+	out, err := runApp(t, "audit-terms", "--datahub-gms-url", dh.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		users + ": urn:li:glossaryTerm:Deleted does not exist",
+		users + ": urn:li:glossaryTerm:Gone does not exist",
+		"2 datasets scanned, 1 reference 2 missing glossary terms",
+		"Run with --fix",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output is missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Email does not exist") || strings.Contains(out, orders+":") {
+		t.Errorf("an existing term was reported:\n%s", out)
+	}
+	if len(dh.posted) != 0 {
+		t.Errorf("posted %d entities without --fix", len(dh.posted))
+	}
+
+	out, err = runApp(t, "audit-terms", "--datahub-gms-url", dh.URL, "--fix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, users+": 2 associations removed") {
+		t.Errorf("output is missing the removals:\n%s", out)
+	}
+	if urns := dh.postedURNs(); !slices.Equal(urns, []string{users, users}) {
+		t.Fatalf("posted %v", urns)
+	}
+
+	var terms struct {
+		Value struct {
+			Terms []struct {
+				URN string `json:"urn"`
+			} `json:"terms"`
+		} `json:"value"`
+	}
+	if err := json.Unmarshal(dh.posted[0]["glossaryTerms"], &terms); err != nil {
+		t.Fatal(err)
+	}
+	if len(terms.Value.Terms) != 1 || terms.Value.Terms[0].URN != "urn:li:glossaryTerm:Email" {
+		t.Errorf("posted terms %+v", terms.Value.Terms)
+	}
+	if fields := string(dh.posted[1]["schemaMetadata"]); strings.Contains(fields, "Gone") {
+		t.Errorf("the field association wasn't removed: %s", fields)
+	}
+}
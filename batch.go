@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+	"github.com/rubiojr/dsg/internal/log"
+	storage "github.com/rubiojr/dsg/internal/storage/sqlite"
+	"github.com/urfave/cli/v2"
+)
+
+// batchResult is the outcome of generating (and posting) a single batch prompt
+type batchResult struct {
+	HistoryID int64
+	URN       string
+	Err       error
+}
+
+// parsePrompts splits a prompts file in individual prompts.
+// If the file contains --- separator lines, each block is a prompt,
+// otherwise every non-empty line is a prompt.
+func parsePrompts(data string) []string {
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+
+	separated := false
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "---" {
+			separated = true
+			break
+		}
+	}
+
+	var prompts []string
+	if !separated {
+		for _, line := range lines {
+			if line = strings.TrimSpace(line); line != "" {
+				prompts = append(prompts, line)
+			}
+		}
+		return prompts
+	}
+
+	var block strings.Builder
+	flush := func() {
+		if p := strings.TrimSpace(block.String()); p != "" {
+			prompts = append(prompts, p)
+		}
+		block.Reset()
+	}
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "---" {
+			flush()
+			continue
+		}
+		block.WriteString(line + "\n")
+	}
+	flush()
+
+	return prompts
+}
+
+func runBatchGenerate(c *cli.Context) error {
+	data, err := os.ReadFile(c.String("prompts-file"))
+	if err != nil {
+		return fmt.Errorf("error reading prompts file: %w", err)
+	}
+
+	prompts := parsePrompts(string(data))
+	if len(prompts) == 0 {
+		return fmt.Errorf("no prompts found in %s", c.String("prompts-file"))
+	}
+
+	concurrency := c.Int("concurrency")
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	g, err := newGenerator(c)
+	if err != nil {
+		return err
+	}
+
+	var dh entityPoster
+	if !c.Bool("skip-post") {
+		dh, err = newPosterFromContext(c)
+		if err != nil {
+			return err
+		}
+		if err := ensureDatasetTags(c, g.defaults.Tags, os.Stdout); err != nil {
+			return err
+		}
+	}
+
+	db, err := storage.NewSQLiteStorage(storage.WithCompression(c.Bool("compress-history")))
+	if err != nil {
+		return fmt.Errorf("failed to initialize history database: %w", err)
+	}
+	defer db.Close()
+
+	batchID := fmt.Sprintf("batch-%d", time.Now().UnixMilli())
+	fmt.Printf("Generating %d prompts (batch %s)...\n", len(prompts), batchID)
+
+	results := make([]batchResult, len(prompts))
+	postOpts := &datahub.PostOptions{ContinueOnError: c.Bool("continue-on-error")}
+
+	// SQLite doesn't like concurrent writers
+	var dbMu sync.Mutex
+	atInterrupt(func() {
+		// Let the write in progress finish, and keep the lock so the
+		// workers don't use the database once closed
+		dbMu.Lock()
+		db.Close()
+	})
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i, prompt := range prompts {
+		wg.Add(1)
+		go func(i int, prompt string) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			gen, err := g.generate(c.Context, prompt)
+			<-sem
+			if err != nil {
+				results[i] = batchResult{HistoryID: -1, Err: err}
+				return
+			}
+
+			result := batchResult{HistoryID: -1, URN: gen.SchemaURN}
+
+			dbMu.Lock()
+			id, err := saveGeneration(db, g, gen, batchID)
+			dbMu.Unlock()
+			if err != nil {
+				fmt.Printf("Warning: Failed to save prompt %d to history: %v\n", i+1, err)
+			} else {
+				result.HistoryID = id
+			}
+
+			if dh != nil {
+				result.Err = checkDatasetDuplicates(gen.Response, c.Bool("strict"))
+				if result.Err == nil {
+					_, result.Err = dh.PostEntity("dataset", gen.Response, postOpts)
+				}
+				if result.HistoryID > -1 {
+					dbMu.Lock()
+					updateHistoryStatus(db, result.HistoryID, result.Err)
+					dbMu.Unlock()
+				}
+			}
+
+			log.Debugf("Prompt %d finished\n", i+1)
+			results[i] = result
+		}(i, prompt)
+	}
+	wg.Wait()
+
+	failed := 0
+	fmt.Println()
+	fmt.Printf("%-4s %-8s %-8s %s\n", "#", "HISTORY", "STATUS", "URN / ERROR")
+	fmt.Println(strings.Repeat("-", 100))
+	for i, r := range results {
+		history := "-"
+		if r.HistoryID > -1 {
+			history = fmt.Sprintf("%d", r.HistoryID)
+		}
+		if r.Err != nil {
+			failed++
+			fmt.Printf("%-4d %-8s %-8s %s\n", i+1, history, "failed", r.Err)
+			continue
+		}
+		fmt.Printf("%-4d %-8s %-8s %s\n", i+1, history, "ok", r.URN)
+	}
+	fmt.Println()
+	fmt.Printf("%d succeeded, %d failed\n", len(results)-failed, failed)
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d prompts failed", failed, len(results))
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	storage "github.com/rubiojr/dsg/internal/storage/sqlite"
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestParsePrompts(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{
+			name: "lines",
+			data: "a users table\n\n  an orders table  \r\n\n",
+			want: []string{"a users table", "an orders table"},
+		},
+		{
+			name: "blocks",
+			data: "a users table\nwith an email\n---\n\n---\nan orders table\n  ---  \n",
+			want: []string{"a users table\nwith an email", "an orders table"},
+		},
+		{
+			name: "empty",
+			data: "\n  \n",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		if got := parsePrompts(tt.data); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: parsePrompts = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// userInputRe extracts the user input from the built-in prompt
+var userInputRe = regexp.MustCompile(`(?s)<user_input>\n(.*?)\n</user_input>`)
+
+// userInput returns the user input in the last message of req
+func userInput(req openai.ChatCompletionRequest) string {
+	m := userInputRe.FindStringSubmatch(lastMessage(req))
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+func TestBatchGenerate(t *testing.T) {
+	dataDir := testDataDir(t)
+	dh := newDataHubStub(t)
+	dh.fail = func(urn string) bool { return urn == datasetURN("beta") }
+	apiBase, requests := openAIStub(t, func(req openai.ChatCompletionRequest) string {
+		name := userInput(req)
+		if name == "gamma" {
+			return "not json"
+		}
+		return datasetJSON(name)
+	})
+
+	promptsFile := filepath.Join(t.TempDir(), "prompts.txt")
+	os.WriteFile(promptsFile, []byte("alpha\nbeta\ngamma\n"), 0644)
+
+	out, err := runApp(t, "batch-generate",
+		"--prompts-file", promptsFile,
+		"--concurrency", "2",
+		"--repair-attempts", "0",
+		"--api-key", "test",
+		"--api-base", apiBase,
+		"--model", "m",
+		"--datahub-gms-url", dh.URL,
+	)
+	if err == nil || !strings.Contains(err.Error(), "2 of 3 prompts failed") {
+		t.Errorf("err = %v", err)
+	}
+	if len(*requests) != 3 {
+		t.Errorf("sent %d OpenAI requests, want 3", len(*requests))
+	}
+	if urns := dh.postedURNs(); !slices.Equal(urns, []string{datasetURN("alpha")}) {
+		t.Errorf("posted %v", urns)
+	}
+	if !strings.Contains(out, "1 succeeded, 2 failed") || !strings.Contains(out, datasetURN("alpha")) {
+		t.Errorf("unexpected summary:\n%s", out)
+	}
+
+	db, err := storage.NewSQLiteStorage(storage.WithDataDir(dataDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	history, err := db.ListResponses(10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("saved %d history entries, want 2", len(history))
+	}
+	statuses := map[string]string{}
+	for _, resp := range history {
+		statuses[resp.Prompt] = resp.Status
+		if resp.BatchID == "" || resp.BatchID != history[0].BatchID {
+			t.Errorf("entry %d has batch ID %q", resp.ID, resp.BatchID)
+		}
+	}
+	if statuses["alpha"] != storage.StatusPosted || statuses["beta"] != storage.StatusFailed {
+		t.Errorf("history statuses = %v", statuses)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+func TestListDatasetsCountOnly(t *testing.T) {
+	var mu sync.Mutex
+	var queries []url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		queries = append(queries, r.URL.Query())
+		mu.Unlock()
+		w.Write([]byte(`{"scrollId": "next", "entities": [{"urn": "urn:1"}], "metadata": {"total": 1234}}`))
+	}))
+	defer srv.Close()
+
+	out, err := runApp(t, "datasets", "list", "--datahub-gms-url", srv.URL, "--count-only", "--platform", "snowflake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "1234\n" {
+		t.Errorf("printed %q, want the total", out)
+	}
+	if len(queries) != 1 {
+		t.Fatalf("sent %d requests, want a single count request", len(queries))
+	}
+	q := queries[0]
+	if q.Get("count") != "1" || q.Get("scrollId") != "" {
+		t.Errorf("count request query = %v", q)
+	}
+	if got := q.Get("query"); got != `platform:"urn:li:dataPlatform:snowflake"` {
+		t.Errorf("query = %q, want the platform filter", got)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+	"github.com/urfave/cli/v2"
+)
+
+// datasetTagURNs returns the URNs of the --dataset-tag names
+func datasetTagURNs(names []string) []string {
+	var urns []string
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			urns = append(urns, datahub.TagURN(name))
+		}
+	}
+	return urns
+}
+
+// ensureDatasetTags creates the tags missing from every DataHub instance
+// the datasets are posted to, so the tags added with --dataset-tag have a
+// name in the UI
+func ensureDatasetTags(c *cli.Context, tags []string, out io.Writer) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	urls, tokens, err := datahubInstances(c)
+	if err != nil {
+		return err
+	}
+	for i, u := range urls {
+		dh, err := newDataHubClient(c.Context, u, tokens[i])
+		if err != nil {
+			return err
+		}
+		for _, tag := range tags {
+			exists, err := dh.EntityExists("tag", tag)
+			if err != nil {
+				return fmt.Errorf("error checking tag %s: %w", tag, err)
+			}
+			if exists {
+				continue
+			}
+			if err := dh.CreateTag(tag); err != nil {
+				return fmt.Errorf("error creating tag %s: %w", tag, err)
+			}
+			fmt.Fprintf(out, "Tag %s created.\n", tag)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestDatasetTagURNs(t *testing.T) {
+	got := datasetTagURNs([]string{"pii", " ", "urn:li:tag:Sensitive", ""})
+	if want := []string{"urn:li:tag:pii", "urn:li:tag:Sensitive"}; !slices.Equal(got, want) {
+		t.Errorf("datasetTagURNs = %v, want %v", got, want)
+	}
+}
+
+func TestGenerateDatasetTags(t *testing.T) {
+	testDataDir(t)
+	dh := newDataHubStub(t)
+	dh.entities["urn:li:tag:pii"] = `{"urn": "urn:li:tag:pii"}`
+	apiBase, _ := openAIStub(t, func(openai.ChatCompletionRequest) string {
+		// The model tagged the dataset already
+		return strings.Replace(datasetJSON("users"), `"datasetKey"`,
+			`"globalTags": {"value": {"tags": [{"tag": "urn:li:tag:Sensitive"}, {"tag": "urn:li:tag:pii"}]}}, "datasetKey"`, 1)
+	})
+
+	withStdin(t, "a users table\n")
+	t.Setenv("DSG_PROVENANCE_TAG", "generated-by-dsg")
+	out, err := runApp(t, "generate", "--api-key", "test", "--api-base", apiBase, "--model", "m",
+		"--datahub-gms-url", dh.URL, "--dataset-tag", "pii", "--dataset-tag", "urn:li:tag:reviewed")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the missing tags are created, before the dataset is posted
+	want := []string{"urn:li:tag:reviewed", "urn:li:tag:generated-by-dsg", datasetURN("users")}
+	if urns := dh.postedURNs(); !slices.Equal(urns, want) {
+		t.Fatalf("posted %v, want %v", urns, want)
+	}
+	for _, tag := range want[:2] {
+		if !strings.Contains(out, "Tag "+tag+" created.") {
+			t.Errorf("output is missing the creation of %s:\n%s", tag, out)
+		}
+	}
+
+	var tags datahub.GlobalTagsContainer
+	if err := json.Unmarshal(dh.posted[2]["globalTags"], &tags); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, tag := range tags.Value.Tags {
+		got = append(got, tag.Tag)
+	}
+	if want := []string{"urn:li:tag:Sensitive", "urn:li:tag:pii", "urn:li:tag:reviewed", "urn:li:tag:generated-by-dsg"}; !slices.Equal(got, want) {
+		t.Errorf("posted tags %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+	"github.com/urfave/cli/v2"
+)
+
+// deleteResult is the result of deleting a single dataset
+type deleteResult struct {
+	URN string
+	Err error
+}
+
+// findDatasets returns the URNs of the datasets of the platform, if set,
+// whose name starts with prefix
+func findDatasets(dh *datahub.Client, platform, prefix string) ([]string, error) {
+	var urns []string
+	err := dh.GetDatasets(func(datasets []*datahub.Dataset) error {
+		for _, ds := range datasets {
+			if prefix != "" {
+				_, name, _, err := datahub.ParseDatasetURN(ds.URN)
+				if err != nil || !strings.HasPrefix(name, prefix) {
+					continue
+				}
+			}
+			urns = append(urns, ds.URN)
+		}
+		return nil
+	}, &datahub.ListOptions{PerPage: 100, Platform: platform})
+	return urns, err
+}
+
+func runDeleteEntity(c *cli.Context) error {
+	platform := platformURN(c.String("platform"))
+	prefix := c.String("prefix")
+	if platform == "" && prefix == "" {
+		return usagef("--platform or --prefix is required")
+	}
+
+	concurrency := c.Int("concurrency")
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	dh, err := newClientFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	urns, err := findDatasets(dh, platform, prefix)
+	if err != nil {
+		return fmt.Errorf("error listing datasets: %w", err)
+	}
+	if len(urns) == 0 {
+		fmt.Println("No matching datasets found.")
+		return nil
+	}
+
+	for _, urn := range urns {
+		fmt.Println(urn)
+	}
+	fmt.Println()
+
+	if c.Bool("dry-run") {
+		fmt.Printf("%d datasets would be deleted.\n", len(urns))
+		return nil
+	}
+
+	if !c.Bool("force") {
+		fmt.Printf("Are you sure you want to delete these %d datasets from DataHub? This action cannot be undone. (y/N): ", len(urns))
+		reader := bufio.NewReader(os.Stdin)
+		confirm, err := reader.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("failed to read confirmation: %w", err)
+		}
+
+		confirm = strings.TrimSpace(strings.ToLower(confirm))
+		if confirm != "y" && confirm != "yes" {
+			fmt.Println("Delete operation cancelled.")
+			return nil
+		}
+	}
+
+	results := make([]deleteResult, len(urns))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i, urn := range urns {
+		wg.Add(1)
+		go func(i int, urn string) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			err := dh.DeleteEntity("dataset", urn)
+			<-sem
+			results[i] = deleteResult{URN: urn, Err: err}
+		}(i, urn)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			fmt.Printf("failed  %s: %v\n", r.URN, r.Err)
+		}
+	}
+	fmt.Printf("%d deleted, %d failed\n", len(results)-failed, failed)
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d datasets could not be deleted", failed, len(results))
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// deleteServer lists the datasets of the platform in the query, in two
+// pages, and records the URNs deleted. Deleting the URNs in fail fails.
+type deleteServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	deleted []string
+	fail    map[string]bool
+}
+
+func newDeleteServer(t *testing.T, datasets map[string][]string) *deleteServer {
+	s := &deleteServer{fail: map[string]bool{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if r.Method == http.MethodDelete {
+			urn, _ := url.PathUnescape(strings.TrimPrefix(r.URL.EscapedPath(), "/openapi/v3/entity/dataset/"))
+			if s.fail[urn] {
+				http.Error(w, "boom", http.StatusInternalServerError)
+				return
+			}
+			s.deleted = append(s.deleted, urn)
+			return
+		}
+
+		var urns []string
+		for platform, names := range datasets {
+			if !strings.Contains(r.URL.Query().Get("query"), platform) {
+				continue
+			}
+			for _, name := range names {
+				urns = append(urns, fmt.Sprintf("urn:li:dataset:(%s,%s,PROD)", platform, name))
+			}
+		}
+		slices.Sort(urns)
+
+		// The first page has a single dataset
+		page, next := urns, ""
+		if r.URL.Query().Get("scrollId") == "" && len(urns) > 1 {
+			page, next = urns[:1], "page2"
+		} else if len(urns) > 1 {
+			page = urns[1:]
+		}
+		var entities []string
+		for _, urn := range page {
+			entities = append(entities, fmt.Sprintf(`{"urn": %q}`, urn))
+		}
+		fmt.Fprintf(w, `{"scrollId": %q, "entities": [%s]}`, next, strings.Join(entities, ","))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestDeleteEntity(t *testing.T) {
+	srv := newDeleteServer(t, map[string][]string{
+		"urn:li:dataPlatform:mysql":    {"sales.orders", "sales.users", "hr.people"},
+		"urn:li:dataPlatform:postgres": {"sales.invoices"},
+	})
+
+	out, err := runApp(t, "delete-entity", "--datahub-gms-url", srv.URL, "--platform", "mysql", "--prefix", "sales.", "--force")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"urn:li:dataset:(urn:li:dataPlatform:mysql,sales.orders,PROD)",
+		"urn:li:dataset:(urn:li:dataPlatform:mysql,sales.users,PROD)",
+	}
+	slices.Sort(srv.deleted)
+	if !slices.Equal(srv.deleted, want) {
+		t.Errorf("deleted %v, want %v", srv.deleted, want)
+	}
+	if !strings.Contains(out, "2 deleted, 0 failed") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestDeleteEntityDryRun(t *testing.T) {
+	srv := newDeleteServer(t, map[string][]string{
+		"urn:li:dataPlatform:mysql": {"orders", "users"},
+	})
+
+	out, err := runApp(t, "delete-entity", "--datahub-gms-url", srv.URL, "--platform", "urn:li:dataPlatform:mysql", "--dry-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(srv.deleted) != 0 {
+		t.Errorf("deleted %v in a dry run", srv.deleted)
+	}
+	for _, want := range []string{datasetURN("orders"), datasetURN("users"), "2 datasets would be deleted."} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output is missing %q:\n%s", want, out)
+		}
+	}
+
+	// Cancelled
+	withStdin(t, "n\n")
+	out, err = runApp(t, "delete-entity", "--datahub-gms-url", srv.URL, "--platform", "mysql")
+	if err != nil || len(srv.deleted) != 0 || !strings.Contains(out, "Delete operation cancelled.") {
+		t.Errorf("err = %v, deleted %v, output:\n%s", err, srv.deleted, out)
+	}
+}
+
+func TestDeleteEntityFailures(t *testing.T) {
+	srv := newDeleteServer(t, map[string][]string{
+		"urn:li:dataPlatform:mysql": {"orders", "users"},
+	})
+	srv.fail[datasetURN("users")] = true
+
+	out, err := runApp(t, "delete-entity", "--datahub-gms-url", srv.URL, "--platform", "mysql", "--force", "--concurrency", "1")
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 datasets could not be deleted") {
+		t.Errorf("err = %v", err)
+	}
+	if !slices.Equal(srv.deleted, []string{datasetURN("orders")}) {
+		t.Errorf("deleted %v", srv.deleted)
+	}
+	if !strings.Contains(out, "failed  "+datasetURN("users")) || !strings.Contains(out, "1 deleted, 1 failed") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	if _, err := runApp(t, "delete-entity", "--datahub-gms-url", srv.URL, "--force"); exitCode(err) != exitUsage {
+		t.Errorf("err = %v, want a usage error without --platform nor --prefix", err)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+	"github.com/urfave/cli/v2"
+)
+
+// printExistingDiffs prints the field differences between the datasets in
+// payload and the datasets with the same URN already in DataHub. With
+// several DataHub instances, the first one is compared.
+func printExistingDiffs(c *cli.Context, payload string, out io.Writer) error {
+	var datasets []datahub.Dataset
+	if err := json.Unmarshal([]byte(payload), &datasets); err != nil {
+		return fmt.Errorf("error decoding JSON: %w", err)
+	}
+
+	urls, tokens, err := datahubInstances(c)
+	if err != nil {
+		return err
+	}
+	dh, err := newDataHubClient(c.Context, urls[0], tokens[0])
+	if err != nil {
+		return err
+	}
+
+	for _, ds := range datasets {
+		fields := ds.SchemaMetadata.Value.Fields
+		existing, err := dh.GetSchemaFields(ds.URN)
+		if errors.Is(err, datahub.ErrNotFound) {
+			fmt.Fprintf(out, "%s: new dataset (%d fields)\n", ds.URN, len(fields))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		diff := datahub.DiffFields(existing, fields)
+		if diff.Empty() {
+			fmt.Fprintf(out, "%s: no field changes\n", ds.URN)
+			continue
+		}
+		fmt.Fprintf(out, "%s: %d added, %d removed, %d changed\n", ds.URN, len(diff.Added), len(diff.Removed), len(diff.Changed))
+		fmt.Fprint(out, diff)
+	}
+	fmt.Fprintln(out)
+
+	return nil
+}
+
+// confirmPost asks whether to post the datasets. Anything but yes, including
+// stdin being closed, cancels the post.
+func confirmPost(out io.Writer) bool {
+	fmt.Fprint(out, "Post the datasets to DataHub? (y/N): ")
+	reader := bufio.NewReader(os.Stdin)
+	confirm, err := reader.ReadString('\n')
+	if err != nil {
+		fmt.Fprintln(out)
+		return false
+	}
+
+	confirm = strings.TrimSpace(strings.ToLower(confirm))
+	return confirm == "y" || confirm == "yes"
+}
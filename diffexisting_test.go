@@ -0,0 +1,55 @@
+package main
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	storage "github.com/rubiojr/dsg/internal/storage/sqlite"
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestGenerateDiffExisting(t *testing.T) {
+	dataDir := testDataDir(t)
+	dh := newDataHubStub(t)
+	dh.entities[datasetURN("alpha")] = `{"urn": "` + datasetURN("alpha") + `", "schemaMetadata": {"value": {"fields": [
+  {"fieldPath": "id", "type": {"type": {"com.linkedin.schema.NumberType": {}}}, "nativeDataType": "int"},
+  {"fieldPath": "legacy", "type": {"type": {"com.linkedin.schema.StringType": {}}}, "nativeDataType": "text"}
+]}}}`
+	apiBase, _ := openAIStub(t, func(openai.ChatCompletionRequest) string { return datasetJSON("alpha", "beta") })
+	// The prompt comes from the history, so stdin answers the confirmation
+	ids := seedHistory(t, dataDir, &storage.Response{Prompt: "alpha and beta", Response: "[]"})
+
+	for _, answer := range []string{"n", "y"} {
+		withStdin(t, answer+"\n")
+		out, err := runApp(t, "generate", "--diff-existing",
+			"--prompt-from", strconv.FormatInt(ids[0], 10),
+			"--api-key", "test",
+			"--api-base", apiBase,
+			"--model", "m",
+			"--datahub-gms-url", dh.URL,
+		)
+		if err != nil {
+			t.Fatalf("%s: %v", answer, err)
+		}
+		for _, want := range []string{
+			datasetURN("alpha") + ": 0 added, 1 removed, 0 changed\n- legacy (string (text))\n",
+			datasetURN("beta") + ": new dataset (1 fields)\n",
+			"Post the datasets to DataHub? (y/N): ",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("%s: output is missing %q:\n%s", answer, want, out)
+			}
+		}
+
+		posted := dh.postedURNs()
+		if answer == "n" {
+			if len(posted) != 0 || !strings.Contains(out, "Not posting the datasets.") {
+				t.Errorf("posted %v after declining:\n%s", posted, out)
+			}
+		} else if !slices.Equal(posted, []string{datasetURN("alpha"), datasetURN("beta")}) {
+			t.Errorf("posted %v", posted)
+		}
+	}
+}
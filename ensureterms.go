@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+	"github.com/urfave/cli/v2"
+)
+
+// defaultTermDefinition is the definition of the glossary terms created by
+// ensure-terms
+const defaultTermDefinition = "Placeholder created by dsg, pending a definition"
+
+func runEnsureTerms(c *cli.Context) error {
+	data, err := readInputFile(c.Args().First(), os.Stdin)
+	if err != nil {
+		return err
+	}
+	datasets, err := datahub.DecodeEntities[datahub.Dataset](data, false)
+	if err != nil {
+		return fmt.Errorf("error decoding JSON: %w", err)
+	}
+
+	urns := datahub.ReferencedTerms(datasets)
+	if len(urns) == 0 {
+		fmt.Println("No glossary terms referenced")
+		return nil
+	}
+
+	dh, err := newClientFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var missing []datahub.GlossaryTerm
+	for _, urn := range urns {
+		exists, err := dh.GlossaryTermExists(urn)
+		if err != nil {
+			return err
+		}
+		if exists {
+			fmt.Printf("%s: exists\n", urn)
+			continue
+		}
+		missing = append(missing, datahub.NewPlaceholderTerm(urn, c.String("definition")))
+	}
+
+	if c.Bool("dry-run") {
+		for _, t := range missing {
+			fmt.Printf("%s: would be created\n", t.URN)
+		}
+		fmt.Printf("\n%d glossary terms would be created, %d already exist\n", len(missing), len(urns)-len(missing))
+		return nil
+	}
+
+	if len(missing) > 0 {
+		payload, err := json.Marshal(missing)
+		if err != nil {
+			return fmt.Errorf("error encoding glossary terms to JSON: %w", err)
+		}
+		count, err := dh.PostEntity("glossaryTerm", string(payload), nil)
+		for _, t := range missing[:count] {
+			fmt.Printf("%s: created\n", t.URN)
+		}
+		if err != nil {
+			return fmt.Errorf("error creating glossary terms: %w", err)
+		}
+	}
+
+	fmt.Printf("\n%d glossary terms created, %d already exist\n", len(missing), len(urns)-len(missing))
+	return nil
+}
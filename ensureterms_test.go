@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+)
+
+func TestEnsureTerms(t *testing.T) {
+	dh := newDataHubStub(t)
+	dh.entities["urn:li:glossaryTerm:email"] = `{"urn": "urn:li:glossaryTerm:email", "glossaryTermInfo": {"value": {"name": "Email", "definition": "An email address", "termSource": "INTERNAL"}}}`
+
+	withTerms := strings.Replace(datasetJSON("users"), `"nativeDataType": "int"}`,
+		`"nativeDataType": "int", "glossaryTerms": {"terms": [{"urn": "urn:li:glossaryTerm:email"}, {"urn": "urn:li:glossaryTerm:pii"}, {"urn": "urn:li:glossaryTerm:customer_id"}]}}`, 1)
+	input := filepath.Join(t.TempDir(), "datasets.json")
+	os.WriteFile(input, []byte(withTerms), 0644)
+
+	out, err := runApp(t, "ensure-terms", "--dry-run", "--datahub-gms-url", dh.URL, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "urn:li:glossaryTerm:pii: would be created") || !strings.Contains(out, "2 glossary terms would be created, 1 already exist") {
+		t.Errorf("unexpected dry run output:\n%s", out)
+	}
+	if len(dh.posted) != 0 {
+		t.Fatalf("the dry run posted %d entities", len(dh.posted))
+	}
+
+	out, err = runApp(t, "ensure-terms", "--definition", "To be defined", "--datahub-gms-url", dh.URL, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"urn:li:glossaryTerm:email: exists",
+		"urn:li:glossaryTerm:pii: created",
+		"urn:li:glossaryTerm:customer_id: created",
+		"2 glossary terms created, 1 already exist",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output is missing %q:\n%s", want, out)
+		}
+	}
+
+	want := []string{"urn:li:glossaryTerm:pii", "urn:li:glossaryTerm:customer_id"}
+	if urns := dh.postedURNs(); !slices.Equal(urns, want) {
+		t.Fatalf("posted %v, want %v", urns, want)
+	}
+	for i, item := range dh.posted {
+		var info datahub.GlossaryTermInfo
+		json.Unmarshal(item["glossaryTermInfo"], &info)
+		name := strings.TrimPrefix(want[i], "urn:li:glossaryTerm:")
+		if info.Value != (datahub.GlossaryTermValue{Name: name, Definition: "To be defined", Source: "INTERNAL"}) {
+			t.Errorf("posted glossaryTermInfo %s", item["glossaryTermInfo"])
+		}
+	}
+
+	// Once created, nothing is posted again
+	for _, urn := range want {
+		dh.entities[urn] = `{"urn": "` + urn + `", "glossaryTermInfo": {"value": {"name": "x", "definition": "x", "termSource": "INTERNAL"}}}`
+	}
+	out, err = runApp(t, "ensure-terms", "--datahub-gms-url", dh.URL, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "0 glossary terms created, 3 already exist") || len(dh.posted) != 2 {
+		t.Errorf("posted %d entities:\n%s", len(dh.posted), out)
+	}
+
+	os.WriteFile(input, []byte(datasetJSON("orders")), 0644)
+	if out, err := runApp(t, "ensure-terms", "--datahub-gms-url", dh.URL, input); err != nil || !strings.Contains(out, "No glossary terms referenced") {
+		t.Errorf("out = %q, err = %v", out, err)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+	"github.com/rubiojr/dsg/internal/datahub/graphql"
+	"github.com/sashabaranov/go-openai"
+)
+
+// Exit codes, so scripts can tell failures apart
+const (
+	exitFailure    = 1 // any other error
+	exitUsage      = 2 // invalid command line arguments
+	exitAuth       = 3 // DataHub or OpenAI rejected the credentials
+	exitNetwork    = 4 // DataHub or OpenAI could not be reached
+	exitValidation = 5 // invalid input (JSON, URNs, ...)
+	exitRejected   = 6 // DataHub rejected the request
+	exitTimeout    = 7 // the command took longer than --timeout
+)
+
+var (
+	// commandTimeout is the --timeout of the whole command, 0 for none
+	commandTimeout time.Duration
+	// timeoutContext is the command context with the --timeout deadline
+	timeoutContext context.Context
+	cancelTimeout  context.CancelFunc
+)
+
+// timeoutError is returned when the command is cancelled by --timeout
+type timeoutError struct {
+	timeout time.Duration
+	err     error
+}
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("command timed out after %s: %v", e.timeout, e.err)
+}
+
+func (e *timeoutError) Unwrap() error {
+	return e.err
+}
+
+// checkTimeout returns err as a timeoutError if the command failed because
+// --timeout expired
+func checkTimeout(err error) error {
+	if timeoutContext != nil && errors.Is(timeoutContext.Err(), context.DeadlineExceeded) {
+		return &timeoutError{timeout: commandTimeout, err: err}
+	}
+	return err
+}
+
+// usageError is returned for invalid command line arguments
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+func usagef(format string, args ...any) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// cliUsagePrefixes are the prefixes of the urfave/cli and flag package
+// errors caused by invalid arguments, which aren't exported as types
+var cliUsagePrefixes = []string{
+	"Required flag",
+	"flag provided but not defined",
+	"flag needs an argument",
+	"invalid value",
+	"invalid boolean",
+}
+
+// exitCode returns the exit code for err
+func exitCode(err error) int {
+	var timeoutErr *timeoutError
+	if errors.As(err, &timeoutErr) {
+		return exitTimeout
+	}
+
+	var usageErr *usageError
+	if errors.As(err, &usageErr) {
+		return exitUsage
+	}
+	for _, prefix := range cliUsagePrefixes {
+		if strings.HasPrefix(err.Error(), prefix) {
+			return exitUsage
+		}
+	}
+
+	if errors.Is(err, datahub.ErrUnauthorized) {
+		return exitAuth
+	}
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) && isAuthStatus(apiErr.HTTPStatusCode) {
+		return exitAuth
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) && isAuthStatus(reqErr.HTTPStatusCode) {
+		return exitAuth
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return exitNetwork
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.Is(err, datahub.ErrValidation) || errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+		return exitValidation
+	}
+
+	var dhErr *datahub.DataHubError
+	var gqlErr *graphql.Error
+	if errors.As(err, &dhErr) || errors.As(err, &gqlErr) {
+		return exitRejected
+	}
+
+	return exitFailure
+}
+
+func isAuthStatus(code int) bool {
+	return code == http.StatusUnauthorized || code == http.StatusForbidden
+}
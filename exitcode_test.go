@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+	"github.com/rubiojr/dsg/internal/datahub/graphql"
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestExitCode(t *testing.T) {
+	var syntaxErr error = &json.SyntaxError{}
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("something failed"), exitFailure},
+		{usagef("bad flag"), exitUsage},
+		{fmt.Errorf("Required flag \"entity-type\" not set"), exitUsage},
+		{fmt.Errorf("wrapped: %w", &datahub.DataHubError{StatusCode: http.StatusUnauthorized}), exitAuth},
+		{&datahub.DataHubError{StatusCode: http.StatusForbidden}, exitAuth},
+		{&openai.APIError{HTTPStatusCode: http.StatusUnauthorized}, exitAuth},
+		{&openai.RequestError{HTTPStatusCode: http.StatusForbidden}, exitAuth},
+		{&openai.APIError{HTTPStatusCode: http.StatusInternalServerError}, exitFailure},
+		{fmt.Errorf("posting: %w", context.DeadlineExceeded), exitNetwork},
+		{&datahub.ValidationError{Msg: "invalid URN"}, exitValidation},
+		{fmt.Errorf("decoding: %w", syntaxErr), exitValidation},
+		{&datahub.DataHubError{StatusCode: http.StatusBadRequest}, exitRejected},
+		{&datahub.DataHubError{StatusCode: http.StatusNotFound}, exitRejected},
+		{&graphql.Error{Messages: []string{"denied"}}, exitRejected},
+		{&timeoutError{timeout: time.Second, err: context.DeadlineExceeded}, exitTimeout},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("exitCode(%T %v) = %d, want %d", tt.err, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestFromJSONExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.json")
+	os.WriteFile(valid, []byte(datasetJSON("alpha")), 0644)
+	invalid := filepath.Join(dir, "invalid.json")
+	os.WriteFile(invalid, []byte(`[{"urn": `), 0644)
+
+	status := func(code int) string {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, http.StatusText(code), code)
+		}))
+		t.Cleanup(srv.Close)
+		return srv.URL
+	}
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"usage", []string{"from-json", "--entity-type", "dataset", "--no-such-flag", valid}, exitUsage},
+		{"auth", []string{"from-json", "--datahub-gms-url", status(http.StatusUnauthorized), valid}, exitAuth},
+		{"network", []string{"from-json", "--datahub-gms-url", down.URL, valid}, exitNetwork},
+		{"validation", []string{"from-json", "--entity-type", "dataset", "--datahub-gms-url", status(http.StatusOK), invalid}, exitValidation},
+		{"rejected", []string{"from-json", "--datahub-gms-url", status(http.StatusBadRequest), valid}, exitRejected},
+	}
+	for _, tt := range tests {
+		_, err := runApp(t, tt.args...)
+		if got := exitCode(err); got != tt.want {
+			t.Errorf("%s: exit code %d, want %d (err = %v)", tt.name, got, tt.want, err)
+		}
+	}
+}
+
+// slowServer answers the requests when they're cancelled, or after a minute
+func slowServer(t *testing.T) string {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The cancellation is only noticed once the body is read
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Minute):
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestCommandTimeout(t *testing.T) {
+	testDataDir(t)
+	t.Cleanup(func() {
+		commandTimeout, timeoutContext, cancelTimeout = 0, nil, nil
+	})
+	input := filepath.Join(t.TempDir(), "datasets.json")
+	os.WriteFile(input, []byte(datasetJSON("alpha")), 0644)
+	slow := slowServer(t)
+	dh := newDataHubStub(t)
+
+	commands := map[string][]string{
+		"generate":  {"generate", "--api-key", "test", "--api-base", slow + "/v1", "--model", "m", "--datahub-gms-url", dh.URL},
+		"from-json": {"from-json", "--datahub-gms-url", slow, input},
+	}
+	for name, args := range commands {
+		withStdin(t, "alpha\n")
+		start := time.Now()
+		_, err := runApp(t, append([]string{"--timeout", "100ms"}, args...)...)
+		if elapsed := time.Since(start); elapsed > 10*time.Second {
+			t.Errorf("%s: took %s with a 100ms timeout", name, elapsed)
+		}
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+			continue
+		}
+		err = checkTimeout(err)
+		if exitCode(err) != exitTimeout || !strings.Contains(err.Error(), "command timed out after 100ms") {
+			t.Errorf("%s: err = %v (exit code %d), want a timeout", name, err, exitCode(err))
+		}
+		cancelTimeout()
+	}
+	if urns := dh.postedURNs(); len(urns) != 0 {
+		t.Errorf("posted %v", urns)
+	}
+
+	// Without --timeout, errors are returned unchanged
+	commandTimeout, timeoutContext = 0, nil
+	err := fmt.Errorf("something failed")
+	if checkTimeout(err) != err {
+		t.Error("the error was wrapped without a timeout")
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	storage "github.com/rubiojr/dsg/internal/storage/sqlite"
+	"github.com/urfave/cli/v2"
+)
+
+// csvExportHeader are the columns of the CSV history export
+var csvExportHeader = []string{"id", "created_at", "schema_name", "dataset_name", "status", "tokens", "model"}
+
+// runExport writes the whole history to stdout or --output
+func runExport(c *cli.Context) error {
+	db, err := storage.NewSQLiteStorage()
+	if err != nil {
+		return fmt.Errorf("failed to initialize history database: %w", err)
+	}
+	defer db.Close()
+
+	var out io.Writer = os.Stdout
+	if path := c.String("output"); path != "" && path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("error creating export file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	w := bufio.NewWriter(out)
+
+	switch c.String("format") {
+	case "csv":
+		err = exportCSV(db, w)
+	case "json":
+		err = exportJSON(db, w)
+	default:
+		return usagef("unsupported export format %q: must be csv or json", c.String("format"))
+	}
+	if err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
+
+// exportCSV writes the history as CSV, one row at a time
+func exportCSV(db *storage.SQLiteStorage, out io.Writer) error {
+	w := csv.NewWriter(out)
+	if err := w.Write(csvExportHeader); err != nil {
+		return fmt.Errorf("error writing CSV: %w", err)
+	}
+
+	err := db.EachResponse(func(resp *storage.Response) error {
+		return w.Write([]string{
+			strconv.FormatInt(resp.ID, 10),
+			resp.CreatedAt.Format("2006-01-02 15:04:05"),
+			resp.SchemaName,
+			resp.DatasetName,
+			resp.Status,
+			strconv.Itoa(resp.Tokens),
+			resp.Model,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("error exporting history: %w", err)
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// exportJSON writes the history as a JSON array, one entry at a time
+func exportJSON(db *storage.SQLiteStorage, out io.Writer) error {
+	if _, err := io.WriteString(out, "["); err != nil {
+		return err
+	}
+
+	first := true
+	err := db.EachResponse(func(resp *storage.Response) error {
+		data, err := json.Marshal(resp)
+		if err != nil {
+			return err
+		}
+		if !first {
+			if _, err := io.WriteString(out, ",\n"); err != nil {
+				return err
+			}
+		}
+		first = false
+		_, err = out.Write(data)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("error exporting history: %w", err)
+	}
+
+	_, err = io.WriteString(out, "]\n")
+	return err
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	storage "github.com/rubiojr/dsg/internal/storage/sqlite"
+)
+
+func TestExportCSV(t *testing.T) {
+	dataDir := testDataDir(t)
+	ids := seedHistory(t, dataDir,
+		&storage.Response{Prompt: "p1", Response: "[]", SchemaName: "users", DatasetName: "db.users", Tokens: 120, Model: "m"},
+		&storage.Response{Prompt: "p2", Response: "[]", SchemaName: `orders, "v2"`, DatasetName: "line\nbreak"},
+	)
+
+	out, err := runApp(t, "export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out, "id,created_at,schema_name,dataset_name,status,tokens,model\n") {
+		t.Errorf("unexpected header:\n%s", out)
+	}
+	if !strings.Contains(out, `,"orders, ""v2""","line`+"\n"+`break",generated,0,`+"\n") {
+		t.Errorf("fields with commas, quotes or newlines are not escaped:\n%s", out)
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("exported %d rows, want 3", len(rows))
+	}
+	want := [][]string{
+		{fmt.Sprint(ids[0]), "users", "db.users", "generated", "120", "m"},
+		{fmt.Sprint(ids[1]), `orders, "v2"`, "line\nbreak", "generated", "0", ""},
+	}
+	for i, row := range rows[1:] {
+		// created_at is set when saving
+		if _, err := time.Parse("2006-01-02 15:04:05", row[1]); err != nil {
+			t.Errorf("row %d: invalid created_at: %v", i, err)
+		}
+		got := append([]string{row[0]}, row[2:]...)
+		if !slices.Equal(got, want[i]) {
+			t.Errorf("row %d = %q, want %q", i, got, want[i])
+		}
+	}
+}
+
+func TestExportJSONFile(t *testing.T) {
+	dataDir := testDataDir(t)
+	seedHistory(t, dataDir,
+		&storage.Response{Prompt: "p1", Response: "[]", SchemaName: "users"},
+		&storage.Response{Prompt: "p2", Response: "[]", SchemaName: "orders"},
+	)
+
+	path := filepath.Join(t.TempDir(), "history.json")
+	out, err := runApp(t, "export", "--format", "json", "--output", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "" {
+		t.Errorf("export --output printed %q", out)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var responses []storage.Response
+	if err := json.Unmarshal(data, &responses); err != nil {
+		t.Fatalf("invalid JSON export: %v\n%s", err, data)
+	}
+	if len(responses) != 2 || responses[0].SchemaName != "users" || responses[1].SchemaName != "orders" {
+		t.Errorf("exported %+v", responses)
+	}
+
+	if _, err := runApp(t, "export", "--format", "xml"); exitCode(err) != exitUsage {
+		t.Errorf("err = %v, want a usage error", err)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// errNoJSON is returned when a model response contains no JSON at all
+var errNoJSON = errors.New("the response contains no JSON array or object")
+
+// extractJSON returns the first top-level JSON array or object in a model
+// response, without the markdown code fences or prose models sometimes wrap
+// it with. A single object is returned as a one element array.
+func extractJSON(content string) (string, error) {
+	content = strings.TrimSpace(content)
+	if json.Valid([]byte(content)) {
+		return wrapObject(content), nil
+	}
+
+	// Prefer the content of the first fenced block, e.g. ```json ... ```
+	if _, fenced, ok := strings.Cut(content, "```"); ok {
+		// Skip the language tag
+		if i := strings.IndexByte(fenced, '\n'); i >= 0 {
+			fenced = fenced[i+1:]
+		}
+		fenced, _, _ = strings.Cut(fenced, "```")
+		if fenced = strings.TrimSpace(fenced); json.Valid([]byte(fenced)) {
+			return wrapObject(fenced), nil
+		}
+	}
+
+	// Otherwise look for the first array or object that decodes
+	for i := 0; i < len(content); i++ {
+		if content[i] != '[' && content[i] != '{' {
+			continue
+		}
+		var raw json.RawMessage
+		if err := json.NewDecoder(strings.NewReader(content[i:])).Decode(&raw); err == nil {
+			return wrapObject(string(raw)), nil
+		}
+	}
+
+	return "", errNoJSON
+}
+
+// wrapObject wraps a JSON object in an array, other values are unchanged
+func wrapObject(s string) string {
+	if strings.HasPrefix(s, "{") {
+		return "[" + s + "]"
+	}
+	return s
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestExtractJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "clean", content: `[{"urn":"x"}]`, want: `[{"urn":"x"}]`},
+		{name: "whitespace", content: "\n  [{\"urn\":\"x\"}]  \n", want: `[{"urn":"x"}]`},
+		{name: "object", content: `{"urn":"x"}`, want: `[{"urn":"x"}]`},
+		{name: "fenced", content: "```json\n[{\"urn\":\"x\"}]\n```", want: `[{"urn":"x"}]`},
+		{name: "fenced without language", content: "```\n{\"urn\":\"x\"}\n```", want: `[{"urn":"x"}]`},
+		{
+			name:    "fenced with prose",
+			content: "Here is the dataset:\n\n```json\n[{\"urn\":\"x\"}]\n```\n\nLet me know if you need [more] changes.",
+			want:    `[{"urn":"x"}]`,
+		},
+		{
+			name:    "prose",
+			content: "Sure! The dataset {as requested} is [{\"urn\":\"x\", \"tags\": [\"a\"]}] and nothing else.",
+			want:    `[{"urn":"x", "tags": ["a"]}]`,
+		},
+		{
+			name:    "invalid fenced block",
+			content: "```json\n[{\"urn\":\n```\nor rather {\"urn\":\"y\"}",
+			want:    `[{"urn":"y"}]`,
+		},
+	}
+	for _, tt := range tests {
+		got, err := extractJSON(tt.content)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: extractJSON = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	for _, content := range []string{"", "no JSON here", "```json\n```", `[{"urn": "x",}]`} {
+		if _, err := extractJSON(content); !errors.Is(err, errNoJSON) {
+			t.Errorf("extractJSON(%q) error = %v, want errNoJSON", content, err)
+		}
+	}
+}
+
+func TestGenerateJSONFenced(t *testing.T) {
+	calls := 0
+	complete := func([]openai.ChatCompletionMessage) (string, int, error) {
+		calls++
+		return "Here you go:\n```json\n[{\"urn\":\"x\"}]\n```", 1, nil
+	}
+
+	content, _, err := generateJSON(complete, generationRequest{Prompt: "request"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != `[{"urn":"x"}]` || calls != 1 {
+		t.Errorf("content = %q after %d completions", content, calls)
+	}
+}
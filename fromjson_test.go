@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFromJSONStdin(t *testing.T) {
+	for _, args := range [][]string{nil, {"-"}} {
+		dh := newDataHubStub(t)
+		input := datasetJSON("alpha", "beta")
+		withStdin(t, input)
+
+		_, err := runApp(t, append([]string{"from-json", "--datahub-gms-url", dh.URL}, args...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []string{datasetURN("alpha"), datasetURN("beta")}
+		if urns := dh.postedURNs(); !slices.Equal(urns, want) {
+			t.Fatalf("args %q: posted %v, want %v", args, urns, want)
+		}
+
+		// The piped entities are posted unmodified
+		var sent, piped []json.RawMessage
+		for _, item := range dh.posted {
+			data, _ := json.Marshal(item)
+			sent = append(sent, data)
+		}
+		json.Unmarshal([]byte(input), &piped)
+		for i := range piped {
+			if !jsonEqual(t, sent[i], piped[i]) {
+				t.Errorf("posted %s, want %s", sent[i], piped[i])
+			}
+		}
+	}
+}
+
+// jsonEqual returns true if a and b are the same JSON value
+func jsonEqual(t *testing.T, a, b []byte) bool {
+	t.Helper()
+	var va, vb interface{}
+	if err := json.Unmarshal(a, &va); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, &vb); err != nil {
+		t.Fatal(err)
+	}
+	da, _ := json.Marshal(va)
+	db, _ := json.Marshal(vb)
+	return string(da) == string(db)
+}
+
+func TestReadInputFileTerminal(t *testing.T) {
+	// Like a terminal, /dev/null is a character device
+	tty, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer tty.Close()
+
+	_, err = readInputFile("", tty)
+	if exitCode(err) != exitUsage {
+		t.Errorf("err = %v, want a usage error", err)
+	}
+}
+
+func TestFromJSONPayloadFormat(t *testing.T) {
+	// Compact, with keys out of order and a field unknown to dsg
+	entity := `{"urn":"` + datasetURN("alpha") + `","unknown":{"kept":true},"datasetKey":{"value":{"platform":"urn:li:dataPlatform:mysql","name":"alpha","origin":"PROD"}}}`
+	path := filepath.Join(t.TempDir(), "datasets.json")
+	os.WriteFile(path, []byte("[ "+entity+" ]\n"), 0644)
+
+	tests := []struct {
+		flag string
+		want string
+	}{
+		{flag: "", want: "[" + entity + "]"},
+		{flag: "--compact", want: "[" + entity + "]"},
+		{flag: "--pretty", want: `[{
+    "urn": "` + datasetURN("alpha") + `",
+    "unknown": {
+      "kept": true
+    },
+    "datasetKey": {
+      "value": {
+        "platform": "urn:li:dataPlatform:mysql",
+        "name": "alpha",
+        "origin": "PROD"
+      }
+    }
+  }]`},
+	}
+	for _, tt := range tests {
+		dh := newDataHubStub(t)
+		args := []string{"from-json", "--datahub-gms-url", dh.URL}
+		if tt.flag != "" {
+			args = append(args, tt.flag)
+		}
+		if _, err := runApp(t, append(args, path)...); err != nil {
+			t.Fatalf("%q: %v", tt.flag, err)
+		}
+		if len(dh.bodies) != 1 || dh.bodies[0] != tt.want {
+			t.Errorf("%q: posted %q, want %q", tt.flag, dh.bodies, tt.want)
+		}
+	}
+
+	dh := newDataHubStub(t)
+	_, err := runApp(t, "from-json", "--datahub-gms-url", dh.URL, "--pretty", "--compact", path)
+	if err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Errorf("err = %v, want an error for --pretty and --compact", err)
+	}
+	if len(dh.bodies) != 0 {
+		t.Errorf("posted %q with conflicting flags", dh.bodies)
+	}
+}
+
+func TestFromJSONDuplicateURNs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "datasets.json")
+	os.WriteFile(path, []byte(datasetJSON("alpha", "beta", "alpha")), 0644)
+
+	dh := newDataHubStub(t)
+	_, err := runApp(t, "from-json", "--datahub-gms-url", dh.URL, "--strict", path)
+	if err == nil || !strings.Contains(err.Error(), "duplicate URNs found: "+datasetURN("alpha")) {
+		t.Errorf("err = %v, want a duplicate URNs error", err)
+	}
+	if urns := dh.postedURNs(); urns != nil {
+		t.Errorf("posted %v with --strict", urns)
+	}
+
+	// Without --strict, the duplicates are only a warning
+	if _, err := runApp(t, "from-json", "--datahub-gms-url", dh.URL, path); err != nil {
+		t.Fatal(err)
+	}
+	if urns := dh.postedURNs(); len(urns) != 3 {
+		t.Errorf("posted %v", urns)
+	}
+}
+
+func TestFromJSONAmbiguousEntityType(t *testing.T) {
+	dh := newDataHubStub(t)
+	withStdin(t, `[{"urn": "urn:li:glossaryTerm:Email", "schemaMetadata": {}}]`)
+
+	_, err := runApp(t, "from-json", "--datahub-gms-url", dh.URL)
+	if err == nil || !strings.Contains(err.Error(), "ambiguous entity type (dataset, glossaryTerm), use --entity-type") {
+		t.Errorf("err = %v, want an ambiguous entity type error", err)
+	}
+	if len(dh.bodies) != 0 {
+		t.Errorf("posted %q", dh.bodies)
+	}
+}
+
+func TestFromJSONInvalidEntities(t *testing.T) {
+	dh := newDataHubStub(t)
+	bad := strings.Replace(datasetJSON("beta"), `"version": 0`, `"version": "0"`, 1)
+	input := filepath.Join(t.TempDir(), "datasets.json")
+	payload := strings.TrimSuffix(datasetJSON("alpha"), "]") + "," + strings.TrimPrefix(bad, "[")
+	os.WriteFile(input, []byte(payload), 0644)
+
+	_, err := runApp(t, "from-json", "--datahub-gms-url", dh.URL, input)
+	if exitCode(err) != exitValidation {
+		t.Fatalf("err = %v (exit code %d), want a validation error", err, exitCode(err))
+	}
+	want := "entity 2 (" + datasetURN("beta") + "): schemaMetadata.value.version: expected int, got string"
+	if !strings.Contains(err.Error(), "1 of 2 entities are invalid") || !strings.Contains(err.Error(), want) {
+		t.Errorf("err = %v, want %q", err, want)
+	}
+	if urns := dh.postedURNs(); len(urns) != 0 {
+		t.Errorf("posted %v", urns)
+	}
+
+	// Fields unknown to dsg are only rejected on request
+	os.WriteFile(input, []byte(strings.Replace(datasetJSON("alpha"), `"urn"`, `"extra": 1, "urn"`, 1)), 0644)
+	if _, err := runApp(t, "from-json", "--disallow-unknown-fields", "--datahub-gms-url", dh.URL, input); exitCode(err) != exitValidation || !strings.Contains(err.Error(), `unknown field "extra"`) {
+		t.Errorf("err = %v, want an unknown field error", err)
+	}
+	if _, err := runApp(t, "from-json", "--datahub-gms-url", dh.URL, input); err != nil {
+		t.Fatal(err)
+	}
+	if urns := dh.postedURNs(); !slices.Equal(urns, []string{datasetURN("alpha")}) {
+		t.Errorf("posted %v", urns)
+	}
+}
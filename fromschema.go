@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+	"github.com/rubiojr/dsg/internal/importer"
+	"github.com/urfave/cli/v2"
+)
+
+// runFromSchema creates a dataset from an Avro schema or a JSON Schema,
+// without the AI
+func runFromSchema(c *cli.Context) error {
+	avroFile, jsonSchemaFile := c.String("avro"), c.String("json-schema")
+	if (avroFile == "") == (jsonSchemaFile == "") {
+		return usagef("either --avro or --json-schema is required")
+	}
+
+	platform := platformURN(c.String("platform"))
+	env := strings.ToUpper(c.String("datahub-env"))
+	if err := datahub.ValidateFabric(env); err != nil {
+		return err
+	}
+
+	var s *importer.Schema
+	if avroFile != "" {
+		data, err := os.ReadFile(avroFile)
+		if err != nil {
+			return fmt.Errorf("error reading Avro schema: %w", err)
+		}
+		s, err = importer.ParseAvro(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", avroFile, err)
+		}
+	} else {
+		data, err := os.ReadFile(jsonSchemaFile)
+		if err != nil {
+			return fmt.Errorf("error reading JSON Schema: %w", err)
+		}
+		s, err = importer.ParseJSONSchema(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", jsonSchemaFile, err)
+		}
+	}
+
+	if name := c.String("name"); name != "" {
+		s.Name = name
+	}
+	if s.Name == "" {
+		return usagef("the schema has no title, set the dataset name with --name")
+	}
+
+	return postDatasets(c, []datahub.Dataset{s.ToDataset(platform, env)})
+}
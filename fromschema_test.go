@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+)
+
+func TestFromSchema(t *testing.T) {
+	dir := t.TempDir()
+	avro := filepath.Join(dir, "user.avsc")
+	os.WriteFile(avro, []byte(`{"type": "record", "name": "User", "namespace": "shop", "fields": [
+  {"name": "id", "type": "long"},
+  {"name": "address", "type": {"type": "record", "name": "Address", "fields": [{"name": "city", "type": "string"}]}}
+]}`), 0644)
+	jsonSchema := filepath.Join(dir, "order.json")
+	os.WriteFile(jsonSchema, []byte(`{"type": "object", "properties": {"id": {"type": "integer"}}}`), 0644)
+
+	dh := newDataHubStub(t)
+	if _, err := runApp(t, "from-schema", "--avro", avro, "--platform", "kafka", "--datahub-gms-url", dh.URL); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runApp(t, "from-schema", "--json-schema", jsonSchema, "--name", "orders", "--platform", "kafka", "--datahub-gms-url", dh.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"urn:li:dataset:(urn:li:dataPlatform:kafka,shop.User,PROD)",
+		"urn:li:dataset:(urn:li:dataPlatform:kafka,orders,PROD)",
+	}
+	if urns := dh.postedURNs(); !slices.Equal(urns, want) {
+		t.Fatalf("posted %v, want %v", urns, want)
+	}
+	var schema datahub.SchemaMetadataContainer
+	json.Unmarshal(dh.posted[0]["schemaMetadata"], &schema)
+	var paths []string
+	for _, f := range schema.Value.Fields {
+		paths = append(paths, f.FieldPath)
+	}
+	if !slices.Equal(paths, []string{"id", "address", "address.city"}) {
+		t.Errorf("posted fields %v", paths)
+	}
+
+	for _, args := range [][]string{
+		{"from-schema", "--platform", "kafka"},
+		{"from-schema", "--avro", avro, "--json-schema", jsonSchema, "--platform", "kafka"},
+		// No title nor --name
+		{"from-schema", "--json-schema", jsonSchema, "--platform", "kafka"},
+	} {
+		if _, err := runApp(t, args...); exitCode(err) != exitUsage {
+			t.Errorf("%q: err = %v, want a usage error", args, err)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+	"github.com/rubiojr/dsg/internal/ddl"
+	"github.com/urfave/cli/v2"
+)
+
+// runFromSQL creates datasets from the CREATE TABLE statements in a SQL
+// file, without the AI
+func runFromSQL(c *cli.Context) error {
+	platform := platformURN(c.String("platform"))
+	env := strings.ToUpper(c.String("datahub-env"))
+	if err := datahub.ValidateFabric(env); err != nil {
+		return err
+	}
+
+	data, err := readInputFile(c.String("file"), os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	tables, err := ddl.Parse(string(data))
+	if err != nil {
+		return err
+	}
+
+	datasets := make([]datahub.Dataset, 0, len(tables))
+	for _, t := range tables {
+		datasets = append(datasets, t.ToDataset(platform, env))
+	}
+
+	return postDatasets(c, datasets)
+}
+
+// postDatasets posts the datasets to DataHub, or prints them with --stdout
+func postDatasets(c *cli.Context, datasets []datahub.Dataset) error {
+	if c.Bool("stdout") {
+		out, err := json.MarshalIndent(datasets, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error encoding datasets: %w", err)
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	payload, err := json.Marshal(datasets)
+	if err != nil {
+		return fmt.Errorf("error encoding datasets: %w", err)
+	}
+
+	dh, err := newPosterFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	count, err := dh.PostEntity("dataset", string(payload), &datahub.PostOptions{ContinueOnError: c.Bool("continue-on-error")})
+	if err != nil {
+		if count > 0 {
+			fmt.Printf("%d entities successfully created in DataHub before the errors\n", count)
+		}
+		return fmt.Errorf("error adding datasets: %w", err)
+	}
+
+	for _, ds := range datasets {
+		fmt.Printf("Created %s (%d fields)\n", ds.URN, len(ds.SchemaMetadata.Value.Fields))
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+)
+
+func TestFromSQL(t *testing.T) {
+	dh := newDataHubStub(t)
+	path := filepath.Join(t.TempDir(), "schema.sql")
+	os.WriteFile(path, []byte("CREATE TABLE users (id INT NOT NULL, email VARCHAR(255));\nCREATE TABLE orders (id INT);\n"), 0644)
+
+	out, err := runApp(t, "from-sql", "--file", path, "--platform", "postgres", "--datahub-gms-url", dh.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"urn:li:dataset:(urn:li:dataPlatform:postgres,users,PROD)",
+		"urn:li:dataset:(urn:li:dataPlatform:postgres,orders,PROD)",
+	}
+	if urns := dh.postedURNs(); !slices.Equal(urns, want) {
+		t.Errorf("posted %v, want %v", urns, want)
+	}
+	if !strings.Contains(out, "Created "+want[0]+" (2 fields)") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	var schema datahub.SchemaMetadataContainer
+	json.Unmarshal(dh.posted[0]["schemaMetadata"], &schema)
+	if schema.Value.PlatformSchema.MySqlDDL.TableSchema != "CREATE TABLE users (id INT NOT NULL, email VARCHAR(255))" {
+		t.Errorf("table schema = %q", schema.Value.PlatformSchema.MySqlDDL.TableSchema)
+	}
+}
+
+func TestFromSQLStdout(t *testing.T) {
+	withStdin(t, "CREATE TABLE users (id INT NOT NULL)")
+
+	out, err := runApp(t, "from-sql", "--file", "-", "--platform", "mysql", "--datahub-env", "dev", "--stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var datasets []datahub.Dataset
+	if err := json.Unmarshal([]byte(out), &datasets); err != nil {
+		t.Fatalf("invalid output: %v\n%s", err, out)
+	}
+	if len(datasets) != 1 || datasets[0].URN != "urn:li:dataset:(urn:li:dataPlatform:mysql,users,DEV)" {
+		t.Errorf("datasets = %+v", datasets)
+	}
+
+	withStdin(t, "SELECT 1")
+	if _, err := runApp(t, "from-sql", "--file", "-", "--platform", "mysql", "--stdout"); exitCode(err) != exitValidation {
+		t.Errorf("err = %v, want a validation error", err)
+	}
+}
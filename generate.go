@@ -0,0 +1,715 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+	"github.com/rubiojr/dsg/internal/log"
+	"github.com/rubiojr/dsg/internal/spec"
+	"github.com/sashabaranov/go-openai"
+	"github.com/urfave/cli/v2"
+)
+
+// generateFlags returns the flags shared by the commands generating datasets
+func generateFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "api-key",
+			EnvVars: []string{"OPENAI_API_KEY"},
+			Usage:   "OpenAI API key (required)",
+		},
+		&cli.StringFlag{
+			Name:    "api-base",
+			EnvVars: []string{"OPENAI_API_BASE"},
+			Usage:   "OpenAI API base URL (for Azure OpenAI)",
+			Value:   "https://api.openai.com/v1",
+		},
+		&cli.StringFlag{
+			Name:    "model",
+			EnvVars: []string{"OPENAI_MODEL"},
+			Usage:   "OpenAI model to use",
+			Value:   "gpt-4o",
+		},
+		&cli.StringSliceFlag{
+			Name:    "model-fallback",
+			EnvVars: []string{"OPENAI_MODEL_FALLBACK"},
+			Usage:   "Models tried in order when --model is unavailable or rate limited, comma separated or repeated",
+		},
+		&cli.BoolFlag{
+			Name:    "azure",
+			EnvVars: []string{"OPENAI_USE_AZURE"},
+			Usage:   "Use Azure OpenAI",
+			Value:   false,
+		},
+		&cli.StringFlag{
+			Name:    "azure-deployment",
+			EnvVars: []string{"AZURE_OPENAI_DEPLOYMENT"},
+			Usage:   "Azure OpenAI deployment name (required when using Azure)",
+		},
+		&cli.StringFlag{
+			Name:    "azure-api-version",
+			EnvVars: []string{"AZURE_OPENAI_API_VERSION"},
+			Usage:   "Azure OpenAI API version",
+			Value:   "2023-05-15",
+		},
+		&cli.StringSliceFlag{
+			Name:  "ai-header",
+			Usage: "Extra header (key=value) sent to OpenAI compatible gateways, can be repeated",
+		},
+		&cli.StringFlag{
+			Name:    "ai-org-id",
+			EnvVars: []string{"OPENAI_ORG_ID"},
+			Usage:   "OpenAI organization ID",
+		},
+		&cli.StringFlag{
+			Name:    "datahub-gms-url",
+			EnvVars: []string{"DATAHUB_GMS_URL"},
+			Usage:   "DataHub URL",
+			Value:   "https://api.datahub.io",
+		},
+		&cli.StringFlag{
+			Name:    "datahub-gms-token",
+			EnvVars: []string{"DATAHUB_GMS_TOKEN"},
+			Usage:   "DataHub token",
+		},
+		&cli.BoolFlag{
+			Name:  "strict-instances",
+			Usage: "With several --datahub-gms-url, stop at the first failing instance instead of posting to the rest",
+		},
+		&cli.BoolFlag{
+			Name:  "continue-on-error",
+			Usage: "Keep posting the remaining entities when one fails",
+			Value: false,
+		},
+		&cli.BoolFlag{
+			Name:  "skip-post",
+			Usage: "Do not post the datasets to DataHub",
+			Value: false,
+		},
+		&cli.BoolFlag{
+			Name:  "strict",
+			Usage: "Fail instead of warning when datasets share a URN",
+		},
+		&cli.IntFlag{
+			Name:  "few-shot",
+			Usage: "Number of previously posted generations sent as examples",
+			Value: 0,
+		},
+		&cli.IntFlag{
+			Name:  "few-shot-max-tokens",
+			Usage: "Approximate token budget for the few-shot examples",
+			Value: 4000,
+		},
+		&cli.IntFlag{
+			Name:  "seed",
+			Usage: "Seed for best-effort deterministic generations (reused from history with --prompt-from)",
+		},
+		&cli.IntFlag{
+			Name:  "repair-attempts",
+			Usage: "Times the model is asked to fix an invalid JSON response",
+			Value: 2,
+		},
+		&cli.BoolFlag{
+			Name:  "allow-empty",
+			Usage: "Accept model responses without datasets instead of failing",
+		},
+		&cli.BoolFlag{
+			Name:  "strict-schema",
+			Usage: "Constrain the model to the dataset JSON Schema with structured outputs, if the model supports them",
+		},
+		&cli.StringFlag{
+			Name:    "system-prompt",
+			EnvVars: []string{"DSG_SYSTEM_PROMPT"},
+			Usage:   "Instructions sent to the model as the system message (defaults to the built-in instructions)",
+		},
+		&cli.StringFlag{
+			Name:    "prompt-prefix",
+			EnvVars: []string{"DSG_PROMPT_PREFIX"},
+			Usage:   "Standing instructions added before every user prompt, e.g. naming conventions",
+		},
+		&cli.StringFlag{
+			Name:    "prompt-suffix",
+			EnvVars: []string{"DSG_PROMPT_SUFFIX"},
+			Usage:   "Standing instructions added after every user prompt",
+		},
+		&cli.IntFlag{
+			Name:    "context-window",
+			EnvVars: []string{"OPENAI_CONTEXT_WINDOW"},
+			Usage:   "Model context window in tokens, used to reject prompts that are too long (0 disables the check, known models are detected)",
+		},
+		&cli.BoolFlag{
+			Name:  "check-ai",
+			Usage: "Check that the OpenAI API is reachable and the key is valid before generating",
+		},
+		&cli.StringFlag{
+			Name:    "datahub-env",
+			EnvVars: []string{"DATAHUB_ENV"},
+			Usage:   "DataHub environment (origin) forced on the generated datasets (" + strings.Join(datahub.Fabrics, ", ") + ")",
+		},
+		&cli.StringFlag{
+			Name:  "platform",
+			Usage: "DataHub platform for the generated datasets (name or URN), detected from the prompt if not set",
+		},
+		&cli.StringFlag{
+			Name:  "dataset-name",
+			Usage: "Name of the generated dataset, used to build its URN instead of the generated one",
+		},
+		&cli.StringFlag{
+			Name:  "platform-instance",
+			Usage: "DataHub platform instance of the generated datasets (instance ID or urn:li:dataPlatformInstance URN)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "default-term",
+			Usage: "Glossary term URN added to every generated dataset, can be repeated",
+		},
+		&cli.StringSliceFlag{
+			Name:  "dataset-tag",
+			Usage: "Tag name or URN added to every generated dataset and created if missing, can be repeated",
+		},
+		&cli.StringFlag{
+			Name:    "provenance-tag",
+			EnvVars: []string{"DSG_PROVENANCE_TAG"},
+			Usage:   "Tag added to every generated dataset besides the --dataset-tag ones, e.g. generated-by-dsg",
+		},
+		&cli.StringFlag{
+			Name:  "description-prefix",
+			Usage: "Text prepended to the description of every generated dataset",
+		},
+		&cli.BoolFlag{
+			Name:  "with-ddl",
+			Usage: "Ask the model to include the CREATE TABLE statement in the platform schema",
+		},
+		&cli.StringFlag{
+			Name:  "platform-map",
+			Usage: "JSON file mapping bare platform names in the generated datasets to DataHub platform URNs",
+		},
+		&cli.StringFlag{
+			Name:  "platform-keywords",
+			Usage: "JSON file mapping prompt keywords to DataHub platform URNs used to detect the platform",
+		},
+		&cli.BoolFlag{
+			Name:    "compress-history",
+			EnvVars: []string{"DSG_COMPRESS_HISTORY"},
+			Usage:   "Compress the prompts and responses saved to the history",
+			Value:   false,
+		},
+		&cli.StringFlag{
+			Name:    "prompt-template",
+			EnvVars: []string{"DSG_PROMPT_TEMPLATE"},
+			Usage:   "Go text/template file used to build the prompt ({{.Reference}}, {{.UserInput}}, {{.Timestamp}})",
+		},
+	}
+}
+
+// generator generates datasets using the OpenAI API
+type generator struct {
+	client       *openai.Client
+	model        string
+	systemPrompt string
+	template     *PromptTemplate
+	examples     []fewShotExample
+	seed         *int
+	maxRepairs   int
+	platform     string
+	// datasetName replaces the generated dataset name, if set
+	datasetName string
+	keywords    map[string]string
+	// platformMap maps bare platform names to platform URNs
+	platformMap map[string]string
+	env         string
+	withDDL     bool
+	// platformInstance is attached to the generated datasets, if set
+	platformInstance string
+	// contextWindow is the model context window in tokens, 0 if unknown
+	contextWindow int
+	// fixedContextWindow is set when contextWindow applies to every model
+	fixedContextWindow bool
+	// fallbackModels are tried in order when model is unavailable
+	fallbackModels []string
+	// progress reports the model being waited on, if set
+	progress progress
+	// out receives the warnings of the generation, stderr if nil, so they
+	// never mix with a JSON document on stdout
+	out io.Writer
+	// strictSchema sends the datasets JSON Schema to the model
+	strictSchema bool
+	// promptPrefix and promptSuffix wrap every user prompt
+	promptPrefix string
+	promptSuffix string
+	// allowEmpty accepts responses without datasets
+	allowEmpty bool
+	// responseFile caches the raw AI response, see cachedResponse
+	responseFile string
+	// defaults are merged into every generated dataset
+	defaults datahub.DatasetDefaults
+}
+
+// generation is the result of generating datasets from a user prompt
+type generation struct {
+	UserInput   string
+	Prompt      string
+	FullPrompt  string
+	Response    string
+	SchemaName  string
+	SchemaURN   string
+	DatasetName string
+	URNs        []string
+	Seed        *int
+	// Tokens is the number of tokens used, including repair attempts
+	Tokens int
+	// Model is the model that generated the response
+	Model string
+}
+
+// newGenerator creates a generator configured from the generateFlags
+func newGenerator(c *cli.Context) (*generator, error) {
+	var client *openai.Client
+	var err error
+	// No OpenAI client is needed when only printing the prompt or
+	// converting a spec file
+	specOnly := c.String("spec-file") != "" && !c.Bool("spec-prompt")
+	// nor when the response is read from --response-file
+	responseFile := c.String("response-file")
+	_, statErr := os.Stat(responseFile)
+	cached := responseFile != "" && statErr == nil
+	if !c.Bool("prompt-only") && !specOnly && !cached {
+		client, err = newOpenAIClient(c)
+		if err != nil {
+			return nil, err
+		}
+		if c.Bool("check-ai") {
+			if err := checkAI(c.Context, client, c.String("api-base")); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	promptTemplate, err := loadPromptTemplate(c.String("prompt-template"))
+	if err != nil {
+		return nil, err
+	}
+
+	systemPrompt := c.String("system-prompt")
+	if systemPrompt == "" {
+		systemPrompt = defaultSystemPrompt
+	}
+
+	g := &generator{
+		client:       client,
+		model:        c.String("model"),
+		systemPrompt: systemPrompt,
+		template:     promptTemplate,
+		maxRepairs:   c.Int("repair-attempts"),
+		withDDL:      c.Bool("with-ddl"),
+	}
+
+	g.fallbackModels = c.StringSlice("model-fallback")
+	g.strictSchema = c.Bool("strict-schema")
+	g.promptPrefix = c.String("prompt-prefix")
+	g.promptSuffix = c.String("prompt-suffix")
+	g.allowEmpty = c.Bool("allow-empty")
+	g.responseFile = responseFile
+	g.contextWindow = c.Int("context-window")
+	g.fixedContextWindow = c.IsSet("context-window")
+	if !g.fixedContextWindow {
+		g.contextWindow = contextWindow(g.model)
+	}
+
+	g.env = strings.ToUpper(c.String("datahub-env"))
+	if g.env != "" {
+		if err := datahub.ValidateFabric(g.env); err != nil {
+			return nil, err
+		}
+	}
+
+	g.platform = platformURN(c.String("platform"))
+	g.datasetName = c.String("dataset-name")
+	g.platformInstance = c.String("platform-instance")
+	if g.platformInstance != "" {
+		_, instancePlatform, err := datahub.ParsePlatformInstance(g.platformInstance)
+		if err != nil {
+			return nil, err
+		}
+		// Instance URNs tell the platform too
+		if g.platform == "" {
+			g.platform = instancePlatform
+		}
+	}
+	g.defaults = datahub.DatasetDefaults{
+		Terms:             c.StringSlice("default-term"),
+		Tags:              datasetTagURNs(append(c.StringSlice("dataset-tag"), c.String("provenance-tag"))),
+		DescriptionPrefix: c.String("description-prefix"),
+	}
+	if err := g.defaults.Validate(); err != nil {
+		return nil, err
+	}
+
+	g.keywords, err = loadPlatformKeywords(c.String("platform-keywords"))
+	if err != nil {
+		return nil, err
+	}
+	g.platformMap, err = loadPlatformMap(c.String("platform-map"))
+	if err != nil {
+		return nil, err
+	}
+
+	if c.IsSet("seed") {
+		seed := c.Int("seed")
+		g.seed = &seed
+	}
+
+	if n := c.Int("few-shot"); n > 0 {
+		g.examples, err = loadFewShotExamples(n, c.Int("few-shot-max-tokens"))
+		if err != nil {
+			return nil, err
+		}
+		log.Debugf("Using %d few-shot examples from history\n", len(g.examples))
+	}
+
+	return g, nil
+}
+
+// newOpenAIClient creates an OpenAI (or Azure OpenAI) client from the generateFlags
+func newOpenAIClient(c *cli.Context) (*openai.Client, error) {
+	apiKey := c.String("api-key")
+	apiBase := c.String("api-base")
+	useAzure := c.Bool("azure")
+	azureDeployment := c.String("azure-deployment")
+	azureAPIVersion := c.String("azure-api-version")
+
+	if apiKey == "" {
+		return nil, usagef("api-key is required")
+	}
+
+	if err := validateAPIBase(apiBase); err != nil {
+		return nil, err
+	}
+
+	// Validate Azure arguments
+	if useAzure && azureDeployment == "" {
+		return nil, usagef("azure-deployment is required when using Azure OpenAI")
+	}
+
+	headers, err := parseHeaders(c.StringSlice("ai-header"))
+	if err != nil {
+		return nil, err
+	}
+
+	hc, err := newHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	hc = withMetrics(withHeaders(hc, headers), "openai")
+
+	if useAzure {
+		config := openai.DefaultAzureConfig(apiKey, azureDeployment)
+		config.APIVersion = azureAPIVersion
+		config.BaseURL = apiBase
+		config.HTTPClient = hc
+		return openai.NewClientWithConfig(config), nil
+	}
+
+	config := openai.DefaultConfig(apiKey)
+	config.BaseURL = apiBase
+	config.OrgID = c.String("ai-org-id")
+	config.HTTPClient = hc
+	return openai.NewClientWithConfig(config), nil
+}
+
+// render renders the user and combined (system + user) prompts for userInput
+func (g *generator) render(userInput string) (string, string, error) {
+	platform := g.platform
+	if platform == "" {
+		platform = detectPlatform(userInput, g.keywords)
+		if platform != "" {
+			log.Debugf("Detected platform %s from the prompt\n", platform)
+		}
+	}
+
+	data := PromptData{
+		Reference:    trainingDataset,
+		UserInput:    sanitizeUserInput(userInput),
+		PromptPrefix: strings.TrimSpace(g.promptPrefix),
+		PromptSuffix: strings.TrimSpace(g.promptSuffix),
+		Timestamp:    time.Now().UnixMilli(),
+		Platform:     platform,
+		WithDDL:      g.withDDL,
+	}
+	if !g.template.UsesPromptAffixes() {
+		// Templates predating {{.PromptPrefix}} and {{.PromptSuffix}} get
+		// them in the user input
+		data.UserInput = sanitizeUserInput(wrapUserInput(g.promptPrefix, userInput, g.promptSuffix))
+	}
+	prompt, err := g.template.Render(data)
+	if err != nil {
+		return "", "", err
+	}
+
+	return prompt, combinedPrompt(g.systemPrompt, prompt), nil
+}
+
+// wrapUserInput surrounds userInput with the --prompt-prefix and
+// --prompt-suffix instructions, separated by blank lines
+func wrapUserInput(prefix, userInput, suffix string) string {
+	if prefix = strings.TrimSpace(prefix); prefix != "" {
+		userInput = prefix + "\n\n" + userInput
+	}
+	if suffix = strings.TrimSpace(suffix); suffix != "" {
+		userInput = strings.TrimRight(userInput, "\n") + "\n\n" + suffix + "\n"
+	}
+	return userInput
+}
+
+// generate generates the datasets described by userInput
+func (g *generator) generate(ctx context.Context, userInput string) (*generation, error) {
+	prompt, fullPrompt, err := g.render(userInput)
+	if err != nil {
+		return nil, err
+	}
+
+	gr := generationRequest{
+		Model:        g.model,
+		SystemPrompt: g.systemPrompt,
+		Examples:     g.examples,
+		Prompt:       prompt,
+		Seed:         g.seed,
+		MaxRepairs:   g.maxRepairs,
+		StrictSchema: g.strictSchema,
+		Out:          g.output(),
+	}
+
+	responseData, cached, err := g.cachedResponse()
+	if err != nil {
+		return nil, err
+	}
+
+	// Fall back to the next model when one is unavailable
+	models := append([]string{g.model}, g.fallbackModels...)
+	var tokens int
+	for i, model := range models {
+		if cached {
+			break
+		}
+		gr.Model = model
+		if err := checkContextWindow(gr, g.modelContextWindow(model)); err != nil {
+			return nil, err
+		}
+
+		var used int
+		responseData, used, err = sendOpenAIRequest(ctx, g.client, gr)
+		tokens += used
+		if err == nil {
+			break
+		}
+		if i == len(models)-1 || !isModelUnavailable(err) {
+			return nil, fmt.Errorf("error sending request to OpenAI: %w", err)
+		}
+		if g.progress != nil {
+			g.progress.Update(fmt.Sprintf("Waiting for %s", models[i+1]))
+		}
+		fmt.Fprintf(g.output(), "Warning: model %s is unavailable (%v), falling back to %s\n", model, err, models[i+1])
+	}
+	if !cached {
+		if err := g.cacheResponse(responseData); err != nil {
+			return nil, err
+		}
+	}
+
+	gen, err := g.newGeneration(userInput, prompt, fullPrompt, responseData)
+	if err != nil {
+		return nil, err
+	}
+	gen.Seed = g.seed
+	gen.Tokens = tokens
+	gen.Model = gr.Model
+
+	if g.withDDL {
+		var datasets []datahub.Dataset
+		if err := json.Unmarshal([]byte(gen.Response), &datasets); err != nil {
+			return nil, fmt.Errorf("error parsing JSON response: %w", err)
+		}
+		for _, ds := range datasets {
+			if ds.SchemaMetadata.Value.PlatformSchema.MySqlDDL.TableSchema == "" {
+				fmt.Fprintf(g.output(), "Warning: the model did not generate the DDL for %s\n", ds.URN)
+			}
+		}
+	}
+
+	return gen, nil
+}
+
+// cachedResponse returns the raw AI response saved to --response-file by a
+// previous run, if any, so the same input can be generated again without
+// calling the API
+func (g *generator) cachedResponse() (string, bool, error) {
+	if g.responseFile == "" {
+		return "", false, nil
+	}
+	data, err := os.ReadFile(g.responseFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("error reading response file: %w", err)
+	}
+	fmt.Fprintf(g.output(), "Using the response in %s instead of calling the API\n", g.responseFile)
+	return string(data), true, nil
+}
+
+// cacheResponse saves the raw AI response to --response-file, if set
+func (g *generator) cacheResponse(responseData string) error {
+	if g.responseFile == "" {
+		return nil
+	}
+	// Only readable by the user, like the prompt file
+	if err := os.WriteFile(g.responseFile, []byte(responseData), 0600); err != nil {
+		return fmt.Errorf("error writing response file: %w", err)
+	}
+	return nil
+}
+
+// output returns the writer of the generation warnings
+func (g *generator) output() io.Writer {
+	if g.out == nil {
+		return os.Stderr
+	}
+	return g.out
+}
+
+// modelContextWindow returns the context window of model, --context-window
+// if set
+func (g *generator) modelContextWindow(model string) int {
+	if g.fixedContextWindow || model == g.model {
+		return g.contextWindow
+	}
+	return contextWindow(model)
+}
+
+// generateFromSpec converts a dataset spec into datasets, without the AI.
+// source is the spec file content, saved to the history as the prompt.
+func (g *generator) generateFromSpec(s *spec.Spec, source string) (*generation, error) {
+	data, err := json.Marshal(s.ToDatasets())
+	if err != nil {
+		return nil, fmt.Errorf("error encoding datasets: %w", err)
+	}
+	return g.newGeneration(source, source, "", string(data))
+}
+
+// newGeneration applies the generator settings to the generated datasets
+// in responseData and returns the resulting generation
+func (g *generator) newGeneration(userInput, prompt, fullPrompt, responseData string) (*generation, error) {
+	responseData, unknown, err := datahub.NormalizeDatasetPlatforms(responseData, g.platformMap)
+	if err != nil {
+		return nil, err
+	}
+	for _, platform := range unknown {
+		fmt.Fprintf(g.output(), "Warning: unknown platform %q, using %s (add it to --platform-map)\n", platform, datahub.PlatformURNPrefix+strings.ToLower(platform))
+	}
+
+	// Dataset URNs are rebuilt from their keys rather than trusting the model
+	var warnings []string
+	responseData, warnings, err = datahub.RebuildDatasetURNs(responseData, datahub.URNOverrides{
+		Platform: g.platform,
+		Name:     g.datasetName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(g.output(), "Warning: %s\n", w)
+	}
+
+	if g.env != "" {
+		responseData, err = datahub.SetDatasetsOrigin(responseData, g.env)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if g.platformInstance != "" {
+		responseData, err = datahub.SetDatasetsPlatformInstance(responseData, g.platformInstance)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !g.defaults.Empty() {
+		responseData, err = datahub.ApplyDatasetDefaults(responseData, g.defaults)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Generations are saved with a stable layout, so they can be compared
+	canonical, err := datahub.CanonicalJSON([]byte(responseData))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing JSON response: %w", err)
+	}
+	responseData = string(canonical)
+
+	gen := &generation{
+		UserInput:  userInput,
+		Prompt:     prompt,
+		FullPrompt: fullPrompt,
+		Response:   responseData,
+	}
+	gen.SchemaName, gen.SchemaURN, gen.DatasetName, err = extractSchemaInfo(responseData)
+	if err != nil {
+		return nil, err
+	}
+
+	var datasets []datahub.Dataset
+	if err := json.Unmarshal([]byte(responseData), &datasets); err != nil {
+		return nil, fmt.Errorf("error parsing JSON response: %w", err)
+	}
+	if len(datasets) == 0 && !g.allowEmpty {
+		return nil, fmt.Errorf("the model returned no datasets (use --allow-empty to accept empty responses)")
+	}
+	gen.URNs = make([]string, 0, len(datasets))
+	for _, ds := range datasets {
+		gen.URNs = append(gen.URNs, ds.URN)
+	}
+
+	return gen, nil
+}
+
+// extractSchemaInfo returns the schema name, URN and dataset name of the
+// first dataset in the response
+func extractSchemaInfo(responseData string) (string, string, string, error) {
+	// Parse the JSON response
+	var jsonResponse []map[string]interface{}
+	if err := json.Unmarshal([]byte(responseData), &jsonResponse); err != nil {
+		return "", "", "", fmt.Errorf("error parsing JSON response: %w", err)
+	}
+
+	// Extract schema information
+	var schemaName, schemaURN, datasetName string
+	if len(jsonResponse) > 0 {
+		if metadata, ok := jsonResponse[0]["schemaMetadata"].(map[string]interface{}); ok {
+			if value, ok := metadata["value"].(map[string]interface{}); ok {
+				if name, ok := value["schemaName"].(string); ok {
+					schemaName = name
+				}
+			}
+		}
+		if urn, ok := jsonResponse[0]["urn"].(string); ok {
+			schemaURN = urn
+		}
+		if datasetKey, ok := jsonResponse[0]["datasetKey"].(map[string]interface{}); ok {
+			if value, ok := datasetKey["value"].(map[string]interface{}); ok {
+				if name, ok := value["name"].(string); ok {
+					datasetName = name
+				}
+			}
+		}
+	}
+
+	return schemaName, schemaURN, datasetName, nil
+}
@@ -0,0 +1,508 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+	storage "github.com/rubiojr/dsg/internal/storage/sqlite"
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestPromptOnly(t *testing.T) {
+	dataDir := testDataDir(t)
+	withStdin(t, "a users table with an email\n")
+
+	out, err := runApp(t, "generate", "--prompt-only")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		defaultSystemPrompt,
+		strings.TrimSpace(trainingDataset),
+		"<user_input>\na users table with an email\n</user_input>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("prompt is missing %q", want)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dataDir, "history.db")); !os.IsNotExist(err) {
+		t.Errorf("the history database was created: %v", err)
+	}
+}
+
+func TestGenerateSummaryJSON(t *testing.T) {
+	testDataDir(t)
+	for _, fail := range []bool{false, true} {
+		dh := newDataHubStub(t)
+		dh.fail = func(string) bool { return fail }
+		apiBase, _ := openAIStub(t, func(req openai.ChatCompletionRequest) string {
+			return datasetJSON(userInput(req))
+		})
+		withStdin(t, "alpha\n")
+
+		out, err := runApp(t, "generate",
+			"--summary", "json",
+			"--api-key", "test",
+			"--api-base", apiBase,
+			"--model", "m",
+			"--datahub-gms-url", dh.URL,
+			"--repair-attempts", "0",
+		)
+		if (err != nil) != fail {
+			t.Fatalf("fail=%v: err = %v", fail, err)
+		}
+
+		// stdout only holds the summary
+		var keys map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(out), &keys); err != nil {
+			t.Fatalf("fail=%v: invalid summary %q: %v", fail, out, err)
+		}
+		for _, key := range []string{"history_id", "urns", "count", "schema_name", "tokens", "posted", "errors"} {
+			if _, ok := keys[key]; !ok {
+				t.Errorf("fail=%v: summary is missing %q: %s", fail, key, out)
+			}
+		}
+
+		var summary generateSummary
+		json.Unmarshal([]byte(out), &summary)
+		if summary.HistoryID < 1 || !slices.Equal(summary.URNs, []string{datasetURN("alpha")}) ||
+			summary.Count != 1 || summary.SchemaName != "alpha" || summary.Tokens != 10 {
+			t.Errorf("fail=%v: summary = %+v", fail, summary)
+		}
+		if summary.Posted == fail || (len(summary.Errors) > 0) != fail {
+			t.Errorf("fail=%v: posted = %v, errors = %q", fail, summary.Posted, summary.Errors)
+		}
+	}
+}
+
+func TestGenerateWithDDL(t *testing.T) {
+	testDataDir(t)
+	const ddl = "CREATE TABLE alpha (id int)"
+	for _, modelWritesDDL := range []bool{true, false} {
+		dh := newDataHubStub(t)
+		apiBase, requests := openAIStub(t, func(req openai.ChatCompletionRequest) string {
+			data := datasetJSON(userInput(req))
+			if modelWritesDDL && strings.Contains(lastMessage(req), "CREATE TABLE statement") {
+				data = strings.Replace(data, `"tableSchema": ""`, `"tableSchema": "`+ddl+`"`, 1)
+			}
+			return data
+		})
+		withStdin(t, "alpha\n")
+
+		out, err := runApp(t, "generate",
+			"--with-ddl",
+			"--api-key", "test",
+			"--api-base", apiBase,
+			"--model", "m",
+			"--datahub-gms-url", dh.URL,
+			"--repair-attempts", "0",
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(*requests) != 1 || !strings.Contains(lastMessage((*requests)[0]), "CREATE TABLE statement") {
+			t.Fatalf("the prompt is missing the DDL instructions")
+		}
+		if len(dh.posted) != 1 {
+			t.Fatalf("posted %d datasets, want 1", len(dh.posted))
+		}
+
+		var metadata struct {
+			Value datahub.SchemaMetadata `json:"value"`
+		}
+		if err := json.Unmarshal(dh.posted[0]["schemaMetadata"], &metadata); err != nil {
+			t.Fatal(err)
+		}
+		tableSchema := metadata.Value.PlatformSchema.MySqlDDL.TableSchema
+		warned := strings.Contains(out, "Warning: the model did not generate the DDL for "+datasetURN("alpha"))
+		if modelWritesDDL && (tableSchema != ddl || warned) {
+			t.Errorf("tableSchema = %q, warned = %v", tableSchema, warned)
+		}
+		if !modelWritesDDL && (tableSchema != "" || !warned) {
+			t.Errorf("missing DDL: tableSchema = %q, warned = %v:\n%s", tableSchema, warned, out)
+		}
+	}
+}
+
+func TestGenerateNoSaveHistory(t *testing.T) {
+	dataDir := filepath.Join(t.TempDir(), "missing")
+	t.Setenv("DSG_DATA_DIR", dataDir)
+
+	dh := newDataHubStub(t)
+	apiBase, _ := openAIStub(t, func(req openai.ChatCompletionRequest) string {
+		return datasetJSON(userInput(req))
+	})
+	withStdin(t, "alpha\n")
+
+	out, err := runApp(t, "generate",
+		"--no-save-history",
+		"--stdout",
+		"--api-key", "test",
+		"--api-base", apiBase,
+		"--model", "m",
+		"--datahub-gms-url", dh.URL,
+		"--repair-attempts", "0",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, datasetURN("alpha")) {
+		t.Errorf("--stdout didn't print the datasets:\n%s", out)
+	}
+	if urns := dh.postedURNs(); !slices.Equal(urns, []string{datasetURN("alpha")}) {
+		t.Errorf("posted %v", urns)
+	}
+	if _, err := os.Stat(dataDir); !os.IsNotExist(err) {
+		t.Errorf("the data directory was created: %v", err)
+	}
+
+	// With an existing history database, no row is added
+	seedHistory(t, dataDir, &storage.Response{Prompt: "seed", Response: "[]"})
+	withStdin(t, "beta\n")
+	if _, err := runApp(t, "generate", "--no-save-history", "--api-key", "test", "--api-base", apiBase,
+		"--model", "m", "--datahub-gms-url", dh.URL, "--repair-attempts", "0"); err != nil {
+		t.Fatal(err)
+	}
+	db, err := storage.NewSQLiteStorage(storage.WithDataDir(dataDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	history, err := db.ListResponses(10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].Prompt != "seed" {
+		t.Errorf("history = %+v, want only the seeded entry", history)
+	}
+
+	// Reading the history is refused too
+	for _, flag := range []string{"--few-shot=1", "--suggest"} {
+		if _, err := runApp(t, "generate", "--no-save-history", flag); exitCode(err) != exitUsage {
+			t.Errorf("%s: err = %v, want a usage error", flag, err)
+		}
+	}
+}
+
+func TestGeneratePlatformInstance(t *testing.T) {
+	testDataDir(t)
+	dh := newDataHubStub(t)
+	apiBase, _ := openAIStub(t, func(req openai.ChatCompletionRequest) string {
+		return datasetJSON(userInput(req))
+	})
+	withStdin(t, "alpha\n")
+
+	_, err := runApp(t, "generate",
+		"--platform-instance", "eu_account",
+		"--api-key", "test",
+		"--api-base", apiBase,
+		"--model", "m",
+		"--datahub-gms-url", dh.URL,
+		"--repair-attempts", "0",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if urns := dh.postedURNs(); !slices.Equal(urns, []string{datasetURN("eu_account.alpha")}) {
+		t.Errorf("posted %v", urns)
+	}
+	if len(dh.posted) == 1 && !strings.Contains(string(dh.posted[0]["dataPlatformInstance"]), "urn:li:dataPlatformInstance:(urn:li:dataPlatform:mysql,eu_account)") {
+		t.Errorf("dataPlatformInstance = %s", dh.posted[0]["dataPlatformInstance"])
+	}
+}
+
+func TestGenerateKeepTemp(t *testing.T) {
+	testDataDir(t)
+	dh := newDataHubStub(t)
+	apiBase, _ := openAIStub(t, func(req openai.ChatCompletionRequest) string {
+		return datasetJSON(userInput(req))
+	})
+
+	for _, keep := range []bool{false, true} {
+		tmp := t.TempDir()
+		t.Setenv("TMPDIR", tmp)
+		withStdin(t, "alpha\n")
+
+		args := []string{"generate", "--api-key", "test", "--api-base", apiBase, "--model", "m", "--datahub-gms-url", dh.URL}
+		if keep {
+			args = append(args, "--keep-temp")
+		}
+		out, err := runApp(t, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		files, _ := filepath.Glob(filepath.Join(tmp, "*prompt*"))
+		if !keep {
+			if len(files) != 0 || strings.Contains(out, "Keeping") {
+				t.Errorf("the temporary files were kept: %v\n%s", files, out)
+			}
+			continue
+		}
+
+		if len(files) != 2 {
+			t.Fatalf("kept %v, want the prompt and response files", files)
+		}
+		for _, path := range files {
+			if !strings.Contains(out, "Keeping the") || !strings.Contains(out, path) {
+				t.Errorf("output is missing %s:\n%s", path, out)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if perm := info.Mode().Perm(); perm != 0600 {
+				t.Errorf("%s has permissions %o, want 600", path, perm)
+			}
+			data, _ := os.ReadFile(path)
+			if strings.HasSuffix(path, ".response.json") {
+				if !jsonEqual(t, data, []byte(datasetJSON("alpha"))) {
+					t.Errorf("response file = %s", data)
+				}
+			} else if !strings.Contains(string(data), "alpha") {
+				t.Errorf("prompt file = %s", data)
+			}
+		}
+	}
+}
+
+func TestGenerateDatasetName(t *testing.T) {
+	testDataDir(t)
+	dh := newDataHubStub(t)
+	apiBase, _ := openAIStub(t, func(req openai.ChatCompletionRequest) string {
+		// A URN disagreeing with the dataset key
+		return strings.Replace(datasetJSON(userInput(req)), datasetURN("alpha"), datasetURN("wrong"), 1)
+	})
+	withStdin(t, "alpha\n")
+
+	_, err := runApp(t, "generate",
+		"--dataset-name", "sales.alpha",
+		"--platform", "postgres",
+		"--datahub-env", "DEV",
+		"--api-key", "test",
+		"--api-base", apiBase,
+		"--model", "m",
+		"--datahub-gms-url", dh.URL,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "urn:li:dataset:(urn:li:dataPlatform:postgres,sales.alpha,DEV)"
+	if urns := dh.postedURNs(); !slices.Equal(urns, []string{want}) {
+		t.Fatalf("posted %v, want %s", urns, want)
+	}
+	var key datahub.DatasetKeyContainer
+	json.Unmarshal(dh.posted[0]["datasetKey"], &key)
+	if key.Value.Platform != "urn:li:dataPlatform:postgres" || key.Value.Name != "sales.alpha" || key.Value.Origin != "DEV" {
+		t.Errorf("posted dataset key %+v", key.Value)
+	}
+}
+
+func TestGenerateStableBytes(t *testing.T) {
+	dataDir := testDataDir(t)
+	dh := newDataHubStub(t)
+
+	// The model writes the same dataset with different key orders and spacing
+	outputs := []string{
+		`[{"urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)", "schemaMetadata": {"value": {"schemaName": "users", "platform": "urn:li:dataPlatform:mysql", "version": 0, "hash": "", "fields": []}}}]`,
+		`[ { "schemaMetadata": { "value": { "fields": [], "hash": "", "version": 0, "platform": "urn:li:dataPlatform:mysql", "schemaName": "users" } }, "urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)" } ]`,
+	}
+	for _, output := range outputs {
+		apiBase, _ := openAIStub(t, func(openai.ChatCompletionRequest) string { return output })
+		withStdin(t, "users\n")
+		if _, err := runApp(t, "generate", "--api-key", "test", "--api-base", apiBase, "--model", "m", "--datahub-gms-url", dh.URL); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db, err := storage.NewSQLiteStorage(storage.WithDataDir(dataDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	history, err := db.ListResponses(10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("%d history entries, want 2", len(history))
+	}
+	if history[0].Response != history[1].Response {
+		t.Errorf("the generations were saved with different bytes:\n%s\n%s", history[0].Response, history[1].Response)
+	}
+	canonical, _ := datahub.CanonicalJSON([]byte(history[0].Response))
+	if history[0].Response != string(canonical) {
+		t.Errorf("the generation wasn't saved as canonical JSON:\n%s", history[0].Response)
+	}
+
+	if len(dh.bodies) != 2 || dh.bodies[0] != dh.bodies[1] {
+		t.Errorf("posted bodies differ: %q", dh.bodies)
+	}
+}
+
+func TestWrapUserInput(t *testing.T) {
+	tests := []struct {
+		prefix, input, suffix, want string
+	}{
+		{"", "a users table\n", "", "a users table\n"},
+		{"Use snake_case.", "a users table\n", "", "Use snake_case.\n\na users table\n"},
+		{"", "a users table\n", "Add a created_at field.", "a users table\n\nAdd a created_at field.\n"},
+		{" Use snake_case.\n", "a users table\n\n", "\nAdd a created_at field. ", "Use snake_case.\n\na users table\n\nAdd a created_at field.\n"},
+		{"  ", "a users table", "\n", "a users table"},
+	}
+	for _, tt := range tests {
+		if got := wrapUserInput(tt.prefix, tt.input, tt.suffix); got != tt.want {
+			t.Errorf("wrapUserInput(%q, %q, %q) = %q, want %q", tt.prefix, tt.input, tt.suffix, got, tt.want)
+		}
+	}
+}
+
+// checkPromptAffixes fails unless the prefix comes before the user input
+// block and the suffix after it
+func checkPromptAffixes(t *testing.T, prompt string) {
+	t.Helper()
+	order := []string{"Name every field in snake_case.", "<user_input>\na users table\n</user_input>", "Include a created_at timestamp."}
+	last := -1
+	for _, want := range order {
+		i := strings.Index(prompt, want)
+		if i <= last {
+			t.Errorf("prompt doesn't have %q after %d:\n%s", want, last, prompt)
+			return
+		}
+		last = i
+	}
+}
+
+func TestPromptPrefixSuffix(t *testing.T) {
+	dataDir := testDataDir(t)
+	withStdin(t, "a users table\n")
+
+	out, err := runApp(t, "generate", "--prompt-only",
+		"--prompt-prefix", "Name every field in snake_case.",
+		"--prompt-suffix", "Include a created_at timestamp.",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPromptAffixes(t, out)
+
+	// The standing instructions are saved with the rendered prompt of the
+	// history entry
+	dh := newDataHubStub(t)
+	apiBase, requests := openAIStub(t, func(openai.ChatCompletionRequest) string { return datasetJSON("users") })
+	withStdin(t, "a users table\n")
+	t.Setenv("DSG_PROMPT_SUFFIX", "Include a created_at timestamp.")
+	if _, err := runApp(t, "generate", "--prompt-prefix", "Name every field in snake_case.",
+		"--api-key", "test", "--api-base", apiBase, "--model", "m", "--datahub-gms-url", dh.URL); err != nil {
+		t.Fatal(err)
+	}
+	if len(*requests) != 1 {
+		t.Fatalf("sent %d requests, want 1", len(*requests))
+	}
+	checkPromptAffixes(t, lastMessage((*requests)[0]))
+
+	db, err := storage.NewSQLiteStorage(storage.WithDataDir(dataDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	history, err := db.ListResponses(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("%d history entries, want 1", len(history))
+	}
+	checkPromptAffixes(t, history[0].RenderedPrompt)
+}
+
+func TestGenerateEmpty(t *testing.T) {
+	testDataDir(t)
+	for _, response := range []string{"[]", "[ ]\n", "```json\n[]\n```", "There are no tables to describe: []"} {
+		dh := newDataHubStub(t)
+		apiBase, _ := openAIStub(t, func(openai.ChatCompletionRequest) string { return response })
+		args := []string{"generate", "--api-key", "test", "--api-base", apiBase, "--model", "m",
+			"--datahub-gms-url", dh.URL, "--repair-attempts", "0"}
+
+		withStdin(t, "nothing\n")
+		_, err := runApp(t, args...)
+		if err == nil || !strings.Contains(err.Error(), "the model returned no datasets") {
+			t.Errorf("%q: err = %v, want a no datasets error", response, err)
+		}
+
+		withStdin(t, "nothing\n")
+		if _, err := runApp(t, append(args, "--allow-empty")...); err != nil {
+			t.Errorf("%q with --allow-empty: %v", response, err)
+		}
+		if urns := dh.postedURNs(); len(urns) != 0 {
+			t.Errorf("%q: posted %v", response, urns)
+		}
+	}
+}
+
+func TestGenerateResponseFile(t *testing.T) {
+	dataDir := testDataDir(t)
+	dh := newDataHubStub(t)
+	apiBase, requests := openAIStub(t, func(req openai.ChatCompletionRequest) string {
+		return datasetJSON(userInput(req))
+	})
+	responseFile := filepath.Join(t.TempDir(), "response.json")
+
+	// Cache miss: the API is called and the response saved
+	withStdin(t, "alpha\n")
+	if _, err := runApp(t, "generate", "--response-file", responseFile, "--api-key", "test", "--api-base", apiBase,
+		"--model", "m", "--datahub-gms-url", dh.URL); err != nil {
+		t.Fatal(err)
+	}
+	if len(*requests) != 1 {
+		t.Fatalf("sent %d requests, want 1", len(*requests))
+	}
+	data, err := os.ReadFile(responseFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != datasetJSON("alpha") {
+		t.Errorf("response file = %s", data)
+	}
+	if fi, _ := os.Stat(responseFile); fi.Mode().Perm() != 0600 {
+		t.Errorf("response file mode = %v, want 0600", fi.Mode().Perm())
+	}
+
+	// Cache hit: no API call nor API key needed, even for another input
+	t.Setenv("OPENAI_API_KEY", "")
+	withStdin(t, "beta\n")
+	out, err := runApp(t, "generate", "--response-file", responseFile, "--api-base", apiBase,
+		"--model", "m", "--datahub-gms-url", dh.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*requests) != 1 {
+		t.Errorf("sent %d requests, want the cached response to be used", len(*requests))
+	}
+	if !strings.Contains(out, "Using the response in "+responseFile) {
+		t.Errorf("output doesn't mention the response file:\n%s", out)
+	}
+
+	// Both generations are posted and saved to the history
+	if urns := dh.postedURNs(); !slices.Equal(urns, []string{datasetURN("alpha"), datasetURN("alpha")}) {
+		t.Errorf("posted %v", urns)
+	}
+	db, err := storage.NewSQLiteStorage(storage.WithDataDir(dataDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	history, err := db.ListResponses(10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].Prompt != "beta\n" || !strings.Contains(history[0].Response, datasetURN("alpha")) {
+		t.Errorf("history = %+v", history)
+	}
+}
@@ -6,6 +6,8 @@ require (
 	github.com/mattn/go-sqlite3 v1.14.24
 	github.com/sashabaranov/go-openai v1.38.0
 	github.com/urfave/cli/v2 v2.27.6
+	golang.org/x/time v0.11.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+	"github.com/rubiojr/dsg/internal/datahub/graphql"
+	"github.com/rubiojr/dsg/internal/log"
+	"github.com/urfave/cli/v2"
+)
+
+// DataHub APIs selectable with --api
+const (
+	apiOpenAPI = "openapi"
+	apiGraphQL = "graphql"
+)
+
+// useGraphQL returns true if the GraphQL API was selected with --api
+func useGraphQL(c *cli.Context) (bool, error) {
+	switch c.String("api") {
+	case "", apiOpenAPI:
+		return false, nil
+	case apiGraphQL:
+		return true, nil
+	default:
+		return false, usagef("unsupported DataHub API %q: must be %s or %s", c.String("api"), apiOpenAPI, apiGraphQL)
+	}
+}
+
+func newGraphQLClient(ctx context.Context, gmsURL, token string) (*graphql.Client, error) {
+	if strings.Contains(gmsURL, ",") {
+		return nil, usagef("multiple DataHub URLs are not supported with the GraphQL API")
+	}
+
+	hc, err := datahubHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+
+	gql := graphql.NewClient(gmsURL, token)
+	gql.HttpClient = hc
+	gql.Context = ctx
+	log.AddField("datahub_url", redactURL(gql.URL))
+
+	return gql, nil
+}
+
+// postGraphQL sends the datasets or glossary terms using the GraphQL API
+// and returns the number of entities successfully sent
+func postGraphQL(gql *graphql.Client, entityType string, datasets []datahub.Dataset, terms []datahub.GlossaryTerm, continueOnError bool) (int, error) {
+	var send []func() error
+	switch entityType {
+	case "dataset":
+		for _, ds := range datasets {
+			send = append(send, func() error { return gql.UpdateDataset(ds) })
+		}
+	case "glossaryTerm":
+		for _, term := range terms {
+			send = append(send, func() error {
+				_, err := gql.CreateGlossaryTerm(term)
+				return err
+			})
+		}
+	default:
+		return 0, fmt.Errorf("entity type %s is not supported by the GraphQL API", entityType)
+	}
+
+	var count int
+	batchErr := &datahub.BatchError{Total: len(send)}
+	for i, f := range send {
+		if err := f(); err != nil {
+			if !continueOnError {
+				return count, err
+			}
+			batchErr.Failures = append(batchErr.Failures, &datahub.ItemError{Index: i, Err: err})
+			continue
+		}
+		count++
+	}
+
+	if len(batchErr.Failures) > 0 {
+		return count, batchErr
+	}
+	return count, nil
+}
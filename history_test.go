@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	storage "github.com/rubiojr/dsg/internal/storage/sqlite"
+)
+
+func TestShowCompressedJSON(t *testing.T) {
+	dataDir := testDataDir(t)
+	db, err := storage.NewSQLiteStorage(storage.WithDataDir(dataDir), storage.WithCompression(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := db.SaveResponse(&storage.Response{Prompt: "a users table", Response: datasetJSON("users")})
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runApp(t, "show", "--json", fmt.Sprint(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var item HistoryItem
+	if err := json.Unmarshal([]byte(out), &item); err != nil {
+		t.Fatalf("show --json printed invalid JSON: %v\n%s", err, out)
+	}
+	if item.Prompt != "a users table" || len(item.Datasets) != 1 || item.Datasets[0].URN != datasetURN("users") {
+		t.Errorf("unexpected history item %+v", item)
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		ago  time.Duration
+		want string
+	}{
+		{0, "just now"},
+		{5 * time.Second, "just now"},
+		{30 * time.Second, "30 seconds ago"},
+		{time.Minute, "1 minute ago"},
+		{45 * time.Minute, "45 minutes ago"},
+		{time.Hour, "1 hour ago"},
+		{3*time.Hour + 20*time.Minute, "3 hours ago"},
+		{24 * time.Hour, "1 day ago"},
+		{6*24*time.Hour + 23*time.Hour, "6 days ago"},
+		{7 * 24 * time.Hour, "2024-03-08 12:00:00"},
+		{60 * 24 * time.Hour, "2024-01-15 12:00:00"},
+		// Clock skew
+		{-time.Hour, "2024-03-15 13:00:00"},
+	}
+	for _, tt := range tests {
+		if got := relativeTime(now.Add(-tt.ago), now); got != tt.want {
+			t.Errorf("relativeTime(%s ago) = %q, want %q", tt.ago, got, tt.want)
+		}
+	}
+}
+
+func TestDataDir(t *testing.T) {
+	t.Setenv("DSG_DATA_DIR", "")
+
+	// --data-dir, created if missing
+	flagDir := filepath.Join(t.TempDir(), "project", "dsg")
+	if _, err := runApp(t, "--data-dir", flagDir, "history"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(flagDir, "history.db")); err != nil {
+		t.Errorf("the database wasn't created in --data-dir: %v", err)
+	}
+
+	seedHistory(t, flagDir, &storage.Response{Prompt: "a project table", SchemaName: "project_table", Response: "[]"})
+	out, err := runApp(t, "--data-dir", flagDir, "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "project_table") {
+		t.Errorf("history doesn't list the --data-dir entries:\n%s", out)
+	}
+
+	// DSG_DATA_DIR
+	envDir := filepath.Join(t.TempDir(), "env", "dsg")
+	t.Setenv("DSG_DATA_DIR", envDir)
+	out, err = runApp(t, "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(envDir, "history.db")); err != nil {
+		t.Errorf("the database wasn't created in DSG_DATA_DIR: %v", err)
+	}
+	if strings.Contains(out, "project_table") {
+		t.Errorf("history lists the --data-dir entries:\n%s", out)
+	}
+}
+
+func TestHistoryFull(t *testing.T) {
+	dataDir := testDataDir(t)
+	long := "a table of customer orders with the order date, the amount, the currency\nand the shipping address"
+	seedHistory(t, dataDir,
+		&storage.Response{Prompt: long, SchemaName: "orders", Response: "[]"},
+		&storage.Response{Prompt: "日本語のユーザーテーブル、メールアドレスと名前と住所と電話番号と生年月日と登録日と最終ログイン日時付き", SchemaName: "users", Response: "[]"},
+	)
+
+	out, err := runApp(t, "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, "PROMPT") || strings.Contains(out, "customer orders") {
+		t.Errorf("history shows the prompts without --full:\n%s", out)
+	}
+
+	out, err = runApp(t, "history", "--full")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oneLine := strings.Join(strings.Fields(long), " ")
+	for _, want := range []string{
+		"PROMPT",
+		" " + truncateString(oneLine, 48) + "\n",
+		" 日本語のユーザーテーブル、メールアドレスと名前と住所と電話番号と生年月日と登録日と最終ログ...\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("history --full is missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, oneLine) {
+		t.Errorf("history --full doesn't truncate the prompts:\n%s", out)
+	}
+
+	out, err = runApp(t, "history", "--full", "--no-truncate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, " "+oneLine+"\n") {
+		t.Errorf("history --full --no-truncate is missing the whole prompt:\n%s", out)
+	}
+}
+
+func TestTruncateString(t *testing.T) {
+	tests := []struct {
+		s    string
+		max  int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"exactly10!", 10, "exactly10!"},
+		{"a longer string", 10, "a longe..."},
+		{"ñandú ñandú", 8, "ñandú..."},
+	}
+	for _, tt := range tests {
+		if got := truncateString(tt.s, tt.max); got != tt.want {
+			t.Errorf("truncateString(%q, %d) = %q, want %q", tt.s, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestCountDatasets(t *testing.T) {
+	tests := map[string]int{
+		datasetJSON("alpha"):                  1,
+		datasetJSON("alpha", "beta", "gamma"): 3,
+		"[]":                                  0,
+		"not json":                            0,
+	}
+	for response, want := range tests {
+		if got := countDatasets(response); got != want {
+			t.Errorf("countDatasets(%.20q) = %d, want %d", response, got, want)
+		}
+	}
+}
+
+func TestShowMultipleDatasets(t *testing.T) {
+	dataDir := testDataDir(t)
+	ids := seedHistory(t, dataDir, &storage.Response{
+		Prompt:     "three tables",
+		SchemaName: "alpha",
+		SchemaURN:  datasetURN("alpha"),
+		Response:   datasetJSON("alpha", "beta", "gamma"),
+	})
+	id := fmt.Sprint(ids[0])
+
+	out, err := runApp(t, "show", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Datasets:    3\n",
+		"  1. alpha (" + datasetURN("alpha") + ")\n",
+		"  2. beta (" + datasetURN("beta") + ")\n",
+		"  3. gamma (" + datasetURN("gamma") + ")\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("show is missing %q:\n%s", want, out)
+		}
+	}
+
+	out, err = runApp(t, "show", "--json", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var item HistoryItem
+	if err := json.Unmarshal([]byte(out), &item); err != nil {
+		t.Fatal(err)
+	}
+	var urns []string
+	for _, ds := range item.Datasets {
+		urns = append(urns, ds.URN)
+	}
+	if !slices.Equal(urns, []string{datasetURN("alpha"), datasetURN("beta"), datasetURN("gamma")}) {
+		t.Errorf("show --json datasets = %v", urns)
+	}
+
+	out, err = runApp(t, "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, " alpha (+2) ") {
+		t.Errorf("history doesn't flag the multi-dataset entry:\n%s", out)
+	}
+}
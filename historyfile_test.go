@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPostHistoryFile(t *testing.T) {
+	dh := newDataHubStub(t)
+	dir := t.TempDir()
+
+	// An exported history entry has the datasets in the raw response
+	response, _ := json.Marshal(map[string]string{"prompt": "alpha and beta", "response": datasetJSON("alpha", "beta")})
+	path := filepath.Join(dir, "entry.json")
+	os.WriteFile(path, response, 0644)
+
+	out, err := runApp(t, "post-history-file", "--datahub-gms-url", dh.URL, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "2 entities successfully created in DataHub!\n  " + datasetURN("alpha") + "\n  " + datasetURN("beta") + "\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("output is missing %q:\n%s", want, out)
+	}
+	if urns := dh.postedURNs(); !slices.Equal(urns, []string{datasetURN("alpha"), datasetURN("beta")}) {
+		t.Errorf("posted %v", urns)
+	}
+}
+
+func TestPostHistoryFileInvalid(t *testing.T) {
+	dh := newDataHubStub(t)
+	dir := t.TempDir()
+
+	tests := map[string]string{
+		`{"response": "Sorry, I can't help with that"}`: "invalid datasets in history file",
+		`{"response": "{\"urn\": \"x\"}"}`:              "invalid datasets in history file",
+		`{"response": "[{\"urn\": 1}]"}`:                "entity 1: urn: expected string, got number",
+		`{"response": ""}`:                              "has no datasets or response",
+		`{"prompt": "p"}`:                               "has no datasets or response",
+		`not json`:                                      "error decoding history file",
+	}
+	for content, want := range tests {
+		path := filepath.Join(dir, "entry.json")
+		os.WriteFile(path, []byte(content), 0644)
+		_, err := runApp(t, "post-history-file", "--datahub-gms-url", dh.URL, path)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: err = %v, want %q", content, err, want)
+			continue
+		}
+		if exitCode(err) != exitValidation {
+			t.Errorf("%s: exit code = %d, want %d", content, exitCode(err), exitValidation)
+		}
+	}
+	if len(dh.posted) != 0 {
+		t.Errorf("posted %d entities", len(dh.posted))
+	}
+
+	if _, err := runApp(t, "post-history-file", "--datahub-gms-url", dh.URL); exitCode(err) != exitUsage {
+		t.Errorf("err = %v, want a usage error without a file", err)
+	}
+}
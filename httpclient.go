@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rubiojr/dsg/internal/cassette"
+	"github.com/rubiojr/dsg/internal/datahub"
+	"github.com/rubiojr/dsg/internal/log"
+	"github.com/rubiojr/dsg/internal/metrics"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	httpClientOnce sync.Once
+	httpClient     *http.Client
+	httpClientErr  error
+)
+
+// httpProxy is the --proxy all the requests are sent through, nil to use the
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables
+var httpProxy *url.URL
+
+// parseProxy validates a --proxy URL
+func parseProxy(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return nil, usagef("invalid --proxy URL %q", raw)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+		return u, nil
+	default:
+		return nil, usagef("invalid --proxy URL %q: the scheme must be http, https or socks5", raw)
+	}
+}
+
+// baseTransport returns the transport the requests are sent with: the
+// default one, which honors the proxy environment variables, or a copy of
+// it using --proxy and tlsConfig if set
+func baseTransport(tlsConfig *tls.Config) http.RoundTripper {
+	if httpProxy == nil && tlsConfig == nil {
+		return http.DefaultTransport
+	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if httpProxy != nil {
+		t.Proxy = http.ProxyURL(httpProxy)
+	}
+	t.TLSClientConfig = tlsConfig
+	return t
+}
+
+// newHTTPClient returns the HTTP client shared by the DataHub and OpenAI clients.
+// When DSG_HTTP_CASSETTE is set, requests are recorded to (or replayed from)
+// the cassette file at that path.
+func newHTTPClient() (*http.Client, error) {
+	httpClientOnce.Do(func() {
+		path := os.Getenv("DSG_HTTP_CASSETTE")
+		if path == "" {
+			httpClient = &http.Client{Transport: baseTransport(nil)}
+			return
+		}
+
+		t, err := cassette.New(path, baseTransport(nil))
+		if err != nil {
+			httpClientErr = fmt.Errorf("error loading HTTP cassette: %w", err)
+			return
+		}
+		if t.Replaying() {
+			log.Debugf("Replaying HTTP interactions from %s\n", path)
+		} else {
+			log.Debugf("Recording HTTP interactions to %s\n", path)
+		}
+		httpClient = &http.Client{Transport: t}
+	})
+
+	return httpClient, httpClientErr
+}
+
+// datahubRateLimit is the --rate-limit applied to the DataHub clients
+var datahubRateLimit int
+
+// datahubTimeout is the --datahub-timeout of the DataHub requests, 0 for none
+var datahubTimeout time.Duration
+
+// datahubGzip is the --gzip of the DataHub clients
+var datahubGzip bool
+
+// datahubMaxRetries is the --datahub-max-retries of the DataHub clients
+var datahubMaxRetries = datahub.DefaultMaxRetries
+
+// datahubPageRetries is the --datahub-page-retries of the DataHub clients
+var datahubPageRetries = datahub.DefaultPageRetries
+
+// datahubTLS is the TLS configuration set with --insecure-skip-verify and
+// --ca-cert for the DataHub clients, nil to use the defaults
+var datahubTLS *tls.Config
+
+// datahubTLSWarnOnce prints the datahubTLS warnings once per run
+var datahubTLSWarnOnce sync.Once
+
+// datahubHTTPClient returns the HTTP client used to talk to DataHub: the
+// shared HTTP client, with the datahubTLS configuration if set
+func datahubHTTPClient() (*http.Client, error) {
+	hc, err := newHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+
+	if datahubTLS != nil {
+		useCassette := os.Getenv("DSG_HTTP_CASSETTE") != ""
+		// Commands can create several clients, warn only once
+		datahubTLSWarnOnce.Do(func() {
+			if datahubTLS.InsecureSkipVerify {
+				fmt.Fprintln(os.Stderr, "Warning: TLS certificate verification is disabled for DataHub (--insecure-skip-verify), the connection can be intercepted. Prefer --ca-cert.")
+			}
+			if useCassette {
+				fmt.Fprintln(os.Stderr, "Warning: --insecure-skip-verify and --ca-cert are ignored when DSG_HTTP_CASSETTE is set")
+			}
+		})
+		if !useCassette {
+			// Cassettes wrap the shared transport
+			hc = &http.Client{Transport: baseTransport(datahubTLS)}
+		}
+	}
+
+	if datahubTimeout > 0 {
+		// Don't change the timeout of the shared client
+		hc = &http.Client{Transport: hc.Transport, Timeout: datahubTimeout}
+	}
+
+	return withMetrics(hc, "datahub"), nil
+}
+
+// newClientFromContext creates a DataHub client configured with the
+// --datahub-gms-url and --datahub-gms-token flags of the command and the
+// global DataHub flags (rate limit, retries, timeout, TLS and proxy)
+func newClientFromContext(c *cli.Context) (*datahub.Client, error) {
+	gmsURL := c.String("datahub-gms-url")
+	if strings.Contains(gmsURL, ",") {
+		return nil, usagef("multiple DataHub URLs are only supported when posting entities")
+	}
+	return newDataHubClient(c.Context, gmsURL, c.String("datahub-gms-token"))
+}
+
+// newDataHubClient creates a DataHub client using the DataHub HTTP client,
+// its requests are cancelled when ctx is done
+func newDataHubClient(ctx context.Context, gmsURL, token string) (*datahub.Client, error) {
+	hc, err := datahubHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []datahub.ClientOption{
+		datahub.WithRateLimit(datahubRateLimit),
+		datahub.WithMaxRetries(datahubMaxRetries),
+		datahub.WithPageRetries(datahubPageRetries),
+		datahub.WithContext(ctx),
+	}
+	if datahubGzip {
+		opts = append(opts, datahub.WithGzip(datahub.DefaultGzipMinSize))
+	}
+	dh := datahub.NewClient(gmsURL, token, opts...)
+	dh.HttpClient = hc
+	log.AddField("datahub_url", redactURL(dh.URL))
+
+	return dh, nil
+}
+
+// redactURL strips credentials and query parameters from a URL so it can be logged
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "REDACTED"
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
+
+// headerTransport adds headers to every request
+type headerTransport struct {
+	headers http.Header
+	next    http.RoundTripper
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		req.Header[k] = v
+	}
+	return t.next.RoundTrip(req)
+}
+
+// parseHeaders parses a list of key=value headers
+func parseHeaders(values []string) (http.Header, error) {
+	headers := http.Header{}
+	for _, v := range values {
+		key, value, ok := strings.Cut(v, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t:") {
+			return nil, fmt.Errorf("invalid header %q: expected key=value", v)
+		}
+		headers.Add(key, strings.TrimSpace(value))
+	}
+	return headers, nil
+}
+
+// withHeaders returns a copy of client adding headers to every request
+func withHeaders(client *http.Client, headers http.Header) *http.Client {
+	if len(headers) == 0 {
+		return client
+	}
+
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	c := *client
+	c.Transport = &headerTransport{headers: headers, next: next}
+	return &c
+}
+
+// metricsCollector records the HTTP requests when --metrics or --metrics-file
+// is set, nil otherwise
+var metricsCollector *metrics.Collector
+
+// withMetrics returns a copy of client recording its requests under the
+// service name, or client itself when metrics are disabled
+func withMetrics(client *http.Client, service string) *http.Client {
+	if metricsCollector == nil {
+		return client
+	}
+
+	c := *client
+	c.Transport = metricsCollector.Transport(service, client.Transport)
+	return &c
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+	"github.com/urfave/cli/v2"
+)
+
+// defaultInspectAspects are the dataset aspects inspected when none are given
+var defaultInspectAspects = []string{"datasetKey", "datasetProperties", "schemaMetadata", "editableSchemaMetadata", "globalTags", "glossaryTerms"}
+
+func runInspect(c *cli.Context) error {
+	urn := c.String("urn")
+	if _, _, _, err := datahub.ParseDatasetURN(urn); err != nil {
+		return err
+	}
+
+	aspects := c.StringSlice("aspects")
+	if len(aspects) == 0 {
+		aspects = defaultInspectAspects
+	}
+
+	dh, err := newClientFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	found, err := dh.GetAspects("dataset", urn, aspects)
+	if err != nil {
+		return fmt.Errorf("error fetching %s: %w", urn, err)
+	}
+
+	if c.Bool("json") {
+		entity := map[string]interface{}{"urn": urn}
+		for aspect, raw := range found {
+			entity[aspect] = raw
+		}
+		data, err := json.MarshalIndent(entity, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error encoding aspects: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Printf("URN: %s\n", urn)
+	for _, aspect := range aspects {
+		fmt.Printf("\n%s:\n", aspect)
+		raw, ok := found[aspect]
+		if !ok {
+			fmt.Println("  (not set)")
+			continue
+		}
+
+		// Aspects come wrapped in a value object
+		var wrapped struct {
+			Value json.RawMessage `json:"value"`
+		}
+		if err := json.Unmarshal(raw, &wrapped); err == nil && len(wrapped.Value) > 0 {
+			raw = wrapped.Value
+		}
+
+		var out bytes.Buffer
+		if err := json.Indent(&out, raw, "  ", "  "); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: invalid %s aspect: %v\n", aspect, err)
+			out.Reset()
+			out.Write(raw)
+		}
+		fmt.Printf("  %s\n", out.String())
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// inspectServer serves a dataset with its schema and glossary terms,
+// returning the aspects requested
+func inspectServer(t *testing.T) (*httptest.Server, *[]string) {
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Query()["aspects"]
+		io.WriteString(w, `{
+  "urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)",
+  "schemaMetadata": {"value": {"schemaName": "users", "fields": [{"fieldPath": "email"}]}},
+  "glossaryTerms": {"value": {"terms": [{"urn": "urn:li:glossaryTerm:pii"}]}},
+  "status": {"value": {"removed": false}}
+}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requested
+}
+
+func TestInspect(t *testing.T) {
+	srv, requested := inspectServer(t)
+
+	out, err := runApp(t, "inspect",
+		"--datahub-gms-url", srv.URL,
+		"--urn", datasetURN("users"),
+		"--aspects", "schemaMetadata,glossaryTerms,ownership",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"schemaMetadata", "glossaryTerms", "ownership"}; !slices.Equal(*requested, want) {
+		t.Errorf("requested aspects %v, want %v", *requested, want)
+	}
+	for _, want := range []string{
+		"URN: " + datasetURN("users"),
+		"schemaMetadata:\n  {\n    \"schemaName\": \"users\",",
+		"\"fieldPath\": \"email\"",
+		"glossaryTerms:\n  {\n    \"terms\": [",
+		"urn:li:glossaryTerm:pii",
+		"ownership:\n  (not set)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output is missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "status") {
+		t.Errorf("an aspect not requested was printed:\n%s", out)
+	}
+}
+
+func TestInspectJSON(t *testing.T) {
+	srv, requested := inspectServer(t)
+
+	out, err := runApp(t, "inspect", "--datahub-gms-url", srv.URL, "--urn", datasetURN("users"), "--json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(*requested, defaultInspectAspects) {
+		t.Errorf("requested aspects %v, want %v", *requested, defaultInspectAspects)
+	}
+
+	var entity map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(out), &entity); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if string(entity["urn"]) != `"`+datasetURN("users")+`"` || entity["schemaMetadata"] == nil || entity["glossaryTerms"] == nil {
+		t.Errorf("output = %s", out)
+	}
+	if _, ok := entity["status"]; ok {
+		t.Errorf("an aspect not requested was printed:\n%s", out)
+	}
+}
+
+func TestInspectInvalidURN(t *testing.T) {
+	if _, err := runApp(t, "inspect", "--urn", "users"); exitCode(err) != exitValidation {
+		t.Errorf("err = %v, want a validation error", err)
+	}
+}
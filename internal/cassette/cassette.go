@@ -0,0 +1,199 @@
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// redactedHeaders lists the headers that never make it to a cassette file
+var redactedHeaders = []string{"Authorization", "Api-Key", "X-Api-Key", "Cookie", "Set-Cookie"}
+
+// Interaction is a single recorded HTTP request/response pair
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request contains the recorded request data
+type Request struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers"`
+	Body    string      `json:"body"`
+}
+
+// Response contains the recorded response data
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Headers    http.Header `json:"headers"`
+	Body       string      `json:"body"`
+}
+
+// Transport is an http.RoundTripper that records interactions to a cassette
+// file, or replays them if the cassette file already exists
+type Transport struct {
+	path         string
+	next         http.RoundTripper
+	replay       bool
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// New creates a new cassette transport backed by the file at path.
+// If the file exists its interactions are replayed, otherwise the requests
+// are sent using next and recorded.
+func New(path string, next http.RoundTripper) (*Transport, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	t := &Transport{path: path, next: next}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return t, nil
+		}
+		return nil, fmt.Errorf("error reading cassette: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &t.interactions); err != nil {
+		return nil, fmt.Errorf("error decoding cassette: %w", err)
+	}
+	t.replay = true
+	t.used = make([]bool, len(t.interactions))
+
+	return t, nil
+}
+
+// Replaying returns true if the transport is replaying a cassette
+func (t *Transport) Replaying() bool {
+	return t.replay
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.replay {
+		return t.replayRequest(req, body)
+	}
+
+	return t.recordRequest(req, body)
+}
+
+func (t *Transport) replayRequest(req *http.Request, body string) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i, in := range t.interactions {
+		if t.used[i] {
+			continue
+		}
+		if in.Request.Method != req.Method || in.Request.URL != req.URL.String() || in.Request.Body != body {
+			continue
+		}
+		t.used[i] = true
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        in.Response.Headers.Clone(),
+			Body:          io.NopCloser(bytes.NewBufferString(in.Response.Body)),
+			ContentLength: int64(len(in.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no recorded interaction for %s %s", req.Method, req.URL)
+}
+
+func (t *Transport) recordRequest(req *http.Request, body string) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.interactions = append(t.interactions, Interaction{
+		Request: Request{
+			Method:  req.Method,
+			URL:     req.URL.String(),
+			Headers: redact(req.Header),
+			Body:    body,
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Headers:    redact(resp.Header),
+			Body:       string(respBody),
+		},
+	})
+
+	// Save after every interaction so nothing is lost if the process exits early
+	if err := t.save(); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (t *Transport) save() error {
+	data, err := json.MarshalIndent(t.interactions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding cassette: %w", err)
+	}
+
+	if err := os.WriteFile(t.path, data, 0600); err != nil {
+		return fmt.Errorf("error writing cassette: %w", err)
+	}
+
+	return nil
+}
+
+// readBody reads the request body and restores it so it can be sent
+func readBody(req *http.Request) (string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return "", nil
+	}
+
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading request body: %w", err)
+	}
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(data))
+
+	return string(data), nil
+}
+
+func redact(h http.Header) http.Header {
+	h = h.Clone()
+	for _, name := range redactedHeaders {
+		if h.Get(name) != "" {
+			h.Set(name, "REDACTED")
+		}
+	}
+	return h
+}
@@ -0,0 +1,90 @@
+package cassette
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordThenReplay(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Write([]byte("echo:" + string(body)))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	rec, err := New(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Replaying() {
+		t.Fatal("expected recording mode for a missing cassette")
+	}
+	if got := doPost(t, rec, srv.URL, "hello"); got != "echo:hello" {
+		t.Fatalf("recorded body = %q", got)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret") || strings.Contains(string(data), "token") {
+		t.Errorf("cassette leaks credentials:\n%s", data)
+	}
+	if !strings.Contains(string(data), "REDACTED") {
+		t.Errorf("cassette does not redact headers:\n%s", data)
+	}
+
+	srv.Close()
+
+	play, err := New(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !play.Replaying() {
+		t.Fatal("expected replay mode for an existing cassette")
+	}
+	if got := doPost(t, play, srv.URL, "hello"); got != "echo:hello" {
+		t.Fatalf("replayed body = %q", got)
+	}
+	if calls != 1 {
+		t.Errorf("server called %d times, want 1", calls)
+	}
+
+	// Each interaction is replayed only once
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("hello"))
+	if _, err := play.RoundTrip(req); err == nil {
+		t.Error("expected an error replaying an exhausted interaction")
+	}
+}
+
+func doPost(t *testing.T, rt http.RoundTripper, url, body string) string {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer token")
+
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,287 @@
+package datahub
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"slices"
+	"strings"
+)
+
+// SetOwnership replaces the ownership aspect of a dataset
+func (c *Client) SetOwnership(datasetURN string, ownership Ownership) error {
+	for _, o := range ownership.Owners {
+		if !strings.HasPrefix(o.Owner, "urn:li:corpuser:") && !strings.HasPrefix(o.Owner, "urn:li:corpGroup:") {
+			return invalidf("invalid owner URN %q: must start with urn:li:corpuser: or urn:li:corpGroup:", o.Owner)
+		}
+		if !slices.Contains(OwnershipTypes, o.Type) {
+			return invalidf("invalid ownership type %q: must be one of %s", o.Type, strings.Join(OwnershipTypes, ", "))
+		}
+	}
+
+	return c.postAspect("dataset", datasetURN, "ownership", OwnershipContainer{Value: ownership})
+}
+
+// SetDomains replaces the domains aspect of a dataset
+func (c *Client) SetDomains(datasetURN string, domains ...string) error {
+	for _, d := range domains {
+		if err := ValidateDomainURN(d); err != nil {
+			return err
+		}
+	}
+
+	return c.postAspect("dataset", datasetURN, "domains", DomainsContainer{Value: Domains{Domains: domains}})
+}
+
+// CreateDomain creates a domain entity named after the last part of its URN
+func (c *Client) CreateDomain(domainURN string) error {
+	if err := ValidateDomainURN(domainURN); err != nil {
+		return err
+	}
+
+	created := NewAuditStamp("")
+	props := DomainProperties{
+		Name:    strings.TrimPrefix(domainURN, "urn:li:domain:"),
+		Created: &created,
+	}
+
+	return c.postAspect("domain", domainURN, "domainProperties", DomainPropertiesContainer{Value: props})
+}
+
+// ValidateDomainURN returns an error if urn is not a valid domain URN
+func ValidateDomainURN(urn string) error {
+	if !strings.HasPrefix(urn, "urn:li:domain:") || len(urn) == len("urn:li:domain:") {
+		return invalidf("invalid domain URN %q: must start with urn:li:domain:", urn)
+	}
+	return nil
+}
+
+// AddUpstreams adds upstream datasets to the lineage of a dataset.
+// Existing upstreams are kept, upstreams already present are updated.
+func (c *Client) AddUpstreams(datasetURN string, upstreams ...Upstream) error {
+	for _, u := range upstreams {
+		if !strings.HasPrefix(u.Dataset, "urn:li:dataset:") {
+			return invalidf("invalid upstream dataset URN: %s", u.Dataset)
+		}
+		if !slices.Contains(LineageTypes, u.Type) {
+			return invalidf("invalid lineage type %q: must be one of %s", u.Type, strings.Join(LineageTypes, ", "))
+		}
+	}
+
+	var current UpstreamLineageContainer
+	if _, err := c.getAspect("dataset", datasetURN, "upstreamLineage", &current); err != nil {
+		return fmt.Errorf("error fetching current lineage: %w", err)
+	}
+
+	lineage := current.Value
+	for _, u := range upstreams {
+		i := slices.IndexFunc(lineage.Upstreams, func(e Upstream) bool { return e.Dataset == u.Dataset })
+		if i >= 0 {
+			lineage.Upstreams[i] = u
+			continue
+		}
+		lineage.Upstreams = append(lineage.Upstreams, u)
+	}
+
+	return c.postAspect("dataset", datasetURN, "upstreamLineage", UpstreamLineageContainer{Value: lineage})
+}
+
+// UpdateGlossaryTermDefinition updates the definition of an existing glossary
+// term, preserving the rest of its glossaryTermInfo aspect. If appendDef is
+// true, the definition is appended to the current one.
+func (c *Client) UpdateGlossaryTermDefinition(urn, definition string, appendDef bool) error {
+	var info map[string]interface{}
+	found, err := c.getAspect("glossaryTerm", urn, "glossaryTermInfo", &info)
+	if err != nil {
+		return fmt.Errorf("error fetching glossary term: %w", err)
+	}
+	if !found {
+		return fmt.Errorf("glossary term %s not found", urn)
+	}
+
+	value, ok := info["value"].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("unexpected glossaryTermInfo aspect for %s", urn)
+	}
+
+	if current, _ := value["definition"].(string); appendDef && current != "" {
+		definition = current + "\n\n" + definition
+	}
+	value["definition"] = definition
+
+	return c.postAspect("glossaryTerm", urn, "glossaryTermInfo", map[string]interface{}{"value": value})
+}
+
+// GetAspects fetches the given aspects of an entity, keyed by aspect name.
+// Aspects the entity doesn't have are missing from the result.
+func (c *Client) GetAspects(resource, urn string, aspects []string) (map[string]json.RawMessage, error) {
+	query := neturl.Values{"systemMetadata": {"false"}, "aspects": aspects}
+	url := fmt.Sprintf("%s/openapi/v3/entity/%s/%s?%s", c.URL, resource, neturl.PathEscape(urn), query.Encode())
+	req, err := http.NewRequestWithContext(c.ctx(), "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.Header.Set("accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s %s: %w", resource, urn, ErrNotFound)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, NewDataHubError(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+
+	var entity map[string]json.RawMessage
+	if err := json.Unmarshal(body, &entity); err != nil {
+		return nil, fmt.Errorf("error unmarshaling response: %w", err)
+	}
+
+	found := make(map[string]json.RawMessage, len(aspects))
+	for _, aspect := range aspects {
+		if raw, ok := entity[aspect]; ok {
+			found[aspect] = raw
+		}
+	}
+	return found, nil
+}
+
+// getAspect fetches a single aspect of an entity into v.
+// It returns false if the entity or the aspect does not exist.
+func (c *Client) getAspect(resource, urn, aspect string, v interface{}) (bool, error) {
+	url := fmt.Sprintf("%s/openapi/v3/entity/%s/%s?systemMetadata=false&aspects=%s", c.URL, resource, neturl.PathEscape(urn), aspect)
+	req, err := http.NewRequestWithContext(c.ctx(), "GET", url, nil)
+	if err != nil {
+		return false, fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.Header.Set("accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return false, NewDataHubError(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("error reading response body: %w", err)
+	}
+
+	var entity map[string]json.RawMessage
+	if err := json.Unmarshal(body, &entity); err != nil {
+		return false, fmt.Errorf("error unmarshaling response: %w", err)
+	}
+
+	raw, ok := entity[aspect]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return false, fmt.Errorf("error unmarshaling %s aspect: %w", aspect, err)
+	}
+
+	return true, nil
+}
+
+// DeleteEntity deletes an entity and all its aspects from DataHub
+func (c *Client) DeleteEntity(resource, urn string) error {
+	url := fmt.Sprintf("%s/openapi/v3/entity/%s/%s", c.URL, resource, neturl.PathEscape(urn))
+	req, err := http.NewRequestWithContext(c.ctx(), "DELETE", url, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.Header.Set("accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s %s: %w", resource, urn, ErrNotFound)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return NewDataHubError(resp)
+	}
+
+	return nil
+}
+
+// EntityExists returns true if the entity identified by urn exists in DataHub
+func (c *Client) EntityExists(resource, urn string) (bool, error) {
+	url := fmt.Sprintf("%s/openapi/v3/entity/%s/%s?systemMetadata=false", c.URL, resource, neturl.PathEscape(urn))
+	req, err := http.NewRequestWithContext(c.ctx(), "GET", url, nil)
+	if err != nil {
+		return false, fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.Header.Set("accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return false, NewDataHubError(resp)
+	}
+
+	return true, nil
+}
+
+// postAspect upserts a single aspect of an entity
+func (c *Client) postAspect(resource, urn, aspect string, value interface{}) error {
+	if !strings.HasPrefix(urn, "urn:li:"+resource+":") {
+		return invalidf("invalid %s URN: %s", resource, urn)
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"urn":  urn,
+		aspect: value,
+	})
+	if err != nil {
+		return fmt.Errorf("error encoding %s aspect: %w", aspect, err)
+	}
+
+	return c.postSingleEntity(resource, string(payload))
+}
@@ -0,0 +1,314 @@
+package datahub
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// aspectServer stubs the entity endpoints, serving the aspects in entities
+// keyed by URN and recording the posted entities
+type aspectServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	entities map[string]map[string]json.RawMessage
+	posted   []map[string]json.RawMessage
+}
+
+func newAspectServer(t *testing.T) *aspectServer {
+	s := &aspectServer{entities: map[string]map[string]json.RawMessage{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if r.Method == http.MethodGet {
+			entity, ok := s.entities[pathURN(r)]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode(entity)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		var items []map[string]json.RawMessage
+		if err := json.Unmarshal(body, &items); err != nil {
+			t.Errorf("unexpected request body %s", body)
+			return
+		}
+		s.posted = append(s.posted, items...)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// pathURN returns the URN of an /openapi/v3/entity/<resource>/<urn> request
+func pathURN(r *http.Request) string {
+	const n = len("/openapi/v3/entity/")
+	path := r.URL.Path[n:]
+	for i := range path {
+		if path[i] == '/' {
+			return path[i+1:]
+		}
+	}
+	return ""
+}
+
+// aspect decodes the aspect of the i-th posted entity into v
+func (s *aspectServer) aspect(t *testing.T, i int, name string, v interface{}) {
+	t.Helper()
+	if i >= len(s.posted) {
+		t.Fatalf("only %d entities posted", len(s.posted))
+	}
+	if err := json.Unmarshal(s.posted[i][name], v); err != nil {
+		t.Fatalf("error decoding posted %s aspect: %v", name, err)
+	}
+}
+
+func TestOwnershipJSON(t *testing.T) {
+	ownership := OwnershipContainer{Value: Ownership{
+		Owners:       []Owner{{Owner: "urn:li:corpuser:alice", Type: "DATAOWNER"}},
+		LastModified: AuditStamp{Time: 1700000000000, Actor: DefaultActor},
+	}}
+
+	data, err := json.Marshal(ownership)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"value":{"owners":[{"owner":"urn:li:corpuser:alice","type":"DATAOWNER"}],"lastModified":{"time":1700000000000,"actor":"urn:li:corpuser:datahub"}}}`
+	if string(data) != want {
+		t.Errorf("got  %s\nwant %s", data, want)
+	}
+}
+
+func TestSetOwnership(t *testing.T) {
+	srv := newAspectServer(t)
+	c := NewClient(srv.URL, "")
+	urn := "urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)"
+
+	err := c.SetOwnership(urn, Ownership{
+		Owners:       []Owner{{Owner: "urn:li:corpGroup:data", Type: "TECHNICAL_OWNER"}},
+		LastModified: NewAuditStamp(""),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var posted OwnershipContainer
+	srv.aspect(t, 0, "ownership", &posted)
+	if string(srv.posted[0]["urn"]) != `"`+urn+`"` {
+		t.Errorf("posted urn = %s", srv.posted[0]["urn"])
+	}
+	if len(posted.Value.Owners) != 1 || posted.Value.Owners[0].Owner != "urn:li:corpGroup:data" {
+		t.Errorf("posted owners = %+v", posted.Value.Owners)
+	}
+	if posted.Value.LastModified.Actor != DefaultActor || posted.Value.LastModified.Time == 0 {
+		t.Errorf("posted audit stamp = %+v", posted.Value.LastModified)
+	}
+
+	for _, owner := range []Owner{
+		{Owner: "alice", Type: "DATAOWNER"},
+		{Owner: "urn:li:corpuser:alice", Type: "OWNER"},
+	} {
+		err := c.SetOwnership(urn, Ownership{Owners: []Owner{owner}})
+		if !errors.Is(err, ErrValidation) {
+			t.Errorf("SetOwnership(%+v) = %v, want a validation error", owner, err)
+		}
+	}
+	if len(srv.posted) != 1 {
+		t.Errorf("invalid owners were posted")
+	}
+}
+
+func TestDomainsJSON(t *testing.T) {
+	data, err := json.Marshal(DomainsContainer{Value: Domains{Domains: []string{"urn:li:domain:marketing"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"value":{"domains":["urn:li:domain:marketing"]}}`; string(data) != want {
+		t.Errorf("got  %s\nwant %s", data, want)
+	}
+}
+
+func TestValidateDomainURN(t *testing.T) {
+	tests := map[string]bool{
+		"urn:li:domain:marketing": true,
+		"urn:li:domain:":          false,
+		"urn:li:corpuser:alice":   false,
+		"marketing":               false,
+	}
+	for urn, valid := range tests {
+		err := ValidateDomainURN(urn)
+		if valid && err != nil {
+			t.Errorf("ValidateDomainURN(%q) = %v", urn, err)
+		}
+		if !valid && !errors.Is(err, ErrValidation) {
+			t.Errorf("ValidateDomainURN(%q) = %v, want a validation error", urn, err)
+		}
+	}
+}
+
+func TestSetDomains(t *testing.T) {
+	srv := newAspectServer(t)
+	c := NewClient(srv.URL, "")
+	urn := "urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)"
+
+	if err := c.SetDomains(urn, "marketing"); !errors.Is(err, ErrValidation) {
+		t.Errorf("SetDomains with an invalid domain = %v, want a validation error", err)
+	}
+	if err := c.CreateDomain("urn:li:domain:marketing"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetDomains(urn, "urn:li:domain:marketing"); err != nil {
+		t.Fatal(err)
+	}
+	if len(srv.posted) != 2 {
+		t.Fatalf("posted %d entities, want 2", len(srv.posted))
+	}
+
+	var props DomainPropertiesContainer
+	srv.aspect(t, 0, "domainProperties", &props)
+	if props.Value.Name != "marketing" || props.Value.Created == nil {
+		t.Errorf("posted domain properties = %+v", props.Value)
+	}
+
+	var domains DomainsContainer
+	srv.aspect(t, 1, "domains", &domains)
+	if len(domains.Value.Domains) != 1 || domains.Value.Domains[0] != "urn:li:domain:marketing" {
+		t.Errorf("posted domains = %+v", domains.Value)
+	}
+}
+
+func TestAddUpstreams(t *testing.T) {
+	srv := newAspectServer(t)
+	c := NewClient(srv.URL, "")
+	urn := "urn:li:dataset:(urn:li:dataPlatform:mysql,report,PROD)"
+	orders := "urn:li:dataset:(urn:li:dataPlatform:mysql,orders,PROD)"
+	users := "urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)"
+	events := "urn:li:dataset:(urn:li:dataPlatform:kafka,events,PROD)"
+
+	existing, _ := json.Marshal(UpstreamLineageContainer{Value: UpstreamLineage{
+		Upstreams: []Upstream{{Dataset: orders, Type: "COPY", AuditStamp: NewAuditStamp("")}},
+	}})
+	srv.entities[urn] = map[string]json.RawMessage{"upstreamLineage": existing}
+
+	err := c.AddUpstreams(urn,
+		Upstream{Dataset: users, Type: "TRANSFORMED", AuditStamp: NewAuditStamp("")},
+		Upstream{Dataset: events, Type: "VIEW", AuditStamp: NewAuditStamp("")},
+		Upstream{Dataset: orders, Type: "TRANSFORMED", AuditStamp: NewAuditStamp("")},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lineage UpstreamLineageContainer
+	srv.aspect(t, 0, "upstreamLineage", &lineage)
+	want := map[string]string{orders: "TRANSFORMED", users: "TRANSFORMED", events: "VIEW"}
+	if len(lineage.Value.Upstreams) != len(want) {
+		t.Fatalf("posted upstreams = %+v", lineage.Value.Upstreams)
+	}
+	for _, u := range lineage.Value.Upstreams {
+		if want[u.Dataset] != u.Type {
+			t.Errorf("upstream %s has type %q, want %q", u.Dataset, u.Type, want[u.Dataset])
+		}
+		if u.AuditStamp.Time == 0 || u.AuditStamp.Actor == "" {
+			t.Errorf("upstream %s has no audit stamp", u.Dataset)
+		}
+	}
+
+	for _, u := range []Upstream{
+		{Dataset: users, Type: "DERIVED"},
+		{Dataset: "urn:li:corpuser:alice", Type: "COPY"},
+	} {
+		if err := c.AddUpstreams(urn, u); !errors.Is(err, ErrValidation) {
+			t.Errorf("AddUpstreams(%+v) = %v, want a validation error", u, err)
+		}
+	}
+}
+
+func TestUpdateGlossaryTermDefinition(t *testing.T) {
+	srv := newAspectServer(t)
+	c := NewClient(srv.URL, "")
+	urn := "urn:li:glossaryTerm:Test.PersonalData"
+	srv.entities[urn] = map[string]json.RawMessage{
+		"glossaryTermInfo": json.RawMessage(`{"value":{"name":"PersonalData","termSource":"EXTERNAL","definition":"Old definition","customProperties":{"k":"v"}}}`),
+	}
+
+	tests := []struct {
+		appendDef bool
+		want      string
+	}{
+		{appendDef: false, want: "New definition"},
+		{appendDef: true, want: "Old definition\n\nNew definition"},
+	}
+	for i, tt := range tests {
+		if err := c.UpdateGlossaryTermDefinition(urn, "New definition", tt.appendDef); err != nil {
+			t.Fatal(err)
+		}
+
+		// Only the glossaryTermInfo aspect is posted
+		if len(srv.posted[i]) != 2 {
+			t.Errorf("posted %v, want only the urn and the aspect", srv.posted[i])
+		}
+		var info struct {
+			Value map[string]interface{} `json:"value"`
+		}
+		srv.aspect(t, i, "glossaryTermInfo", &info)
+		if info.Value["definition"] != tt.want {
+			t.Errorf("append=%t: definition = %q, want %q", tt.appendDef, info.Value["definition"], tt.want)
+		}
+		if info.Value["name"] != "PersonalData" || info.Value["termSource"] != "EXTERNAL" || info.Value["customProperties"] == nil {
+			t.Errorf("the other fields were not preserved: %v", info.Value)
+		}
+	}
+
+	if err := c.UpdateGlossaryTermDefinition("urn:li:glossaryTerm:Missing", "x", false); err == nil {
+		t.Error("expected an error updating a missing term")
+	}
+}
+
+func TestGetAspects(t *testing.T) {
+	var query url.Values
+	srv := newAspectServer(t)
+	c := NewClient(srv.URL, "")
+	urn := "urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)"
+	srv.entities[urn] = map[string]json.RawMessage{
+		"urn":            json.RawMessage(`"` + urn + `"`),
+		"schemaMetadata": json.RawMessage(`{"value":{"schemaName":"users"}}`),
+		"globalTags":     json.RawMessage(`{"value": {"tags": []}}`),
+	}
+	c.HttpClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		query = req.URL.Query()
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+
+	found, err := c.GetAspects("dataset", urn, []string{"schemaMetadata", "glossaryTerms"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := query["aspects"]; !slices.Equal(got, []string{"schemaMetadata", "glossaryTerms"}) {
+		t.Errorf("requested aspects %v", got)
+	}
+	if len(found) != 1 || string(found["schemaMetadata"]) != `{"value":{"schemaName":"users"}}` {
+		t.Errorf("found = %s", found)
+	}
+
+	_, err = c.GetAspects("dataset", "urn:li:dataset:(urn:li:dataPlatform:mysql,missing,PROD)", []string{"schemaMetadata"})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+}
+
+// roundTripFunc is a fake transport
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
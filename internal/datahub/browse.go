@@ -0,0 +1,116 @@
+package datahub
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// BrowseResult contains the child paths and entities found at a browse path
+type BrowseResult struct {
+	Path        string         `json:"path"`
+	Groups      []BrowseGroup  `json:"groups"`
+	Entities    []BrowseEntity `json:"entities"`
+	NumEntities int            `json:"numEntities"`
+	Metadata    BrowseMetadata `json:"metadata"`
+}
+
+// BrowseGroup is a child path of a browse node
+type BrowseGroup struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// BrowseEntity is an entity found at a browse node
+type BrowseEntity struct {
+	Name string `json:"name"`
+	URN  string `json:"urn"`
+}
+
+// BrowseMetadata contains metadata about the browse node
+type BrowseMetadata struct {
+	Path             string `json:"path"`
+	TotalNumEntities int    `json:"totalNumEntities"`
+}
+
+// browsePageSize is the number of entities requested per browse page
+const browsePageSize = 100
+
+// Browse lists the child paths and datasets at the given browse path.
+// An empty path browses the root node. Nodes with more datasets than fit in
+// a page are fetched one page at a time.
+func (c *Client) Browse(path string) (*BrowseResult, error) {
+	path = "/" + strings.Trim(path, "/")
+
+	result, err := c.browsePage(path, 0)
+	if err != nil {
+		return nil, err
+	}
+	for len(result.Entities) < result.NumEntities {
+		page, err := c.browsePage(path, len(result.Entities))
+		if err != nil {
+			return nil, err
+		}
+		if len(page.Entities) == 0 {
+			// The node changed while browsing it
+			break
+		}
+		result.Entities = append(result.Entities, page.Entities...)
+	}
+
+	result.Path = path
+	return result, nil
+}
+
+// browsePage fetches the page of the browse path starting at entity start
+func (c *Client) browsePage(path string, start int) (*BrowseResult, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"path":   path,
+		"entity": "dataset",
+		"start":  start,
+		"limit":  browsePageSize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error encoding browse request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/entities?action=browse", c.URL)
+	req, err := http.NewRequestWithContext(c.ctx(), "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.Header.Set("accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-RestLi-Protocol-Version", "2.0.0")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, NewDataHubError(resp)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+
+	var result struct {
+		Value BrowseResult `json:"value"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("error unmarshaling response: %w", err)
+	}
+
+	return &result.Value, nil
+}
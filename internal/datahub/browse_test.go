@@ -0,0 +1,77 @@
+package datahub
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// browseServer stubs the browse action of a node with groups and total
+// entities, served limit entities at a time
+func browseServer(t *testing.T, groups []BrowseGroup, total int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/entities" || r.URL.Query().Get("action") != "browse" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+
+		var req struct {
+			Path   string `json:"path"`
+			Entity string `json:"entity"`
+			Start  int    `json:"start"`
+			Limit  int    `json:"limit"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+			return
+		}
+		if req.Path != "/prod/mysql" || req.Entity != "dataset" {
+			t.Errorf("unexpected browse request %+v", req)
+		}
+
+		result := BrowseResult{Groups: groups, NumEntities: total}
+		for i := req.Start; i < total && i < req.Start+req.Limit; i++ {
+			result.Entities = append(result.Entities, BrowseEntity{
+				Name: fmt.Sprintf("table%d", i),
+				URN:  fmt.Sprintf("urn:li:dataset:(urn:li:dataPlatform:mysql,table%d,PROD)", i),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"value": result})
+	}))
+}
+
+func TestBrowse(t *testing.T) {
+	srv := browseServer(t, []BrowseGroup{{Name: "db1", Count: 3}}, 2)
+	defer srv.Close()
+
+	result, err := NewClient(srv.URL, "").Browse("prod/mysql/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Path != "/prod/mysql" {
+		t.Errorf("path = %q", result.Path)
+	}
+	if len(result.Groups) != 1 || result.Groups[0] != (BrowseGroup{Name: "db1", Count: 3}) {
+		t.Errorf("groups = %+v", result.Groups)
+	}
+	if len(result.Entities) != 2 || result.Entities[1].Name != "table1" {
+		t.Errorf("entities = %+v", result.Entities)
+	}
+}
+
+func TestBrowsePages(t *testing.T) {
+	srv := browseServer(t, nil, 2*browsePageSize+1)
+	defer srv.Close()
+
+	result, err := NewClient(srv.URL, "").Browse("/prod/mysql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Entities) != 2*browsePageSize+1 {
+		t.Fatalf("got %d entities, want %d", len(result.Entities), 2*browsePageSize+1)
+	}
+	if last := result.Entities[len(result.Entities)-1]; last.Name != fmt.Sprintf("table%d", 2*browsePageSize) {
+		t.Errorf("last entity = %+v", last)
+	}
+}
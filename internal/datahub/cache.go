@@ -0,0 +1,56 @@
+package datahub
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DiskCache caches listing responses on disk, keyed by request URL, so they
+// can be revalidated with If-None-Match
+type DiskCache struct {
+	dir string
+}
+
+// cacheEntry is a cached response body and its ETag
+type cacheEntry struct {
+	ETag string `json:"etag"`
+	Body []byte `json:"body"`
+}
+
+// NewDiskCache creates a cache storing its entries in dir
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+func (d *DiskCache) path(key string) string {
+	return filepath.Join(d.dir, fmt.Sprintf("%x.json", sha256.Sum256([]byte(key))))
+}
+
+// get returns the cached entry for key, if any
+func (d *DiskCache) get(key string) (*cacheEntry, bool) {
+	data, err := os.ReadFile(d.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var e cacheEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, false
+	}
+	return &e, true
+}
+
+// put stores the body and ETag of the response to key
+func (d *DiskCache) put(key, etag string, body []byte) error {
+	data, err := json.Marshal(cacheEntry{ETag: etag, Body: body})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(d.path(key), data, 0644)
+}
@@ -0,0 +1,89 @@
+package datahub
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCachedListing(t *testing.T) {
+	etag, urn := `"v1"`, "urn:1"
+	var ifNoneMatch []string
+	full := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ifNoneMatch = append(ifNoneMatch, r.Header.Get("If-None-Match"))
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full++
+		w.Header().Set("ETag", etag)
+		fmt.Fprintf(w, `{"entities":[{"urn":%q}]}`, urn)
+	}))
+	defer srv.Close()
+
+	cache, err := NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(srv.URL, "")
+	c.Cache = cache
+
+	list := func() []string {
+		t.Helper()
+		var urns []string
+		err := c.GetDatasets(func(datasets []*Dataset) error {
+			for _, ds := range datasets {
+				urns = append(urns, ds.URN)
+			}
+			return nil
+		}, &ListOptions{PerPage: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return urns
+	}
+
+	// Cache miss
+	if urns := list(); len(urns) != 1 || urns[0] != "urn:1" {
+		t.Fatalf("first listing = %v", urns)
+	}
+	// Not modified, served from the cache
+	if urns := list(); len(urns) != 1 || urns[0] != "urn:1" {
+		t.Fatalf("cached listing = %v", urns)
+	}
+	if full != 1 || ifNoneMatch[1] != `"v1"` {
+		t.Errorf("full responses = %d, If-None-Match headers = %q", full, ifNoneMatch)
+	}
+
+	// Modified, fetched again and cached
+	etag, urn = `"v2"`, "urn:2"
+	if urns := list(); len(urns) != 1 || urns[0] != "urn:2" {
+		t.Fatalf("listing after a change = %v", urns)
+	}
+	if urns := list(); len(urns) != 1 || urns[0] != "urn:2" {
+		t.Fatalf("cached listing after a change = %v", urns)
+	}
+	if full != 2 || ifNoneMatch[3] != `"v2"` {
+		t.Errorf("full responses = %d, If-None-Match headers = %q", full, ifNoneMatch)
+	}
+}
+
+func TestListingWithoutCache(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			t.Errorf("conditional request without a cache")
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"entities":[{"urn":"urn:1"}]}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "")
+	for i := 0; i < 2; i++ {
+		if err := c.GetDatasets(func([]*Dataset) error { return nil }, &ListOptions{PerPage: 10}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package datahub
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// CanonicalJSON returns data indented with two spaces and with the object
+// keys sorted, so equivalent entities always have the same bytes no matter
+// the order of the model output or the post-processing they went through.
+// Unlike marshaling the typed models, fields unknown to dsg are kept, and
+// numbers are written as they were read.
+func CanonicalJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("error decoding JSON: %w", err)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	// Maps are encoded with sorted keys
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("error encoding JSON: %w", err)
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
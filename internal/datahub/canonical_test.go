@@ -0,0 +1,67 @@
+package datahub
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	a := `[{"urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)", "schemaMetadata": {"value": {"version": 0, "fields": [{"nativeDataType": "int", "fieldPath": "id"}]}}, "custom": {"b": 1.50, "a": "<x>"}}]`
+	b := `[
+	{
+		"custom": {"a": "<x>", "b": 1.50},
+		"schemaMetadata": {"value": {"fields": [{"fieldPath": "id", "nativeDataType": "int"}], "version": 0}},
+		"urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)"
+	}
+]`
+
+	ca, err := CanonicalJSON([]byte(a))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cb, err := CanonicalJSON([]byte(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(ca) != string(cb) {
+		t.Errorf("equivalent datasets have different bytes:\n%s\n%s", ca, cb)
+	}
+
+	want := `[
+  {
+    "custom": {
+      "a": "<x>",
+      "b": 1.50
+    },
+    "schemaMetadata": {
+      "value": {
+        "fields": [
+          {
+            "fieldPath": "id",
+            "nativeDataType": "int"
+          }
+        ],
+        "version": 0
+      }
+    },
+    "urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)"
+  }
+]`
+	if string(ca) != want {
+		t.Errorf("CanonicalJSON =\n%s\nwant\n%s", ca, want)
+	}
+
+	// Canonicalizing is idempotent
+	if again, err := CanonicalJSON(ca); err != nil || string(again) != string(ca) {
+		t.Errorf("canonicalizing again = %s, %v", again, err)
+	}
+
+	// Large numbers aren't rounded
+	if got, err := CanonicalJSON([]byte(`{"time": 12345678901234567890}`)); err != nil || !strings.Contains(string(got), "12345678901234567890") {
+		t.Errorf("CanonicalJSON = %s, %v", got, err)
+	}
+
+	if _, err := CanonicalJSON([]byte(`[{"urn": }]`)); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
@@ -1,12 +1,18 @@
 package datahub
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	neturl "net/url"
 	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
 )
 
 // Client represents the DataHub API client
@@ -14,33 +20,139 @@ type Client struct {
 	URL        string
 	Token      string
 	HttpClient *http.Client
+	// MaxBodySize is the maximum size in bytes of a listing response body
+	MaxBodySize int64
+	// Cache enables conditional requests for dataset listings when set
+	Cache *DiskCache
+	// Limiter limits the rate of the requests posting entities when set
+	Limiter *rate.Limiter
+	// MaxRetries is the number of times a rate limited (429) post is retried
+	MaxRetries int
+	// PageRetries is the number of times a listing page failing with a
+	// transient error is retried
+	PageRetries int
+	// Context cancels the requests when set
+	Context context.Context
+	// GzipMinSize compresses the bodies of the requests posting entities
+	// that are at least this many bytes long when greater than 0
+	GzipMinSize int
+}
+
+// DefaultMaxBodySize is the default maximum size of a listing response body
+const DefaultMaxBodySize = 64 << 20
+
+// ErrBodyTooLarge is returned when a response body exceeds the client MaxBodySize
+var ErrBodyTooLarge = errors.New("response body too large")
+
+// limitedReader reads from r until more than n bytes are read, then fails
+// with ErrBodyTooLarge
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, ErrBodyTooLarge
+	}
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, ErrBodyTooLarge
+	}
+	return n, err
 }
 
 // NewClient creates a new DataHub client
-func NewClient(url string, token string) *Client {
+func NewClient(url string, token string, opts ...ClientOption) *Client {
 	if url == "" {
 		url = "http://localhost:8080"
 	}
 
-	return &Client{
-		URL:        url,
-		Token:      token,
-		HttpClient: http.DefaultClient,
+	c := &Client{
+		URL:         url,
+		Token:       token,
+		HttpClient:  http.DefaultClient,
+		MaxBodySize: DefaultMaxBodySize,
+		MaxRetries:  DefaultMaxRetries,
+		PageRetries: DefaultPageRetries,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// datasetQuery returns the search query matching the datasets of platform,
+// or all the datasets if platform is empty
+func datasetQuery(platform string) string {
+	if platform == "" {
+		return "*"
 	}
+	return fmt.Sprintf("platform:%q", platform)
 }
-func (c *Client) paginateDatasets(count int, scrollId string) ([]*Dataset, string, error) {
+
+func (c *Client) paginateDatasets(count int, scrollId string, opts *ListOptions) ([]*Dataset, string, int, error) {
+	query := neturl.QueryEscape(datasetQuery(opts.Platform))
 	var url string
 	if scrollId == "" {
 		// Initial request without scrollId
-		url = fmt.Sprintf("%s/openapi/v3/entity/dataset?systemMetadata=false&aspects=glossaryTerms&aspects=editableSchemaMetadata&includeSoftDelete=false&skipCache=false&aspects=schemaMetadata&count=%d&sort=urn&sortOrder=ASCENDING&query=%%2A", c.URL, count)
+		url = fmt.Sprintf("%s/openapi/v3/entity/dataset?systemMetadata=false&aspects=glossaryTerms&aspects=editableSchemaMetadata&aspects=status&includeSoftDelete=%t&skipCache=false&aspects=schemaMetadata&count=%d&sort=urn&sortOrder=ASCENDING&query=%s", c.URL, opts.IncludeSoftDeleted, count, query)
 	} else {
 		// Follow-up request with scrollId
-		url = fmt.Sprintf("%s/openapi/v3/entity/dataset?systemMetadata=false&aspects=glossaryTerms&aspects=editableSchemaMetadata&includeSoftDelete=false&skipCache=false&aspects=schemaMetadata&count=%d&scrollId=%s", c.URL, count, scrollId)
+		url = fmt.Sprintf("%s/openapi/v3/entity/dataset?systemMetadata=false&aspects=glossaryTerms&aspects=editableSchemaMetadata&aspects=status&includeSoftDelete=%t&skipCache=false&aspects=schemaMetadata&count=%d&query=%s&scrollId=%s", c.URL, opts.IncludeSoftDeleted, count, query, neturl.QueryEscape(scrollId))
+	}
+
+	result, err := fetchEntityPage[*Dataset](c, url)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	if len(result.Entities) == 0 {
+		return []*Dataset{}, "", result.Metadata.Total, nil
+	}
+
+	return result.Entities, result.ScrollId, result.Metadata.Total, nil
+}
+
+// entityPage is a page of entities returned by the entity endpoints
+type entityPage[T any] struct {
+	ScrollId string `json:"scrollId,omitempty"`
+	Entities []T    `json:"entities"`
+	Metadata struct {
+		Total int `json:"total"`
+	} `json:"metadata,omitempty"`
+}
+
+// fetchEntityPage fetches a page of entities from url, using the cache if
+// set. Transient failures (network errors, 429 and 5xx responses, truncated
+// bodies) are retried up to c.PageRetries times with the same url, so a long
+// scan resumes from the same scrollId instead of starting over.
+func fetchEntityPage[T any](c *Client, url string) (*entityPage[T], error) {
+	for attempt := 0; ; attempt++ {
+		page, wait, err := fetchEntityPageOnce[T](c, url, attempt)
+		if err == nil || wait < 0 || attempt >= c.PageRetries {
+			return page, err
+		}
+		select {
+		case <-time.After(wait):
+		case <-c.ctx().Done():
+			return nil, fmt.Errorf("error waiting to retry: %w", c.ctx().Err())
+		}
 	}
+}
 
-	req, err := http.NewRequest("GET", url, nil)
+// fetchEntityPageOnce fetches a page of entities from url. On failure, it
+// returns how long to wait before retrying, or a negative duration if the
+// error is not transient.
+func fetchEntityPageOnce[T any](c *Client, url string, attempt int) (*entityPage[T], time.Duration, error) {
+	req, err := http.NewRequestWithContext(c.ctx(), "GET", url, nil)
 	if err != nil {
-		return nil, "", fmt.Errorf("error creating request: %w", err)
+		return nil, -1, fmt.Errorf("error creating request: %w", err)
 	}
 
 	req.Header.Set("accept", "application/json")
@@ -48,78 +160,241 @@ func (c *Client) paginateDatasets(count int, scrollId string) ([]*Dataset, strin
 		req.Header.Set("Authorization", "Bearer "+c.Token)
 	}
 
+	var cached *cacheEntry
+	if c.Cache != nil {
+		if e, ok := c.Cache.get(url); ok {
+			cached = e
+			req.Header.Set("If-None-Match", e.ETag)
+		}
+	}
+
 	resp, err := c.HttpClient.Do(req)
 	if err != nil {
-		return nil, "", fmt.Errorf("error sending request: %w", err)
+		if c.ctx().Err() != nil {
+			return nil, -1, fmt.Errorf("error sending request: %w", err)
+		}
+		return nil, backoff(attempt), fmt.Errorf("error sending request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, "", fmt.Errorf("request failed with status code: %d", resp.StatusCode)
+	maxBodySize := c.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultMaxBodySize
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, "", fmt.Errorf("error reading response body: %w", err)
+	var body io.Reader = &limitedReader{r: resp.Body, n: maxBodySize}
+	var cacheBuf *bytes.Buffer
+	etag := resp.Header.Get("ETag")
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		// Not modified, use the cached page
+		body = bytes.NewReader(cached.Body)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		wait := time.Duration(-1)
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			wait = retryAfter(resp, attempt)
+		}
+		return nil, wait, NewDataHubError(resp)
+	case c.Cache != nil && etag != "":
+		cacheBuf = &bytes.Buffer{}
+		body = io.TeeReader(body, cacheBuf)
 	}
 
-	var result struct {
-		ScrollId string     `json:"scrollId,omitempty"`
-		Entities []*Dataset `json:"entities"`
-		Metadata struct {
-			Total int `json:"total"`
-		} `json:"metadata,omitempty"`
-	}
+	var result entityPage[T]
 
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, "", fmt.Errorf("error unmarshaling response: %w", err)
+	// Decode while reading so the whole body is never held in memory
+	dec := json.NewDecoder(body)
+	if err := dec.Decode(&result); err != nil {
+		if errors.Is(err, ErrBodyTooLarge) {
+			return nil, -1, fmt.Errorf("%w: exceeds %d bytes", ErrBodyTooLarge, maxBodySize)
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) && c.ctx().Err() == nil {
+			// The connection was closed before the end of the page
+			return nil, backoff(attempt), fmt.Errorf("error unmarshaling response: %w", err)
+		}
+		return nil, -1, fmt.Errorf("error unmarshaling response: %w", err)
 	}
 
-	if len(result.Entities) == 0 {
-		return []*Dataset{}, "", nil
+	if cacheBuf != nil {
+		// The decoder may stop before the end of the body, cache all of it
+		if _, err := io.Copy(io.Discard, body); err != nil {
+			return nil, -1, fmt.Errorf("error reading response body: %w", err)
+		}
+		if err := c.Cache.put(url, etag, cacheBuf.Bytes()); err != nil {
+			return nil, -1, fmt.Errorf("error caching response: %w", err)
+		}
 	}
 
-	return result.Entities, result.ScrollId, nil
+	return &result, 0, nil
+}
+
+// CountDatasets returns the number of datasets matching opts, as reported by
+// DataHub, without listing them
+func (c *Client) CountDatasets(opts *ListOptions) (int, error) {
+	_, _, total, err := c.paginateDatasets(1, "", opts)
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
 }
 
 type ListOptions struct {
 	PerPage int
+	// IncludeSoftDeleted also lists datasets that have been soft-deleted
+	IncludeSoftDeleted bool
+	// ScrollID resumes a previous listing from the given scroll position
+	ScrollID string
+	// MaxResults stops the listing once that many datasets have been returned
+	MaxResults int
+	// Platform only lists the datasets of this platform URN, if set
+	Platform string
+}
+
+// DatasetIterator iterates over the DataHub datasets one page at a time.
+// The scroll position can be saved with ScrollID to resume the listing later.
+type DatasetIterator struct {
+	client    *Client
+	opts      ListOptions
+	scrollID  string
+	done      bool
+	delivered int
+}
+
+// NewDatasetIterator creates an iterator listing the datasets with opts
+func (c *Client) NewDatasetIterator(opts *ListOptions) *DatasetIterator {
+	return &DatasetIterator{
+		client:   c,
+		opts:     *opts,
+		scrollID: opts.ScrollID,
+	}
+}
+
+// Next returns the next page of datasets, or nil when there are no more datasets
+func (it *DatasetIterator) Next() ([]*Dataset, error) {
+	if it.done {
+		return nil, nil
+	}
+
+	count := it.opts.PerPage
+	if max := it.opts.MaxResults; max > 0 && max-it.delivered < count {
+		// Don't fetch more than needed
+		count = max - it.delivered
+	}
+
+	datasets, nextScrollId, _, err := it.client.paginateDatasets(count, it.scrollID, &it.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// If there's no scrollId in the response, we're at the end
+	if len(datasets) == 0 || nextScrollId == "" {
+		it.done = true
+	}
+	it.scrollID = nextScrollId
+
+	if len(datasets) == 0 {
+		return nil, nil
+	}
+
+	if max := it.opts.MaxResults; max > 0 {
+		if len(datasets) > max-it.delivered {
+			datasets = datasets[:max-it.delivered]
+		}
+		if it.delivered+len(datasets) >= max {
+			it.done = true
+		}
+	}
+	it.delivered += len(datasets)
+
+	return datasets, nil
+}
+
+// ScrollID returns the scroll position of the next page, empty when done
+func (it *DatasetIterator) ScrollID() string {
+	if it.done {
+		return ""
+	}
+	return it.scrollID
 }
 
 // GetAllDatasets retrieves all datasets from DataHub using scrollId pagination
 func (c *Client) GetDatasets(page func(datasets []*Dataset) error, opts *ListOptions) error {
-	count := opts.PerPage // Number of records per page
-	scrollId := ""
+	it := c.NewDatasetIterator(opts)
 
 	for {
-		datasets, nextScrollId, err := c.paginateDatasets(count, scrollId)
+		datasets, err := it.Next()
 		if err != nil {
 			return err
 		}
 
 		// If no more datasets, break the loop
-		if len(datasets) == 0 {
+		if datasets == nil {
 			break
 		}
 
 		if err := page(datasets); err != nil {
 			return err
 		}
+	}
 
-		// If there's no scrollId in the response, we're at the end
-		if nextScrollId == "" {
-			break
-		}
+	return nil
+}
+
+// PostOptions configures how entities are posted
+type PostOptions struct {
+	// ContinueOnError keeps posting the remaining entities when one fails
+	ContinueOnError bool
+}
+
+// ItemError is the error returned for a single entity in a batch
+type ItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *ItemError) Error() string {
+	return fmt.Sprintf("entity %d: %v", e.Index+1, e.Err)
+}
 
-		// Update scrollId for the next request
-		scrollId = nextScrollId
+func (e *ItemError) Unwrap() error {
+	return e.Err
+}
+
+// BatchError summarizes the entities that failed to be posted in a batch
+type BatchError struct {
+	Total    int
+	Failures []*ItemError
+}
+
+func (e *BatchError) Error() string {
+	indices := make([]string, 0, len(e.Failures))
+	for _, f := range e.Failures {
+		indices = append(indices, fmt.Sprintf("%d", f.Index+1))
 	}
 
-	return nil
+	msg := fmt.Sprintf("%d of %d entities failed (%s)", len(e.Failures), e.Total, strings.Join(indices, ", "))
+	for _, f := range e.Failures {
+		msg += "\n  " + f.Error()
+	}
+	return msg
 }
 
-// PostDataset sends one or more datasets to the DataHub API
-func (c *Client) PostEntity(resource, payload string) (int, error) {
+// Unwrap returns the errors of the failed entities
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failures))
+	for _, f := range e.Failures {
+		errs = append(errs, f)
+	}
+	return errs
+}
+
+// PostEntity sends one or more entities to the DataHub API.
+// It returns the number of entities successfully posted.
+func (c *Client) PostEntity(resource, payload string, opts *PostOptions) (int, error) {
+	if opts == nil {
+		opts = &PostOptions{}
+	}
+
 	// Check if the payload is an array of datasets
 	trimmedPayload := strings.TrimSpace(payload)
 
@@ -132,47 +407,81 @@ func (c *Client) PostEntity(resource, payload string) (int, error) {
 		}
 
 		// Post each dataset individually
-		count := len(datasets)
+		count := 0
+		batchErr := &BatchError{Total: len(datasets)}
 		for i, dataset := range datasets {
 			err := c.postSingleEntity(resource, string(dataset))
 			if err != nil {
-				return 0, fmt.Errorf("error posting dataset %d: %w", i+1, err)
+				if !opts.ContinueOnError {
+					return count, fmt.Errorf("error posting dataset %d: %w", i+1, err)
+				}
+				batchErr.Failures = append(batchErr.Failures, &ItemError{Index: i, Err: err})
+				continue
 			}
+			count++
+		}
+
+		if len(batchErr.Failures) > 0 {
+			return count, batchErr
 		}
 
 		return count, nil
 	}
 
 	return 0, errors.New("error parsing dataset array")
-	// If it's not an array, post as single dataset
-	///return 1, c.postSingleEntity(resource, payload)
 }
 
-// postSingleDataset sends a single dataset to the DataHub API
+// postSingleDataset sends a single dataset to the DataHub API.
+// Rate limited requests are retried up to c.MaxRetries times.
 func (c *Client) postSingleEntity(resource, payload string) error {
 	url := fmt.Sprintf("%s/openapi/v3/entity/%s?async=false&systemMetadata=false", c.URL, resource)
-	req, err := http.NewRequest("POST", url, strings.NewReader("["+payload+"]"))
+	body, encoding, err := c.requestBody("[" + payload + "]")
 	if err != nil {
-		return fmt.Errorf("error creating request: %w", err)
+		return err
 	}
 
-	req.Header.Set("accept", "application/json")
-	req.Header.Set("Content-Type", "application/json")
-	if c.Token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.Token)
-	}
+	for attempt := 0; ; attempt++ {
+		if err := c.wait(); err != nil {
+			return fmt.Errorf("error waiting for the rate limiter: %w", err)
+		}
 
-	resp, err := c.HttpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("error sending request: %w", err)
-	}
-	defer resp.Body.Close()
+		req, err := http.NewRequestWithContext(c.ctx(), "POST", url, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("error creating request: %w", err)
+		}
 
-	io.Copy(io.Discard, resp.Body)
+		req.Header.Set("accept", "application/json")
+		req.Header.Set("Content-Type", "application/json")
+		if encoding != "" {
+			req.Header.Set("Content-Encoding", encoding)
+		}
+		if c.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.Token)
+		}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("request failed with status code: %d", resp.StatusCode)
-	}
+		resp, err := c.HttpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("error sending request: %w", err)
+		}
 
-	return nil
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < c.MaxRetries {
+			wait := retryAfter(resp, attempt)
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			select {
+			case <-time.After(wait):
+			case <-c.ctx().Done():
+				return fmt.Errorf("error waiting to retry: %w", c.ctx().Err())
+			}
+			continue
+		}
+
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return NewDataHubError(resp)
+		}
+		io.Copy(io.Discard, resp.Body)
+
+		return nil
+	}
 }
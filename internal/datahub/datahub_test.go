@@ -0,0 +1,331 @@
+package datahub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestPostEntityContinueOnError(t *testing.T) {
+	var mu sync.Mutex
+	var posted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var items []struct {
+			URN string `json:"urn"`
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &items); err != nil || len(items) != 1 {
+			t.Errorf("unexpected request body %s", body)
+		}
+		if items[0].URN == "urn:2" {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		mu.Lock()
+		posted = append(posted, items[0].URN)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "")
+	payload := `[{"urn":"urn:1"},{"urn":"urn:2"},{"urn":"urn:3"},{"urn":"urn:4"}]`
+
+	count, err := c.PostEntity("dataset", payload, &PostOptions{ContinueOnError: true})
+	if count != 3 {
+		t.Errorf("count = %d, want 3", count)
+	}
+	if want := []string{"urn:1", "urn:3", "urn:4"}; !slices.Equal(posted, want) {
+		t.Errorf("posted %v, want %v", posted, want)
+	}
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("err = %v, want a *BatchError", err)
+	}
+	if batchErr.Total != 4 || len(batchErr.Failures) != 1 || batchErr.Failures[0].Index != 1 {
+		t.Errorf("unexpected batch error %+v", batchErr)
+	}
+	var dhErr *DataHubError
+	if !errors.As(err, &dhErr) {
+		t.Errorf("batch error does not wrap the DataHub error: %v", err)
+	}
+
+	// Without the option, the batch stops at the first failure
+	posted = nil
+	count, err = c.PostEntity("dataset", payload, nil)
+	if err == nil || count != 1 || len(posted) != 1 {
+		t.Errorf("count = %d, posted = %v, err = %v", count, posted, err)
+	}
+}
+
+func TestIncludeSoftDeleted(t *testing.T) {
+	for _, include := range []bool{false, true} {
+		var params []string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			params = append(params, r.URL.Query().Get("includeSoftDelete"))
+			if r.URL.Query().Get("scrollId") == "" {
+				w.Write([]byte(`{"scrollId":"next","entities":[{"urn":"urn:1"}]}`))
+				return
+			}
+			w.Write([]byte(`{"entities":[{"urn":"urn:2"}]}`))
+		}))
+
+		c := NewClient(srv.URL, "")
+		err := c.GetDatasets(func([]*Dataset) error { return nil }, &ListOptions{PerPage: 1, IncludeSoftDeleted: include})
+		srv.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := strconv.FormatBool(include)
+		if len(params) != 2 || params[0] != want || params[1] != want {
+			t.Errorf("IncludeSoftDeleted=%t: includeSoftDelete params = %v, want %s in both requests", include, params, want)
+		}
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A page that never ends
+		w.Write([]byte(`{"entities":[`))
+		entity := []byte(`{"urn":"urn:li:dataset:x"},`)
+		for i := 0; i < 1<<16; i++ {
+			if _, err := w.Write(entity); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "")
+	c.MaxBodySize = 1024
+	err := c.GetDatasets(func([]*Dataset) error { return nil }, &ListOptions{PerPage: 10})
+	if !errors.Is(err, ErrBodyTooLarge) {
+		t.Fatalf("err = %v, want ErrBodyTooLarge", err)
+	}
+}
+
+func TestMaxBodySizeNotExceeded(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"entities":[{"urn":"urn:li:dataset:x"}]}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "")
+	c.MaxBodySize = int64(len(`{"entities":[{"urn":"urn:li:dataset:x"}]}`))
+	var got int
+	err := c.GetDatasets(func(datasets []*Dataset) error {
+		got += len(datasets)
+		return nil
+	}, &ListOptions{PerPage: 10})
+	if err != nil || got != 1 {
+		t.Fatalf("got %d datasets, err = %v", got, err)
+	}
+}
+
+// scrollServer serves pages of one dataset, urn:<n>, with scroll IDs "<n>"
+// up to the last page
+func scrollServer(t *testing.T, last int, scrollIDs *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scrollID := r.URL.Query().Get("scrollId")
+		*scrollIDs = append(*scrollIDs, scrollID)
+
+		n := 1
+		if scrollID != "" {
+			var err error
+			if n, err = strconv.Atoi(scrollID); err != nil {
+				t.Errorf("unexpected scroll ID %q", scrollID)
+				return
+			}
+			n++
+		}
+		page := map[string]interface{}{
+			"entities": []map[string]string{{"urn": fmt.Sprintf("urn:%d", n)}},
+			"metadata": map[string]int{"total": last},
+		}
+		if n < last {
+			page["scrollId"] = strconv.Itoa(n)
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+}
+
+func TestDatasetIteratorResume(t *testing.T) {
+	var scrollIDs []string
+	srv := scrollServer(t, 4, &scrollIDs)
+	defer srv.Close()
+	c := NewClient(srv.URL, "")
+
+	it := c.NewDatasetIterator(&ListOptions{PerPage: 1})
+	for i := 0; i < 2; i++ {
+		if _, err := it.Next(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkpoint := it.ScrollID()
+	if checkpoint != "2" {
+		t.Fatalf("scroll ID after 2 pages = %q, want 2", checkpoint)
+	}
+
+	// Resume where the first iterator stopped
+	scrollIDs = nil
+	var urns []string
+	err := c.GetDatasets(func(datasets []*Dataset) error {
+		for _, ds := range datasets {
+			urns = append(urns, ds.URN)
+		}
+		return nil
+	}, &ListOptions{PerPage: 1, ScrollID: checkpoint})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"urn:3", "urn:4"}; !slices.Equal(urns, want) {
+		t.Errorf("resumed listing returned %v, want %v", urns, want)
+	}
+	if want := []string{"2", "3"}; !slices.Equal(scrollIDs, want) {
+		t.Errorf("requested scroll IDs %v, want %v", scrollIDs, want)
+	}
+}
+
+func TestDatasetIteratorDone(t *testing.T) {
+	var scrollIDs []string
+	srv := scrollServer(t, 1, &scrollIDs)
+	defer srv.Close()
+
+	it := NewClient(srv.URL, "").NewDatasetIterator(&ListOptions{PerPage: 1})
+	datasets, err := it.Next()
+	if err != nil || len(datasets) != 1 {
+		t.Fatalf("datasets = %v, err = %v", datasets, err)
+	}
+	if it.ScrollID() != "" {
+		t.Errorf("scroll ID = %q after the last page", it.ScrollID())
+	}
+	if datasets, _ := it.Next(); datasets != nil || len(scrollIDs) != 1 {
+		t.Errorf("the iterator fetched past the last page")
+	}
+}
+
+func TestMaxResults(t *testing.T) {
+	var counts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		counts = append(counts, q.Get("count"))
+		start, _ := strconv.Atoi(q.Get("scrollId"))
+		count, _ := strconv.Atoi(q.Get("count"))
+
+		var entities []map[string]string
+		for i := start; i < start+count && i < 100; i++ {
+			entities = append(entities, map[string]string{"urn": fmt.Sprintf("urn:%d", i)})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"entities": entities,
+			"scrollId": strconv.Itoa(start + count),
+		})
+	}))
+	defer srv.Close()
+
+	// Pages are retried regardless of the post retries
+	var urns []string
+	err := NewClient(srv.URL, "", WithMaxRetries(0)).GetDatasets(func(datasets []*Dataset) error {
+		for _, ds := range datasets {
+			urns = append(urns, ds.URN)
+		}
+		return nil
+	}, &ListOptions{PerPage: 3, MaxResults: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(urns) != 7 || urns[6] != "urn:6" {
+		t.Errorf("listed %v, want urn:0 to urn:6", urns)
+	}
+	// The last page only asks for the datasets left
+	if want := []string{"3", "3", "1"}; !slices.Equal(counts, want) {
+		t.Errorf("requested counts %v, want %v", counts, want)
+	}
+}
+
+func TestPaginationRetry(t *testing.T) {
+	var mu sync.Mutex
+	var scrollIDs []string
+	failures := map[string]int{}
+	pages := scrollServer(t, 3, new([]string))
+	defer pages.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		scrollID := r.URL.Query().Get("scrollId")
+		scrollIDs = append(scrollIDs, scrollID)
+		failures[scrollID]++
+		attempt := failures[scrollID]
+		mu.Unlock()
+
+		switch {
+		case scrollID == "1" && attempt == 1:
+			// Page 2 fails once
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		case scrollID == "2" && attempt == 1:
+			// Page 3 is cut short once
+			w.Header().Set("Content-Length", "100")
+			io.WriteString(w, `{"entities": [{"urn": `)
+		default:
+			resp, err := http.Get(pages.URL + r.URL.RequestURI())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer resp.Body.Close()
+			io.Copy(w, resp.Body)
+		}
+	}))
+	defer srv.Close()
+
+	// Pages are retried regardless of the post retries
+	var urns []string
+	err := NewClient(srv.URL, "", WithMaxRetries(0)).GetDatasets(func(datasets []*Dataset) error {
+		for _, ds := range datasets {
+			urns = append(urns, ds.URN)
+		}
+		return nil
+	}, &ListOptions{PerPage: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"urn:1", "urn:2", "urn:3"}; !slices.Equal(urns, want) {
+		t.Errorf("listed %v, want %v", urns, want)
+	}
+	// The failed pages are requested again with the same scroll ID
+	if want := []string{"", "1", "1", "2", "2"}; !slices.Equal(scrollIDs, want) {
+		t.Errorf("requested scroll IDs %q, want %q", scrollIDs, want)
+	}
+
+	// Without retries left, the error is returned
+	failures, scrollIDs = map[string]int{}, nil
+	c := NewClient(srv.URL, "", WithPageRetries(0))
+	err = c.GetDatasets(func([]*Dataset) error { return nil }, &ListOptions{PerPage: 1})
+	var dhErr *DataHubError
+	if !errors.As(err, &dhErr) || dhErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("err = %v, want the 503 error", err)
+	}
+}
+
+func TestPaginationNoRetry(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Error(w, "bad request", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	err := NewClient(srv.URL, "").GetDatasets(func([]*Dataset) error { return nil }, &ListOptions{PerPage: 1})
+	if err == nil || requests != 1 {
+		t.Errorf("requests = %d, err = %v, want a single request", requests, err)
+	}
+}
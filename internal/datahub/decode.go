@@ -0,0 +1,100 @@
+package datahub
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DecodeIssue is an entity of a JSON array that couldn't be decoded
+type DecodeIssue struct {
+	// Index is the position of the entity in the array, starting at 0
+	Index int
+	URN   string
+	// Path is the JSON path of the offending value in the entity, e.g.
+	// schemaMetadata.value.fields.2.fieldPath, if known
+	Path string
+	Msg  string
+}
+
+func (i DecodeIssue) String() string {
+	entity := fmt.Sprintf("entity %d", i.Index+1)
+	if i.URN != "" {
+		entity += " (" + i.URN + ")"
+	}
+	if i.Path != "" {
+		return fmt.Sprintf("%s: %s: %s", entity, i.Path, i.Msg)
+	}
+	return fmt.Sprintf("%s: %s", entity, i.Msg)
+}
+
+// DecodeError lists every invalid entity of a JSON array. It matches
+// ErrValidation with errors.Is.
+type DecodeError struct {
+	Total  int
+	Issues []DecodeIssue
+}
+
+func (e *DecodeError) Error() string {
+	msg := fmt.Sprintf("%d of %d entities are invalid", len(e.Issues), e.Total)
+	for _, i := range e.Issues {
+		msg += "\n  " + i.String()
+	}
+	return msg
+}
+
+func (e *DecodeError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// DecodeEntities decodes the JSON array of entities in data. Every entity
+// is decoded on its own, so all the invalid ones are reported in a
+// DecodeError instead of the first one. With disallowUnknown, fields unknown
+// to dsg make the entity invalid too.
+func DecodeEntities[T any](data []byte, disallowUnknown bool) ([]T, error) {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			line := bytes.Count(data[:syntaxErr.Offset], []byte("\n")) + 1
+			return nil, invalidf("invalid JSON at line %d: %v", line, err)
+		}
+		return nil, invalidf("invalid JSON: %v", err)
+	}
+
+	entities := make([]T, len(raws))
+	decodeErr := &DecodeError{Total: len(raws)}
+	for i, raw := range raws {
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		if disallowUnknown {
+			dec.DisallowUnknownFields()
+		}
+		if err := dec.Decode(&entities[i]); err != nil {
+			decodeErr.Issues = append(decodeErr.Issues, decodeIssue(i, raw, err))
+		}
+	}
+	if len(decodeErr.Issues) > 0 {
+		return nil, decodeErr
+	}
+	return entities, nil
+}
+
+// decodeIssue describes the error decoding the entity raw
+func decodeIssue(index int, raw json.RawMessage, err error) DecodeIssue {
+	issue := DecodeIssue{Index: index, Msg: strings.TrimPrefix(err.Error(), "json: ")}
+	var entity struct {
+		URN string `json:"urn"`
+	}
+	if json.Unmarshal(raw, &entity) == nil {
+		issue.URN = entity.URN
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		issue.Path = typeErr.Field
+		issue.Msg = fmt.Sprintf("expected %s, got %s", typeErr.Type, typeErr.Value)
+	}
+	return issue
+}
@@ -0,0 +1,63 @@
+package datahub
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDecodeEntities(t *testing.T) {
+	data := []byte(`[
+  {"urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,good,PROD)", "schemaMetadata": {"value": {"version": 1}}},
+  {"urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,bad,PROD)", "schemaMetadata": {"value": {"version": "1"}}},
+  {"urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,other,PROD)", "extra": true},
+  {"schemaMetadata": {"value": {"fields": [{"fieldPath": "id"}, {"fieldPath": 2}]}}}
+]`)
+
+	_, err := DecodeEntities[Dataset](data, false)
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) || !errors.Is(err, ErrValidation) {
+		t.Fatalf("err = %v, want a DecodeError", err)
+	}
+	want := []DecodeIssue{
+		{Index: 1, URN: "urn:li:dataset:(urn:li:dataPlatform:mysql,bad,PROD)", Path: "schemaMetadata.value.version", Msg: "expected int, got string"},
+		{Index: 3, Path: "schemaMetadata.value.fields.1.fieldPath", Msg: "expected string, got number"},
+	}
+	if decodeErr.Total != 4 || len(decodeErr.Issues) != len(want) {
+		t.Fatalf("issues = %+v", decodeErr.Issues)
+	}
+	for i, issue := range decodeErr.Issues {
+		if issue != want[i] {
+			t.Errorf("issue %d = %+v, want %+v", i, issue, want[i])
+		}
+	}
+	wantMsg := `2 of 4 entities are invalid
+  entity 2 (urn:li:dataset:(urn:li:dataPlatform:mysql,bad,PROD)): schemaMetadata.value.version: expected int, got string
+  entity 4: schemaMetadata.value.fields.1.fieldPath: expected string, got number`
+	if err.Error() != wantMsg {
+		t.Errorf("err =\n%s\nwant\n%s", err, wantMsg)
+	}
+
+	// Unknown fields are rejected on request
+	_, err = DecodeEntities[Dataset](data, true)
+	if !errors.As(err, &decodeErr) || len(decodeErr.Issues) != 3 {
+		t.Fatalf("err = %v, want 3 issues", err)
+	}
+	if issue := decodeErr.Issues[1]; issue.Index != 2 || !strings.Contains(issue.Msg, `unknown field "extra"`) {
+		t.Errorf("unknown field issue = %+v", issue)
+	}
+
+	datasets, err := DecodeEntities[Dataset]([]byte(`[{"urn": "a"}, {"urn": "b"}]`), true)
+	if err != nil || len(datasets) != 2 || datasets[1].URN != "b" {
+		t.Errorf("datasets = %+v, err = %v", datasets, err)
+	}
+
+	for input, want := range map[string]string{
+		"[\n  {\"urn\": }\n]": "invalid JSON at line 2",
+		`{"urn": "a"}`:        "invalid JSON: ",
+	} {
+		if _, err := DecodeEntities[Dataset]([]byte(input), false); err == nil || !errors.Is(err, ErrValidation) || !strings.Contains(err.Error(), want) {
+			t.Errorf("DecodeEntities(%q) error = %v, want %q", input, err, want)
+		}
+	}
+}
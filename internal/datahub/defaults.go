@@ -0,0 +1,132 @@
+package datahub
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DatasetDefaults are applied to every dataset of a generation
+type DatasetDefaults struct {
+	// Terms are glossary term URNs added to each dataset
+	Terms []string
+	// Tags are tag URNs added to the globalTags of each dataset
+	Tags []string
+	// DescriptionPrefix is prepended to each dataset description
+	DescriptionPrefix string
+}
+
+// Empty returns true if there are no defaults to apply
+func (d DatasetDefaults) Empty() bool {
+	return len(d.Terms) == 0 && len(d.Tags) == 0 && d.DescriptionPrefix == ""
+}
+
+// Validate returns an error if a default term is not a glossary term URN or
+// a default tag is not a tag URN
+func (d DatasetDefaults) Validate() error {
+	for _, t := range d.Terms {
+		if !strings.HasPrefix(t, "urn:li:glossaryTerm:") || len(t) == len("urn:li:glossaryTerm:") {
+			return invalidf("invalid glossary term URN %q: must start with urn:li:glossaryTerm:", t)
+		}
+	}
+	for _, t := range d.Tags {
+		if err := ValidateTagURN(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyDatasetDefaults merges the defaults into every dataset in a JSON array
+// of datasets. Terms and tags already attached to a dataset are kept and not
+// repeated, and descriptions already starting with the prefix are left
+// alone. Unknown fields are preserved.
+func ApplyDatasetDefaults(payload string, defaults DatasetDefaults) (string, error) {
+	if err := defaults.Validate(); err != nil {
+		return "", err
+	}
+
+	var datasets []map[string]interface{}
+	if err := json.Unmarshal([]byte(payload), &datasets); err != nil {
+		return "", fmt.Errorf("error parsing dataset array: %w", err)
+	}
+
+	for _, ds := range datasets {
+		if len(defaults.Terms) > 0 {
+			addDefaultTerms(ds, defaults.Terms)
+		}
+		if len(defaults.Tags) > 0 {
+			addDefaultTags(ds, defaults.Tags)
+		}
+		if defaults.DescriptionPrefix != "" {
+			prefixDescription(ds, defaults.DescriptionPrefix)
+		}
+	}
+
+	data, err := json.Marshal(datasets)
+	if err != nil {
+		return "", fmt.Errorf("error encoding datasets: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// addDefaultTerms adds the terms missing from the glossaryTerms aspect of ds
+func addDefaultTerms(ds map[string]interface{}, terms []string) {
+	container, _ := ds["glossaryTerms"].(map[string]interface{})
+	if container == nil {
+		container = map[string]interface{}{}
+		ds["glossaryTerms"] = container
+	}
+	value, _ := container["value"].(map[string]interface{})
+	if value == nil {
+		value = map[string]interface{}{}
+		container["value"] = value
+	}
+
+	current, _ := value["terms"].([]interface{})
+	seen := map[string]bool{}
+	for _, t := range current {
+		if assoc, ok := t.(map[string]interface{}); ok {
+			if urn, ok := assoc["urn"].(string); ok {
+				seen[urn] = true
+			}
+		}
+	}
+
+	for _, t := range terms {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		current = append(current, TermAssociation{URN: t})
+	}
+	value["terms"] = current
+
+	if stamp, ok := value["auditStamp"].(map[string]interface{}); !ok || stamp["time"] == nil {
+		value["auditStamp"] = NewAuditStamp("")
+	}
+}
+
+// prefixDescription prepends prefix to the datasetProperties description of ds
+func prefixDescription(ds map[string]interface{}, prefix string) {
+	container, _ := ds["datasetProperties"].(map[string]interface{})
+	if container == nil {
+		container = map[string]interface{}{}
+		ds["datasetProperties"] = container
+	}
+	value, _ := container["value"].(map[string]interface{})
+	if value == nil {
+		value = map[string]interface{}{}
+		container["value"] = value
+	}
+
+	description, _ := value["description"].(string)
+	switch {
+	case description == "":
+		description = prefix
+	case !strings.HasPrefix(description, prefix):
+		description = strings.TrimRight(prefix, " ") + " " + description
+	}
+	value["description"] = description
+}
@@ -0,0 +1,90 @@
+package datahub
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestApplyDatasetDefaults(t *testing.T) {
+	payload := `[
+  {
+    "urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)",
+    "glossaryTerms": {"value": {"terms": [{"urn": "urn:li:glossaryTerm:Email"}, {"urn": "urn:li:glossaryTerm:Customer"}], "auditStamp": {"time": 42, "actor": "urn:li:corpuser:model"}}},
+    "datasetProperties": {"value": {"name": "users", "description": "The users"}},
+    "unknown": true
+  },
+  {
+    "urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,orders,PROD)",
+    "datasetProperties": {"value": {"description": "[generated] The orders"}}
+  },
+  {
+    "urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,items,PROD)"
+  }
+]`
+	out, err := ApplyDatasetDefaults(payload, DatasetDefaults{
+		Terms:             []string{"urn:li:glossaryTerm:Generated", "urn:li:glossaryTerm:Email"},
+		DescriptionPrefix: "[generated] ",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var datasets []struct {
+		Dataset
+		Unknown bool `json:"unknown"`
+	}
+	if err := json.Unmarshal([]byte(out), &datasets); err != nil {
+		t.Fatal(err)
+	}
+
+	wantTerms := [][]string{
+		// The model terms are kept, first, and not repeated
+		{"urn:li:glossaryTerm:Email", "urn:li:glossaryTerm:Customer", "urn:li:glossaryTerm:Generated"},
+		{"urn:li:glossaryTerm:Generated", "urn:li:glossaryTerm:Email"},
+		{"urn:li:glossaryTerm:Generated", "urn:li:glossaryTerm:Email"},
+	}
+	wantDescriptions := []string{"[generated] The users", "[generated] The orders", "[generated] "}
+	for i, ds := range datasets {
+		var terms []string
+		for _, term := range ds.GlossaryTerms.Value.Terms {
+			terms = append(terms, term.URN)
+		}
+		if !slices.Equal(terms, wantTerms[i]) {
+			t.Errorf("dataset %d terms = %v, want %v", i, terms, wantTerms[i])
+		}
+		if ds.GlossaryTerms.Value.AuditStamp.Time == 0 || ds.GlossaryTerms.Value.AuditStamp.Actor == "" {
+			t.Errorf("dataset %d has no audit stamp: %+v", i, ds.GlossaryTerms.Value.AuditStamp)
+		}
+		if ds.DatasetProperties == nil || ds.DatasetProperties.Value.Description != wantDescriptions[i] {
+			t.Errorf("dataset %d description = %+v, want %q", i, ds.DatasetProperties, wantDescriptions[i])
+		}
+	}
+
+	if stamp := datasets[0].GlossaryTerms.Value.AuditStamp; stamp != (AuditStamp{Time: 42, Actor: "urn:li:corpuser:model"}) {
+		t.Errorf("the model audit stamp was replaced: %+v", stamp)
+	}
+	if stamp := datasets[1].GlossaryTerms.Value.AuditStamp; stamp.Actor != DefaultActor {
+		t.Errorf("audit stamp actor = %q, want %q", stamp.Actor, DefaultActor)
+	}
+	if datasets[0].DatasetProperties.Value.Name != "users" || !datasets[0].Unknown {
+		t.Errorf("fields were dropped: %s", out)
+	}
+}
+
+func TestDatasetDefaultsValidate(t *testing.T) {
+	for _, d := range []DatasetDefaults{
+		{Terms: []string{"Email"}},
+		{Terms: []string{"urn:li:glossaryTerm:"}},
+		{Tags: []string{"pii"}},
+	} {
+		if _, err := ApplyDatasetDefaults(`[{}]`, d); !errors.Is(err, ErrValidation) {
+			t.Errorf("ApplyDatasetDefaults(%+v) error = %v, want a validation error", d, err)
+		}
+	}
+
+	if !(DatasetDefaults{}).Empty() || (DatasetDefaults{DescriptionPrefix: "x"}).Empty() {
+		t.Error("unexpected Empty result")
+	}
+}
@@ -0,0 +1,63 @@
+package datahub
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// entityAspects maps the entity types that can be detected to aspects
+// only found in that entity type
+var entityAspects = map[string][]string{
+	"dataset":      {"schemaMetadata", "datasetKey", "datasetProperties", "editableSchemaMetadata"},
+	"glossaryTerm": {"glossaryTermInfo", "glossaryTermKey"},
+	"tag":          {"tagProperties", "tagKey"},
+}
+
+// DetectEntityType infers the entity type of a JSON array of entities from
+// the URN and aspects of its first element
+func DetectEntityType(data []byte) (string, error) {
+	var entities []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &entities); err != nil {
+		return "", fmt.Errorf("error decoding JSON: %w", err)
+	}
+	if len(entities) == 0 {
+		return "", fmt.Errorf("cannot detect the entity type of an empty array")
+	}
+
+	first := entities[0]
+	candidates := map[string]bool{}
+	for entityType, aspects := range entityAspects {
+		for _, aspect := range aspects {
+			if _, ok := first[aspect]; ok {
+				candidates[entityType] = true
+			}
+		}
+	}
+
+	var urn string
+	if raw, ok := first["urn"]; ok {
+		_ = json.Unmarshal(raw, &urn)
+	}
+	for entityType := range entityAspects {
+		if strings.HasPrefix(urn, "urn:li:"+entityType+":") {
+			candidates[entityType] = true
+		}
+	}
+
+	types := make([]string, 0, len(candidates))
+	for entityType := range candidates {
+		types = append(types, entityType)
+	}
+	sort.Strings(types)
+
+	switch len(types) {
+	case 0:
+		return "", fmt.Errorf("cannot detect the entity type, use --entity-type")
+	case 1:
+		return types[0], nil
+	default:
+		return "", fmt.Errorf("ambiguous entity type (%s), use --entity-type", strings.Join(types, ", "))
+	}
+}
@@ -0,0 +1,48 @@
+package datahub
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectEntityType(t *testing.T) {
+	tests := map[string]string{
+		// Aspects
+		`[{"schemaMetadata": {}}]`:                 "dataset",
+		`[{"datasetProperties": {}}]`:              "dataset",
+		`[{"glossaryTermInfo": {}}]`:               "glossaryTerm",
+		`[{"glossaryTermKey": {}}]`:                "glossaryTerm",
+		`[{"tagProperties": {}}]`:                  "tag",
+		`[{"tagKey": {}}, {"schemaMetadata": {}}]`: "tag",
+		// URNs
+		`[{"urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,db.users,PROD)"}]`: "dataset",
+		`[{"urn": "urn:li:glossaryTerm:Email"}]`:                                "glossaryTerm",
+		`[{"urn": "urn:li:tag:pii", "status": {}}]`:                             "tag",
+		// Both agree
+		`[{"urn": "urn:li:glossaryTerm:Email", "glossaryTermInfo": {}}]`: "glossaryTerm",
+		// Only whole entity types match the URN
+		`[{"urn": "urn:li:datasetField:x", "schemaMetadata": {}}]`: "dataset",
+	}
+	for data, want := range tests {
+		got, err := DetectEntityType([]byte(data))
+		if err != nil || got != want {
+			t.Errorf("DetectEntityType(%s) = %q, %v, want %q", data, got, err, want)
+		}
+	}
+}
+
+func TestDetectEntityTypeErrors(t *testing.T) {
+	tests := map[string]string{
+		`[{"schemaMetadata": {}, "glossaryTermInfo": {}}]`:    "ambiguous entity type (dataset, glossaryTerm)",
+		`[{"urn": "urn:li:tag:pii", "glossaryTermInfo": {}}]`: "ambiguous entity type (glossaryTerm, tag)",
+		`[{"urn": "urn:li:corpuser:jdoe", "status": {}}]`:     "cannot detect the entity type",
+		`[]`:                     "empty array",
+		`{"schemaMetadata": {}}`: "error decoding JSON",
+	}
+	for data, want := range tests {
+		_, err := DetectEntityType([]byte(data))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("DetectEntityType(%s) error = %v, want %q", data, err, want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package datahub
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// FieldChange is a field present in both schemas with different attributes
+type FieldChange struct {
+	FieldPath string
+	// Changes describe each attribute changed, e.g. `type string -> number`
+	Changes []string
+}
+
+// FieldDiff holds the differences between two lists of schema fields,
+// matched by field path
+type FieldDiff struct {
+	Added   []SchemaField
+	Removed []SchemaField
+	Changed []FieldChange
+}
+
+// Empty returns true if both lists of fields are the same
+func (d FieldDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffFields compares the fields of an existing schema with the fields
+// replacing them. Added and changed fields keep the order of fields, removed
+// fields the order of existing.
+func DiffFields(existing, fields []SchemaField) FieldDiff {
+	var d FieldDiff
+	current := make(map[string]SchemaField, len(existing))
+	for _, f := range existing {
+		current[f.FieldPath] = f
+	}
+
+	seen := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		seen[f.FieldPath] = true
+		old, ok := current[f.FieldPath]
+		if !ok {
+			d.Added = append(d.Added, f)
+			continue
+		}
+		if changes := fieldChanges(old, f); len(changes) > 0 {
+			d.Changed = append(d.Changed, FieldChange{FieldPath: f.FieldPath, Changes: changes})
+		}
+	}
+	for _, f := range existing {
+		if !seen[f.FieldPath] {
+			d.Removed = append(d.Removed, f)
+		}
+	}
+
+	return d
+}
+
+// fieldChanges describes the attributes that differ between old and f
+func fieldChanges(old, f SchemaField) []string {
+	var changes []string
+	if !sameFieldType(old, f) {
+		changes = append(changes, fmt.Sprintf("type %s -> %s", describeFieldType(old), describeFieldType(f)))
+	}
+	if old.Description != f.Description {
+		changes = append(changes, fmt.Sprintf("description %q -> %q", old.Description, f.Description))
+	}
+	if old.Nullable != f.Nullable {
+		changes = append(changes, fmt.Sprintf("nullable %t -> %t", old.Nullable, f.Nullable))
+	}
+	if oldTerms, terms := fieldTerms(old), fieldTerms(f); !slices.Equal(oldTerms, terms) {
+		changes = append(changes, fmt.Sprintf("glossary terms [%s] -> [%s]", strings.Join(oldTerms, ", "), strings.Join(terms, ", ")))
+	}
+	return changes
+}
+
+// fieldTerms returns the sorted glossary term URNs of the field
+func fieldTerms(f SchemaField) []string {
+	if f.GlossaryTerms == nil {
+		return nil
+	}
+	var terms []string
+	for _, t := range f.GlossaryTerms.Terms {
+		terms = append(terms, t.URN)
+	}
+	slices.Sort(terms)
+	return terms
+}
+
+// String returns the diff with a line per field, prefixed with + for added
+// fields, - for removed fields and ~ for changed fields
+func (d FieldDiff) String() string {
+	var b strings.Builder
+	for _, f := range d.Added {
+		fmt.Fprintf(&b, "+ %s (%s)\n", f.FieldPath, describeFieldType(f))
+	}
+	for _, f := range d.Removed {
+		fmt.Fprintf(&b, "- %s (%s)\n", f.FieldPath, describeFieldType(f))
+	}
+	for _, c := range d.Changed {
+		fmt.Fprintf(&b, "~ %s: %s\n", c.FieldPath, strings.Join(c.Changes, ", "))
+	}
+	return b.String()
+}
+
+// GetSchemaFields returns the schema fields of an existing dataset. The
+// error wraps ErrNotFound if the dataset has no schema.
+func (c *Client) GetSchemaFields(datasetURN string) ([]SchemaField, error) {
+	var schema SchemaMetadataContainer
+	found, err := c.getAspect("dataset", datasetURN, "schemaMetadata", &schema)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching the dataset schema: %w", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("dataset %s: %w", datasetURN, ErrNotFound)
+	}
+	return schema.Value.Fields, nil
+}
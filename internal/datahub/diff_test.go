@@ -0,0 +1,105 @@
+package datahub
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// withTerms returns f associated with the glossary terms
+func withTerms(f SchemaField, terms ...string) SchemaField {
+	f.GlossaryTerms = &FieldGlossaryTermsContainer{}
+	for _, term := range terms {
+		f.GlossaryTerms.Terms = append(f.GlossaryTerms.Terms, TermAssociation{URN: term})
+	}
+	return f
+}
+
+func TestDiffFields(t *testing.T) {
+	existing := []SchemaField{
+		field("id", "number", "int"),
+		field("name", "string", "varchar(255)"),
+		withTerms(field("email", "string", "varchar(255)"), "urn:li:glossaryTerm:pii", "urn:li:glossaryTerm:email"),
+		field("legacy", "string", ""),
+		field("created", "date", "date"),
+	}
+	existing[1].Description = "the user name"
+
+	fields := []SchemaField{
+		field("id", "string", "uuid"),
+		field("name", "string", "VARCHAR(255)"),
+		withTerms(field("email", "string", "varchar(255)"), "urn:li:glossaryTerm:email", "urn:li:glossaryTerm:pii"),
+		field("age", "number", "int"),
+		field("created", "date", "date"),
+	}
+	fields[1].Nullable = true
+	fields[4] = withTerms(fields[4], "urn:li:glossaryTerm:audit")
+
+	d := DiffFields(existing, fields)
+	if got := fieldPaths(d.Added); !slices.Equal(got, []string{"age"}) {
+		t.Errorf("added = %v", got)
+	}
+	if got := fieldPaths(d.Removed); !slices.Equal(got, []string{"legacy"}) {
+		t.Errorf("removed = %v", got)
+	}
+	want := []FieldChange{
+		{FieldPath: "id", Changes: []string{"type number (int) -> string (uuid)"}},
+		{FieldPath: "name", Changes: []string{`description "the user name" -> ""`, "nullable false -> true"}},
+		{FieldPath: "created", Changes: []string{"glossary terms [] -> [urn:li:glossaryTerm:audit]"}},
+	}
+	if len(d.Changed) != len(want) {
+		t.Fatalf("changed = %+v, want %+v", d.Changed, want)
+	}
+	for i, c := range d.Changed {
+		if c.FieldPath != want[i].FieldPath || !slices.Equal(c.Changes, want[i].Changes) {
+			t.Errorf("change %d = %+v, want %+v", i, c, want[i])
+		}
+	}
+	if d.Empty() {
+		t.Error("the diff is empty")
+	}
+
+	wantString := `+ age (number (int))
+- legacy (string)
+~ id: type number (int) -> string (uuid)
+~ name: description "the user name" -> "", nullable false -> true
+~ created: glossary terms [] -> [urn:li:glossaryTerm:audit]
+`
+	if got := d.String(); got != wantString {
+		t.Errorf("String() =\n%s\nwant\n%s", got, wantString)
+	}
+
+	if d := DiffFields(existing, existing); !d.Empty() || d.String() != "" {
+		t.Errorf("diff of the same fields = %+v", d)
+	}
+	if d := DiffFields(nil, fields); len(d.Added) != len(fields) || len(d.Removed) != 0 {
+		t.Errorf("diff against no fields = %+v", d)
+	}
+}
+
+func TestGetSchemaFields(t *testing.T) {
+	srv := newAspectServer(t)
+	c := NewClient(srv.URL, "")
+	urn := "urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)"
+	srv.entities[urn] = map[string]json.RawMessage{
+		"urn":            json.RawMessage(`"` + urn + `"`),
+		"schemaMetadata": json.RawMessage(`{"value": {"schemaName": "users", "fields": [{"fieldPath": "id", "type": {"type": {"com.linkedin.schema.NumberType": {}}}, "nativeDataType": "int"}]}}`),
+	}
+	noSchema := "urn:li:dataset:(urn:li:dataPlatform:mysql,empty,PROD)"
+	srv.entities[noSchema] = map[string]json.RawMessage{"urn": json.RawMessage(`"` + noSchema + `"`)}
+
+	fields, err := c.GetSchemaFields(urn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 1 || fields[0].FieldPath != "id" || fields[0].Type.Type.Name() != "number" {
+		t.Errorf("fields = %+v", fields)
+	}
+
+	for _, urn := range []string{noSchema, "urn:li:dataset:(urn:li:dataPlatform:mysql,missing,PROD)"} {
+		if _, err := c.GetSchemaFields(urn); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: err = %v, want ErrNotFound", urn, err)
+		}
+	}
+}
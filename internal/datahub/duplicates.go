@@ -0,0 +1,25 @@
+package datahub
+
+// DuplicateURNs returns the URNs shared by more than one entity, in order of
+// first appearance. Entities without a URN are ignored.
+func DuplicateURNs[T Dataset | GlossaryTerm](entities []T) []string {
+	seen := make(map[string]int)
+	var dups []string
+	for _, e := range entities {
+		var urn string
+		switch e := any(e).(type) {
+		case Dataset:
+			urn = e.URN
+		case GlossaryTerm:
+			urn = e.URN
+		}
+		if urn == "" {
+			continue
+		}
+		seen[urn]++
+		if seen[urn] == 2 {
+			dups = append(dups, urn)
+		}
+	}
+	return dups
+}
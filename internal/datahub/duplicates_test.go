@@ -0,0 +1,24 @@
+package datahub
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDuplicateURNs(t *testing.T) {
+	datasets := []Dataset{{URN: "a"}, {URN: "b"}, {URN: "c"}, {URN: "b"}, {}, {}, {URN: "a"}, {URN: "b"}}
+	if dups := DuplicateURNs(datasets); !slices.Equal(dups, []string{"b", "a"}) {
+		t.Errorf("DuplicateURNs(datasets) = %v, want [b a]", dups)
+	}
+	if dups := DuplicateURNs([]Dataset{{URN: "a"}, {URN: "b"}, {}, {}}); dups != nil {
+		t.Errorf("DuplicateURNs(unique datasets) = %v", dups)
+	}
+
+	terms := []GlossaryTerm{{URN: "urn:li:glossaryTerm:x"}, {URN: "urn:li:glossaryTerm:x"}}
+	if dups := DuplicateURNs(terms); !slices.Equal(dups, []string{"urn:li:glossaryTerm:x"}) {
+		t.Errorf("DuplicateURNs(terms) = %v", dups)
+	}
+	if dups := DuplicateURNs([]GlossaryTerm{{URN: "urn:li:glossaryTerm:x"}, {URN: "urn:li:glossaryTerm:y"}}); dups != nil {
+		t.Errorf("DuplicateURNs(unique terms) = %v", dups)
+	}
+}
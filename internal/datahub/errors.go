@@ -0,0 +1,74 @@
+package datahub
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrUnauthorized is returned when DataHub rejects the token (401 or 403)
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrNotFound is returned when the DataHub resource doesn't exist
+	ErrNotFound = errors.New("not found")
+	// ErrValidation is returned when the input is invalid, before sending
+	// anything to DataHub
+	ErrValidation = errors.New("validation error")
+)
+
+// maxErrorBodySize limits the response body kept in a DataHubError
+const maxErrorBodySize = 4096
+
+// DataHubError is returned when DataHub answers with a non-2xx status code.
+// It matches ErrUnauthorized and ErrNotFound with errors.Is.
+type DataHubError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *DataHubError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("request failed with status code: %d", e.StatusCode)
+	}
+	return fmt.Sprintf("request failed with status code: %d: %s", e.StatusCode, e.Body)
+}
+
+func (e *DataHubError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	}
+	return false
+}
+
+// NewDataHubError returns a DataHubError with the status code and the
+// beginning of the body of resp
+func NewDataHubError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	return &DataHubError{
+		StatusCode: resp.StatusCode,
+		Body:       strings.TrimSpace(string(body)),
+	}
+}
+
+// ValidationError is returned when the input is rejected before sending
+// anything to DataHub. It matches ErrValidation with errors.Is.
+type ValidationError struct {
+	Msg string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Msg
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+func invalidf(format string, args ...any) error {
+	return &ValidationError{Msg: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,76 @@
+package datahub
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTypedErrors(t *testing.T) {
+	tests := []struct {
+		status       int
+		unauthorized bool
+		notFound     bool
+	}{
+		{http.StatusBadRequest, false, false},
+		{http.StatusUnauthorized, true, false},
+		{http.StatusForbidden, true, false},
+		{http.StatusNotFound, false, true},
+		{http.StatusUnprocessableEntity, false, false},
+		{http.StatusInternalServerError, false, false},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "details of "+http.StatusText(tt.status), tt.status)
+		}))
+		c := NewClient(srv.URL, "")
+		c.MaxRetries = 0
+
+		_, postErr := c.PostEntity("dataset", `[{"urn":"urn:1"}]`, nil)
+		_, listErr := c.NewDatasetIterator(&ListOptions{}).Next()
+		srv.Close()
+
+		for name, err := range map[string]error{"post": postErr, "list": listErr} {
+			var dhErr *DataHubError
+			if !errors.As(err, &dhErr) {
+				t.Errorf("%d %s: err = %v, want a *DataHubError", tt.status, name, err)
+				continue
+			}
+			if dhErr.StatusCode != tt.status || dhErr.Body != "details of "+http.StatusText(tt.status) {
+				t.Errorf("%d %s: %+v", tt.status, name, dhErr)
+			}
+			if errors.Is(err, ErrUnauthorized) != tt.unauthorized || errors.Is(err, ErrNotFound) != tt.notFound {
+				t.Errorf("%d %s: errors.Is(ErrUnauthorized) = %v, errors.Is(ErrNotFound) = %v",
+					tt.status, name, errors.Is(err, ErrUnauthorized), errors.Is(err, ErrNotFound))
+			}
+			if errors.Is(err, ErrValidation) {
+				t.Errorf("%d %s: DataHub errors are not validation errors", tt.status, name)
+			}
+		}
+	}
+}
+
+func TestDataHubErrorBodyLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, strings.Repeat("x", 2*maxErrorBodySize), http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	_, err := NewClient(srv.URL, "").PostEntity("dataset", `[{"urn":"urn:1"}]`, nil)
+	var dhErr *DataHubError
+	if !errors.As(err, &dhErr) || len(dhErr.Body) != maxErrorBodySize {
+		t.Errorf("err = %v", err)
+	}
+	if got := (&DataHubError{StatusCode: 502}).Error(); got != "request failed with status code: 502" {
+		t.Errorf("error without body = %q", got)
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	err := invalidf("invalid URN %q", "x")
+	if err.Error() != `invalid URN "x"` || !errors.Is(err, ErrValidation) || errors.Is(err, ErrNotFound) {
+		t.Errorf("err = %v", err)
+	}
+}
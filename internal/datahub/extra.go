@@ -0,0 +1,90 @@
+package datahub
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// unmarshalWithExtra decodes data into v, a pointer to a struct without
+// custom unmarshaling, and returns the object fields v has no field for
+func unmarshalWithExtra(data []byte, v any) (map[string]json.RawMessage, error) {
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for name := range jsonFieldNames(reflect.TypeOf(v).Elem()) {
+		delete(fields, name)
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return fields, nil
+}
+
+// marshalWithExtra encodes v, a struct without custom marshaling, adding
+// the extra fields
+func marshalWithExtra(v any, extra map[string]json.RawMessage) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for name, raw := range extra {
+		if _, ok := fields[name]; !ok {
+			fields[name] = raw
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// jsonFieldNames returns the JSON names of the fields of struct type t
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = f.Name
+		}
+		names[name] = true
+	}
+	return names
+}
+
+// UnmarshalJSON keeps the fields unknown to dsg in Extra
+func (m *EditableSchemaMetadata) UnmarshalJSON(data []byte) error {
+	type plain EditableSchemaMetadata
+	extra, err := unmarshalWithExtra(data, (*plain)(m))
+	m.Extra = extra
+	return err
+}
+
+// MarshalJSON writes the fields in Extra back
+func (m EditableSchemaMetadata) MarshalJSON() ([]byte, error) {
+	type plain EditableSchemaMetadata
+	return marshalWithExtra(plain(m), m.Extra)
+}
+
+// UnmarshalJSON keeps the fields unknown to dsg in Extra
+func (f *EditableSchemaFieldInfo) UnmarshalJSON(data []byte) error {
+	type plain EditableSchemaFieldInfo
+	extra, err := unmarshalWithExtra(data, (*plain)(f))
+	f.Extra = extra
+	return err
+}
+
+// MarshalJSON writes the fields in Extra back
+func (f EditableSchemaFieldInfo) MarshalJSON() ([]byte, error) {
+	type plain EditableSchemaFieldInfo
+	return marshalWithExtra(plain(f), f.Extra)
+}
@@ -0,0 +1,64 @@
+package datahub
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEditableSchemaMetadataRoundTrip(t *testing.T) {
+	editable := `{"value": {
+  "created": {"time": 1700000000000, "actor": "urn:li:corpuser:alice"},
+  "lastModified": {"time": 1700000001000, "actor": "urn:li:corpuser:bob"},
+  "editableSchemaFieldInfo": [
+    {
+      "fieldPath": "email",
+      "description": "The user email, verified on signup",
+      "globalTags": {"tags": [{"tag": "urn:li:tag:pii"}]},
+      "glossaryTerms": {"terms": [{"urn": "urn:li:glossaryTerm:email"}], "auditStamp": {"time": 1700000000000, "actor": "urn:li:corpuser:alice"}},
+      "documentation": {"links": ["https://wiki.example.com/users"]}
+    },
+    {"fieldPath": "id"}
+  ],
+  "lastModifiedSource": "UI"
+}}`
+	dataset := `[{"urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)", "editableSchemaMetadata": ` + editable + `}]`
+
+	var datasets []Dataset
+	if err := json.Unmarshal([]byte(dataset), &datasets); err != nil {
+		t.Fatal(err)
+	}
+	info := datasets[0].EditableSchemaMetadata.Value.EditableSchemaFieldInfo
+	if len(info) != 2 || info[0].Description != "The user email, verified on signup" ||
+		info[0].GlobalTags == nil || info[0].GlobalTags.Tags[0].Tag != "urn:li:tag:pii" ||
+		info[0].GlossaryTerms == nil || info[0].GlossaryTerms.Terms[0].URN != "urn:li:glossaryTerm:email" {
+		t.Errorf("editable field info = %+v", info)
+	}
+	if _, ok := info[0].Extra["documentation"]; !ok || len(info[1].Extra) != 0 {
+		t.Errorf("field extra = %v, %v", info[0].Extra, info[1].Extra)
+	}
+	if _, ok := datasets[0].EditableSchemaMetadata.Value.Extra["lastModifiedSource"]; !ok {
+		t.Errorf("extra = %v", datasets[0].EditableSchemaMetadata.Value.Extra)
+	}
+
+	data, err := json.Marshal(datasets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if got := decoded[0]["editableSchemaMetadata"]; !jsonEqual(t, got, editable) {
+		t.Errorf("editableSchemaMetadata = %s, want %s", got, editable)
+	}
+
+	// Known fields win over stale extra ones
+	m := EditableSchemaMetadata{
+		EditableSchemaFieldInfo: []EditableSchemaFieldInfo{{FieldPath: "id"}},
+		Extra:                   map[string]json.RawMessage{"editableSchemaFieldInfo": json.RawMessage(`[]`), "custom": json.RawMessage(`1`)},
+	}
+	data, err = json.Marshal(m)
+	if err != nil || !jsonEqual(t, data, `{"editableSchemaFieldInfo": [{"fieldPath": "id"}], "custom": 1}`) {
+		t.Errorf("editable schema metadata = %s, %v", data, err)
+	}
+}
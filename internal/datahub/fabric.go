@@ -0,0 +1,92 @@
+package datahub
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Fabrics are the environments (origins) supported by DataHub
+var Fabrics = []string{
+	"DEV", "TEST", "QA", "UAT", "EI", "PRE", "STG", "NON_PROD",
+	"PROD", "CORP", "RVW", "PRD", "TST", "SIT", "SBX", "SANDBOX",
+}
+
+// ValidateFabric returns an error if fabric isn't a DataHub fabric
+func ValidateFabric(fabric string) error {
+	if !slices.Contains(Fabrics, fabric) {
+		return invalidf("invalid DataHub environment %q: must be one of %s", fabric, strings.Join(Fabrics, ", "))
+	}
+	return nil
+}
+
+// DatasetURN builds a dataset URN from its platform, name and origin
+func DatasetURN(platform, name, origin string) string {
+	return fmt.Sprintf("urn:li:dataset:(%s,%s,%s)", platform, name, origin)
+}
+
+// parseDatasetURN returns the platform, name and origin of a dataset URN
+func parseDatasetURN(urn string) (string, string, string, bool) {
+	inner, ok := strings.CutPrefix(urn, "urn:li:dataset:(")
+	if !ok || !strings.HasSuffix(inner, ")") {
+		return "", "", "", false
+	}
+	inner = strings.TrimSuffix(inner, ")")
+
+	// The platform URN and name may contain commas, the origin never does
+	last := strings.LastIndex(inner, ",")
+	if last < 0 {
+		return "", "", "", false
+	}
+	origin := inner[last+1:]
+	rest := inner[:last]
+
+	first := strings.Index(rest, ",")
+	if first < 0 {
+		return "", "", "", false
+	}
+
+	return rest[:first], rest[first+1:], origin, true
+}
+
+// SetDatasetsOrigin sets the origin of every dataset in a JSON array of
+// datasets, rebuilding the dataset URNs accordingly. Unknown fields are preserved.
+func SetDatasetsOrigin(payload, origin string) (string, error) {
+	if err := ValidateFabric(origin); err != nil {
+		return "", err
+	}
+
+	var datasets []map[string]interface{}
+	if err := json.Unmarshal([]byte(payload), &datasets); err != nil {
+		return "", fmt.Errorf("error parsing dataset array: %w", err)
+	}
+
+	for _, ds := range datasets {
+		urn, _ := ds["urn"].(string)
+		platform, name, _, ok := parseDatasetURN(urn)
+
+		if key, ok := ds["datasetKey"].(map[string]interface{}); ok {
+			if value, ok := key["value"].(map[string]interface{}); ok {
+				value["origin"] = origin
+				if p, ok := value["platform"].(string); ok && p != "" {
+					platform = p
+				}
+				if n, ok := value["name"].(string); ok && n != "" {
+					name = n
+				}
+			}
+		}
+
+		if ok || (platform != "" && name != "") {
+			ds["urn"] = DatasetURN(platform, name, origin)
+		}
+	}
+
+	data, err := json.Marshal(datasets)
+	if err != nil {
+		return "", fmt.Errorf("error encoding datasets: %w", err)
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,75 @@
+package datahub
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestValidateFabric(t *testing.T) {
+	for _, fabric := range []string{"PROD", "DEV", "QA"} {
+		if err := ValidateFabric(fabric); err != nil {
+			t.Errorf("ValidateFabric(%q) = %v", fabric, err)
+		}
+	}
+	for _, fabric := range []string{"", "prod", "PRODUCTION"} {
+		if err := ValidateFabric(fabric); !errors.Is(err, ErrValidation) {
+			t.Errorf("ValidateFabric(%q) = %v, want a validation error", fabric, err)
+		}
+	}
+}
+
+func TestParseDatasetURN(t *testing.T) {
+	platform, name, origin, ok := parseDatasetURN("urn:li:dataset:(urn:li:dataPlatform:hive,db.events,with,commas,PROD)")
+	if !ok || platform != "urn:li:dataPlatform:hive" || name != "db.events,with,commas" || origin != "PROD" {
+		t.Errorf("parseDatasetURN = %q, %q, %q, %t", platform, name, origin, ok)
+	}
+	for _, urn := range []string{"", "urn:li:dataset:x", "urn:li:dataset:(a)", "urn:li:corpuser:(a,b,c)"} {
+		if _, _, _, ok := parseDatasetURN(urn); ok {
+			t.Errorf("parseDatasetURN(%q) succeeded", urn)
+		}
+	}
+}
+
+func TestSetDatasetsOrigin(t *testing.T) {
+	payload := `[
+		{"urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)", "datasetKey": {"value": {"platform": "urn:li:dataPlatform:mysql", "name": "users", "origin": "PROD"}}, "extra": 1},
+		{"urn": "urn:li:dataset:(urn:li:dataPlatform:kafka,events,PROD)"},
+		{"datasetKey": {"value": {"platform": "urn:li:dataPlatform:hive", "name": "logs"}}},
+		{"schemaMetadata": {}}
+	]`
+
+	out, err := SetDatasetsOrigin(payload, "DEV")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var datasets []map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &datasets); err != nil {
+		t.Fatal(err)
+	}
+
+	wantURNs := []interface{}{
+		"urn:li:dataset:(urn:li:dataPlatform:mysql,users,DEV)",
+		"urn:li:dataset:(urn:li:dataPlatform:kafka,events,DEV)",
+		"urn:li:dataset:(urn:li:dataPlatform:hive,logs,DEV)",
+		nil,
+	}
+	for i, want := range wantURNs {
+		if datasets[i]["urn"] != want {
+			t.Errorf("dataset %d URN = %v, want %v", i, datasets[i]["urn"], want)
+		}
+	}
+
+	key := datasets[0]["datasetKey"].(map[string]interface{})["value"].(map[string]interface{})
+	if key["origin"] != "DEV" || key["name"] != "users" {
+		t.Errorf("dataset key = %v", key)
+	}
+	if datasets[0]["extra"] != float64(1) {
+		t.Errorf("unknown fields were not preserved: %v", datasets[0])
+	}
+
+	if _, err := SetDatasetsOrigin(payload, "dev"); !errors.Is(err, ErrValidation) {
+		t.Errorf("SetDatasetsOrigin with an invalid fabric = %v", err)
+	}
+}
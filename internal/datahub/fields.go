@@ -0,0 +1,100 @@
+package datahub
+
+import "fmt"
+
+// FieldTypeCounts holds the number of schema fields per type
+type FieldTypeCounts struct {
+	Strings int
+	Numbers int
+	Others  int
+}
+
+// Total returns the total number of fields
+func (f FieldTypeCounts) Total() int {
+	return f.Strings + f.Numbers + f.Others
+}
+
+// String returns a summary like "12 fields: 8 string, 3 number, 1 other"
+func (f FieldTypeCounts) String() string {
+	noun := "fields"
+	if f.Total() == 1 {
+		noun = "field"
+	}
+	return fmt.Sprintf("%d %s: %d string, %d number, %d other", f.Total(), noun, f.Strings, f.Numbers, f.Others)
+}
+
+// CountFieldTypes tallies the schema field types of all the datasets
+func CountFieldTypes(datasets []Dataset) FieldTypeCounts {
+	var counts FieldTypeCounts
+	for _, ds := range datasets {
+		for _, field := range ds.SchemaMetadata.Value.Fields {
+			switch field.Type.Type.Name() {
+			case "string":
+				counts.Strings++
+			case "number":
+				counts.Numbers++
+			default:
+				counts.Others++
+			}
+		}
+	}
+	return counts
+}
+
+// Name returns the name of the field type: string, number, boolean, date,
+// time, bytes, enum, array, map, record or other
+func (t FieldType) Name() string {
+	switch {
+	case t.StringType != nil:
+		return "string"
+	case t.NumberType != nil:
+		return "number"
+	case t.BooleanType != nil:
+		return "boolean"
+	case t.DateType != nil:
+		return "date"
+	case t.TimeType != nil:
+		return "time"
+	case t.BytesType != nil:
+		return "bytes"
+	case t.EnumType != nil:
+		return "enum"
+	case t.ArrayType != nil:
+		return "array"
+	case t.MapType != nil:
+		return "map"
+	case t.RecordType != nil:
+		return "record"
+	default:
+		return "other"
+	}
+}
+
+// NewFieldType returns the field type named name, as returned by Name.
+// Unknown names are strings.
+func NewFieldType(name string) FieldType {
+	var t FieldType
+	switch name {
+	case "number":
+		t.NumberType = &struct{}{}
+	case "boolean":
+		t.BooleanType = &struct{}{}
+	case "date":
+		t.DateType = &struct{}{}
+	case "time":
+		t.TimeType = &struct{}{}
+	case "bytes":
+		t.BytesType = &struct{}{}
+	case "enum":
+		t.EnumType = &struct{}{}
+	case "array":
+		t.ArrayType = &struct{}{}
+	case "map":
+		t.MapType = &struct{}{}
+	case "record":
+		t.RecordType = &struct{}{}
+	default:
+		t.StringType = &struct{}{}
+	}
+	return t
+}
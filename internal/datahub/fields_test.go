@@ -0,0 +1,63 @@
+package datahub
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFieldTypeName(t *testing.T) {
+	for _, name := range []string{"string", "number", "boolean", "date", "time", "bytes", "enum", "array", "map", "record"} {
+		ft := NewFieldType(name)
+		if got := ft.Name(); got != name {
+			t.Errorf("NewFieldType(%q).Name() = %q", name, got)
+		}
+	}
+	if got := (FieldType{}).Name(); got != "other" {
+		t.Errorf("empty FieldType.Name() = %q, want other", got)
+	}
+}
+
+func TestCountFieldTypes(t *testing.T) {
+	// Two datasets, with a field of every type
+	data := `[
+  {"schemaMetadata": {"value": {"fields": [
+    {"fieldPath": "a", "type": {"type": {"com.linkedin.schema.StringType": {}}}},
+    {"fieldPath": "b", "type": {"type": {"com.linkedin.schema.StringType": {}}}},
+    {"fieldPath": "c", "type": {"type": {"com.linkedin.schema.NumberType": {}}}},
+    {"fieldPath": "d", "type": {"type": {"com.linkedin.schema.BooleanType": {}}}},
+    {"fieldPath": "e", "type": {"type": {"com.linkedin.schema.DateType": {}}}},
+    {"fieldPath": "f", "type": {"type": {"com.linkedin.schema.TimeType": {}}}}
+  ]}}},
+  {"schemaMetadata": {"value": {"fields": [
+    {"fieldPath": "g", "type": {"type": {"com.linkedin.schema.NumberType": {}}}},
+    {"fieldPath": "h", "type": {"type": {"com.linkedin.schema.BytesType": {}}}},
+    {"fieldPath": "i", "type": {"type": {"com.linkedin.schema.EnumType": {}}}},
+    {"fieldPath": "j", "type": {"type": {"com.linkedin.schema.ArrayType": {}}}},
+    {"fieldPath": "k", "type": {"type": {"com.linkedin.schema.MapType": {}}}},
+    {"fieldPath": "l", "type": {"type": {"com.linkedin.schema.RecordType": {}}}},
+    {"fieldPath": "m", "type": {"type": {"com.linkedin.schema.UnknownType": {}}}}
+  ]}}}
+]`
+	var datasets []Dataset
+	if err := json.Unmarshal([]byte(data), &datasets); err != nil {
+		t.Fatal(err)
+	}
+
+	counts := CountFieldTypes(datasets)
+	if counts != (FieldTypeCounts{Strings: 2, Numbers: 2, Others: 9}) {
+		t.Errorf("counts = %+v", counts)
+	}
+	if got := counts.String(); got != "13 fields: 2 string, 2 number, 9 other" {
+		t.Errorf("summary = %q", got)
+	}
+
+	if got := CountFieldTypes(datasets[:1]).String(); got != "6 fields: 2 string, 1 number, 3 other" {
+		t.Errorf("first dataset summary = %q", got)
+	}
+	if got := (FieldTypeCounts{Numbers: 1}).String(); got != "1 field: 0 string, 1 number, 0 other" {
+		t.Errorf("single field summary = %q", got)
+	}
+	if got := CountFieldTypes(nil).String(); got != "0 fields: 0 string, 0 number, 0 other" {
+		t.Errorf("empty summary = %q", got)
+	}
+}
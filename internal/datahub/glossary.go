@@ -0,0 +1,143 @@
+package datahub
+
+import (
+	"fmt"
+	neturl "net/url"
+	"slices"
+	"strings"
+)
+
+// glossaryTermAspects are the aspects fetched when listing glossary terms
+var glossaryTermAspects = []string{"glossaryTermInfo", "status"}
+
+func (c *Client) paginateGlossaryTerms(count int, scrollId string, opts *ListOptions) ([]*GlossaryTerm, string, error) {
+	query := neturl.Values{
+		"systemMetadata":    {"false"},
+		"aspects":           glossaryTermAspects,
+		"includeSoftDelete": {fmt.Sprint(opts.IncludeSoftDeleted)},
+		"skipCache":         {"false"},
+		"count":             {fmt.Sprint(count)},
+		"query":             {"*"},
+	}
+	if scrollId == "" {
+		query.Set("sort", "urn")
+		query.Set("sortOrder", "ASCENDING")
+	} else {
+		query.Set("scrollId", scrollId)
+	}
+	url := fmt.Sprintf("%s/openapi/v3/entity/glossaryTerm?%s", c.URL, query.Encode())
+
+	result, err := fetchEntityPage[*GlossaryTerm](c, url)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return result.Entities, result.ScrollId, nil
+}
+
+// GetGlossaryTerms retrieves the glossary terms from DataHub using scrollId
+// pagination, calling page with every page of terms. The platform option
+// doesn't apply to terms and is ignored.
+func (c *Client) GetGlossaryTerms(page func(terms []*GlossaryTerm) error, opts *ListOptions) error {
+	scrollID := opts.ScrollID
+	delivered := 0
+	for {
+		count := opts.PerPage
+		if max := opts.MaxResults; max > 0 && max-delivered < count {
+			// Don't fetch more than needed
+			count = max - delivered
+		}
+
+		terms, nextScrollID, err := c.paginateGlossaryTerms(count, scrollID, opts)
+		if err != nil {
+			return err
+		}
+		if len(terms) == 0 {
+			return nil
+		}
+
+		delivered += len(terms)
+		if err := page(terms); err != nil {
+			return err
+		}
+
+		// If there's no scrollId in the response, we're at the end
+		if nextScrollID == "" || opts.MaxResults > 0 && delivered >= opts.MaxResults {
+			return nil
+		}
+		scrollID = nextScrollID
+	}
+}
+
+// GlossaryTermExists returns true if the glossary term exists, with its
+// glossaryTermInfo aspect
+func (c *Client) GlossaryTermExists(urn string) (bool, error) {
+	var info GlossaryTermInfo
+	found, err := c.getAspect("glossaryTerm", urn, "glossaryTermInfo", &info)
+	if err != nil {
+		return false, fmt.Errorf("error fetching glossary term %s: %w", urn, err)
+	}
+	return found, nil
+}
+
+// ReferencedTerms returns the glossary term URNs associated with the
+// datasets, their schema fields and their editable schema fields, in order
+// of first appearance
+func ReferencedTerms(datasets []Dataset) []string {
+	seen := map[string]bool{}
+	var urns []string
+	add := func(terms []TermAssociation) {
+		for _, t := range terms {
+			if t.URN != "" && !seen[t.URN] {
+				seen[t.URN] = true
+				urns = append(urns, t.URN)
+			}
+		}
+	}
+
+	for _, ds := range datasets {
+		add(ds.GlossaryTerms.Value.Terms)
+		for _, f := range ds.SchemaMetadata.Value.Fields {
+			if f.GlossaryTerms != nil {
+				add(f.GlossaryTerms.Terms)
+			}
+		}
+		for _, f := range ds.EditableSchemaMetadata.Value.EditableSchemaFieldInfo {
+			if f.GlossaryTerms != nil {
+				add(f.GlossaryTerms.Terms)
+			}
+		}
+	}
+	return urns
+}
+
+// NewPlaceholderTerm returns a glossary term for urn, named after the URN,
+// with the given definition
+func NewPlaceholderTerm(urn, definition string) GlossaryTerm {
+	return GlossaryTerm{
+		URN: urn,
+		Info: GlossaryTermInfo{Value: GlossaryTermValue{
+			Name:       strings.TrimPrefix(urn, "urn:li:glossaryTerm:"),
+			Definition: definition,
+			Source:     "INTERNAL",
+		}},
+	}
+}
+
+// ValidateTermSource returns an error if source is not one of TermSources
+func ValidateTermSource(source string) error {
+	if !slices.Contains(TermSources, source) {
+		return invalidf("invalid glossary term source %q: must be one of %s", source, strings.Join(TermSources, ", "))
+	}
+	return nil
+}
+
+// SetCreator records actor as the technical owner of the term, with an
+// ownership audit stamp by actor, like the DataHub UI does for the user
+// creating a term
+func (t *GlossaryTerm) SetCreator(actor string) {
+	t.Ownership = &OwnershipContainer{Value: Ownership{
+		Owners:       []Owner{{Owner: actor, Type: "TECHNICAL_OWNER"}},
+		LastModified: NewAuditStamp(actor),
+	}}
+}
@@ -0,0 +1,207 @@
+package datahub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// termPages serves the glossary terms in two pages, recording the queries
+func termPages(t *testing.T) (*httptest.Server, *[]url.Values) {
+	var mu sync.Mutex
+	var queries []url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		queries = append(queries, r.URL.Query())
+		mu.Unlock()
+
+		if r.URL.Path != "/openapi/v3/entity/glossaryTerm" {
+			t.Errorf("unexpected request path %s", r.URL.Path)
+		}
+		switch r.URL.Query().Get("scrollId") {
+		case "":
+			fmt.Fprint(w, `{"scrollId": "page2", "entities": [
+  {"urn": "urn:li:glossaryTerm:email", "glossaryTermInfo": {"value": {"name": "Email", "definition": "An email address", "termSource": "INTERNAL"}}},
+  {"urn": "urn:li:glossaryTerm:pii", "glossaryTermInfo": {"value": {"name": "PII", "definition": "Personal data", "termSource": "INTERNAL"}}}
+]}`)
+		case "page2":
+			fmt.Fprint(w, `{"entities": [
+  {"urn": "urn:li:glossaryTerm:ssn", "glossaryTermInfo": {"value": {"name": "SSN", "definition": "", "termSource": "EXTERNAL"}}, "status": {"value": {"removed": true}}}
+]}`)
+		default:
+			t.Errorf("unexpected scroll ID %q", r.URL.Query().Get("scrollId"))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &queries
+}
+
+func TestGetGlossaryTerms(t *testing.T) {
+	srv, queries := termPages(t)
+	c := NewClient(srv.URL, "")
+
+	var pages [][]string
+	var deleted []string
+	err := c.GetGlossaryTerms(func(terms []*GlossaryTerm) error {
+		var names []string
+		for _, term := range terms {
+			names = append(names, term.Info.Value.Name)
+			if term.SoftDeleted() {
+				deleted = append(deleted, term.URN)
+			}
+		}
+		pages = append(pages, names)
+		return nil
+	}, &ListOptions{PerPage: 2, IncludeSoftDeleted: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pages) != 2 || !slices.Equal(pages[0], []string{"Email", "PII"}) || !slices.Equal(pages[1], []string{"SSN"}) {
+		t.Errorf("pages = %v", pages)
+	}
+	if !slices.Equal(deleted, []string{"urn:li:glossaryTerm:ssn"}) {
+		t.Errorf("soft deleted terms = %v", deleted)
+	}
+
+	if len(*queries) != 2 {
+		t.Fatalf("sent %d requests, want 2", len(*queries))
+	}
+	first, second := (*queries)[0], (*queries)[1]
+	if !slices.Equal(first["aspects"], []string{"glossaryTermInfo", "status"}) {
+		t.Errorf("requested aspects %v", first["aspects"])
+	}
+	if first.Get("count") != "2" || first.Get("includeSoftDelete") != "true" || first.Get("sort") != "urn" {
+		t.Errorf("first page query = %v", first)
+	}
+	if second.Get("scrollId") != "page2" || second.Get("sort") != "" {
+		t.Errorf("second page query = %v", second)
+	}
+}
+
+func TestGetGlossaryTermsMaxResults(t *testing.T) {
+	srv, queries := termPages(t)
+	c := NewClient(srv.URL, "")
+
+	var urns []string
+	err := c.GetGlossaryTerms(func(terms []*GlossaryTerm) error {
+		for _, term := range terms {
+			urns = append(urns, term.URN)
+		}
+		return nil
+	}, &ListOptions{PerPage: 100, MaxResults: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urns) != 2 || len(*queries) != 1 || (*queries)[0].Get("count") != "2" {
+		t.Errorf("listed %v in %d requests", urns, len(*queries))
+	}
+}
+
+func TestReferencedTerms(t *testing.T) {
+	data := []byte(`[
+  {
+    "urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)",
+    "glossaryTerms": {"value": {"terms": [{"urn": "urn:li:glossaryTerm:customer"}]}},
+    "schemaMetadata": {"value": {"fields": [
+      {"fieldPath": "id"},
+      {"fieldPath": "email", "glossaryTerms": {"terms": [{"urn": "urn:li:glossaryTerm:email"}, {"urn": "urn:li:glossaryTerm:pii"}]}}
+    ]}},
+    "editableSchemaMetadata": {"value": {"editableSchemaFieldInfo": [
+      {"fieldPath": "ssn", "glossaryTerms": {"terms": [{"urn": "urn:li:glossaryTerm:ssn"}, {"urn": ""}]}}
+    ]}}
+  },
+  {
+    "urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,orders,PROD)",
+    "schemaMetadata": {"value": {"fields": [{"fieldPath": "email", "glossaryTerms": {"terms": [{"urn": "urn:li:glossaryTerm:pii"}]}}]}}
+  }
+]`)
+	datasets, err := DecodeEntities[Dataset](data, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"urn:li:glossaryTerm:customer", "urn:li:glossaryTerm:email", "urn:li:glossaryTerm:pii", "urn:li:glossaryTerm:ssn"}
+	if got := ReferencedTerms(datasets); !slices.Equal(got, want) {
+		t.Errorf("ReferencedTerms = %v, want %v", got, want)
+	}
+	if got := ReferencedTerms(datasets[1:]); !slices.Equal(got, []string{"urn:li:glossaryTerm:pii"}) {
+		t.Errorf("ReferencedTerms = %v", got)
+	}
+	if got := ReferencedTerms(nil); len(got) != 0 {
+		t.Errorf("ReferencedTerms(nil) = %v", got)
+	}
+}
+
+func TestNewPlaceholderTerm(t *testing.T) {
+	term := NewPlaceholderTerm("urn:li:glossaryTerm:pii", "pending")
+	want := GlossaryTermValue{Name: "pii", Definition: "pending", Source: "INTERNAL"}
+	if term.URN != "urn:li:glossaryTerm:pii" || term.Info.Value != want {
+		t.Errorf("term = %+v", term)
+	}
+}
+
+func TestGlossaryTermExists(t *testing.T) {
+	srv := newAspectServer(t)
+	c := NewClient(srv.URL, "")
+	srv.entities["urn:li:glossaryTerm:pii"] = map[string]json.RawMessage{
+		"glossaryTermInfo": json.RawMessage(`{"value": {"name": "PII", "definition": "Personal data", "termSource": "INTERNAL"}}`),
+	}
+	// A term without info, e.g. only referenced by a dataset
+	srv.entities["urn:li:glossaryTerm:bare"] = map[string]json.RawMessage{"urn": json.RawMessage(`"urn:li:glossaryTerm:bare"`)}
+
+	for urn, want := range map[string]bool{
+		"urn:li:glossaryTerm:pii":     true,
+		"urn:li:glossaryTerm:bare":    false,
+		"urn:li:glossaryTerm:missing": false,
+	} {
+		if exists, err := c.GlossaryTermExists(urn); err != nil || exists != want {
+			t.Errorf("GlossaryTermExists(%s) = %v, %v, want %v", urn, exists, err, want)
+		}
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	if _, err := NewClient(failing.URL, "").GlossaryTermExists("urn:li:glossaryTerm:pii"); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestValidateTermSource(t *testing.T) {
+	for _, source := range []string{"INTERNAL", "EXTERNAL"} {
+		if err := ValidateTermSource(source); err != nil {
+			t.Errorf("ValidateTermSource(%q) = %v", source, err)
+		}
+	}
+	for _, source := range []string{"", "internal", "MANUAL"} {
+		if err := ValidateTermSource(source); !errors.Is(err, ErrValidation) {
+			t.Errorf("ValidateTermSource(%q) = %v, want a validation error", source, err)
+		}
+	}
+}
+
+func TestSetCreator(t *testing.T) {
+	start := time.Now().UnixMilli()
+	term := NewPlaceholderTerm("urn:li:glossaryTerm:pii", "pending")
+	term.SetCreator("urn:li:corpuser:alice")
+
+	if term.Ownership == nil {
+		t.Fatal("the term has no ownership")
+	}
+	owners := term.Ownership.Value.Owners
+	if len(owners) != 1 || owners[0] != (Owner{Owner: "urn:li:corpuser:alice", Type: "TECHNICAL_OWNER"}) {
+		t.Errorf("owners = %+v", owners)
+	}
+	if stamp := term.Ownership.Value.LastModified; stamp.Actor != "urn:li:corpuser:alice" || stamp.Time < start {
+		t.Errorf("audit stamp = %+v", stamp)
+	}
+}
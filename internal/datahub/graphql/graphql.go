@@ -0,0 +1,111 @@
+// Package graphql implements a DataHub client using the GraphQL API, for
+// deployments that only expose it or need its mutations.
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+)
+
+// Client sends queries and mutations to the DataHub GraphQL API
+type Client struct {
+	URL        string
+	Token      string
+	HttpClient *http.Client
+	// Context cancels the requests when set
+	Context context.Context
+}
+
+// NewClient creates a GraphQL client for the DataHub GMS at url
+func NewClient(url string, token string) *Client {
+	if url == "" {
+		url = "http://localhost:8080"
+	}
+
+	return &Client{
+		URL:        strings.TrimSuffix(url, "/"),
+		Token:      token,
+		HttpClient: http.DefaultClient,
+	}
+}
+
+// Error contains the errors returned by the GraphQL API
+type Error struct {
+	Messages []string
+}
+
+func (e *Error) Error() string {
+	return "graphql: " + strings.Join(e.Messages, "; ")
+}
+
+type request struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables,omitempty"`
+}
+
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// Do sends query with variables and decodes the data of the response into out,
+// if not nil
+func (c *Client) Do(query string, variables map[string]any, out any) error {
+	payload, err := json.Marshal(request{Query: query, Variables: variables})
+	if err != nil {
+		return fmt.Errorf("error encoding request: %w", err)
+	}
+
+	ctx := c.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", c.URL+"/api/graphql", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return datahub.NewDataHubError(resp)
+	}
+
+	var result response
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+
+	if len(result.Errors) > 0 {
+		gqlErr := &Error{}
+		for _, e := range result.Errors {
+			gqlErr.Messages = append(gqlErr.Messages, e.Message)
+		}
+		return gqlErr
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(result.Data, out); err != nil {
+			return fmt.Errorf("error decoding response data: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,221 @@
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+)
+
+// graphQLServer stubs the GraphQL endpoint, answering the requests with the
+// response bodies returned by reply. It returns the requests received.
+func graphQLServer(t *testing.T, reply func(req request) string) (*Client, *[]request) {
+	var mu sync.Mutex
+	var requests []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/graphql" || r.Method != http.MethodPost {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("Authorization = %q", got)
+		}
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("unexpected request body: %v", err)
+			return
+		}
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+		io.WriteString(w, reply(req))
+	}))
+	t.Cleanup(srv.Close)
+	return NewClient(srv.URL+"/", "token"), &requests
+}
+
+// jsonString returns v encoded as JSON
+func jsonString(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCreateGlossaryTermInput(t *testing.T) {
+	var term datahub.GlossaryTerm
+	term.URN = "urn:li:glossaryTerm:Email"
+	term.Info.Value.Name = "Email"
+	term.Info.Value.Definition = "An email address"
+	if got := jsonString(t, CreateGlossaryTermInput(term)); got != `{"description":"An email address","id":"Email","name":"Email"}` {
+		t.Errorf("input = %s", got)
+	}
+
+	// Without URN nor definition, DataHub generates the ID
+	term = datahub.GlossaryTerm{}
+	term.Info.Value.Name = "Email"
+	if got := jsonString(t, CreateGlossaryTermInput(term)); got != `{"name":"Email"}` {
+		t.Errorf("input without URN = %s", got)
+	}
+}
+
+func TestCreateGlossaryTerm(t *testing.T) {
+	c, requests := graphQLServer(t, func(request) string {
+		return `{"data": {"createGlossaryTerm": "urn:li:glossaryTerm:Email"}}`
+	})
+
+	var term datahub.GlossaryTerm
+	term.URN = "urn:li:glossaryTerm:Email"
+	term.Info.Value.Name = "Email"
+	urn, err := c.CreateGlossaryTerm(term)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if urn != "urn:li:glossaryTerm:Email" {
+		t.Errorf("urn = %q", urn)
+	}
+	if len(*requests) != 1 {
+		t.Fatalf("sent %d requests, want 1", len(*requests))
+	}
+	req := (*requests)[0]
+	if req.Query != createGlossaryTermMutation || jsonString(t, req.Variables) != `{"input":{"id":"Email","name":"Email"}}` {
+		t.Errorf("request = %+v", req)
+	}
+
+	if _, err := c.CreateGlossaryTerm(datahub.GlossaryTerm{}); err == nil || len(*requests) != 1 {
+		t.Errorf("a term without name was sent: %v", err)
+	}
+}
+
+func TestUpdateGlossaryTermDefinition(t *testing.T) {
+	c, requests := graphQLServer(t, func(req request) string {
+		if req.Query == glossaryTermQuery {
+			if req.Variables["urn"] == "urn:li:glossaryTerm:Missing" {
+				return `{"data": {"glossaryTerm": null}}`
+			}
+			return `{"data": {"glossaryTerm": {"properties": {"description": "Old"}}}}`
+		}
+		return `{"data": {"updateDescription": true}}`
+	})
+
+	if err := c.UpdateGlossaryTermDefinition("urn:li:glossaryTerm:Email", "New", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.UpdateGlossaryTermDefinition("urn:li:glossaryTerm:Email", "Only", false); err != nil {
+		t.Fatal(err)
+	}
+	if len(*requests) != 3 {
+		t.Fatalf("sent %d requests, want 3", len(*requests))
+	}
+	want := []string{
+		`{"urn":"urn:li:glossaryTerm:Email"}`,
+		`{"input":{"description":"Old\n\nNew","resourceUrn":"urn:li:glossaryTerm:Email"}}`,
+		`{"input":{"description":"Only","resourceUrn":"urn:li:glossaryTerm:Email"}}`,
+	}
+	for i, req := range *requests {
+		if got := jsonString(t, req.Variables); got != want[i] {
+			t.Errorf("request %d variables = %s, want %s", i, got, want[i])
+		}
+	}
+	if (*requests)[1].Query != updateDescriptionMutation {
+		t.Errorf("query = %s", (*requests)[1].Query)
+	}
+
+	err := c.UpdateGlossaryTermDefinition("urn:li:glossaryTerm:Missing", "New", true)
+	if !errors.Is(err, datahub.ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+}
+
+const taggedDataset = `{
+  "urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)",
+  "globalTags": {"value": {"tags": [{"tag": "urn:li:tag:pii"}, {"tag": "urn:li:tag:gdpr"}]}},
+  "glossaryTerms": {"value": {"terms": [{"urn": "urn:li:glossaryTerm:Customer"}]}},
+  "schemaMetadata": {"value": {"fields": [
+    {"fieldPath": "id"},
+    {"fieldPath": "email", "glossaryTerms": {"terms": [{"urn": "urn:li:glossaryTerm:Email"}]}}
+  ]}},
+  "editableSchemaMetadata": {"value": {"editableSchemaFieldInfo": [
+    {"fieldPath": "email", "glossaryTerms": {"terms": [{"urn": "urn:li:glossaryTerm:PII"}]}},
+    {"fieldPath": "phone", "glossaryTerms": {"terms": [{"urn": "urn:li:glossaryTerm:Phone"}]}}
+  ]}}
+}`
+
+func TestDatasetInputs(t *testing.T) {
+	var ds datahub.Dataset
+	if err := json.Unmarshal([]byte(taggedDataset), &ds); err != nil {
+		t.Fatal(err)
+	}
+	urn := `"resourceUrn":"urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)"`
+
+	tags, terms := DatasetInputs(ds)
+	if got := jsonString(t, tags); got != `[{`+urn+`,"tagUrns":["urn:li:tag:pii","urn:li:tag:gdpr"]}]` {
+		t.Errorf("tags = %s", got)
+	}
+	want := `[{` + urn + `,"termUrns":["urn:li:glossaryTerm:Customer"]},` +
+		`{` + urn + `,"subResource":"email","subResourceType":"DATASET_FIELD","termUrns":["urn:li:glossaryTerm:Email","urn:li:glossaryTerm:PII"]},` +
+		`{` + urn + `,"subResource":"phone","subResourceType":"DATASET_FIELD","termUrns":["urn:li:glossaryTerm:Phone"]}]`
+	if got := jsonString(t, terms); got != want {
+		t.Errorf("terms = %s\nwant %s", got, want)
+	}
+
+	tags, terms = DatasetInputs(datahub.Dataset{URN: "urn:li:dataset:x"})
+	if tags != nil || terms != nil {
+		t.Errorf("inputs of a dataset without tags nor terms: %v, %v", tags, terms)
+	}
+}
+
+func TestUpdateDataset(t *testing.T) {
+	c, requests := graphQLServer(t, func(req request) string {
+		if req.Query == addTagsMutation {
+			return `{"data": {"addTags": true}}`
+		}
+		return `{"data": {"addTerms": true}}`
+	})
+
+	var ds datahub.Dataset
+	json.Unmarshal([]byte(taggedDataset), &ds)
+	if err := c.UpdateDataset(ds); err != nil {
+		t.Fatal(err)
+	}
+	var queries []string
+	for _, req := range *requests {
+		queries = append(queries, req.Query)
+	}
+	want := []string{addTagsMutation, addTermsMutation, addTermsMutation, addTermsMutation}
+	if strings.Join(queries, "\n") != strings.Join(want, "\n") {
+		t.Errorf("sent %d requests:\n%s", len(queries), strings.Join(queries, "\n"))
+	}
+
+	err := c.UpdateDataset(datahub.Dataset{URN: "urn:li:glossaryTerm:x"})
+	if !errors.Is(err, datahub.ErrValidation) || len(*requests) != 4 {
+		t.Errorf("invalid URN: err = %v", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	c, _ := graphQLServer(t, func(request) string {
+		return `{"data": null, "errors": [{"message": "Unauthorized to perform this action"}, {"message": "second"}]}`
+	})
+	err := c.Do(addTagsMutation, nil, nil)
+	var gqlErr *Error
+	if !errors.As(err, &gqlErr) || err.Error() != "graphql: Unauthorized to perform this action; second" {
+		t.Errorf("err = %v", err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad token", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+	err = NewClient(srv.URL, "").Do(addTagsMutation, nil, nil)
+	if !errors.Is(err, datahub.ErrUnauthorized) {
+		t.Errorf("err = %v, want ErrUnauthorized", err)
+	}
+}
@@ -0,0 +1,172 @@
+package graphql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+)
+
+const createGlossaryTermMutation = `mutation createGlossaryTerm($input: CreateGlossaryEntityInput!) {
+  createGlossaryTerm(input: $input)
+}`
+
+const updateDescriptionMutation = `mutation updateDescription($input: DescriptionUpdateInput!) {
+  updateDescription(input: $input)
+}`
+
+const glossaryTermQuery = `query glossaryTerm($urn: String!) {
+  glossaryTerm(urn: $urn) {
+    properties {
+      description
+    }
+  }
+}`
+
+const addTagsMutation = `mutation addTags($input: AddTagsInput!) {
+  addTags(input: $input)
+}`
+
+const addTermsMutation = `mutation addTerms($input: AddTermsInput!) {
+  addTerms(input: $input)
+}`
+
+// CreateGlossaryTermInput returns the CreateGlossaryEntityInput for term
+func CreateGlossaryTermInput(term datahub.GlossaryTerm) map[string]any {
+	input := map[string]any{
+		"name": term.Info.Value.Name,
+	}
+	if id := strings.TrimPrefix(term.URN, "urn:li:glossaryTerm:"); id != "" && id != term.URN {
+		input["id"] = id
+	}
+	if term.Info.Value.Definition != "" {
+		input["description"] = term.Info.Value.Definition
+	}
+	return input
+}
+
+// CreateGlossaryTerm creates a glossary term and returns its URN
+func (c *Client) CreateGlossaryTerm(term datahub.GlossaryTerm) (string, error) {
+	if term.Info.Value.Name == "" {
+		return "", fmt.Errorf("glossary term name is required")
+	}
+
+	var data struct {
+		CreateGlossaryTerm string `json:"createGlossaryTerm"`
+	}
+	vars := map[string]any{"input": CreateGlossaryTermInput(term)}
+	if err := c.Do(createGlossaryTermMutation, vars, &data); err != nil {
+		return "", fmt.Errorf("error creating glossary term: %w", err)
+	}
+	return data.CreateGlossaryTerm, nil
+}
+
+// UpdateGlossaryTermDefinition replaces the definition of a glossary term,
+// or appends to it if appendDef is true
+func (c *Client) UpdateGlossaryTermDefinition(urn, definition string, appendDef bool) error {
+	if appendDef {
+		var data struct {
+			GlossaryTerm *struct {
+				Properties *struct {
+					Description string `json:"description"`
+				} `json:"properties"`
+			} `json:"glossaryTerm"`
+		}
+		if err := c.Do(glossaryTermQuery, map[string]any{"urn": urn}, &data); err != nil {
+			return fmt.Errorf("error fetching glossary term: %w", err)
+		}
+		if data.GlossaryTerm == nil {
+			return fmt.Errorf("glossary term %s: %w", urn, datahub.ErrNotFound)
+		}
+		if p := data.GlossaryTerm.Properties; p != nil && p.Description != "" {
+			definition = p.Description + "\n\n" + definition
+		}
+	}
+
+	vars := map[string]any{"input": map[string]any{
+		"resourceUrn": urn,
+		"description": definition,
+	}}
+	if err := c.Do(updateDescriptionMutation, vars, nil); err != nil {
+		return fmt.Errorf("error updating glossary term definition: %w", err)
+	}
+	return nil
+}
+
+// DatasetInputs returns the AddTagsInput and AddTermsInput values needed to
+// apply the tags and glossary terms of ds, including the field level terms
+func DatasetInputs(ds datahub.Dataset) (tags []map[string]any, terms []map[string]any) {
+	var tagURNs []string
+	for _, t := range ds.GlobalTags.Value.Tags {
+		tagURNs = append(tagURNs, t.Tag)
+	}
+	if len(tagURNs) > 0 {
+		tags = append(tags, map[string]any{"resourceUrn": ds.URN, "tagUrns": tagURNs})
+	}
+
+	if urns := termURNs(ds.GlossaryTerms.Value.Terms); len(urns) > 0 {
+		terms = append(terms, map[string]any{"resourceUrn": ds.URN, "termUrns": urns})
+	}
+
+	fieldTerms := map[string][]datahub.TermAssociation{}
+	var fields []string
+	addField := func(path string, t []datahub.TermAssociation) {
+		if len(t) == 0 {
+			return
+		}
+		if _, ok := fieldTerms[path]; !ok {
+			fields = append(fields, path)
+		}
+		fieldTerms[path] = append(fieldTerms[path], t...)
+	}
+	for _, f := range ds.SchemaMetadata.Value.Fields {
+		if f.GlossaryTerms != nil {
+			addField(f.FieldPath, f.GlossaryTerms.Terms)
+		}
+	}
+	for _, f := range ds.EditableSchemaMetadata.Value.EditableSchemaFieldInfo {
+		if f.GlossaryTerms != nil {
+			addField(f.FieldPath, f.GlossaryTerms.Terms)
+		}
+	}
+	for _, path := range fields {
+		terms = append(terms, map[string]any{
+			"resourceUrn":     ds.URN,
+			"termUrns":        termURNs(fieldTerms[path]),
+			"subResourceType": "DATASET_FIELD",
+			"subResource":     path,
+		})
+	}
+
+	return tags, terms
+}
+
+// UpdateDataset adds the tags and glossary terms of ds to an existing dataset.
+// The DataHub GraphQL API can't create datasets or change their schema, use
+// the datahub client for that.
+func (c *Client) UpdateDataset(ds datahub.Dataset) error {
+	if !strings.HasPrefix(ds.URN, "urn:li:dataset:") {
+		return &datahub.ValidationError{Msg: fmt.Sprintf("invalid dataset URN: %s", ds.URN)}
+	}
+
+	tags, terms := DatasetInputs(ds)
+	for _, input := range tags {
+		if err := c.Do(addTagsMutation, map[string]any{"input": input}, nil); err != nil {
+			return fmt.Errorf("error adding tags to %s: %w", ds.URN, err)
+		}
+	}
+	for _, input := range terms {
+		if err := c.Do(addTermsMutation, map[string]any{"input": input}, nil); err != nil {
+			return fmt.Errorf("error adding glossary terms to %s: %w", ds.URN, err)
+		}
+	}
+	return nil
+}
+
+func termURNs(terms []datahub.TermAssociation) []string {
+	urns := make([]string, 0, len(terms))
+	for _, t := range terms {
+		urns = append(urns, t.URN)
+	}
+	return urns
+}
@@ -0,0 +1,39 @@
+package datahub
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+)
+
+// DefaultGzipMinSize is the size in bytes from which request bodies are
+// compressed with WithGzip, smaller ones aren't worth it
+const DefaultGzipMinSize = 1024
+
+// WithGzip compresses the bodies of the requests posting entities with gzip
+// when they are at least minSize bytes long. The DataHub server (or the
+// proxy in front of it) must accept gzip encoded requests. A value of 0 or
+// less disables the compression.
+func WithGzip(minSize int) ClientOption {
+	return func(c *Client) {
+		c.GzipMinSize = minSize
+	}
+}
+
+// requestBody returns the body of a request posting payload and its
+// Content-Encoding, empty if it's not compressed
+func (c *Client) requestBody(payload string) ([]byte, string, error) {
+	if c.GzipMinSize <= 0 || len(payload) < c.GzipMinSize {
+		return []byte(payload), "", nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(payload)); err != nil {
+		return nil, "", fmt.Errorf("error compressing request body: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, "", fmt.Errorf("error compressing request body: %w", err)
+	}
+	return buf.Bytes(), "gzip", nil
+}
@@ -0,0 +1,135 @@
+package datahub
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// gzipRequest is a request received by newGzipServer
+type gzipRequest struct {
+	encoding string
+	// body is the decompressed body
+	body string
+}
+
+// newGzipServer returns a server recording the posted bodies, decompressing
+// the gzip encoded ones. The first response is a 429 when rateLimit is set.
+func newGzipServer(t *testing.T, rateLimit bool) (*httptest.Server, *[]gzipRequest) {
+	var mu sync.Mutex
+	var requests []gzipRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		req := gzipRequest{encoding: r.Header.Get("Content-Encoding")}
+		var body io.Reader = r.Body
+		if req.encoding == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Errorf("the body isn't gzip encoded: %v", err)
+				http.Error(w, "bad body", http.StatusBadRequest)
+				return
+			}
+			body = zr
+		}
+		data, err := io.ReadAll(body)
+		if err != nil {
+			t.Errorf("error reading the body: %v", err)
+		}
+		req.body = string(data)
+		requests = append(requests, req)
+
+		if rateLimit && len(requests) == 1 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+// largeEntity returns an entity at least size bytes long
+func largeEntity(urn string, size int) string {
+	data, _ := json.Marshal(map[string]string{"urn": urn, "description": strings.Repeat("x", size)})
+	return string(data)
+}
+
+func TestGzip(t *testing.T) {
+	srv, requests := newGzipServer(t, false)
+	c := NewClient(srv.URL, "", WithGzip(DefaultGzipMinSize))
+
+	large := largeEntity("urn:1", DefaultGzipMinSize)
+	small := `{"urn":"urn:2"}`
+	count, err := c.PostEntity("dataset", "["+large+","+small+"]", nil)
+	if err != nil || count != 2 {
+		t.Fatalf("count = %d, err = %v", count, err)
+	}
+	if len(*requests) != 2 {
+		t.Fatalf("%d requests, want 2", len(*requests))
+	}
+	// Small bodies are not worth compressing
+	want := []gzipRequest{{encoding: "gzip", body: "[" + large + "]"}, {body: "[" + small + "]"}}
+	for i, req := range *requests {
+		if req != want[i] {
+			t.Errorf("request %d = %+v, want %+v", i, req, want[i])
+		}
+	}
+
+	// Disabled
+	*requests = nil
+	for _, c := range []*Client{NewClient(srv.URL, ""), NewClient(srv.URL, "", WithGzip(0))} {
+		if err := c.postSingleEntity("dataset", large); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, req := range *requests {
+		if req.encoding != "" || req.body != "["+large+"]" {
+			t.Errorf("request %d encoding = %q, body of %d bytes", i, req.encoding, len(req.body))
+		}
+	}
+}
+
+func TestGzipRetry(t *testing.T) {
+	srv, requests := newGzipServer(t, true)
+	c := NewClient(srv.URL, "", WithGzip(DefaultGzipMinSize))
+
+	large := largeEntity("urn:1", DefaultGzipMinSize)
+	if err := c.postSingleEntity("dataset", large); err != nil {
+		t.Fatal(err)
+	}
+	// The retry sends the whole compressed body again
+	if len(*requests) != 2 {
+		t.Fatalf("%d requests, want 2", len(*requests))
+	}
+	for i, req := range *requests {
+		if req.encoding != "gzip" || req.body != "["+large+"]" {
+			t.Errorf("request %d encoding = %q, body = %.40q", i, req.encoding, req.body)
+		}
+	}
+}
+
+func TestRequestBody(t *testing.T) {
+	c := NewClient("http://localhost", "", WithGzip(10))
+	if body, encoding, err := c.requestBody("short"); err != nil || encoding != "" || string(body) != "short" {
+		t.Errorf("requestBody(short) = %q, %q, %v", body, encoding, err)
+	}
+
+	payload := strings.Repeat("a", 100)
+	body, encoding, err := c.requestBody(payload)
+	if err != nil || encoding != "gzip" || len(body) >= len(payload) {
+		t.Fatalf("requestBody = %d bytes, %q, %v", len(body), encoding, err)
+	}
+	zr, err := gzip.NewReader(strings.NewReader(string(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := io.ReadAll(zr); err != nil || string(data) != payload {
+		t.Errorf("decompressed %q, %v", data, err)
+	}
+}
@@ -0,0 +1,99 @@
+package datahub
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// PlatformInstanceURN builds a data platform instance URN
+func PlatformInstanceURN(platform, instance string) string {
+	return fmt.Sprintf("urn:li:dataPlatformInstance:(%s,%s)", platform, instance)
+}
+
+// ParsePlatformInstance validates a platform instance given as a plain ID
+// (e.g. "prod-account") or as a full instance URN, and returns the instance
+// ID and platform URN (empty if not given).
+func ParsePlatformInstance(instance string) (string, string, error) {
+	if inner, ok := strings.CutPrefix(instance, "urn:li:dataPlatformInstance:("); ok {
+		inner, ok = strings.CutSuffix(inner, ")")
+		platform, id, found := strings.Cut(inner, ",")
+		if !ok || !found || !strings.HasPrefix(platform, "urn:li:dataPlatform:") || !validInstanceID(id) {
+			return "", "", invalidf("invalid platform instance URN %q: must be urn:li:dataPlatformInstance:(urn:li:dataPlatform:<platform>,<instance>)", instance)
+		}
+		return id, platform, nil
+	}
+
+	if !validInstanceID(instance) {
+		return "", "", invalidf("invalid platform instance %q: must not be empty or contain commas, parentheses or spaces", instance)
+	}
+	return instance, "", nil
+}
+
+func validInstanceID(id string) bool {
+	return id != "" && !strings.ContainsAny(id, ",() \t\n")
+}
+
+// SetDatasetsPlatformInstance attaches the dataPlatformInstance aspect to
+// every dataset in a JSON array of datasets and prefixes the dataset names
+// (and URNs) with the instance, as DataHub does for multi-instance platforms.
+// Unknown fields are preserved.
+func SetDatasetsPlatformInstance(payload, instance string) (string, error) {
+	id, instancePlatform, err := ParsePlatformInstance(instance)
+	if err != nil {
+		return "", err
+	}
+
+	var datasets []map[string]interface{}
+	if err := json.Unmarshal([]byte(payload), &datasets); err != nil {
+		return "", fmt.Errorf("error parsing dataset array: %w", err)
+	}
+
+	for i, ds := range datasets {
+		urn, _ := ds["urn"].(string)
+		platform, name, origin, _ := parseDatasetURN(urn)
+
+		key, _ := ds["datasetKey"].(map[string]interface{})
+		value, _ := key["value"].(map[string]interface{})
+		if p, ok := value["platform"].(string); ok && p != "" {
+			platform = p
+		}
+		if n, ok := value["name"].(string); ok && n != "" {
+			name = n
+		}
+		if o, ok := value["origin"].(string); ok && o != "" {
+			origin = o
+		}
+
+		if platform == "" || name == "" {
+			return "", invalidf("dataset %d has no platform or name to attach the platform instance to", i+1)
+		}
+		if instancePlatform != "" && instancePlatform != platform {
+			return "", invalidf("platform instance %s doesn't belong to the %s platform of dataset %d", instance, platform, i+1)
+		}
+
+		if !strings.HasPrefix(name, id+".") {
+			name = id + "." + name
+		}
+		if value != nil {
+			value["name"] = name
+		}
+		if origin == "" {
+			origin = "PROD"
+		}
+		ds["urn"] = DatasetURN(platform, name, origin)
+		ds["dataPlatformInstance"] = DataPlatformInstanceContainer{
+			Value: DataPlatformInstance{
+				Platform: platform,
+				Instance: PlatformInstanceURN(platform, id),
+			},
+		}
+	}
+
+	data, err := json.Marshal(datasets)
+	if err != nil {
+		return "", fmt.Errorf("error encoding datasets: %w", err)
+	}
+
+	return string(data), nil
+}
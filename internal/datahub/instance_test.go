@@ -0,0 +1,106 @@
+package datahub
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPlatformInstanceURN(t *testing.T) {
+	got := PlatformInstanceURN("urn:li:dataPlatform:snowflake", "eu_account")
+	if got != "urn:li:dataPlatformInstance:(urn:li:dataPlatform:snowflake,eu_account)" {
+		t.Errorf("PlatformInstanceURN = %q", got)
+	}
+}
+
+func TestParsePlatformInstance(t *testing.T) {
+	tests := []struct {
+		instance, id, platform string
+	}{
+		{"eu_account", "eu_account", ""},
+		{"urn:li:dataPlatformInstance:(urn:li:dataPlatform:snowflake,eu_account)", "eu_account", "urn:li:dataPlatform:snowflake"},
+	}
+	for _, tt := range tests {
+		id, platform, err := ParsePlatformInstance(tt.instance)
+		if err != nil || id != tt.id || platform != tt.platform {
+			t.Errorf("ParsePlatformInstance(%q) = %q, %q, %v", tt.instance, id, platform, err)
+		}
+	}
+
+	for _, instance := range []string{
+		"",
+		"eu account",
+		"eu,account",
+		"urn:li:dataPlatformInstance:(urn:li:dataPlatform:snowflake,eu_account",
+		"urn:li:dataPlatformInstance:(snowflake,eu_account)",
+		"urn:li:dataPlatformInstance:(urn:li:dataPlatform:snowflake)",
+		"urn:li:dataPlatformInstance:(urn:li:dataPlatform:snowflake,)",
+	} {
+		if _, _, err := ParsePlatformInstance(instance); !errors.Is(err, ErrValidation) {
+			t.Errorf("ParsePlatformInstance(%q) error = %v, want a validation error", instance, err)
+		}
+	}
+}
+
+func TestSetDatasetsPlatformInstance(t *testing.T) {
+	payload := `[
+  {"urn": "urn:li:dataset:(urn:li:dataPlatform:snowflake,db.users,DEV)", "datasetKey": {"value": {"platform": "urn:li:dataPlatform:snowflake", "name": "db.users", "origin": "DEV"}}, "unknown": 1},
+  {"urn": "urn:li:dataset:(urn:li:dataPlatform:snowflake,eu_account.db.orders,PROD)"}
+]`
+	out, err := SetDatasetsPlatformInstance(payload, "eu_account")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var datasets []struct {
+		Dataset
+		Unknown *int `json:"unknown"`
+	}
+	if err := json.Unmarshal([]byte(out), &datasets); err != nil {
+		t.Fatal(err)
+	}
+	if len(datasets) != 2 {
+		t.Fatalf("got %d datasets", len(datasets))
+	}
+
+	wantURNs := []string{
+		"urn:li:dataset:(urn:li:dataPlatform:snowflake,eu_account.db.users,DEV)",
+		// Already prefixed names are kept
+		"urn:li:dataset:(urn:li:dataPlatform:snowflake,eu_account.db.orders,PROD)",
+	}
+	for i, ds := range datasets {
+		if ds.URN != wantURNs[i] {
+			t.Errorf("dataset %d URN = %q, want %q", i, ds.URN, wantURNs[i])
+		}
+		want := DataPlatformInstance{
+			Platform: "urn:li:dataPlatform:snowflake",
+			Instance: "urn:li:dataPlatformInstance:(urn:li:dataPlatform:snowflake,eu_account)",
+		}
+		if ds.DataPlatformInstance == nil || ds.DataPlatformInstance.Value != want {
+			t.Errorf("dataset %d dataPlatformInstance = %+v", i, ds.DataPlatformInstance)
+		}
+	}
+	if datasets[0].Key.Value.Name != "eu_account.db.users" {
+		t.Errorf("datasetKey name = %q", datasets[0].Key.Value.Name)
+	}
+	if datasets[0].Unknown == nil {
+		t.Error("unknown fields were dropped")
+	}
+}
+
+func TestSetDatasetsPlatformInstanceErrors(t *testing.T) {
+	tests := []struct {
+		payload, instance, want string
+	}{
+		{`[{"urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)"}]`, "urn:li:dataPlatformInstance:(urn:li:dataPlatform:snowflake,eu)", "doesn't belong to the urn:li:dataPlatform:mysql platform"},
+		{`[{"urn": "not a dataset URN"}]`, "eu", "has no platform or name"},
+		{`[{"urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)"}]`, "bad instance", "invalid platform instance"},
+	}
+	for _, tt := range tests {
+		_, err := SetDatasetsPlatformInstance(tt.payload, tt.instance)
+		if !errors.Is(err, ErrValidation) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("SetDatasetsPlatformInstance(%s, %q) error = %v, want %q", tt.payload, tt.instance, err, tt.want)
+		}
+	}
+}
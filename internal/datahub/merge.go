@@ -0,0 +1,111 @@
+package datahub
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// FieldConflict is a field present in both schemas with different types
+type FieldConflict struct {
+	FieldPath string
+	// Existing and New are the field types, including the native type
+	Existing string
+	New      string
+}
+
+func (f FieldConflict) String() string {
+	return fmt.Sprintf("%s is %s, not %s", f.FieldPath, f.Existing, f.New)
+}
+
+// MergeFields appends the fields not already in existing, matching them by
+// field path. Existing fields are kept as they are, and the ones redefined
+// with a different type are returned as conflicts.
+func MergeFields(existing, fields []SchemaField) ([]SchemaField, []FieldConflict) {
+	merged := append([]SchemaField{}, existing...)
+	index := make(map[string]int, len(existing))
+	for i, f := range existing {
+		index[f.FieldPath] = i
+	}
+
+	var conflicts []FieldConflict
+	for _, f := range fields {
+		if i, ok := index[f.FieldPath]; ok {
+			if current := merged[i]; !sameFieldType(current, f) {
+				conflicts = append(conflicts, FieldConflict{
+					FieldPath: f.FieldPath,
+					Existing:  describeFieldType(current),
+					New:       describeFieldType(f),
+				})
+			}
+			continue
+		}
+		index[f.FieldPath] = len(merged)
+		merged = append(merged, f)
+	}
+
+	return merged, conflicts
+}
+
+func sameFieldType(a, b SchemaField) bool {
+	if a.Type.Type.Name() != b.Type.Type.Name() {
+		return false
+	}
+	return a.NativeDataType == "" || b.NativeDataType == "" || strings.EqualFold(a.NativeDataType, b.NativeDataType)
+}
+
+func describeFieldType(f SchemaField) string {
+	if f.NativeDataType == "" {
+		return f.Type.Type.Name()
+	}
+	return fmt.Sprintf("%s (%s)", f.Type.Type.Name(), f.NativeDataType)
+}
+
+// MergeSchemaFields adds fields to the schema of an existing dataset and
+// bumps the schema version. Fields already in the schema are left alone, and
+// the ones redefined with a different type are returned as conflicts.
+// The rest of the schemaMetadata aspect is preserved.
+func (c *Client) MergeSchemaFields(datasetURN string, fields []SchemaField) (int, []FieldConflict, error) {
+	var raw json.RawMessage
+	found, err := c.getAspect("dataset", datasetURN, "schemaMetadata", &raw)
+	if err != nil {
+		return 0, nil, fmt.Errorf("error fetching the dataset schema: %w", err)
+	}
+	if !found {
+		return 0, nil, fmt.Errorf("dataset %s: %w", datasetURN, ErrNotFound)
+	}
+
+	// Typed to merge the fields, untyped to keep what dsg doesn't know about
+	var current SchemaMetadataContainer
+	var aspect map[string]interface{}
+	if err := json.Unmarshal(raw, &current); err != nil {
+		return 0, nil, fmt.Errorf("error unmarshaling schemaMetadata aspect: %w", err)
+	}
+	if err := json.Unmarshal(raw, &aspect); err != nil {
+		return 0, nil, fmt.Errorf("error unmarshaling schemaMetadata aspect: %w", err)
+	}
+	value, ok := aspect["value"].(map[string]interface{})
+	if !ok {
+		return 0, nil, fmt.Errorf("unexpected schemaMetadata aspect for %s", datasetURN)
+	}
+
+	existing := current.Value.Fields
+	merged, conflicts := MergeFields(existing, fields)
+	added := merged[len(existing):]
+	if len(added) == 0 {
+		return 0, conflicts, nil
+	}
+
+	currentFields, _ := value["fields"].([]interface{})
+	for _, f := range added {
+		currentFields = append(currentFields, f)
+	}
+	value["fields"] = currentFields
+	value["version"] = current.Value.Version + 1
+
+	if err := c.postAspect("dataset", datasetURN, "schemaMetadata", map[string]interface{}{"value": value}); err != nil {
+		return 0, conflicts, err
+	}
+
+	return len(added), conflicts, nil
+}
@@ -0,0 +1,144 @@
+package datahub
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// field returns a field of the type named typ
+func field(path, typ, native string) SchemaField {
+	return SchemaField{FieldPath: path, Type: FieldTypeContainer{Type: NewFieldType(typ)}, NativeDataType: native}
+}
+
+func fieldPaths(fields []SchemaField) []string {
+	var paths []string
+	for _, f := range fields {
+		paths = append(paths, f.FieldPath)
+	}
+	return paths
+}
+
+func TestMergeFields(t *testing.T) {
+	existing := []SchemaField{
+		field("id", "number", "int"),
+		field("name", "string", "VARCHAR(255)"),
+		field("created", "date", ""),
+	}
+	existing[1].Description = "the user name"
+
+	merged, conflicts := MergeFields(existing, []SchemaField{
+		field("email", "string", "varchar(255)"),
+		// Same type, the native type compared case insensitively
+		field("name", "string", "varchar(255)"),
+		// No native type to compare
+		field("created", "date", "datetime"),
+		field("id", "string", "uuid"),
+		field("email", "number", ""),
+		field("age", "number", "int"),
+	})
+
+	if got, want := fieldPaths(merged), []string{"id", "name", "created", "email", "age"}; !slices.Equal(got, want) {
+		t.Errorf("merged fields = %v, want %v", got, want)
+	}
+	if merged[0].Type.Type.Name() != "number" || merged[1].Description != "the user name" || merged[2].NativeDataType != "" {
+		t.Errorf("the existing fields were modified: %+v", merged[:3])
+	}
+	if len(existing) != 3 {
+		t.Errorf("existing has %d fields, want 3", len(existing))
+	}
+
+	want := []FieldConflict{
+		{FieldPath: "id", Existing: "number (int)", New: "string (uuid)"},
+		{FieldPath: "email", Existing: "string (varchar(255))", New: "number"},
+	}
+	if !slices.Equal(conflicts, want) {
+		t.Errorf("conflicts = %+v, want %+v", conflicts, want)
+	}
+	if got := conflicts[0].String(); got != "id is number (int), not string (uuid)" {
+		t.Errorf("conflict = %q", got)
+	}
+
+	if _, conflicts := MergeFields(existing, []SchemaField{field("name", "string", "text")}); len(conflicts) != 1 {
+		t.Errorf("a different native type is not a conflict: %+v", conflicts)
+	}
+}
+
+func TestMergeSchemaFields(t *testing.T) {
+	srv := newAspectServer(t)
+	c := NewClient(srv.URL, "")
+	urn := "urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)"
+	srv.entities[urn] = map[string]json.RawMessage{
+		"urn": json.RawMessage(`"` + urn + `"`),
+		"schemaMetadata": json.RawMessage(`{"value": {
+  "schemaName": "users",
+  "platform": "urn:li:dataPlatform:mysql",
+  "version": 2,
+  "hash": "",
+  "platformSchema": {"com.linkedin.schema.MySqlDDL": {"tableSchema": "CREATE TABLE users"}},
+  "fields": [{"fieldPath": "id", "type": {"type": {"com.linkedin.schema.NumberType": {}}}, "nativeDataType": "int", "isPartOfKey": true}],
+  "primaryKeys": ["id"]
+}}`),
+	}
+
+	added, conflicts, err := c.MergeSchemaFields(urn, []SchemaField{
+		field("id", "string", "uuid"),
+		field("email", "string", "varchar(255)"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 1 || len(conflicts) != 1 || conflicts[0].FieldPath != "id" {
+		t.Errorf("added = %d, conflicts = %+v", added, conflicts)
+	}
+
+	var posted struct {
+		Value struct {
+			Version        int                          `json:"version"`
+			PlatformSchema json.RawMessage              `json:"platformSchema"`
+			PrimaryKeys    []string                     `json:"primaryKeys"`
+			Fields         []map[string]json.RawMessage `json:"fields"`
+		} `json:"value"`
+	}
+	srv.aspect(t, 0, "schemaMetadata", &posted)
+	if posted.Value.Version != 3 {
+		t.Errorf("posted version %d, want 3", posted.Value.Version)
+	}
+	if len(posted.Value.Fields) != 2 || string(posted.Value.Fields[1]["fieldPath"]) != `"email"` {
+		t.Fatalf("posted fields = %v", posted.Value.Fields)
+	}
+	if string(posted.Value.Fields[0]["isPartOfKey"]) != "true" || !slices.Equal(posted.Value.PrimaryKeys, []string{"id"}) {
+		t.Errorf("the unknown keys were lost: %+v", posted.Value)
+	}
+	if !jsonEqual(t, posted.Value.PlatformSchema, `{"com.linkedin.schema.MySqlDDL": {"tableSchema": "CREATE TABLE users"}}`) {
+		t.Errorf("posted platformSchema %s", posted.Value.PlatformSchema)
+	}
+
+	// Nothing to add
+	srv.posted = nil
+	added, _, err = c.MergeSchemaFields(urn, []SchemaField{field("id", "number", "INT")})
+	if err != nil || added != 0 || len(srv.posted) != 0 {
+		t.Errorf("added = %d, err = %v, posted %d entities", added, err, len(srv.posted))
+	}
+
+	_, _, err = c.MergeSchemaFields("urn:li:dataset:(urn:li:dataPlatform:mysql,missing,PROD)", []SchemaField{field("id", "number", "")})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+}
+
+// jsonEqual reports whether the JSON documents a and b are equal
+func jsonEqual(t *testing.T, a json.RawMessage, b string) bool {
+	t.Helper()
+	var va, vb interface{}
+	if err := json.Unmarshal(a, &va); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(b), &vb); err != nil {
+		t.Fatal(err)
+	}
+	ja, _ := json.Marshal(va)
+	jb, _ := json.Marshal(vb)
+	return string(ja) == string(jb)
+}
@@ -1,14 +1,29 @@
 package datahub
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type GlossaryTerm struct {
-	URN  string           `json:"urn"`
-	Info GlossaryTermInfo `json:"glossaryTermInfo"`
+	URN       string              `json:"urn"`
+	Info      GlossaryTermInfo    `json:"glossaryTermInfo"`
+	Status    *StatusContainer    `json:"status,omitempty"`
+	Ownership *OwnershipContainer `json:"ownership,omitempty"`
+}
+
+// SoftDeleted returns true if the glossary term has been soft-deleted
+func (t *GlossaryTerm) SoftDeleted() bool {
+	return t.Status != nil && t.Status.Value.Removed
 }
 
 type GlossaryTermInfo struct {
 	Value GlossaryTermValue `json:"value"`
 }
 
+// Glossary term sources supported by DataHub
+var TermSources = []string{"INTERNAL", "EXTERNAL"}
+
 type GlossaryTermValue struct {
 	Name       string `json:"name"`
 	Definition string `json:"definition"`
@@ -23,15 +38,56 @@ type Dataset struct {
 	GlossaryTerms          GlossaryTermsContainer          `json:"glossaryTerms"`
 	URN                    string                          `json:"urn"`
 	EditableSchemaMetadata EditableSchemaMetadataContainer `json:"editableSchemaMetadata,omitempty"`
+	Status                 *StatusContainer                `json:"status,omitempty"`
+	DataPlatformInstance   *DataPlatformInstanceContainer  `json:"dataPlatformInstance,omitempty"`
+	DatasetProperties      *DatasetPropertiesContainer     `json:"datasetProperties,omitempty"`
 }
 
+// SoftDeleted returns true if the dataset has been soft-deleted
+func (d *Dataset) SoftDeleted() bool {
+	return d.Status != nil && d.Status.Value.Removed
+}
+
+// DatasetPropertiesContainer wraps DatasetProperties with a value field
+type DatasetPropertiesContainer struct {
+	Value DatasetProperties `json:"value"`
+}
+
+// DatasetProperties contains the descriptive properties of a dataset
+type DatasetProperties struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// StatusContainer wraps Status with a value field
+type StatusContainer struct {
+	Value Status `json:"value"`
+}
+
+// Status contains the soft-deletion status of an entity
+type Status struct {
+	Removed bool `json:"removed"`
+}
+
+// EditableSchemaMetadata holds the schema metadata edited in DataHub, like
+// field descriptions, tags and terms. Fields unknown to dsg are kept in
+// Extra, so posting a dataset doesn't drop them.
 type EditableSchemaMetadata struct {
-	EditableSchemaFieldInfo []EditableSchemaFieldInfo `json:"editableSchemaFieldInfo"`
+	Created                 *AuditStamp                `json:"created,omitempty"`
+	LastModified            *AuditStamp                `json:"lastModified,omitempty"`
+	Deleted                 *AuditStamp                `json:"deleted,omitempty"`
+	EditableSchemaFieldInfo []EditableSchemaFieldInfo  `json:"editableSchemaFieldInfo"`
+	Extra                   map[string]json.RawMessage `json:"-"`
 }
 
+// EditableSchemaFieldInfo is the edited metadata of a schema field. Fields
+// unknown to dsg are kept in Extra.
 type EditableSchemaFieldInfo struct {
-	FieldPath     string                      `json:"fieldPath"`
-	GlossaryTerms FieldGlossaryTermsContainer `json:"glossaryTerms"`
+	FieldPath     string                       `json:"fieldPath"`
+	Description   string                       `json:"description,omitempty"`
+	GlobalTags    *GlobalTags                  `json:"globalTags,omitempty"`
+	GlossaryTerms *FieldGlossaryTermsContainer `json:"glossaryTerms,omitempty"`
+	Extra         map[string]json.RawMessage   `json:"-"`
 }
 
 type EditableSchemaMetadataContainer struct {
@@ -53,9 +109,14 @@ type SchemaMetadata struct {
 	Fields         []SchemaField  `json:"fields"`
 }
 
-// PlatformSchema contains platform-specific schema information
+// PlatformSchema contains platform-specific schema information. Only
+// MySqlDDL is modeled, the other platform schemas are kept in Raw so they
+// survive decoding and encoding datasets.
 type PlatformSchema struct {
-	MySqlDDL MySqlDDL `json:"com.linkedin.schema.MySqlDDL"`
+	MySqlDDL MySqlDDL
+	// Raw holds the platform schemas other than MySqlDDL as they were read,
+	// keyed by type, e.g. com.linkedin.schema.KafkaSchema
+	Raw map[string]json.RawMessage
 }
 
 // MySqlDDL contains MySQL-specific DDL information
@@ -70,6 +131,7 @@ type SchemaField struct {
 	Type           FieldTypeContainer           `json:"type"`
 	NativeDataType string                       `json:"nativeDataType"`
 	Recursive      bool                         `json:"recursive"`
+	Nullable       bool                         `json:"nullable,omitempty"`
 	GlossaryTerms  *FieldGlossaryTermsContainer `json:"glossaryTerms,omitempty"`
 }
 
@@ -85,8 +147,16 @@ type FieldTypeContainer struct {
 
 // FieldType represents the type of a field, which can be one of several types
 type FieldType struct {
-	StringType *struct{} `json:"com.linkedin.schema.StringType,omitempty"`
-	NumberType *struct{} `json:"com.linkedin.schema.NumberType,omitempty"`
+	StringType  *struct{} `json:"com.linkedin.schema.StringType,omitempty"`
+	NumberType  *struct{} `json:"com.linkedin.schema.NumberType,omitempty"`
+	BooleanType *struct{} `json:"com.linkedin.schema.BooleanType,omitempty"`
+	DateType    *struct{} `json:"com.linkedin.schema.DateType,omitempty"`
+	TimeType    *struct{} `json:"com.linkedin.schema.TimeType,omitempty"`
+	BytesType   *struct{} `json:"com.linkedin.schema.BytesType,omitempty"`
+	EnumType    *struct{} `json:"com.linkedin.schema.EnumType,omitempty"`
+	ArrayType   *struct{} `json:"com.linkedin.schema.ArrayType,omitempty"`
+	MapType     *struct{} `json:"com.linkedin.schema.MapType,omitempty"`
+	RecordType  *struct{} `json:"com.linkedin.schema.RecordType,omitempty"`
 }
 
 // DatasetKeyContainer wraps DatasetKey with a value field
@@ -116,6 +186,17 @@ type TagAssociation struct {
 	Tag string `json:"tag"`
 }
 
+// TagPropertiesContainer wraps TagProperties with a value field
+type TagPropertiesContainer struct {
+	Value TagProperties `json:"value"`
+}
+
+// TagProperties contains the properties of a tag entity
+type TagProperties struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
 // GlossaryTermsContainer wraps GlossaryTerms with a value field
 type GlossaryTermsContainer struct {
 	Value GlossaryTerms `json:"value"`
@@ -137,3 +218,102 @@ type AuditStamp struct {
 	Time  int64  `json:"time"`
 	Actor string `json:"actor"`
 }
+
+// DefaultActor is the actor used in audit stamps when none is provided
+const DefaultActor = "urn:li:corpuser:datahub"
+
+// NewAuditStamp returns an audit stamp for the current time.
+// The DefaultActor is used if actor is empty.
+func NewAuditStamp(actor string) AuditStamp {
+	if actor == "" {
+		actor = DefaultActor
+	}
+	return AuditStamp{
+		Time:  time.Now().UnixMilli(),
+		Actor: actor,
+	}
+}
+
+// Ownership types supported by DataHub
+var OwnershipTypes = []string{
+	"TECHNICAL_OWNER",
+	"BUSINESS_OWNER",
+	"DATA_STEWARD",
+	"NONE",
+	"DATAOWNER",
+	"PRODUCER",
+	"DEVELOPER",
+	"CONSUMER",
+	"STAKEHOLDER",
+	"DELEGATE",
+}
+
+// OwnershipContainer wraps Ownership with a value field
+type OwnershipContainer struct {
+	Value Ownership `json:"value"`
+}
+
+// Ownership contains the owners of an entity
+type Ownership struct {
+	Owners       []Owner    `json:"owners"`
+	LastModified AuditStamp `json:"lastModified"`
+}
+
+// Owner represents a user or group owning an entity
+type Owner struct {
+	Owner string `json:"owner"`
+	Type  string `json:"type"`
+}
+
+// DomainsContainer wraps Domains with a value field
+type DomainsContainer struct {
+	Value Domains `json:"value"`
+}
+
+// Domains contains the domains an entity belongs to
+type Domains struct {
+	Domains []string `json:"domains"`
+}
+
+// DomainPropertiesContainer wraps DomainProperties with a value field
+type DomainPropertiesContainer struct {
+	Value DomainProperties `json:"value"`
+}
+
+// DomainProperties contains the properties of a domain entity
+type DomainProperties struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Created     *AuditStamp `json:"created,omitempty"`
+}
+
+// Lineage types supported by DataHub
+var LineageTypes = []string{"TRANSFORMED", "COPY", "VIEW"}
+
+// UpstreamLineageContainer wraps UpstreamLineage with a value field
+type UpstreamLineageContainer struct {
+	Value UpstreamLineage `json:"value"`
+}
+
+// UpstreamLineage contains the datasets an entity derives from
+type UpstreamLineage struct {
+	Upstreams []Upstream `json:"upstreams"`
+}
+
+// Upstream represents a dataset an entity derives from
+type Upstream struct {
+	AuditStamp AuditStamp `json:"auditStamp"`
+	Dataset    string     `json:"dataset"`
+	Type       string     `json:"type"`
+}
+
+// DataPlatformInstanceContainer wraps DataPlatformInstance with a value field
+type DataPlatformInstanceContainer struct {
+	Value DataPlatformInstance `json:"value"`
+}
+
+// DataPlatformInstance identifies the platform instance an entity belongs to
+type DataPlatformInstance struct {
+	Platform string `json:"platform"`
+	Instance string `json:"instance,omitempty"`
+}
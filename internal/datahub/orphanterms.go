@@ -0,0 +1,91 @@
+package datahub
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// RemoveTermAssociations removes the associations with the glossary terms
+// in terms from a dataset, its schema fields and its editable schema fields,
+// leaving the rest of the aspects unchanged. Only the aspects referencing
+// one of the terms are posted. It returns the number of associations
+// removed.
+func (c *Client) RemoveTermAssociations(datasetURN string, terms map[string]bool) (int, error) {
+	// Where the term associations are in each aspect value
+	aspects := []struct {
+		name string
+		// list is the key of the list of items with glossaryTerms in the
+		// aspect value, empty if the value has them itself
+		list string
+	}{
+		{name: "glossaryTerms"},
+		{name: "schemaMetadata", list: "fields"},
+		{name: "editableSchemaMetadata", list: "editableSchemaFieldInfo"},
+	}
+
+	removed := 0
+	for _, a := range aspects {
+		var raw json.RawMessage
+		found, err := c.getAspect("dataset", datasetURN, a.name, &raw)
+		if err != nil {
+			return removed, fmt.Errorf("error fetching the %s aspect: %w", a.name, err)
+		}
+		if !found {
+			continue
+		}
+
+		// Untyped to keep what dsg doesn't know about
+		var aspect map[string]interface{}
+		if err := json.Unmarshal(raw, &aspect); err != nil {
+			return removed, fmt.Errorf("error unmarshaling %s aspect: %w", a.name, err)
+		}
+		value, ok := aspect["value"].(map[string]interface{})
+		if !ok {
+			return removed, fmt.Errorf("unexpected %s aspect for %s", a.name, datasetURN)
+		}
+
+		n := 0
+		if a.list == "" {
+			n = removeTerms(value, terms)
+		} else {
+			items, _ := value[a.list].([]interface{})
+			for _, item := range items {
+				if item, ok := item.(map[string]interface{}); ok {
+					if container, ok := item["glossaryTerms"].(map[string]interface{}); ok {
+						n += removeTerms(container, terms)
+					}
+				}
+			}
+		}
+		if n == 0 {
+			continue
+		}
+
+		if err := c.postAspect("dataset", datasetURN, a.name, map[string]interface{}{"value": value}); err != nil {
+			return removed, err
+		}
+		removed += n
+	}
+
+	return removed, nil
+}
+
+// removeTerms removes the associations with terms from the terms list of a
+// glossaryTerms value and returns how many were removed
+func removeTerms(value map[string]interface{}, terms map[string]bool) int {
+	current, _ := value["terms"].([]interface{})
+	kept := make([]interface{}, 0, len(current))
+	for _, t := range current {
+		if assoc, ok := t.(map[string]interface{}); ok {
+			if urn, _ := assoc["urn"].(string); terms[urn] {
+				continue
+			}
+		}
+		kept = append(kept, t)
+	}
+	if len(kept) == len(current) {
+		return 0
+	}
+	value["terms"] = kept
+	return len(current) - len(kept)
+}
@@ -0,0 +1,105 @@
+package datahub
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestRemoveTermAssociations(t *testing.T) {
+	srv := newAspectServer(t)
+	c := NewClient(srv.URL, "")
+	urn := "urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)"
+	srv.entities[urn] = map[string]json.RawMessage{
+		"urn": json.RawMessage(`"` + urn + `"`),
+		"glossaryTerms": json.RawMessage(`{"value": {
+  "terms": [{"urn": "urn:li:glossaryTerm:Email"}, {"urn": "urn:li:glossaryTerm:Deleted"}],
+  "auditStamp": {"time": 42, "actor": "urn:li:corpuser:alice"}
+}}`),
+		"schemaMetadata": json.RawMessage(`{"value": {
+  "schemaName": "users",
+  "version": 2,
+  "fields": [
+    {"fieldPath": "id", "nativeDataType": "int"},
+    {"fieldPath": "email", "nativeDataType": "varchar", "glossaryTerms": {"terms": [{"urn": "urn:li:glossaryTerm:Deleted"}, {"urn": "urn:li:glossaryTerm:Gone"}]}}
+  ]
+}}`),
+		// Nothing to remove from the editable fields
+		"editableSchemaMetadata": json.RawMessage(`{"value": {
+  "editableSchemaFieldInfo": [{"fieldPath": "email", "glossaryTerms": {"terms": [{"urn": "urn:li:glossaryTerm:Email"}]}}]
+}}`),
+	}
+
+	removed, err := c.RemoveTermAssociations(urn, map[string]bool{
+		"urn:li:glossaryTerm:Deleted": true,
+		"urn:li:glossaryTerm:Gone":    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 3 {
+		t.Errorf("removed %d associations, want 3", removed)
+	}
+	if len(srv.posted) != 2 || srv.posted[0]["glossaryTerms"] == nil || srv.posted[1]["schemaMetadata"] == nil {
+		t.Fatalf("posted %v, want the glossaryTerms and schemaMetadata aspects", srv.posted)
+	}
+
+	var terms struct {
+		Value struct {
+			Terms      []TermAssociation `json:"terms"`
+			AuditStamp AuditStamp        `json:"auditStamp"`
+		} `json:"value"`
+	}
+	srv.aspect(t, 0, "glossaryTerms", &terms)
+	if len(terms.Value.Terms) != 1 || terms.Value.Terms[0].URN != "urn:li:glossaryTerm:Email" {
+		t.Errorf("posted terms %+v", terms.Value.Terms)
+	}
+	if terms.Value.AuditStamp.Actor != "urn:li:corpuser:alice" {
+		t.Errorf("the audit stamp was lost: %+v", terms.Value)
+	}
+
+	var schema struct {
+		Value struct {
+			Version int                          `json:"version"`
+			Fields  []map[string]json.RawMessage `json:"fields"`
+		} `json:"value"`
+	}
+	srv.aspect(t, 1, "schemaMetadata", &schema)
+	if schema.Value.Version != 2 || len(schema.Value.Fields) != 2 {
+		t.Fatalf("posted schema %+v", schema.Value)
+	}
+	if !jsonEqual(t, schema.Value.Fields[1]["glossaryTerms"], `{"terms": []}`) {
+		t.Errorf("posted field terms %s", schema.Value.Fields[1]["glossaryTerms"])
+	}
+
+	// Nothing references the terms
+	srv.posted = nil
+	removed, err = c.RemoveTermAssociations(urn, map[string]bool{"urn:li:glossaryTerm:Other": true})
+	if err != nil || removed != 0 || len(srv.posted) != 0 {
+		t.Errorf("removed = %d, err = %v, posted %d aspects", removed, err, len(srv.posted))
+	}
+	if removed, err := c.RemoveTermAssociations("urn:li:dataset:(urn:li:dataPlatform:mysql,missing,PROD)", map[string]bool{"urn:li:glossaryTerm:Gone": true}); err != nil || removed != 0 {
+		t.Errorf("missing dataset: removed = %d, err = %v", removed, err)
+	}
+}
+
+func TestRemoveTerms(t *testing.T) {
+	var value map[string]interface{}
+	json.Unmarshal([]byte(`{"terms": [{"urn": "a"}, {"urn": "b"}, "bogus", {"urn": "a"}]}`), &value)
+
+	if n := removeTerms(value, map[string]bool{"a": true}); n != 2 {
+		t.Errorf("removed %d, want 2", n)
+	}
+	var urns []string
+	for _, term := range value["terms"].([]interface{}) {
+		if assoc, ok := term.(map[string]interface{}); ok {
+			urns = append(urns, assoc["urn"].(string))
+		}
+	}
+	if !slices.Equal(urns, []string{"b"}) || len(value["terms"].([]interface{})) != 2 {
+		t.Errorf("terms = %v", value["terms"])
+	}
+	if n := removeTerms(map[string]interface{}{}, map[string]bool{"a": true}); n != 0 {
+		t.Errorf("removed %d from an empty value", n)
+	}
+}
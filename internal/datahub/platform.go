@@ -0,0 +1,79 @@
+package datahub
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// PlatformURNPrefix is the prefix of the data platform URNs
+const PlatformURNPrefix = "urn:li:dataPlatform:"
+
+// NormalizePlatform returns the platform URN of a bare platform name (e.g.
+// "mysql") using platforms, a map of lowercase names to URNs. URNs are
+// returned untouched. Names missing from platforms are turned into a URN
+// as is, and known is false.
+func NormalizePlatform(platform string, platforms map[string]string) (urn string, known bool) {
+	if platform == "" || strings.HasPrefix(platform, PlatformURNPrefix) {
+		return platform, true
+	}
+	name := strings.ToLower(strings.TrimSpace(platform))
+	if urn, ok := platforms[name]; ok {
+		return urn, true
+	}
+	return PlatformURNPrefix + name, false
+}
+
+// NormalizeDatasetPlatforms replaces the bare platform names of every
+// dataset in a JSON array of datasets with platform URNs, in the dataset
+// key, the schema metadata and the dataset URN. It returns the new payload
+// and the platform names not found in platforms.
+// Unknown fields are preserved, and the payload is returned as is when all
+// the platforms are URNs already.
+func NormalizeDatasetPlatforms(payload string, platforms map[string]string) (string, []string, error) {
+	var datasets []map[string]interface{}
+	if err := json.Unmarshal([]byte(payload), &datasets); err != nil {
+		return "", nil, fmt.Errorf("error parsing dataset array: %w", err)
+	}
+
+	var unknown []string
+	seen := map[string]bool{}
+	changed := false
+	normalize := func(platform string) string {
+		urn, known := NormalizePlatform(platform, platforms)
+		if urn != platform {
+			changed = true
+		}
+		if !known && !seen[platform] {
+			seen[platform] = true
+			unknown = append(unknown, platform)
+		}
+		return urn
+	}
+
+	for _, ds := range datasets {
+		for _, aspect := range []string{"datasetKey", "schemaMetadata"} {
+			container, _ := ds[aspect].(map[string]interface{})
+			value, _ := container["value"].(map[string]interface{})
+			if p, ok := value["platform"].(string); ok {
+				value["platform"] = normalize(p)
+			}
+		}
+
+		urn, _ := ds["urn"].(string)
+		if platform, name, origin, ok := parseDatasetURN(urn); ok {
+			ds["urn"] = DatasetURN(normalize(platform), name, origin)
+		}
+	}
+
+	if !changed {
+		return payload, nil, nil
+	}
+
+	data, err := json.Marshal(datasets)
+	if err != nil {
+		return "", nil, fmt.Errorf("error encoding datasets: %w", err)
+	}
+
+	return string(data), unknown, nil
+}
@@ -0,0 +1,99 @@
+package datahub
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestNormalizePlatform(t *testing.T) {
+	platforms := map[string]string{"postgres": "urn:li:dataPlatform:postgres", "sql server": "urn:li:dataPlatform:mssql"}
+
+	tests := []struct {
+		platform string
+		want     string
+		known    bool
+	}{
+		{platform: "postgres", want: "urn:li:dataPlatform:postgres", known: true},
+		{platform: " Postgres ", want: "urn:li:dataPlatform:postgres", known: true},
+		{platform: "SQL Server", want: "urn:li:dataPlatform:mssql", known: true},
+		{platform: "urn:li:dataPlatform:mysql", want: "urn:li:dataPlatform:mysql", known: true},
+		{platform: "urn:li:dataPlatform:whatever", want: "urn:li:dataPlatform:whatever", known: true},
+		{platform: "", want: "", known: true},
+		{platform: "Teradata", want: "urn:li:dataPlatform:teradata", known: false},
+	}
+	for _, tt := range tests {
+		urn, known := NormalizePlatform(tt.platform, platforms)
+		if urn != tt.want || known != tt.known {
+			t.Errorf("NormalizePlatform(%q) = %q, %v, want %q, %v", tt.platform, urn, known, tt.want, tt.known)
+		}
+	}
+}
+
+func TestNormalizeDatasetPlatforms(t *testing.T) {
+	platforms := map[string]string{"mysql": "urn:li:dataPlatform:mysql"}
+	payload := `[
+  {
+    "urn": "urn:li:dataset:(mysql,users,PROD)",
+    "datasetKey": {"value": {"platform": "MySQL", "name": "users", "origin": "PROD"}},
+    "schemaMetadata": {"value": {"schemaName": "users", "platform": "mysql", "fields": [], "custom": 1}}
+  },
+  {
+    "urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,orders,PROD)",
+    "datasetKey": {"value": {"platform": "urn:li:dataPlatform:mysql", "name": "orders", "origin": "PROD"}},
+    "schemaMetadata": {"value": {"schemaName": "orders", "platform": "urn:li:dataPlatform:mysql"}}
+  },
+  {
+    "urn": "urn:li:dataset:(Teradata,events,PROD)",
+    "datasetKey": {"value": {"platform": "Teradata", "name": "events", "origin": "PROD"}},
+    "schemaMetadata": {"value": {"schemaName": "events", "platform": "Teradata"}}
+  }
+]`
+
+	normalized, unknown, err := NormalizeDatasetPlatforms(payload, platforms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(unknown, []string{"Teradata"}) {
+		t.Errorf("unknown platforms = %v", unknown)
+	}
+
+	var datasets []struct {
+		URN        string `json:"urn"`
+		DatasetKey struct {
+			Value struct {
+				Platform string `json:"platform"`
+			} `json:"value"`
+		} `json:"datasetKey"`
+		SchemaMetadata struct {
+			Value map[string]json.RawMessage `json:"value"`
+		} `json:"schemaMetadata"`
+	}
+	if err := json.Unmarshal([]byte(normalized), &datasets); err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ urn, platform string }{
+		{"urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)", "urn:li:dataPlatform:mysql"},
+		{"urn:li:dataset:(urn:li:dataPlatform:mysql,orders,PROD)", "urn:li:dataPlatform:mysql"},
+		{"urn:li:dataset:(urn:li:dataPlatform:teradata,events,PROD)", "urn:li:dataPlatform:teradata"},
+	}
+	for i, ds := range datasets {
+		if ds.URN != want[i].urn || ds.DatasetKey.Value.Platform != want[i].platform ||
+			string(ds.SchemaMetadata.Value["platform"]) != `"`+want[i].platform+`"` {
+			t.Errorf("dataset %d = %+v, want %+v", i, ds, want[i])
+		}
+	}
+	if string(datasets[0].SchemaMetadata.Value["custom"]) != "1" {
+		t.Errorf("the unknown fields were lost: %s", normalized)
+	}
+
+	// URNs are left untouched
+	urns := `[{"urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,orders,PROD)", "datasetKey": {"value": {"platform": "urn:li:dataPlatform:mysql"}}}]`
+	if got, unknown, err := NormalizeDatasetPlatforms(urns, platforms); err != nil || got != urns || unknown != nil {
+		t.Errorf("NormalizeDatasetPlatforms = %q, %v, %v", got, unknown, err)
+	}
+
+	if _, _, err := NormalizeDatasetPlatforms("not json", platforms); err == nil {
+		t.Error("expected an error for an invalid payload")
+	}
+}
@@ -0,0 +1,42 @@
+package datahub
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// mySqlDDLType is the platformSchema key of MySqlDDL
+const mySqlDDLType = "com.linkedin.schema.MySqlDDL"
+
+// UnmarshalJSON reads the MySqlDDL schema and keeps the others in Raw
+func (p *PlatformSchema) UnmarshalJSON(data []byte) error {
+	var schemas map[string]json.RawMessage
+	if err := json.Unmarshal(data, &schemas); err != nil {
+		return err
+	}
+
+	*p = PlatformSchema{}
+	if raw, ok := schemas[mySqlDDLType]; ok {
+		if err := json.Unmarshal(raw, &p.MySqlDDL); err != nil {
+			return fmt.Errorf("%s: %w", mySqlDDLType, err)
+		}
+		delete(schemas, mySqlDDLType)
+	}
+	if len(schemas) > 0 {
+		p.Raw = schemas
+	}
+	return nil
+}
+
+// MarshalJSON writes the MySqlDDL schema and the raw ones. platformSchema
+// is a union, so an empty MySqlDDL is left out when there are raw schemas.
+func (p PlatformSchema) MarshalJSON() ([]byte, error) {
+	schemas := make(map[string]any, len(p.Raw)+1)
+	for k, v := range p.Raw {
+		schemas[k] = v
+	}
+	if len(p.Raw) == 0 || p.MySqlDDL != (MySqlDDL{}) {
+		schemas[mySqlDDLType] = p.MySqlDDL
+	}
+	return json.Marshal(schemas)
+}
@@ -0,0 +1,65 @@
+package datahub
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPlatformSchemaRoundTrip(t *testing.T) {
+	tests := map[string]string{
+		"kafka":  `{"com.linkedin.schema.KafkaSchema": {"documentSchema": "{\"type\": \"record\"}", "documentSchemaType": "AVRO", "keySchema": null}}`,
+		"mysql":  `{"com.linkedin.schema.MySqlDDL": {"tableSchema": "CREATE TABLE users (id int)"}}`,
+		"both":   `{"com.linkedin.schema.MySqlDDL": {"tableSchema": "CREATE TABLE users (id int)"}, "com.linkedin.schema.OtherSchema": {"rawSchema": "x"}}`,
+		"other":  `{"com.linkedin.schema.OtherSchema": {"rawSchema": "message User { int64 id = 1; }"}}`,
+		"empty":  `{"com.linkedin.schema.MySqlDDL": {"tableSchema": ""}}`,
+		"nested": `{"com.linkedin.schema.EspressoSchema": {"documentSchema": "{}", "tableSchema": "t", "extra": [1, 2.50, {"a": true}]}}`,
+	}
+	for name, platformSchema := range tests {
+		dataset := `[{"urn": "urn:li:dataset:(urn:li:dataPlatform:kafka,users,PROD)", "schemaMetadata": {"value": {"schemaName": "users", "platformSchema": ` + platformSchema + `}}}]`
+
+		var datasets []Dataset
+		if err := json.Unmarshal([]byte(dataset), &datasets); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		data, err := json.Marshal(datasets)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		var decoded []struct {
+			SchemaMetadata struct {
+				Value struct {
+					PlatformSchema json.RawMessage `json:"platformSchema"`
+				} `json:"value"`
+			} `json:"schemaMetadata"`
+		}
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got := decoded[0].SchemaMetadata.Value.PlatformSchema; !jsonEqual(t, got, platformSchema) {
+			t.Errorf("%s: platformSchema = %s, want %s", name, got, platformSchema)
+		}
+	}
+}
+
+func TestPlatformSchemaJSON(t *testing.T) {
+	var p PlatformSchema
+	if err := json.Unmarshal([]byte(`{"com.linkedin.schema.MySqlDDL": {"tableSchema": "t"}, "com.linkedin.schema.KafkaSchema": {"documentSchema": "d"}}`), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.MySqlDDL.TableSchema != "t" || len(p.Raw) != 1 || string(p.Raw["com.linkedin.schema.KafkaSchema"]) != `{"documentSchema": "d"}` {
+		t.Errorf("platform schema = %+v", p)
+	}
+
+	// A zero PlatformSchema is an empty MySqlDDL, as before Raw
+	data, err := json.Marshal(PlatformSchema{})
+	if err != nil || string(data) != `{"com.linkedin.schema.MySqlDDL":{"tableSchema":""}}` {
+		t.Errorf("zero platform schema = %s, %v", data, err)
+	}
+
+	for _, invalid := range []string{`[]`, `{"com.linkedin.schema.MySqlDDL": "t"}`} {
+		if err := json.Unmarshal([]byte(invalid), &p); err == nil {
+			t.Errorf("expected an error decoding %s", invalid)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package datahub
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// DefaultMaxRetries is the number of times a rate limited (429) request is retried
+const DefaultMaxRetries = 3
+
+// DefaultPageRetries is the number of times a listing page failing with a
+// transient error is retried
+const DefaultPageRetries = 3
+
+// maxRetryWait caps the time waited before retrying a rate limited request
+const maxRetryWait = time.Minute
+
+// ClientOption configures a Client
+type ClientOption func(*Client)
+
+// WithRateLimit limits the entities posted to perSecond requests per second.
+// A value of 0 or less disables the limit.
+func WithRateLimit(perSecond int) ClientOption {
+	return func(c *Client) {
+		if perSecond <= 0 {
+			c.Limiter = nil
+			return
+		}
+		c.Limiter = rate.NewLimiter(rate.Limit(perSecond), 1)
+	}
+}
+
+// WithContext cancels the requests of the client when ctx is done
+func WithContext(ctx context.Context) ClientOption {
+	return func(c *Client) {
+		c.Context = ctx
+	}
+}
+
+// WithMaxRetries sets the number of times a rate limited request is retried
+func WithMaxRetries(n int) ClientOption {
+	return func(c *Client) {
+		c.MaxRetries = n
+	}
+}
+
+// WithPageRetries retries the listing pages failing with transient errors up
+// to n times
+func WithPageRetries(n int) ClientOption {
+	return func(c *Client) {
+		c.PageRetries = n
+	}
+}
+
+// wait blocks until the rate limiter allows another request
+func (c *Client) wait() error {
+	if c.Limiter == nil {
+		return nil
+	}
+	return c.Limiter.Wait(c.ctx())
+}
+
+// ctx returns the client Context, or the background context if not set
+func (c *Client) ctx() context.Context {
+	if c.Context == nil {
+		return context.Background()
+	}
+	return c.Context
+}
+
+// retryAfter returns how long to wait before retrying a rate limited
+// response, honoring its Retry-After header. Without one, the wait doubles
+// with each attempt starting at one second.
+func retryAfter(resp *http.Response, attempt int) time.Duration {
+	wait := backoff(attempt)
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			wait = time.Duration(secs) * time.Second
+		} else if t, err := http.ParseTime(v); err == nil {
+			wait = time.Until(t)
+		}
+	}
+	return min(max(wait, 0), maxRetryWait)
+}
+
+// backoff returns the wait before retry number attempt, doubling with each
+// attempt starting at one second
+func backoff(attempt int) time.Duration {
+	// Large shifts overflow
+	if attempt >= 16 {
+		return maxRetryWait
+	}
+	return min(time.Second<<attempt, maxRetryWait)
+}
@@ -0,0 +1,108 @@
+package datahub
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimitSpacing(t *testing.T) {
+	var mu sync.Mutex
+	var times []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		times = append(times, time.Now())
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	const perSecond = 20
+	c := NewClient(srv.URL, "", WithRateLimit(perSecond))
+	start := time.Now()
+	count, err := c.PostEntity("dataset", `[{"urn":"urn:1"},{"urn":"urn:2"},{"urn":"urn:3"},{"urn":"urn:4"},{"urn":"urn:5"}]`, nil)
+	if err != nil || count != 5 {
+		t.Fatalf("count = %d, err = %v", count, err)
+	}
+
+	// The first request is sent right away and every other one waits for
+	// the interval. Arrival times jitter, so only the total is checked.
+	interval := time.Second / perSecond
+	if total := times[len(times)-1].Sub(start); total < 4*interval {
+		t.Errorf("5 requests sent in %v, want at least %v", total, 4*interval)
+	}
+
+	if c := NewClient(srv.URL, "", WithRateLimit(0)); c.Limiter != nil {
+		t.Error("WithRateLimit(0) should disable the limit")
+	}
+}
+
+func TestRetryRateLimited(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		if requests <= 2 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "")
+	if _, err := c.PostEntity("dataset", `[{"urn":"urn:1"}]`, nil); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 3 {
+		t.Errorf("sent %d requests, want 3", requests)
+	}
+
+	// Without retries left, the 429 is returned
+	requests = 0
+	c.MaxRetries = 1
+	_, err := c.PostEntity("dataset", `[{"urn":"urn:1"}]`, nil)
+	var dhErr *DataHubError
+	if !errors.As(err, &dhErr) || dhErr.StatusCode != http.StatusTooManyRequests || requests != 2 {
+		t.Errorf("requests = %d, err = %v", requests, err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	header := func(v string) *http.Response {
+		resp := &http.Response{Header: http.Header{}}
+		if v != "" {
+			resp.Header.Set("Retry-After", v)
+		}
+		return resp
+	}
+
+	tests := []struct {
+		value   string
+		attempt int
+		want    time.Duration
+	}{
+		{"", 0, time.Second},
+		{"", 2, 4 * time.Second},
+		{"", 20, maxRetryWait},
+		{"3", 0, 3 * time.Second},
+		{"3600", 0, maxRetryWait},
+		{"-1", 1, 2 * time.Second},
+		{"soon", 0, time.Second},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, 0},
+	}
+	for _, tt := range tests {
+		if got := retryAfter(header(tt.value), tt.attempt); got != tt.want {
+			t.Errorf("retryAfter(%q, %d) = %v, want %v", tt.value, tt.attempt, got, tt.want)
+		}
+	}
+
+	// HTTP dates have a resolution of one second
+	got := retryAfter(header(time.Now().Add(10*time.Second).UTC().Format(http.TimeFormat)), 0)
+	if got < 8*time.Second || got > 10*time.Second {
+		t.Errorf("retryAfter(date in 10s) = %v", got)
+	}
+}
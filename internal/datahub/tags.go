@@ -0,0 +1,69 @@
+package datahub
+
+import (
+	"strings"
+)
+
+// TagURNPrefix is the prefix of tag URNs
+const TagURNPrefix = "urn:li:tag:"
+
+// TagURN returns the URN of the tag name, or name itself if it's already a
+// tag URN
+func TagURN(name string) string {
+	name = strings.TrimSpace(name)
+	if strings.HasPrefix(name, TagURNPrefix) {
+		return name
+	}
+	return TagURNPrefix + name
+}
+
+// ValidateTagURN returns an error if urn is not a valid tag URN
+func ValidateTagURN(urn string) error {
+	if !strings.HasPrefix(urn, TagURNPrefix) || len(urn) == len(TagURNPrefix) {
+		return invalidf("invalid tag URN %q: must start with %s", urn, TagURNPrefix)
+	}
+	return nil
+}
+
+// CreateTag creates a tag entity named after the last part of its URN
+func (c *Client) CreateTag(tagURN string) error {
+	if err := ValidateTagURN(tagURN); err != nil {
+		return err
+	}
+
+	props := TagProperties{Name: strings.TrimPrefix(tagURN, TagURNPrefix)}
+	return c.postAspect("tag", tagURN, "tagProperties", TagPropertiesContainer{Value: props})
+}
+
+// addDefaultTags adds the tags missing from the globalTags aspect of ds
+func addDefaultTags(ds map[string]interface{}, tags []string) {
+	container, _ := ds["globalTags"].(map[string]interface{})
+	if container == nil {
+		container = map[string]interface{}{}
+		ds["globalTags"] = container
+	}
+	value, _ := container["value"].(map[string]interface{})
+	if value == nil {
+		value = map[string]interface{}{}
+		container["value"] = value
+	}
+
+	current, _ := value["tags"].([]interface{})
+	seen := map[string]bool{}
+	for _, t := range current {
+		if assoc, ok := t.(map[string]interface{}); ok {
+			if urn, ok := assoc["tag"].(string); ok {
+				seen[urn] = true
+			}
+		}
+	}
+
+	for _, t := range tags {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		current = append(current, TagAssociation{Tag: t})
+	}
+	value["tags"] = current
+}
@@ -0,0 +1,113 @@
+package datahub
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestTagURN(t *testing.T) {
+	tests := map[string]string{
+		"pii":                  "urn:li:tag:pii",
+		" generated-by-dsg ":   "urn:li:tag:generated-by-dsg",
+		"urn:li:tag:pii":       "urn:li:tag:pii",
+		"urn:li:tag:Sensitive": "urn:li:tag:Sensitive",
+	}
+	for name, want := range tests {
+		if got := TagURN(name); got != want {
+			t.Errorf("TagURN(%q) = %q, want %q", name, got, want)
+		}
+		if err := ValidateTagURN(TagURN(name)); err != nil {
+			t.Errorf("ValidateTagURN(%q) = %v", TagURN(name), err)
+		}
+	}
+	for _, urn := range []string{"pii", "urn:li:tag:", "urn:li:glossaryTerm:pii"} {
+		if err := ValidateTagURN(urn); !errors.Is(err, ErrValidation) {
+			t.Errorf("ValidateTagURN(%q) = %v, want a validation error", urn, err)
+		}
+	}
+}
+
+func TestApplyDatasetDefaultsTags(t *testing.T) {
+	payload := `[
+  {
+    "urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)",
+    "globalTags": {"value": {"tags": [{"tag": "urn:li:tag:Sensitive", "context": "model"}, {"tag": "urn:li:tag:pii"}]}}
+  },
+  {
+    "urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,orders,PROD)",
+    "globalTags": {"value": {}}
+  },
+  {
+    "urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,items,PROD)"
+  }
+]`
+	out, err := ApplyDatasetDefaults(payload, DatasetDefaults{
+		Tags: []string{"urn:li:tag:pii", "urn:li:tag:generated-by-dsg"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var datasets []Dataset
+	if err := json.Unmarshal([]byte(out), &datasets); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		// The model tags are kept, first, and not repeated
+		{"urn:li:tag:Sensitive", "urn:li:tag:pii", "urn:li:tag:generated-by-dsg"},
+		{"urn:li:tag:pii", "urn:li:tag:generated-by-dsg"},
+		{"urn:li:tag:pii", "urn:li:tag:generated-by-dsg"},
+	}
+	for i, ds := range datasets {
+		var tags []string
+		for _, tag := range ds.GlobalTags.Value.Tags {
+			tags = append(tags, tag.Tag)
+		}
+		if !slices.Equal(tags, want[i]) {
+			t.Errorf("dataset %d tags = %v, want %v", i, tags, want[i])
+		}
+	}
+
+	var raw []struct {
+		GlobalTags struct {
+			Value struct {
+				Tags []map[string]string `json:"tags"`
+			} `json:"value"`
+		} `json:"globalTags"`
+	}
+	json.Unmarshal([]byte(out), &raw)
+	if got := raw[0].GlobalTags.Value.Tags[0]["context"]; got != "model" {
+		t.Errorf("the existing tag association fields were lost: %s", out)
+	}
+}
+
+func TestCreateTag(t *testing.T) {
+	srv := newAspectServer(t)
+	c := NewClient(srv.URL, "")
+	srv.entities["urn:li:tag:pii"] = map[string]json.RawMessage{"urn": json.RawMessage(`"urn:li:tag:pii"`)}
+
+	if exists, err := c.EntityExists("tag", "urn:li:tag:pii"); err != nil || !exists {
+		t.Errorf("EntityExists(pii) = %v, %v", exists, err)
+	}
+	if exists, err := c.EntityExists("tag", "urn:li:tag:generated-by-dsg"); err != nil || exists {
+		t.Errorf("EntityExists(generated-by-dsg) = %v, %v", exists, err)
+	}
+
+	if err := c.CreateTag("urn:li:tag:generated-by-dsg"); err != nil {
+		t.Fatal(err)
+	}
+	var props TagPropertiesContainer
+	srv.aspect(t, 0, "tagProperties", &props)
+	if props.Value.Name != "generated-by-dsg" || string(srv.posted[0]["urn"]) != `"urn:li:tag:generated-by-dsg"` {
+		t.Errorf("posted %v", srv.posted[0])
+	}
+
+	if err := c.CreateTag("pii"); !errors.Is(err, ErrValidation) {
+		t.Errorf("err = %v, want a validation error", err)
+	}
+	if len(srv.posted) != 1 {
+		t.Errorf("posted %d entities, want 1", len(srv.posted))
+	}
+}
@@ -0,0 +1,37 @@
+package datahub
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// NewTLSConfig returns the TLS configuration to connect to DataHub instances
+// using self-signed certificates. caCertFile is a PEM file with CA
+// certificates trusted on top of the system ones, insecure disables the
+// certificate verification. It returns nil if neither is set.
+func NewTLSConfig(insecure bool, caCertFile string) (*tls.Config, error) {
+	if !insecure && caCertFile == "" {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{InsecureSkipVerify: insecure}
+	if caCertFile != "" {
+		pem, err := os.ReadFile(caCertFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA certificate: %w", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, invalidf("no PEM certificates found in %s", caCertFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,80 @@
+package datahub
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeCert writes the certificate of a TLS test server to a PEM file
+func writeCert(t *testing.T, srv *httptest.Server) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	cfg, err := NewTLSConfig(false, "")
+	if err != nil || cfg != nil {
+		t.Errorf("NewTLSConfig without options = %v, %v, want nil", cfg, err)
+	}
+
+	get := func(cfg *tls.Config) error {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}}
+		resp, err := client.Get(srv.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	if err := get(nil); err == nil {
+		t.Fatal("the test server certificate was trusted without a CA")
+	}
+
+	cfg, err = NewTLSConfig(true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.InsecureSkipVerify || cfg.RootCAs != nil {
+		t.Errorf("insecure config = %+v", cfg)
+	}
+	if err := get(cfg); err != nil {
+		t.Errorf("insecure request failed: %v", err)
+	}
+
+	cfg, err = NewTLSConfig(false, writeCert(t, srv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.InsecureSkipVerify || cfg.RootCAs == nil {
+		t.Errorf("CA config = %+v", cfg)
+	}
+	if err := get(cfg); err != nil {
+		t.Errorf("request trusting the CA failed: %v", err)
+	}
+}
+
+func TestNewTLSConfigErrors(t *testing.T) {
+	if _, err := NewTLSConfig(false, filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Error("expected an error for a missing CA certificate")
+	}
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	os.WriteFile(path, []byte("not a certificate"), 0644)
+	if _, err := NewTLSConfig(false, path); !errors.Is(err, ErrValidation) {
+		t.Errorf("err = %v, want ErrValidation", err)
+	}
+}
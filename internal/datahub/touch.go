@@ -0,0 +1,51 @@
+package datahub
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// TouchSchemaMetadata bumps the version of the schemaMetadata aspect value
+// and sets its lastModified audit stamp, leaving the rest unchanged. It
+// returns the new version.
+func TouchSchemaMetadata(value map[string]interface{}, stamp AuditStamp) int {
+	version := 0
+	if v, ok := value["version"].(float64); ok {
+		version = int(v)
+	}
+	version++
+	value["version"] = version
+	value["lastModified"] = stamp
+	return version
+}
+
+// TouchDataset bumps the schema version and the last modified audit stamp
+// of an existing dataset, so DataHub records a change without any content
+// change. It returns the new schema version. The error wraps ErrNotFound if
+// the dataset has no schema.
+func (c *Client) TouchDataset(datasetURN, actor string) (int, error) {
+	var raw json.RawMessage
+	found, err := c.getAspect("dataset", datasetURN, "schemaMetadata", &raw)
+	if err != nil {
+		return 0, fmt.Errorf("error fetching the dataset schema: %w", err)
+	}
+	if !found {
+		return 0, fmt.Errorf("dataset %s: %w", datasetURN, ErrNotFound)
+	}
+
+	// Untyped to keep what dsg doesn't know about
+	var aspect map[string]interface{}
+	if err := json.Unmarshal(raw, &aspect); err != nil {
+		return 0, fmt.Errorf("error unmarshaling schemaMetadata aspect: %w", err)
+	}
+	value, ok := aspect["value"].(map[string]interface{})
+	if !ok {
+		return 0, fmt.Errorf("unexpected schemaMetadata aspect for %s", datasetURN)
+	}
+
+	version := TouchSchemaMetadata(value, NewAuditStamp(actor))
+	if err := c.postAspect("dataset", datasetURN, "schemaMetadata", map[string]interface{}{"value": value}); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
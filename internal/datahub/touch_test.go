@@ -0,0 +1,88 @@
+package datahub
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTouchSchemaMetadata(t *testing.T) {
+	stamp := AuditStamp{Time: 1700000000000, Actor: "urn:li:corpuser:alice"}
+
+	var value map[string]interface{}
+	json.Unmarshal([]byte(`{"schemaName": "users", "version": 2, "lastModified": {"time": 1, "actor": "urn:li:corpuser:bob"}}`), &value)
+	if version := TouchSchemaMetadata(value, stamp); version != 3 {
+		t.Errorf("version = %d, want 3", version)
+	}
+	if value["version"] != 3 || value["lastModified"] != stamp || value["schemaName"] != "users" {
+		t.Errorf("value = %v", value)
+	}
+
+	// Schemas without a version start at 1
+	value = map[string]interface{}{}
+	if version := TouchSchemaMetadata(value, stamp); version != 1 || value["lastModified"] != stamp {
+		t.Errorf("version = %d, value = %v", version, value)
+	}
+}
+
+func TestTouchDataset(t *testing.T) {
+	srv := newAspectServer(t)
+	c := NewClient(srv.URL, "")
+	urn := "urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)"
+	srv.entities[urn] = map[string]json.RawMessage{
+		"urn": json.RawMessage(`"` + urn + `"`),
+		"schemaMetadata": json.RawMessage(`{"value": {
+  "schemaName": "users",
+  "version": 4,
+  "hash": "",
+  "fields": [{"fieldPath": "id", "type": {"type": {"com.linkedin.schema.NumberType": {}}}, "nativeDataType": "int", "isPartOfKey": true}]
+}}`),
+	}
+
+	start := time.Now().UnixMilli()
+	version, err := c.TouchDataset(urn, "urn:li:corpuser:alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 5 {
+		t.Errorf("version = %d, want 5", version)
+	}
+
+	var posted struct {
+		Value struct {
+			SchemaName   string                       `json:"schemaName"`
+			Version      int                          `json:"version"`
+			LastModified AuditStamp                   `json:"lastModified"`
+			Fields       []map[string]json.RawMessage `json:"fields"`
+		} `json:"value"`
+	}
+	srv.aspect(t, 0, "schemaMetadata", &posted)
+	if posted.Value.Version != 5 || posted.Value.SchemaName != "users" {
+		t.Errorf("posted %+v", posted.Value)
+	}
+	if stamp := posted.Value.LastModified; stamp.Actor != "urn:li:corpuser:alice" || stamp.Time < start {
+		t.Errorf("last modified = %+v", stamp)
+	}
+	if len(posted.Value.Fields) != 1 || string(posted.Value.Fields[0]["isPartOfKey"]) != "true" {
+		t.Errorf("the fields were changed: %v", posted.Value.Fields)
+	}
+
+	// The default actor is used without one
+	srv.posted = nil
+	if _, err := c.TouchDataset(urn, ""); err != nil {
+		t.Fatal(err)
+	}
+	srv.aspect(t, 0, "schemaMetadata", &posted)
+	if posted.Value.LastModified.Actor != DefaultActor {
+		t.Errorf("actor = %q, want %q", posted.Value.LastModified.Actor, DefaultActor)
+	}
+
+	srv.posted = nil
+	if _, err := c.TouchDataset("urn:li:dataset:(urn:li:dataPlatform:mysql,missing,PROD)", ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+	if len(srv.posted) != 0 {
+		t.Errorf("posted %d entities for a missing dataset", len(srv.posted))
+	}
+}
@@ -0,0 +1,31 @@
+package datahub
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// UIURL returns the DataHub UI base URL for a GMS URL.
+// GMS URLs ending with /gms (DataHub Cloud) and the default GMS port 8080
+// are translated to the UI counterparts.
+func UIURL(gmsURL string) (string, error) {
+	u, err := url.Parse(gmsURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid DataHub URL: %w", err)
+	}
+
+	u.Path = strings.TrimSuffix(strings.TrimRight(u.Path, "/"), "/gms")
+	if u.Port() == "8080" {
+		u.Host = u.Hostname() + ":9002"
+	}
+	u.RawQuery = ""
+	u.Fragment = ""
+
+	return strings.TrimRight(u.String(), "/"), nil
+}
+
+// DatasetUIURL returns the URL of a dataset page in the DataHub UI
+func DatasetUIURL(uiURL, urn string) string {
+	return strings.TrimRight(uiURL, "/") + "/dataset/" + url.PathEscape(urn)
+}
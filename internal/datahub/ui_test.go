@@ -0,0 +1,36 @@
+package datahub
+
+import "testing"
+
+func TestUIURL(t *testing.T) {
+	tests := map[string]string{
+		"http://localhost:8080":                     "http://localhost:9002",
+		"http://localhost:8080/":                    "http://localhost:9002",
+		"https://acme.acryl.io/gms":                 "https://acme.acryl.io",
+		"https://acme.acryl.io/gms/?token=x#frag":   "https://acme.acryl.io",
+		"https://datahub.example.com":               "https://datahub.example.com",
+		"https://datahub.example.com:8443/api/gms/": "https://datahub.example.com:8443/api",
+	}
+	for gms, want := range tests {
+		got, err := UIURL(gms)
+		if err != nil {
+			t.Errorf("UIURL(%q): %v", gms, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("UIURL(%q) = %q, want %q", gms, got, want)
+		}
+	}
+
+	if _, err := UIURL("://bad"); err == nil {
+		t.Error("expected an error for an invalid URL")
+	}
+}
+
+func TestDatasetUIURL(t *testing.T) {
+	urn := "urn:li:dataset:(urn:li:dataPlatform:mysql,db/users,PROD)"
+	want := "http://localhost:9002/dataset/urn:li:dataset:%28urn:li:dataPlatform:mysql%2Cdb%2Fusers%2CPROD%29"
+	if got := DatasetUIURL("http://localhost:9002/", urn); got != want {
+		t.Errorf("DatasetUIURL = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,131 @@
+package datahub
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ParseDatasetURN returns the platform URN, name and origin of a dataset URN
+// like urn:li:dataset:(urn:li:dataPlatform:mysql,db.table,PROD)
+func ParseDatasetURN(urn string) (platform, name, origin string, err error) {
+	platform, name, origin, ok := parseDatasetURN(urn)
+	if !ok {
+		return "", "", "", invalidf("invalid dataset URN %q: must be urn:li:dataset:(<platform URN>,<name>,<env>)", urn)
+	}
+	if !strings.HasPrefix(platform, PlatformURNPrefix) || len(platform) == len(PlatformURNPrefix) {
+		return "", "", "", invalidf("invalid dataset URN %q: the platform must be a %s URN", urn, PlatformURNPrefix)
+	}
+	if name == "" {
+		return "", "", "", invalidf("invalid dataset URN %q: the name is empty", urn)
+	}
+	if err := ValidateFabric(origin); err != nil {
+		return "", "", "", invalidf("invalid dataset URN %q: %v", urn, err)
+	}
+	return platform, name, origin, nil
+}
+
+// URNOverrides replace the platform and name generated for the datasets
+type URNOverrides struct {
+	// Platform is the platform URN of every dataset, if set
+	Platform string
+	// Name is the name of the dataset, if set. Only valid for one dataset.
+	Name string
+}
+
+// RebuildDatasetURNs sets the URN of every dataset in a JSON array of
+// datasets from its key (platform, name and origin), after applying the
+// overrides to the key and the schema metadata, instead of trusting the
+// generated URN. Missing key fields are taken from the URN.
+// It returns the new payload and a warning for every URN that had to be
+// fixed. Unknown fields are preserved, and the payload is returned as is
+// when nothing changed.
+func RebuildDatasetURNs(payload string, overrides URNOverrides) (string, []string, error) {
+	var datasets []map[string]interface{}
+	if err := json.Unmarshal([]byte(payload), &datasets); err != nil {
+		return "", nil, fmt.Errorf("error parsing dataset array: %w", err)
+	}
+	if overrides.Name != "" && len(datasets) > 1 {
+		return "", nil, invalidf("a dataset name can only be set for a single dataset, got %d datasets", len(datasets))
+	}
+
+	var warnings []string
+	changed := false
+	for i, ds := range datasets {
+		urn, _ := ds["urn"].(string)
+		urnPlatform, urnName, urnOrigin, _ := parseDatasetURN(urn)
+
+		key, _ := ds["datasetKey"].(map[string]interface{})
+		if key == nil {
+			key = map[string]interface{}{}
+			ds["datasetKey"] = key
+		}
+		value, _ := key["value"].(map[string]interface{})
+		if value == nil {
+			value = map[string]interface{}{}
+			key["value"] = value
+		}
+
+		keyField := func(name, fallback string) string {
+			if v, _ := value[name].(string); v != "" {
+				return v
+			}
+			return fallback
+		}
+		platform := keyField("platform", urnPlatform)
+		name := keyField("name", urnName)
+		origin := keyField("origin", urnOrigin)
+		if origin == "" {
+			origin = "PROD"
+		}
+		if platform == "" && overrides.Platform == "" || name == "" && overrides.Name == "" {
+			return "", nil, invalidf("dataset %d has no platform or name to build its URN from", i+1)
+		}
+
+		// Only disagreements in the generated data are worth a warning,
+		// the overrides are expected to change the URN
+		generated := DatasetURN(platform, name, origin)
+		if urn != generated {
+			if _, _, _, err := ParseDatasetURN(urn); err != nil {
+				warnings = append(warnings, fmt.Sprintf("dataset %d: %v, using the dataset key", i+1, err))
+			} else {
+				warnings = append(warnings, fmt.Sprintf("dataset %d: URN %s doesn't match the dataset key, using %s", i+1, urn, generated))
+			}
+		}
+
+		if overrides.Platform != "" {
+			platform = overrides.Platform
+		}
+		if overrides.Name != "" {
+			name = overrides.Name
+		}
+
+		for k, v := range map[string]string{"platform": platform, "name": name, "origin": origin} {
+			if value[k] != v {
+				value[k] = v
+				changed = true
+			}
+		}
+		if schema, ok := ds["schemaMetadata"].(map[string]interface{}); ok {
+			if v, ok := schema["value"].(map[string]interface{}); ok && v["platform"] != platform {
+				v["platform"] = platform
+				changed = true
+			}
+		}
+		if expected := DatasetURN(platform, name, origin); urn != expected {
+			ds["urn"] = expected
+			changed = true
+		}
+	}
+
+	if !changed {
+		return payload, nil, nil
+	}
+
+	data, err := json.Marshal(datasets)
+	if err != nil {
+		return "", nil, fmt.Errorf("error encoding datasets: %w", err)
+	}
+
+	return string(data), warnings, nil
+}
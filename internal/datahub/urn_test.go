@@ -0,0 +1,162 @@
+package datahub
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseDatasetURNValidation(t *testing.T) {
+	tests := []struct {
+		urn                    string
+		platform, name, origin string
+	}{
+		{"urn:li:dataset:(urn:li:dataPlatform:mysql,db.users,PROD)", "urn:li:dataPlatform:mysql", "db.users", "PROD"},
+		{"urn:li:dataset:(urn:li:dataPlatform:hive,logs,DEV)", "urn:li:dataPlatform:hive", "logs", "DEV"},
+		// Names may contain commas
+		{"urn:li:dataset:(urn:li:dataPlatform:s3,bucket/a,b.csv,PROD)", "urn:li:dataPlatform:s3", "bucket/a,b.csv", "PROD"},
+	}
+	for _, tt := range tests {
+		platform, name, origin, err := ParseDatasetURN(tt.urn)
+		if err != nil {
+			t.Errorf("ParseDatasetURN(%q): %v", tt.urn, err)
+			continue
+		}
+		if platform != tt.platform || name != tt.name || origin != tt.origin {
+			t.Errorf("ParseDatasetURN(%q) = %q, %q, %q", tt.urn, platform, name, origin)
+		}
+		if got := DatasetURN(platform, name, origin); got != tt.urn {
+			t.Errorf("DatasetURN = %q, want %q", got, tt.urn)
+		}
+	}
+
+	errs := map[string]string{
+		"urn:li:dataset:(mysql,users,PROD)":                      "the platform must be",
+		"urn:li:dataset:(urn:li:dataPlatform:,users,PROD)":       "the platform must be",
+		"urn:li:dataset:(urn:li:dataPlatform:mysql,,PROD)":       "the name is empty",
+		"urn:li:dataset:(urn:li:dataPlatform:mysql,users,LOCAL)": "LOCAL",
+		"urn:li:dataset:(urn:li:dataPlatform:mysql,users)":       "must be urn:li:dataset:",
+		"urn:li:dataset:urn:li:dataPlatform:mysql,users,PROD":    "must be urn:li:dataset:",
+		"urn:li:corpuser:alice":                                  "must be urn:li:dataset:",
+	}
+	for urn, want := range errs {
+		_, _, _, err := ParseDatasetURN(urn)
+		if !errors.Is(err, ErrValidation) || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseDatasetURN(%q) error = %v, want %q", urn, err, want)
+		}
+	}
+}
+
+// rebuiltDataset is the part of a dataset RebuildDatasetURNs changes
+type rebuiltDataset struct {
+	URN        string `json:"urn"`
+	DatasetKey struct {
+		Value struct {
+			Platform string `json:"platform"`
+			Name     string `json:"name"`
+			Origin   string `json:"origin"`
+		} `json:"value"`
+	} `json:"datasetKey"`
+	SchemaMetadata struct {
+		Value struct {
+			Platform   string `json:"platform"`
+			SchemaName string `json:"schemaName"`
+		} `json:"value"`
+	} `json:"schemaMetadata"`
+}
+
+func rebuild(t *testing.T, payload string, overrides URNOverrides) ([]rebuiltDataset, []string) {
+	t.Helper()
+	out, warnings, err := RebuildDatasetURNs(payload, overrides)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var datasets []rebuiltDataset
+	if err := json.Unmarshal([]byte(out), &datasets); err != nil {
+		t.Fatal(err)
+	}
+	return datasets, warnings
+}
+
+func TestRebuildDatasetURNs(t *testing.T) {
+	payload := `[
+  {
+    "urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,user,PROD)",
+    "datasetKey": {"value": {"platform": "urn:li:dataPlatform:mysql", "name": "users", "origin": "PROD"}},
+    "schemaMetadata": {"value": {"schemaName": "users", "platform": "urn:li:dataPlatform:mysql"}}
+  },
+  {
+    "urn": "orders",
+    "datasetKey": {"value": {"platform": "urn:li:dataPlatform:postgres", "name": "orders", "origin": "DEV"}}
+  },
+  {
+    "urn": "urn:li:dataset:(urn:li:dataPlatform:kafka,events,PROD)",
+    "datasetKey": {"value": {"name": "events"}}
+  }
+]`
+
+	datasets, warnings := rebuild(t, payload, URNOverrides{})
+	want := []string{
+		"urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)",
+		"urn:li:dataset:(urn:li:dataPlatform:postgres,orders,DEV)",
+		"urn:li:dataset:(urn:li:dataPlatform:kafka,events,PROD)",
+	}
+	for i, ds := range datasets {
+		if ds.URN != want[i] {
+			t.Errorf("dataset %d URN = %q, want %q", i+1, ds.URN, want[i])
+		}
+	}
+	// The missing key fields are taken from the URN
+	if key := datasets[2].DatasetKey.Value; key.Platform != "urn:li:dataPlatform:kafka" || key.Origin != "PROD" {
+		t.Errorf("dataset 3 key = %+v", key)
+	}
+	if len(warnings) != 2 ||
+		warnings[0] != "dataset 1: URN urn:li:dataset:(urn:li:dataPlatform:mysql,user,PROD) doesn't match the dataset key, using urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)" ||
+		!strings.HasPrefix(warnings[1], `dataset 2: invalid dataset URN "orders"`) {
+		t.Errorf("warnings = %q", warnings)
+	}
+
+	// The overrides are applied to the key, the schema and the URN
+	datasets, warnings = rebuild(t, `[{
+  "urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)",
+  "datasetKey": {"value": {"platform": "urn:li:dataPlatform:mysql", "name": "users", "origin": "PROD"}},
+  "schemaMetadata": {"value": {"schemaName": "users", "platform": "urn:li:dataPlatform:mysql"}}
+}]`, URNOverrides{Platform: "urn:li:dataPlatform:snowflake", Name: "sales.customers"})
+	ds := datasets[0]
+	if ds.URN != "urn:li:dataset:(urn:li:dataPlatform:snowflake,sales.customers,PROD)" ||
+		ds.DatasetKey.Value.Platform != "urn:li:dataPlatform:snowflake" || ds.DatasetKey.Value.Name != "sales.customers" ||
+		ds.SchemaMetadata.Value.Platform != "urn:li:dataPlatform:snowflake" || ds.SchemaMetadata.Value.SchemaName != "users" {
+		t.Errorf("dataset = %+v", ds)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("the overrides caused warnings: %q", warnings)
+	}
+}
+
+func TestRebuildDatasetURNsUnchanged(t *testing.T) {
+	payload := `[{"urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)", "datasetKey": {"value": {"platform": "urn:li:dataPlatform:mysql", "name": "users", "origin": "PROD"}}}]`
+	got, warnings, err := RebuildDatasetURNs(payload, URNOverrides{Platform: "urn:li:dataPlatform:mysql"})
+	if err != nil || got != payload || warnings != nil {
+		t.Errorf("RebuildDatasetURNs = %q, %q, %v", got, warnings, err)
+	}
+}
+
+func TestRebuildDatasetURNsErrors(t *testing.T) {
+	two := `[{"urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,a,PROD)"}, {"urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,b,PROD)"}]`
+	if _, _, err := RebuildDatasetURNs(two, URNOverrides{Name: "c"}); !errors.Is(err, ErrValidation) {
+		t.Errorf("err = %v, want a validation error for a name with two datasets", err)
+	}
+
+	noName := `[{"urn": "users", "datasetKey": {"value": {"platform": "urn:li:dataPlatform:mysql"}}}]`
+	if _, _, err := RebuildDatasetURNs(noName, URNOverrides{}); !errors.Is(err, ErrValidation) {
+		t.Errorf("err = %v, want a validation error without a name", err)
+	}
+	if _, _, err := RebuildDatasetURNs(noName, URNOverrides{Name: "users"}); err != nil {
+		t.Errorf("the name override wasn't used: %v", err)
+	}
+
+	if _, _, err := RebuildDatasetURNs("not json", URNOverrides{}); err == nil {
+		t.Error("expected an error for an invalid payload")
+	}
+}
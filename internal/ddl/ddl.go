@@ -0,0 +1,469 @@
+// Package ddl parses SQL CREATE TABLE statements into DataHub datasets,
+// without the AI.
+package ddl
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+)
+
+// Table is a table defined by a CREATE TABLE statement
+type Table struct {
+	// Name is the table name, including its schema or database if given
+	Name    string
+	Columns []Column
+	// Statement is the CREATE TABLE statement, as written
+	Statement string
+}
+
+// Column is a table column
+type Column struct {
+	Name string
+	// Type is the SQL type as written, e.g. VARCHAR(255) or INT UNSIGNED
+	Type     string
+	Nullable bool
+	Comment  string
+}
+
+// tokenKind is the kind of a SQL token
+type tokenKind int
+
+const (
+	tokWord tokenKind = iota
+	tokIdent
+	tokString
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	// start and end are the offsets of the token in the SQL source
+	start, end int
+}
+
+// is returns true if the token is the given keyword or punctuation
+func (t token) is(s string) bool {
+	return t.kind != tokIdent && t.kind != tokString && strings.EqualFold(t.text, s)
+}
+
+// Parse returns the tables defined by the CREATE TABLE statements in sql.
+// Other statements are ignored.
+func Parse(sql string) ([]Table, error) {
+	tokens, err := tokenize(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []Table
+	for _, stmt := range splitStatements(tokens) {
+		i := 0
+		if !stmt[i].is("CREATE") {
+			continue
+		}
+		i++
+		for i < len(stmt) && (stmt[i].is("TEMPORARY") || stmt[i].is("TEMP") || stmt[i].is("OR") || stmt[i].is("REPLACE") || stmt[i].is("UNLOGGED")) {
+			i++
+		}
+		if i >= len(stmt) || !stmt[i].is("TABLE") {
+			continue
+		}
+
+		table, err := parseCreateTable(sql, stmt, i+1)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+
+	if len(tables) == 0 {
+		return nil, invalidf("no CREATE TABLE statement found")
+	}
+	return tables, nil
+}
+
+// parseCreateTable parses the statement from the table name at stmt[i]
+func parseCreateTable(sql string, stmt []token, i int) (Table, error) {
+	if i+2 < len(stmt) && stmt[i].is("IF") && stmt[i+1].is("NOT") && stmt[i+2].is("EXISTS") {
+		i += 3
+	}
+
+	var parts []string
+	for i < len(stmt) && (stmt[i].kind == tokWord || stmt[i].kind == tokIdent) {
+		parts = append(parts, stmt[i].text)
+		i++
+		if i < len(stmt) && stmt[i].is(".") {
+			i++
+			continue
+		}
+		break
+	}
+	if len(parts) == 0 {
+		return Table{}, invalidf("CREATE TABLE: missing table name")
+	}
+
+	table := Table{
+		Name:      strings.Join(parts, "."),
+		Statement: sql[stmt[0].start:stmt[len(stmt)-1].end],
+	}
+	if i >= len(stmt) || !stmt[i].is("(") {
+		return Table{}, invalidf("CREATE TABLE %s: missing column definitions", table.Name)
+	}
+
+	defs, err := splitDefinitions(stmt[i+1:])
+	if err != nil {
+		return Table{}, fmt.Errorf("CREATE TABLE %s: %w", table.Name, err)
+	}
+
+	var primaryKey []string
+	for _, def := range defs {
+		if len(def) == 0 {
+			continue
+		}
+		if isConstraint(def) {
+			primaryKey = append(primaryKey, primaryKeyColumns(def)...)
+			continue
+		}
+		col, err := parseColumn(def)
+		if err != nil {
+			return Table{}, fmt.Errorf("CREATE TABLE %s: %w", table.Name, err)
+		}
+		table.Columns = append(table.Columns, col)
+	}
+	if len(table.Columns) == 0 {
+		return Table{}, invalidf("CREATE TABLE %s: no columns", table.Name)
+	}
+
+	// Primary key columns are never NULL
+	for _, name := range primaryKey {
+		for j := range table.Columns {
+			if strings.EqualFold(table.Columns[j].Name, name) {
+				table.Columns[j].Nullable = false
+			}
+		}
+	}
+
+	return table, nil
+}
+
+// constraintKeywords start table constraints rather than column definitions
+var constraintKeywords = []string{"PRIMARY", "KEY", "INDEX", "UNIQUE", "CONSTRAINT", "FOREIGN", "CHECK", "FULLTEXT", "SPATIAL", "EXCLUDE"}
+
+func isConstraint(def []token) bool {
+	for _, kw := range constraintKeywords {
+		if def[0].is(kw) {
+			return true
+		}
+	}
+	return false
+}
+
+// primaryKeyColumns returns the columns of a PRIMARY KEY (...) constraint
+func primaryKeyColumns(def []token) []string {
+	i := 0
+	if def[i].is("CONSTRAINT") {
+		i += 2
+	}
+	if i+1 >= len(def) || !def[i].is("PRIMARY") || !def[i+1].is("KEY") {
+		return nil
+	}
+
+	var columns []string
+	depth := 0
+	for _, t := range def[i+2:] {
+		switch {
+		case t.is("("):
+			depth++
+		case t.is(")"):
+			depth--
+		case depth == 1 && (t.kind == tokWord || t.kind == tokIdent):
+			columns = append(columns, t.text)
+		}
+	}
+	return columns
+}
+
+// typeModifiers are the words that can follow the base name of a SQL type
+var typeModifiers = []string{"UNSIGNED", "SIGNED", "ZEROFILL", "VARYING", "PRECISION", "WITH", "WITHOUT", "TIME", "ZONE", "LOCAL"}
+
+// parseColumn parses a column definition
+func parseColumn(def []token) (Column, error) {
+	if def[0].kind != tokWord && def[0].kind != tokIdent {
+		return Column{}, invalidf("invalid column definition near %q", def[0].text)
+	}
+	col := Column{Name: def[0].text, Nullable: true}
+	if len(def) < 2 || def[1].kind != tokWord {
+		return Column{}, invalidf("column %s: missing type", col.Name)
+	}
+
+	// The type is the base name, its modifiers and its arguments
+	var b strings.Builder
+	b.WriteString(strings.ToUpper(def[1].text))
+	i := 2
+	for i < len(def) {
+		t := def[i]
+		switch {
+		case t.is("("):
+			depth := 0
+			for ; i < len(def); i++ {
+				if def[i].is("(") {
+					depth++
+				} else if def[i].is(")") {
+					depth--
+				}
+				b.WriteString(tokenText(def[i]))
+				if depth == 0 {
+					break
+				}
+			}
+			i++
+			continue
+		case t.is("["):
+			// Postgres arrays
+			b.WriteString("[]")
+			i += 2
+			continue
+		case isTypeModifier(t):
+			b.WriteString(" " + strings.ToUpper(t.text))
+			i++
+			continue
+		}
+		break
+	}
+	col.Type = b.String()
+
+	for ; i < len(def); i++ {
+		t := def[i]
+		switch {
+		case t.is("NOT") && i+1 < len(def) && def[i+1].is("NULL"):
+			col.Nullable = false
+			i++
+		case t.is("NULL"):
+			col.Nullable = true
+		case t.is("PRIMARY"):
+			col.Nullable = false
+		case t.is("COMMENT") && i+1 < len(def) && def[i+1].kind == tokString:
+			col.Comment = def[i+1].text
+			i++
+		}
+	}
+
+	return col, nil
+}
+
+func isTypeModifier(t token) bool {
+	for _, m := range typeModifiers {
+		if t.is(m) {
+			return true
+		}
+	}
+	return false
+}
+
+func tokenText(t token) string {
+	if t.kind == tokString {
+		return "'" + strings.ReplaceAll(t.text, "'", "''") + "'"
+	}
+	return t.text
+}
+
+// splitStatements splits the tokens on semicolons
+func splitStatements(tokens []token) [][]token {
+	var stmts [][]token
+	start := 0
+	for i, t := range tokens {
+		if t.is(";") {
+			if i > start {
+				stmts = append(stmts, tokens[start:i])
+			}
+			start = i + 1
+		}
+	}
+	if start < len(tokens) {
+		stmts = append(stmts, tokens[start:])
+	}
+	return stmts
+}
+
+// splitDefinitions splits the tokens after the opening parenthesis of the
+// column definitions on the top level commas, up to the closing parenthesis
+func splitDefinitions(tokens []token) ([][]token, error) {
+	var defs [][]token
+	depth := 0
+	start := 0
+	for i, t := range tokens {
+		switch {
+		case t.is("("):
+			depth++
+		case t.is(")"):
+			if depth == 0 {
+				return append(defs, tokens[start:i]), nil
+			}
+			depth--
+		case t.is(",") && depth == 0:
+			defs = append(defs, tokens[start:i])
+			start = i + 1
+		}
+	}
+	return nil, invalidf("unterminated column definitions")
+}
+
+// tokenize splits sql into tokens, skipping whitespace and comments
+func tokenize(sql string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case strings.HasPrefix(sql[i:], "--") || c == '#':
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				return tokens, nil
+			}
+			i += end + 1
+		case strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return nil, invalidf("unterminated comment")
+			}
+			i += end + 4
+		case c == '\'':
+			text, end, err := readQuoted(sql, i, '\'')
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokString, text: text, start: i, end: end})
+			i = end
+		case c == '`' || c == '"':
+			text, end, err := readQuoted(sql, i, c)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: text, start: i, end: end})
+			i = end
+		case c == '[' && i+1 < len(sql) && sql[i+1] != ']':
+			// SQL Server quoted identifier
+			end := strings.IndexByte(sql[i:], ']')
+			if end < 0 {
+				return nil, invalidf("unterminated identifier")
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: sql[i+1 : i+end], start: i, end: i + end + 1})
+			i += end + 1
+		case isWordChar(c):
+			start := i
+			for i < len(sql) && isWordChar(sql[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokWord, text: sql[start:i], start: start, end: i})
+		default:
+			tokens = append(tokens, token{kind: tokPunct, text: string(c), start: i, end: i + 1})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// readQuoted reads the quoted text starting at sql[start], where quotes are
+// escaped by doubling them or, in strings, with a backslash. It returns the
+// unquoted text and the offset after the closing quote.
+func readQuoted(sql string, start int, quote byte) (string, int, error) {
+	var b strings.Builder
+	for i := start + 1; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\\' && quote == '\'' && i+1 < len(sql):
+			i++
+			b.WriteByte(sql[i])
+		case c == quote && i+1 < len(sql) && sql[i+1] == quote:
+			i++
+			b.WriteByte(quote)
+		case c == quote:
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, invalidf("unterminated quoted text at offset %d", start)
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// fieldTypes maps the base SQL type names to DataHub field types
+var fieldTypes = map[string]string{
+	"TINYINT": "number", "SMALLINT": "number", "MEDIUMINT": "number", "INT": "number", "INTEGER": "number", "BIGINT": "number",
+	"INT2": "number", "INT4": "number", "INT8": "number", "SERIAL": "number", "SMALLSERIAL": "number", "BIGSERIAL": "number",
+	"DECIMAL": "number", "DEC": "number", "NUMERIC": "number", "NUMBER": "number", "FIXED": "number", "MONEY": "number",
+	"FLOAT": "number", "FLOAT4": "number", "FLOAT8": "number", "DOUBLE": "number", "REAL": "number", "YEAR": "number",
+	"BOOL": "boolean", "BOOLEAN": "boolean", "BIT": "boolean",
+	"DATE": "date",
+	"TIME": "time", "TIMETZ": "time", "DATETIME": "time", "DATETIME2": "time", "TIMESTAMP": "time", "TIMESTAMPTZ": "time",
+	"BLOB": "bytes", "TINYBLOB": "bytes", "MEDIUMBLOB": "bytes", "LONGBLOB": "bytes",
+	"BINARY": "bytes", "VARBINARY": "bytes", "BYTEA": "bytes",
+}
+
+// FieldType returns the DataHub field type of a SQL type. Unknown and
+// character types are strings.
+func FieldType(sqlType string) datahub.FieldType {
+	base := strings.ToUpper(sqlType)
+	if i := strings.IndexAny(base, " ("); i >= 0 {
+		base = base[:i]
+	}
+
+	if strings.HasSuffix(sqlType, "[]") {
+		return datahub.NewFieldType("array")
+	}
+	return datahub.NewFieldType(fieldTypes[base])
+}
+
+// ToDataset converts the table into a DataHub dataset of the platform URN
+// and environment
+func (t *Table) ToDataset(platform, env string) datahub.Dataset {
+	ds := datahub.Dataset{
+		URN: datahub.DatasetURN(platform, t.Name, env),
+		Key: datahub.DatasetKeyContainer{Value: datahub.DatasetKey{
+			Platform: platform,
+			Name:     t.Name,
+			Origin:   env,
+		}},
+		SchemaMetadata: datahub.SchemaMetadataContainer{Value: datahub.SchemaMetadata{
+			SchemaName: t.Name,
+			Platform:   platform,
+			PlatformSchema: datahub.PlatformSchema{
+				MySqlDDL: datahub.MySqlDDL{TableSchema: t.Statement},
+			},
+			Fields: make([]datahub.SchemaField, 0, len(t.Columns)),
+		}},
+		GlobalTags: datahub.GlobalTagsContainer{Value: datahub.GlobalTags{
+			Tags: []datahub.TagAssociation{},
+		}},
+		GlossaryTerms: datahub.GlossaryTermsContainer{Value: datahub.GlossaryTerms{
+			Terms:      []datahub.TermAssociation{},
+			AuditStamp: datahub.NewAuditStamp(""),
+		}},
+		EditableSchemaMetadata: datahub.EditableSchemaMetadataContainer{Value: datahub.EditableSchemaMetadata{
+			EditableSchemaFieldInfo: []datahub.EditableSchemaFieldInfo{},
+		}},
+	}
+
+	for _, c := range t.Columns {
+		ds.SchemaMetadata.Value.Fields = append(ds.SchemaMetadata.Value.Fields, datahub.SchemaField{
+			FieldPath:      c.Name,
+			Description:    c.Comment,
+			Type:           datahub.FieldTypeContainer{Type: FieldType(c.Type)},
+			NativeDataType: c.Type,
+			Nullable:       c.Nullable,
+		})
+	}
+
+	return ds
+}
+
+func invalidf(format string, args ...any) error {
+	return &datahub.ValidationError{Msg: fmt.Sprintf(format, args...)}
+}
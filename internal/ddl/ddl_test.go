@@ -0,0 +1,170 @@
+package ddl
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+)
+
+const usersTable = "CREATE TABLE IF NOT EXISTS `shop`.`users` (\n" +
+	"  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,\n" +
+	"  email VARCHAR(255) NOT NULL COMMENT 'The login, unique',\n" +
+	"  name varchar(100),\n" +
+	"  balance DECIMAL(10, 2) DEFAULT 0.00 NULL,\n" +
+	"  active TINYINT(1) NOT NULL DEFAULT 1,\n" +
+	"  is_admin BOOLEAN,\n" +
+	"  birthday DATE,\n" +
+	"  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,\n" +
+	"  avatar BLOB,\n" +
+	"  tags TEXT[],\n" +
+	"  score DOUBLE PRECISION,\n" +
+	"  PRIMARY KEY (`id`),\n" +
+	"  UNIQUE KEY users_email (email),\n" +
+	"  CONSTRAINT fk_org FOREIGN KEY (org_id) REFERENCES orgs (id)\n" +
+	") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4"
+
+func TestParse(t *testing.T) {
+	sql := "-- the shop schema\nDROP TABLE users;\n" + usersTable + ";\nINSERT INTO users VALUES (1);\n"
+	tables, err := Parse(sql)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 1 {
+		t.Fatalf("parsed %d tables, want 1", len(tables))
+	}
+	table := tables[0]
+	if table.Name != "shop.users" {
+		t.Errorf("table name = %q", table.Name)
+	}
+	if table.Statement != usersTable {
+		t.Errorf("statement = %q", table.Statement)
+	}
+
+	want := []Column{
+		{Name: "id", Type: "BIGINT UNSIGNED", Nullable: false},
+		{Name: "email", Type: "VARCHAR(255)", Nullable: false, Comment: "The login, unique"},
+		{Name: "name", Type: "VARCHAR(100)", Nullable: true},
+		{Name: "balance", Type: "DECIMAL(10,2)", Nullable: true},
+		{Name: "active", Type: "TINYINT(1)", Nullable: false},
+		{Name: "is_admin", Type: "BOOLEAN", Nullable: true},
+		{Name: "birthday", Type: "DATE", Nullable: true},
+		{Name: "created_at", Type: "TIMESTAMP WITH TIME ZONE", Nullable: false},
+		{Name: "avatar", Type: "BLOB", Nullable: true},
+		{Name: "tags", Type: "TEXT[]", Nullable: true},
+		{Name: "score", Type: "DOUBLE PRECISION", Nullable: true},
+	}
+	if !slices.Equal(table.Columns, want) {
+		for i := range max(len(want), len(table.Columns)) {
+			var got, w Column
+			if i < len(table.Columns) {
+				got = table.Columns[i]
+			}
+			if i < len(want) {
+				w = want[i]
+			}
+			if got != w {
+				t.Errorf("column %d = %+v, want %+v", i, got, w)
+			}
+		}
+	}
+}
+
+func TestParseMultipleTables(t *testing.T) {
+	tables, err := Parse(`
+CREATE TABLE orders (id SERIAL PRIMARY KEY, total NUMERIC);
+create temporary table "Order Items" (order_id int not null, sku text);
+CREATE OR REPLACE TABLE public.events (id uuid, payload jsonb)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, table := range tables {
+		names = append(names, table.Name)
+	}
+	if !slices.Equal(names, []string{"orders", "Order Items", "public.events"}) {
+		t.Fatalf("tables = %q", names)
+	}
+	if id := tables[0].Columns[0]; id.Type != "SERIAL" || id.Nullable {
+		t.Errorf("orders.id = %+v", id)
+	}
+	if orderID := tables[1].Columns[0]; orderID.Type != "INT" || orderID.Nullable {
+		t.Errorf("order_id = %+v", orderID)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"SELECT 1":                                 "no CREATE TABLE statement found",
+		"CREATE TABLE (id int)":                    "missing table name",
+		"CREATE TABLE users AS SELECT 1":           "missing column definitions",
+		"CREATE TABLE users (PRIMARY KEY (id))":    "no columns",
+		"CREATE TABLE users (id)":                  "column id: missing type",
+		"CREATE TABLE users (id int, name 'text')": "column name: missing type",
+	}
+	for sql, want := range tests {
+		_, err := Parse(sql)
+		if !errors.Is(err, datahub.ErrValidation) || !strings.Contains(err.Error(), want) {
+			t.Errorf("Parse(%q) error = %v, want %q", sql, err, want)
+		}
+	}
+}
+
+func TestFieldType(t *testing.T) {
+	tests := map[string]string{
+		"INT":                      "number",
+		"bigint unsigned":          "number",
+		"DECIMAL(10,2)":            "number",
+		"DOUBLE PRECISION":         "number",
+		"BOOLEAN":                  "boolean",
+		"DATE":                     "date",
+		"TIMESTAMP WITH TIME ZONE": "time",
+		"DATETIME(6)":              "time",
+		"VARBINARY(16)":            "bytes",
+		"VARCHAR(255)":             "string",
+		"TEXT":                     "string",
+		"JSONB":                    "string",
+		"INT[]":                    "array",
+	}
+	for sqlType, want := range tests {
+		if got := FieldType(sqlType).Name(); got != want {
+			t.Errorf("FieldType(%q) = %s, want %s", sqlType, got, want)
+		}
+	}
+}
+
+func TestToDataset(t *testing.T) {
+	tables, err := Parse(usersTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds := tables[0].ToDataset("urn:li:dataPlatform:mysql", "DEV")
+
+	if ds.URN != "urn:li:dataset:(urn:li:dataPlatform:mysql,shop.users,DEV)" {
+		t.Errorf("URN = %s", ds.URN)
+	}
+	if key := ds.Key.Value; key.Platform != "urn:li:dataPlatform:mysql" || key.Name != "shop.users" || key.Origin != "DEV" {
+		t.Errorf("key = %+v", key)
+	}
+	schema := ds.SchemaMetadata.Value
+	if schema.SchemaName != "shop.users" || schema.PlatformSchema.MySqlDDL.TableSchema != usersTable {
+		t.Errorf("schema = %+v", schema)
+	}
+	if len(schema.Fields) != 11 {
+		t.Fatalf("%d fields, want 11", len(schema.Fields))
+	}
+	email := schema.Fields[1]
+	if email.FieldPath != "email" || email.Type.Type.Name() != "string" || email.NativeDataType != "VARCHAR(255)" ||
+		email.Nullable || email.Description != "The login, unique" {
+		t.Errorf("email field = %+v", email)
+	}
+	if created := schema.Fields[7]; created.Type.Type.Name() != "time" || created.Nullable {
+		t.Errorf("created_at field = %+v", created)
+	}
+	if ds.GlobalTags.Value.Tags == nil || ds.GlossaryTerms.Value.Terms == nil {
+		t.Error("the tags and terms must be empty arrays")
+	}
+}
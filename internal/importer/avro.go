@@ -0,0 +1,207 @@
+package importer
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// avroTypes maps the Avro primitive and logical types to DataHub types
+var avroTypes = map[string]string{
+	"null":    "string",
+	"boolean": "boolean",
+	"int":     "number",
+	"long":    "number",
+	"float":   "number",
+	"double":  "number",
+	"bytes":   "bytes",
+	"fixed":   "bytes",
+	"string":  "string",
+	"enum":    "enum",
+	"array":   "array",
+	"map":     "map",
+
+	"decimal":                "number",
+	"uuid":                   "string",
+	"date":                   "date",
+	"time-millis":            "time",
+	"time-micros":            "time",
+	"timestamp-millis":       "time",
+	"timestamp-micros":       "time",
+	"local-timestamp-millis": "time",
+	"local-timestamp-micros": "time",
+}
+
+// avroSchema is an Avro schema: a type name, a union (array) or a complex
+// type (object)
+type avroSchema struct {
+	Type        json.RawMessage `json:"type"`
+	Name        string          `json:"name"`
+	Namespace   string          `json:"namespace"`
+	Doc         string          `json:"doc"`
+	LogicalType string          `json:"logicalType"`
+	Fields      []avroField     `json:"fields"`
+	Items       json.RawMessage `json:"items"`
+	Values      json.RawMessage `json:"values"`
+}
+
+type avroField struct {
+	Name string          `json:"name"`
+	Doc  string          `json:"doc"`
+	Type json.RawMessage `json:"type"`
+}
+
+// ParseAvro flattens an Avro record schema into a Schema named after the
+// record full name
+func ParseAvro(data []byte) (*Schema, error) {
+	var record avroSchema
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, invalidf("invalid Avro schema: %v", err)
+	}
+	if typeName(record.Type) != "record" {
+		return nil, invalidf("invalid Avro schema: the top level type must be a record")
+	}
+	if record.Name == "" {
+		return nil, invalidf("invalid Avro schema: the record has no name")
+	}
+
+	s := &Schema{Name: record.Name, Description: record.Doc}
+	if record.Namespace != "" && !strings.Contains(record.Name, ".") {
+		s.Name = record.Namespace + "." + record.Name
+	}
+
+	p := &avroParser{
+		schema:    s,
+		named:     map[string]json.RawMessage{record.Name: data, s.Name: data},
+		expanding: map[string]bool{record.Name: true},
+	}
+	if err := p.addFields("", record.Fields); err != nil {
+		return nil, err
+	}
+	if len(s.Fields) == 0 {
+		return nil, invalidf("invalid Avro schema: the record has no fields")
+	}
+	return s, nil
+}
+
+// avroParser flattens the fields of an Avro record into a Schema
+type avroParser struct {
+	schema *Schema
+	// named are the named types (records, enums, fixed) defined so far
+	named map[string]json.RawMessage
+	// expanding are the records whose fields are being added, so recursive
+	// records aren't expanded forever
+	expanding map[string]bool
+}
+
+func (p *avroParser) addFields(parent string, fields []avroField) error {
+	for _, f := range fields {
+		if f.Name == "" {
+			return invalidf("invalid Avro schema: field without name in %s", parent)
+		}
+		if err := p.addField(joinPath(parent, f.Name), f.Doc, f.Type); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addField adds the field at path with the Avro type raw, and its nested
+// fields if it's a record or an array of records
+func (p *avroParser) addField(path, doc string, raw json.RawMessage) error {
+	s := p.schema
+	nullable := false
+
+	// ["null", T] unions are nullable T
+	var union []json.RawMessage
+	if json.Unmarshal(raw, &union) == nil {
+		var types []json.RawMessage
+		for _, t := range union {
+			if typeName(t) == "null" {
+				nullable = true
+				continue
+			}
+			types = append(types, t)
+		}
+		if len(types) != 1 {
+			names := make([]string, 0, len(union))
+			for _, t := range union {
+				names = append(names, typeName(t))
+			}
+			s.Fields = append(s.Fields, Field{Path: path, Type: "string", NativeType: "union<" + strings.Join(names, ",") + ">", Nullable: nullable, Description: doc})
+			return nil
+		}
+		raw = types[0]
+	}
+
+	// References to named types defined before
+	var ref string
+	if json.Unmarshal(raw, &ref) == nil {
+		if named, ok := p.named[ref]; ok {
+			raw = named
+		}
+	}
+
+	var schema avroSchema
+	name := typeName(raw)
+	if json.Unmarshal(raw, &schema) == nil {
+		if schema.Name != "" {
+			p.named[schema.Name] = raw
+			if schema.Namespace != "" {
+				p.named[schema.Namespace+"."+schema.Name] = raw
+			}
+		}
+		if schema.LogicalType != "" {
+			name = schema.LogicalType
+		}
+	}
+
+	switch name {
+	case "":
+		return invalidf("invalid Avro schema: field %s has no type", path)
+	case "record":
+		s.Fields = append(s.Fields, Field{Path: path, Type: "record", NativeType: "record", Nullable: nullable, Description: doc})
+		if p.expanding[schema.Name] {
+			return nil
+		}
+		p.expanding[schema.Name] = true
+		defer delete(p.expanding, schema.Name)
+		return p.addFields(path, schema.Fields)
+	case "array":
+		s.Fields = append(s.Fields, Field{Path: path, Type: "array", NativeType: "array<" + typeName(schema.Items) + ">", Nullable: nullable, Description: doc})
+		var items avroSchema
+		if typeName(schema.Items) == "record" && json.Unmarshal(schema.Items, &items) == nil && !p.expanding[items.Name] {
+			p.expanding[items.Name] = true
+			defer delete(p.expanding, items.Name)
+			return p.addFields(path, items.Fields)
+		}
+		return nil
+	case "map":
+		s.Fields = append(s.Fields, Field{Path: path, Type: "map", NativeType: "map<" + typeName(schema.Values) + ">", Nullable: nullable, Description: doc})
+		return nil
+	}
+
+	fieldType, ok := avroTypes[name]
+	if !ok {
+		// Unknown named types
+		fieldType = "string"
+	}
+	s.Fields = append(s.Fields, Field{Path: path, Type: fieldType, NativeType: name, Nullable: nullable, Description: doc})
+	return nil
+}
+
+// typeName returns the name of an Avro type: a primitive or named type
+// name, or the type of a complex type
+func typeName(raw json.RawMessage) string {
+	var name string
+	if json.Unmarshal(raw, &name) == nil {
+		return name
+	}
+	var schema avroSchema
+	if json.Unmarshal(raw, &schema) == nil {
+		return typeName(schema.Type)
+	}
+	if len(raw) > 0 && raw[0] == '[' {
+		return "union"
+	}
+	return ""
+}
@@ -0,0 +1,142 @@
+package importer
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+)
+
+const userAvro = `{
+  "type": "record",
+  "name": "User",
+  "namespace": "com.shop",
+  "doc": "A shop user",
+  "fields": [
+    {"name": "id", "type": "long"},
+    {"name": "email", "type": ["null", "string"], "doc": "The login"},
+    {"name": "created", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+    {"name": "address", "type": {
+      "type": "record",
+      "name": "Address",
+      "fields": [
+        {"name": "city", "type": "string"},
+        {"name": "geo", "type": {"type": "record", "name": "Geo", "fields": [{"name": "lat", "type": "double"}]}}
+      ]
+    }},
+    {"name": "billing", "type": ["null", "Address"]},
+    {"name": "orders", "type": {"type": "array", "items": {
+      "type": "record",
+      "name": "Order",
+      "fields": [{"name": "total", "type": {"type": "bytes", "logicalType": "decimal", "precision": 10, "scale": 2}}]
+    }}},
+    {"name": "attributes", "type": {"type": "map", "values": "string"}},
+    {"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["ACTIVE", "BLOCKED"]}},
+    {"name": "manager", "type": ["null", "User"]},
+    {"name": "external_id", "type": ["int", "string"]}
+  ]
+}`
+
+// checkFields compares the flattened fields with want, one by one
+func checkFields(t *testing.T, got, want []Field) {
+	t.Helper()
+	for i := range max(len(got), len(want)) {
+		var g, w Field
+		if i < len(got) {
+			g = got[i]
+		}
+		if i < len(want) {
+			w = want[i]
+		}
+		if g != w {
+			t.Errorf("field %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
+
+func TestParseAvro(t *testing.T) {
+	s, err := ParseAvro([]byte(userAvro))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Name != "com.shop.User" || s.Description != "A shop user" {
+		t.Errorf("schema = %q, %q", s.Name, s.Description)
+	}
+
+	checkFields(t, s.Fields, []Field{
+		{Path: "id", Type: "number", NativeType: "long"},
+		{Path: "email", Type: "string", NativeType: "string", Nullable: true, Description: "The login"},
+		{Path: "created", Type: "time", NativeType: "timestamp-millis"},
+		{Path: "address", Type: "record", NativeType: "record"},
+		{Path: "address.city", Type: "string", NativeType: "string"},
+		{Path: "address.geo", Type: "record", NativeType: "record"},
+		{Path: "address.geo.lat", Type: "number", NativeType: "double"},
+		// Named types are expanded where they're referenced
+		{Path: "billing", Type: "record", NativeType: "record", Nullable: true},
+		{Path: "billing.city", Type: "string", NativeType: "string"},
+		{Path: "billing.geo", Type: "record", NativeType: "record"},
+		{Path: "billing.geo.lat", Type: "number", NativeType: "double"},
+		{Path: "orders", Type: "array", NativeType: "array<record>"},
+		{Path: "orders.total", Type: "number", NativeType: "decimal"},
+		{Path: "attributes", Type: "map", NativeType: "map<string>"},
+		{Path: "status", Type: "enum", NativeType: "enum"},
+		// Recursive records aren't expanded
+		{Path: "manager", Type: "record", NativeType: "record", Nullable: true},
+		{Path: "external_id", Type: "string", NativeType: "union<int,string>"},
+	})
+}
+
+func TestParseAvroErrors(t *testing.T) {
+	tests := map[string]string{
+		`not json`: "invalid Avro schema",
+		`"string"`: "invalid Avro schema",
+		`{"type": "enum", "name": "Status", "symbols": ["A"]}`:            "the top level type must be a record",
+		`{"type": "record", "fields": [{"name": "id", "type": "int"}]}`:   "the record has no name",
+		`{"type": "record", "name": "Empty", "fields": []}`:               "the record has no fields",
+		`{"type": "record", "name": "User", "fields": [{"type": "int"}]}`: "field without name",
+		`{"type": "record", "name": "User", "fields": [{"name": "id"}]}`:  "field id has no type",
+	}
+	for schema, want := range tests {
+		_, err := ParseAvro([]byte(schema))
+		if !errors.Is(err, datahub.ErrValidation) || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseAvro(%s) error = %v, want %q", schema, err, want)
+		}
+	}
+}
+
+func TestToDataset(t *testing.T) {
+	s, err := ParseAvro([]byte(userAvro))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds := s.ToDataset("urn:li:dataPlatform:kafka", "PROD")
+
+	if ds.URN != "urn:li:dataset:(urn:li:dataPlatform:kafka,com.shop.User,PROD)" {
+		t.Errorf("URN = %s", ds.URN)
+	}
+	if ds.Key.Value.Platform != "urn:li:dataPlatform:kafka" || ds.SchemaMetadata.Value.Platform != "urn:li:dataPlatform:kafka" {
+		t.Errorf("platform = %q, %q", ds.Key.Value.Platform, ds.SchemaMetadata.Value.Platform)
+	}
+	if ds.DatasetProperties == nil || ds.DatasetProperties.Value.Description != "A shop user" {
+		t.Errorf("dataset properties = %+v", ds.DatasetProperties)
+	}
+
+	var paths []string
+	for _, f := range ds.SchemaMetadata.Value.Fields {
+		paths = append(paths, f.FieldPath)
+	}
+	if len(paths) != len(s.Fields) || !slices.Contains(paths, "address.geo.lat") {
+		t.Errorf("field paths = %v", paths)
+	}
+	email := ds.SchemaMetadata.Value.Fields[1]
+	if email.Type.Type.Name() != "string" || !email.Nullable || email.Description != "The login" || email.NativeDataType != "string" {
+		t.Errorf("email field = %+v", email)
+	}
+
+	s.Description = ""
+	if ds := s.ToDataset("urn:li:dataPlatform:kafka", "PROD"); ds.DatasetProperties != nil {
+		t.Errorf("dataset properties without description = %+v", ds.DatasetProperties)
+	}
+}
@@ -0,0 +1,86 @@
+// Package importer converts Avro schemas and JSON Schemas into DataHub
+// datasets. Nested records become dotted field paths.
+package importer
+
+import (
+	"fmt"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+)
+
+// Schema is a schema flattened into fields
+type Schema struct {
+	Name        string
+	Description string
+	Fields      []Field
+}
+
+// Field is a schema field
+type Field struct {
+	// Path is the field name, prefixed with the names of its parent records
+	Path string
+	// Type is the DataHub type name, see datahub.NewFieldType
+	Type string
+	// NativeType is the type in the source schema
+	NativeType  string
+	Nullable    bool
+	Description string
+}
+
+// ToDataset converts the schema into a DataHub dataset of the platform URN
+// and environment
+func (s *Schema) ToDataset(platform, env string) datahub.Dataset {
+	ds := datahub.Dataset{
+		URN: datahub.DatasetURN(platform, s.Name, env),
+		Key: datahub.DatasetKeyContainer{Value: datahub.DatasetKey{
+			Platform: platform,
+			Name:     s.Name,
+			Origin:   env,
+		}},
+		SchemaMetadata: datahub.SchemaMetadataContainer{Value: datahub.SchemaMetadata{
+			SchemaName: s.Name,
+			Platform:   platform,
+			Fields:     make([]datahub.SchemaField, 0, len(s.Fields)),
+		}},
+		GlobalTags: datahub.GlobalTagsContainer{Value: datahub.GlobalTags{
+			Tags: []datahub.TagAssociation{},
+		}},
+		GlossaryTerms: datahub.GlossaryTermsContainer{Value: datahub.GlossaryTerms{
+			Terms:      []datahub.TermAssociation{},
+			AuditStamp: datahub.NewAuditStamp(""),
+		}},
+		EditableSchemaMetadata: datahub.EditableSchemaMetadataContainer{Value: datahub.EditableSchemaMetadata{
+			EditableSchemaFieldInfo: []datahub.EditableSchemaFieldInfo{},
+		}},
+	}
+
+	if s.Description != "" {
+		ds.DatasetProperties = &datahub.DatasetPropertiesContainer{
+			Value: datahub.DatasetProperties{Description: s.Description},
+		}
+	}
+
+	for _, f := range s.Fields {
+		ds.SchemaMetadata.Value.Fields = append(ds.SchemaMetadata.Value.Fields, datahub.SchemaField{
+			FieldPath:      f.Path,
+			Description:    f.Description,
+			Type:           datahub.FieldTypeContainer{Type: datahub.NewFieldType(f.Type)},
+			NativeDataType: f.NativeType,
+			Nullable:       f.Nullable,
+		})
+	}
+
+	return ds
+}
+
+// joinPath appends name to the parent field path
+func joinPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+func invalidf(format string, args ...any) error {
+	return &datahub.ValidationError{Msg: fmt.Sprintf(format, args...)}
+}
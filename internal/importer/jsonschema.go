@@ -0,0 +1,129 @@
+package importer
+
+import (
+	"encoding/json"
+	"slices"
+	"sort"
+)
+
+// jsonSchemaFormats maps the JSON Schema string formats to DataHub types
+var jsonSchemaFormats = map[string]string{
+	"date":      "date",
+	"date-time": "time",
+	"time":      "time",
+}
+
+// jsonSchema is a JSON Schema, or the schema of one of its properties
+type jsonSchema struct {
+	Title       string                 `json:"title"`
+	Description string                 `json:"description"`
+	Type        json.RawMessage        `json:"type"`
+	Format      string                 `json:"format"`
+	Enum        []json.RawMessage      `json:"enum"`
+	Ref         string                 `json:"$ref"`
+	Properties  map[string]*jsonSchema `json:"properties"`
+	Required    []string               `json:"required"`
+	Items       *jsonSchema            `json:"items"`
+}
+
+// ParseJSONSchema flattens a JSON Schema object into a Schema named after
+// its title. Properties are sorted by name.
+func ParseJSONSchema(data []byte) (*Schema, error) {
+	var root jsonSchema
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, invalidf("invalid JSON Schema: %v", err)
+	}
+	types, _ := root.types()
+	if len(types) != 1 || types[0] != "object" {
+		return nil, invalidf("invalid JSON Schema: the top level type must be an object")
+	}
+
+	s := &Schema{Name: root.Title, Description: root.Description}
+	addJSONSchemaProperties(s, "", &root)
+	if len(s.Fields) == 0 {
+		return nil, invalidf("invalid JSON Schema: the object has no properties")
+	}
+	return s, nil
+}
+
+func addJSONSchemaProperties(s *Schema, parent string, object *jsonSchema) {
+	names := make([]string, 0, len(object.Properties))
+	for name := range object.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		prop := object.Properties[name]
+		if prop == nil {
+			prop = &jsonSchema{}
+		}
+		addJSONSchemaProperty(s, joinPath(parent, name), prop, !slices.Contains(object.Required, name))
+	}
+}
+
+// addJSONSchemaProperty adds the property at path, and its nested
+// properties if it's an object or an array of objects
+func addJSONSchemaProperty(s *Schema, path string, prop *jsonSchema, optional bool) {
+	types, nullable := prop.types()
+	field := Field{Path: path, Nullable: optional || nullable, Description: prop.Description}
+
+	switch {
+	case prop.Ref != "":
+		// References aren't resolved
+		field.Type, field.NativeType = "string", prop.Ref
+	case len(prop.Enum) > 0:
+		field.Type, field.NativeType = "enum", "enum"
+	case len(types) != 1:
+		field.Type, field.NativeType = "string", "any"
+	default:
+		field.NativeType = types[0]
+		switch types[0] {
+		case "integer", "number":
+			field.Type = "number"
+		case "boolean":
+			field.Type = "boolean"
+		case "object":
+			field.Type = "record"
+		case "array":
+			field.Type = "array"
+			if prop.Items != nil {
+				items, _ := prop.Items.types()
+				if len(items) == 1 {
+					field.NativeType = "array<" + items[0] + ">"
+				}
+			}
+		default:
+			field.Type = "string"
+			if t, ok := jsonSchemaFormats[prop.Format]; ok {
+				field.Type, field.NativeType = t, "string("+prop.Format+")"
+			}
+		}
+	}
+	s.Fields = append(s.Fields, field)
+
+	switch field.Type {
+	case "record":
+		addJSONSchemaProperties(s, path, prop)
+	case "array":
+		if prop.Items != nil && prop.Items.Properties != nil {
+			addJSONSchemaProperties(s, path, prop.Items)
+		}
+	}
+}
+
+// types returns the types of the schema without "null", and whether "null"
+// was one of them. Schemas with properties and no type are objects.
+func (js *jsonSchema) types() ([]string, bool) {
+	var types []string
+	var name string
+	if json.Unmarshal(js.Type, &name) == nil {
+		types = []string{name}
+	} else if json.Unmarshal(js.Type, &types) != nil && js.Properties != nil {
+		return []string{"object"}, false
+	}
+
+	nullable := slices.Contains(types, "null")
+	types = slices.DeleteFunc(types, func(t string) bool { return t == "null" })
+	return types, nullable
+}
@@ -0,0 +1,83 @@
+package importer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+)
+
+const orderJSONSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "orders",
+  "description": "Shop orders",
+  "type": "object",
+  "required": ["id", "customer"],
+  "properties": {
+    "id": {"type": "integer"},
+    "customer": {
+      "type": "object",
+      "required": ["name"],
+      "properties": {
+        "name": {"type": "string"},
+        "email": {"type": ["string", "null"], "format": "email"}
+      }
+    },
+    "created": {"type": "string", "format": "date-time", "description": "Creation time"},
+    "items": {"type": "array", "items": {
+      "type": "object",
+      "properties": {"sku": {"type": "string"}, "quantity": {"type": "number"}}
+    }},
+    "status": {"enum": ["new", "paid"]},
+    "tags": {"type": "array", "items": {"type": "string"}},
+    "paid": {"type": "boolean"},
+    "coupon": {"$ref": "#/$defs/coupon"},
+    "metadata": {"properties": {"source": {"type": "string"}}},
+    "extra": {"type": ["string", "integer"]}
+  }
+}`
+
+func TestParseJSONSchema(t *testing.T) {
+	s, err := ParseJSONSchema([]byte(orderJSONSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Name != "orders" || s.Description != "Shop orders" {
+		t.Errorf("schema = %q, %q", s.Name, s.Description)
+	}
+
+	// Sorted by name, optional properties are nullable
+	checkFields(t, s.Fields, []Field{
+		{Path: "coupon", Type: "string", NativeType: "#/$defs/coupon", Nullable: true},
+		{Path: "created", Type: "time", NativeType: "string(date-time)", Nullable: true, Description: "Creation time"},
+		{Path: "customer", Type: "record", NativeType: "object"},
+		{Path: "customer.email", Type: "string", NativeType: "string", Nullable: true},
+		{Path: "customer.name", Type: "string", NativeType: "string"},
+		{Path: "extra", Type: "string", NativeType: "any", Nullable: true},
+		{Path: "id", Type: "number", NativeType: "integer"},
+		{Path: "items", Type: "array", NativeType: "array<object>", Nullable: true},
+		{Path: "items.quantity", Type: "number", NativeType: "number", Nullable: true},
+		{Path: "items.sku", Type: "string", NativeType: "string", Nullable: true},
+		{Path: "metadata", Type: "record", NativeType: "object", Nullable: true},
+		{Path: "metadata.source", Type: "string", NativeType: "string", Nullable: true},
+		{Path: "paid", Type: "boolean", NativeType: "boolean", Nullable: true},
+		{Path: "status", Type: "enum", NativeType: "enum", Nullable: true},
+		{Path: "tags", Type: "array", NativeType: "array<string>", Nullable: true},
+	})
+}
+
+func TestParseJSONSchemaErrors(t *testing.T) {
+	tests := map[string]string{
+		`not json`:                             "invalid JSON Schema",
+		`{"type": "array", "items": {}}`:       "the top level type must be an object",
+		`{"type": ["object", "array"]}`:        "the top level type must be an object",
+		`{"type": "object", "properties": {}}`: "the object has no properties",
+	}
+	for schema, want := range tests {
+		_, err := ParseJSONSchema([]byte(schema))
+		if !errors.Is(err, datahub.ErrValidation) || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseJSONSchema(%s) error = %v, want %q", schema, err, want)
+		}
+	}
+}
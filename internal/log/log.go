@@ -1,47 +1,138 @@
 package log
 
 import (
+	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"os"
+	"strings"
+	"sync"
+	"time"
 )
 
 const envPrefix = "DSGEN_"
 
+const (
+	// FormatText is the default human readable log format
+	FormatText = "text"
+	// FormatJSON emits one JSON object per log line
+	FormatJSON = "json"
+)
+
+// Fields are additional key/value pairs attached to structured log lines
+type Fields map[string]interface{}
+
 var logger = log.New(os.Stdout, "", log.LstdFlags|log.Lshortfile)
 
+var (
+	mu     sync.Mutex
+	format           = FormatText
+	out    io.Writer = os.Stdout
+	fields           = Fields{}
+)
+
 func Init() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 }
 
 func SetOutput(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	out = w
 	logger.SetOutput(w)
 }
 
+// SetFormat sets the log format, either FormatText or FormatJSON
+func SetFormat(f string) error {
+	switch f {
+	case FormatText, FormatJSON:
+	default:
+		return fmt.Errorf("unsupported log format: %s", f)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	format = f
+	return nil
+}
+
+// AddField adds a field included in every structured log line
+func AddField(key string, value interface{}) {
+	mu.Lock()
+	defer mu.Unlock()
+	fields[key] = value
+}
+
 func Printf(format string, args ...interface{}) {
-	logger.Printf(format, args...)
+	output("info", nil, fmt.Sprintf(format, args...))
 }
 
 func Debugf(format string, args ...interface{}) {
-	if os.Getenv(envPrefix+"DEBUG") != "" {
-		logger.Printf(format, args...)
+	if debugEnabled() {
+		output("debug", nil, fmt.Sprintf(format, args...))
 	}
 }
 
 func Debug(args ...interface{}) {
-	if os.Getenv(envPrefix+"DEBUG") != "" {
-		logger.Println(args...)
+	if debugEnabled() {
+		output("debug", nil, fmt.Sprintln(args...))
+	}
+}
+
+// DebugWith logs a debug message with additional fields
+func DebugWith(f Fields, format string, args ...interface{}) {
+	if debugEnabled() {
+		output("debug", f, fmt.Sprintf(format, args...))
 	}
 }
 
 func Fatal(args ...interface{}) {
-	logger.Fatal(args...)
+	output("fatal", nil, fmt.Sprint(args...))
+	os.Exit(1)
 }
 
 func Fatalf(format string, args ...interface{}) {
-	logger.Fatalf(format, args...)
+	output("fatal", nil, fmt.Sprintf(format, args...))
+	os.Exit(1)
 }
 
 func Errorf(format string, args ...interface{}) {
-	logger.Printf(format, args...)
+	output("error", nil, fmt.Sprintf(format, args...))
+}
+
+func debugEnabled() bool {
+	return os.Getenv(envPrefix+"DEBUG") != ""
+}
+
+func output(level string, extra Fields, msg string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if format != FormatJSON {
+		for k, v := range extra {
+			msg = strings.TrimRight(msg, "\n") + fmt.Sprintf(" %s=%v", k, v)
+		}
+		// calldepth 3 reports the caller of the exported log function
+		logger.Output(3, msg)
+		return
+	}
+
+	entry := make(map[string]interface{}, len(fields)+len(extra)+3)
+	for k, v := range fields {
+		entry[k] = v
+	}
+	for k, v := range extra {
+		entry[k] = v
+	}
+	entry["level"] = level
+	entry["time"] = time.Now().Format(time.RFC3339)
+	entry["msg"] = strings.TrimSpace(msg)
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		logger.Output(3, msg)
+		return
+	}
+	out.Write(append(data, '\n'))
 }
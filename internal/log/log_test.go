@@ -0,0 +1,75 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestJSONFormat(t *testing.T) {
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	defer SetOutput(os.Stdout)
+	if err := SetFormat(FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	defer SetFormat(FormatText)
+	t.Setenv(envPrefix+"DEBUG", "1")
+
+	AddField("command", "generate")
+	defer delete(fields, "command")
+
+	Printf("posted %d datasets", 2)
+	DebugWith(Fields{"duration": "1s"}, "request done")
+	Errorf("failed")
+
+	var lines []map[string]interface{}
+	sc := bufio.NewScanner(&buf)
+	for sc.Scan() {
+		var entry map[string]interface{}
+		if err := json.Unmarshal(sc.Bytes(), &entry); err != nil {
+			t.Fatalf("unparseable line %q: %v", sc.Text(), err)
+		}
+		lines = append(lines, entry)
+	}
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3", len(lines))
+	}
+
+	for _, entry := range lines {
+		for _, key := range []string{"level", "time", "msg", "command"} {
+			if _, ok := entry[key]; !ok {
+				t.Errorf("line %v is missing %q", entry, key)
+			}
+		}
+	}
+	if lines[0]["level"] != "info" || lines[0]["msg"] != "posted 2 datasets" {
+		t.Errorf("unexpected info line %v", lines[0])
+	}
+	if lines[1]["level"] != "debug" || lines[1]["duration"] != "1s" {
+		t.Errorf("unexpected debug line %v", lines[1])
+	}
+	if lines[2]["level"] != "error" {
+		t.Errorf("unexpected error line %v", lines[2])
+	}
+}
+
+func TestTextFormat(t *testing.T) {
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	defer SetOutput(os.Stdout)
+
+	Printf("hello")
+	if got := buf.String(); !strings.HasSuffix(got, "hello\n") || strings.HasPrefix(got, "{") {
+		t.Errorf("unexpected text line %q", got)
+	}
+}
+
+func TestSetFormatRejectsUnknown(t *testing.T) {
+	if err := SetFormat("xml"); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
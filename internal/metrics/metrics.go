@@ -0,0 +1,163 @@
+// Package metrics counts and times the HTTP requests sent to DataHub and
+// OpenAI. Nothing is recorded unless a Collector wraps the HTTP clients.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// key identifies a series of requests
+type key struct {
+	Service string
+	Method  string
+	// Status is the HTTP status code, or "error" if the request failed
+	Status string
+}
+
+// Stats are the counts and durations of a series of requests
+type Stats struct {
+	Count int
+	Total time.Duration
+	Max   time.Duration
+}
+
+// Collector records the requests sent through its transports
+type Collector struct {
+	mu     sync.Mutex
+	series map[key]*Stats
+}
+
+// New creates an empty collector
+func New() *Collector {
+	return &Collector{series: map[key]*Stats{}}
+}
+
+// Record adds a request to the collector
+func (c *Collector) Record(service, method, status string, d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	k := key{Service: service, Method: method, Status: status}
+	s, ok := c.series[k]
+	if !ok {
+		s = &Stats{}
+		c.series[k] = s
+	}
+	s.Count++
+	s.Total += d
+	if d > s.Max {
+		s.Max = d
+	}
+}
+
+// Transport returns a RoundTripper recording the requests sent through next
+// under the service name
+func (c *Collector) Transport(service string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{collector: c, service: service, next: next}
+}
+
+type transport struct {
+	collector *Collector
+	service   string
+	next      http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	t.collector.Record(t.service, req.Method, status, time.Since(start))
+	return resp, err
+}
+
+// sortedKeys returns the recorded series in a stable order
+func (c *Collector) sortedKeys() []key {
+	keys := make([]key, 0, len(c.series))
+	for k := range c.series {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.Service != b.Service {
+			return a.Service < b.Service
+		}
+		if a.Method != b.Method {
+			return a.Method < b.Method
+		}
+		return a.Status < b.Status
+	})
+	return keys
+}
+
+// WriteSummary writes a table with the requests recorded
+func (c *Collector) WriteSummary(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.series) == 0 {
+		_, err := fmt.Fprintln(w, "No HTTP requests sent.")
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "%-10s %-7s %-7s %8s %12s %12s %12s\n", "SERVICE", "METHOD", "STATUS", "COUNT", "TOTAL", "AVG", "MAX"); err != nil {
+		return err
+	}
+	for _, k := range c.sortedKeys() {
+		s := c.series[k]
+		avg := s.Total / time.Duration(s.Count)
+		_, err := fmt.Fprintf(w, "%-10s %-7s %-7s %8d %12s %12s %12s\n", k.Service, k.Method, k.Status, s.Count,
+			s.Total.Round(time.Millisecond), avg.Round(time.Millisecond), s.Max.Round(time.Millisecond))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WritePrometheus writes the requests recorded in the Prometheus text
+// exposition format
+func (c *Collector) WritePrometheus(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := c.sortedKeys()
+	metrics := []struct {
+		name, help, kind string
+		value            func(*Stats) string
+	}{
+		{"dsg_http_requests_total", "HTTP requests sent.", "counter", func(s *Stats) string {
+			return strconv.Itoa(s.Count)
+		}},
+		{"dsg_http_request_duration_seconds_total", "Total duration of the HTTP requests in seconds.", "counter", func(s *Stats) string {
+			return strconv.FormatFloat(s.Total.Seconds(), 'f', -1, 64)
+		}},
+		{"dsg_http_request_duration_seconds_max", "Longest HTTP request in seconds.", "gauge", func(s *Stats) string {
+			return strconv.FormatFloat(s.Max.Seconds(), 'f', -1, 64)
+		}},
+	}
+
+	for _, m := range metrics {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind); err != nil {
+			return err
+		}
+		for _, k := range keys {
+			_, err := fmt.Fprintf(w, "%s{service=%q,method=%q,status=%q} %s\n", m.name, k.Service, k.Method, k.Status, m.value(c.series[k]))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
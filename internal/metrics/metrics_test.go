@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c := New()
+	client := &http.Client{Transport: c.Transport("datahub", nil)}
+	for _, path := range []string{"/a", "/b", "/missing"} {
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	resp, err := client.Post(srv.URL, "application/json", strings.NewReader("[]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	failing := &http.Client{Transport: c.Transport("openai", roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	}))}
+	if _, err := failing.Get("http://openai.invalid"); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	want := map[key]int{
+		{Service: "datahub", Method: "GET", Status: "200"}:  2,
+		{Service: "datahub", Method: "GET", Status: "404"}:  1,
+		{Service: "datahub", Method: "POST", Status: "200"}: 1,
+		{Service: "openai", Method: "GET", Status: "error"}: 1,
+	}
+	if len(c.series) != len(want) {
+		t.Errorf("recorded %d series, want %d", len(c.series), len(want))
+	}
+	for k, count := range want {
+		s, ok := c.series[k]
+		if !ok {
+			t.Errorf("%+v wasn't recorded", k)
+			continue
+		}
+		if s.Count != count {
+			t.Errorf("%+v count = %d, want %d", k, s.Count, count)
+		}
+		if s.Total <= 0 || s.Max <= 0 || s.Max > s.Total {
+			t.Errorf("%+v durations = %+v", k, s)
+		}
+	}
+}
+
+// roundTripFunc is a fake transport
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRecord(t *testing.T) {
+	c := New()
+	c.Record("datahub", "POST", "200", 100*time.Millisecond)
+	c.Record("datahub", "POST", "200", 300*time.Millisecond)
+	c.Record("openai", "POST", "200", 2*time.Second)
+
+	s := c.series[key{Service: "datahub", Method: "POST", Status: "200"}]
+	if s.Count != 2 || s.Total != 400*time.Millisecond || s.Max != 300*time.Millisecond {
+		t.Errorf("stats = %+v", s)
+	}
+}
+
+func TestWriteSummary(t *testing.T) {
+	var out strings.Builder
+	if err := New().WriteSummary(&out); err != nil || out.String() != "No HTTP requests sent.\n" {
+		t.Errorf("summary = %q, err = %v", out.String(), err)
+	}
+
+	c := New()
+	c.Record("openai", "POST", "200", 2*time.Second)
+	c.Record("datahub", "POST", "200", 100*time.Millisecond)
+	c.Record("datahub", "POST", "200", 300*time.Millisecond)
+
+	out.Reset()
+	if err := c.WriteSummary(&out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "SERVICE") {
+		t.Fatalf("summary:\n%s", out.String())
+	}
+	if fields := strings.Fields(lines[1]); strings.Join(fields, " ") != "datahub POST 200 2 400ms 200ms 300ms" {
+		t.Errorf("datahub row = %q", lines[1])
+	}
+	if fields := strings.Fields(lines[2]); strings.Join(fields, " ") != "openai POST 200 1 2s 2s 2s" {
+		t.Errorf("openai row = %q", lines[2])
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	c := New()
+	c.Record("openai", "POST", "200", 1500*time.Millisecond)
+	c.Record("datahub", "GET", "404", 100*time.Millisecond)
+	c.Record("datahub", "GET", "404", 200*time.Millisecond)
+
+	var out strings.Builder
+	if err := c.WritePrometheus(&out); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP dsg_http_requests_total HTTP requests sent.
+# TYPE dsg_http_requests_total counter
+dsg_http_requests_total{service="datahub",method="GET",status="404"} 2
+dsg_http_requests_total{service="openai",method="POST",status="200"} 1
+# HELP dsg_http_request_duration_seconds_total Total duration of the HTTP requests in seconds.
+# TYPE dsg_http_request_duration_seconds_total counter
+dsg_http_request_duration_seconds_total{service="datahub",method="GET",status="404"} 0.3
+dsg_http_request_duration_seconds_total{service="openai",method="POST",status="200"} 1.5
+# HELP dsg_http_request_duration_seconds_max Longest HTTP request in seconds.
+# TYPE dsg_http_request_duration_seconds_max gauge
+dsg_http_request_duration_seconds_max{service="datahub",method="GET",status="404"} 0.2
+dsg_http_request_duration_seconds_max{service="openai",method="POST",status="200"} 1.5
+`
+	if out.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
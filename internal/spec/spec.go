@@ -0,0 +1,283 @@
+// Package spec reads structured dataset specs, a YAML alternative to free
+// text prompts that is converted into DataHub datasets without the AI.
+package spec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+	"gopkg.in/yaml.v3"
+)
+
+// Spec describes one or more datasets
+type Spec struct {
+	Datasets []DatasetSpec
+}
+
+// DatasetSpec describes a dataset
+type DatasetSpec struct {
+	Name        string      `yaml:"name"`
+	Platform    string      `yaml:"platform"`
+	Env         string      `yaml:"env"`
+	Description string      `yaml:"description"`
+	Tags        []string    `yaml:"tags"`
+	Terms       []string    `yaml:"terms"`
+	Fields      []FieldSpec `yaml:"fields"`
+}
+
+// FieldSpec describes a schema field
+type FieldSpec struct {
+	Name        string   `yaml:"name"`
+	Type        string   `yaml:"type"`
+	NativeType  string   `yaml:"nativeType"`
+	Description string   `yaml:"description"`
+	Terms       []string `yaml:"terms"`
+}
+
+// FieldTypes are the supported field types
+var FieldTypes = []string{"string", "number"}
+
+// file is the YAML layout of a spec file: either a single dataset, or a
+// datasets list with the platform and env shared by all of them
+type file struct {
+	DatasetSpec `yaml:",inline"`
+	Datasets    []DatasetSpec `yaml:"datasets"`
+}
+
+// Load reads the spec file at path
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading spec file: %w", err)
+	}
+
+	s, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Parse parses and validates a YAML spec
+func Parse(data []byte) (*Spec, error) {
+	var f file
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, invalidf("empty spec")
+		}
+		return nil, invalidf("invalid spec: %v", err)
+	}
+
+	s := &Spec{Datasets: f.Datasets}
+	if len(f.Datasets) == 0 {
+		s.Datasets = []DatasetSpec{f.DatasetSpec}
+	} else {
+		shared := f.DatasetSpec
+		if shared.Name != "" || shared.Description != "" || len(shared.Fields) > 0 || len(shared.Tags) > 0 || len(shared.Terms) > 0 {
+			return nil, invalidf("invalid spec: only platform and env can be set next to datasets")
+		}
+		for i := range s.Datasets {
+			if s.Datasets[i].Platform == "" {
+				s.Datasets[i].Platform = shared.Platform
+			}
+			if s.Datasets[i].Env == "" {
+				s.Datasets[i].Env = shared.Env
+			}
+		}
+	}
+
+	for i := range s.Datasets {
+		if err := s.Datasets[i].normalize(); err != nil {
+			return nil, fmt.Errorf("dataset %d: %w", i+1, err)
+		}
+	}
+
+	return s, nil
+}
+
+// normalize validates the dataset spec, fills in the defaults and turns
+// platform, tag and term names into URNs
+func (d *DatasetSpec) normalize() error {
+	if d.Name == "" {
+		return invalidf("name is required")
+	}
+	if d.Platform == "" {
+		return invalidf("platform is required")
+	}
+	if !strings.HasPrefix(d.Platform, "urn:li:dataPlatform:") {
+		d.Platform = "urn:li:dataPlatform:" + strings.ToLower(d.Platform)
+	}
+
+	d.Env = strings.ToUpper(d.Env)
+	if d.Env == "" {
+		d.Env = "PROD"
+	}
+	if err := datahub.ValidateFabric(d.Env); err != nil {
+		return err
+	}
+
+	for i, t := range d.Tags {
+		d.Tags[i] = prefixURN("urn:li:tag:", t)
+	}
+	for i, t := range d.Terms {
+		d.Terms[i] = prefixURN("urn:li:glossaryTerm:", t)
+	}
+
+	if len(d.Fields) == 0 {
+		return invalidf("at least one field is required")
+	}
+	seen := map[string]bool{}
+	for i := range d.Fields {
+		f := &d.Fields[i]
+		if f.Name == "" {
+			return invalidf("field %d: name is required", i+1)
+		}
+		if seen[f.Name] {
+			return invalidf("field %s: duplicated", f.Name)
+		}
+		seen[f.Name] = true
+
+		f.Type = strings.ToLower(f.Type)
+		if f.Type == "" {
+			f.Type = "string"
+		}
+		if f.Type != "string" && f.Type != "number" {
+			return invalidf("field %s: invalid type %q: must be one of %s", f.Name, f.Type, strings.Join(FieldTypes, ", "))
+		}
+		if f.NativeType == "" {
+			f.NativeType = f.Type
+		}
+		for j, t := range f.Terms {
+			f.Terms[j] = prefixURN("urn:li:glossaryTerm:", t)
+		}
+	}
+
+	return nil
+}
+
+// ToDatasets converts the spec into DataHub datasets
+func (s *Spec) ToDatasets() []datahub.Dataset {
+	datasets := make([]datahub.Dataset, 0, len(s.Datasets))
+	for _, d := range s.Datasets {
+		datasets = append(datasets, d.toDataset())
+	}
+	return datasets
+}
+
+func (d *DatasetSpec) toDataset() datahub.Dataset {
+	stamp := datahub.NewAuditStamp("")
+
+	ds := datahub.Dataset{
+		URN: datahub.DatasetURN(d.Platform, d.Name, d.Env),
+		Key: datahub.DatasetKeyContainer{Value: datahub.DatasetKey{
+			Platform: d.Platform,
+			Name:     d.Name,
+			Origin:   d.Env,
+		}},
+		SchemaMetadata: datahub.SchemaMetadataContainer{Value: datahub.SchemaMetadata{
+			SchemaName: d.Name,
+			Platform:   d.Platform,
+			Fields:     make([]datahub.SchemaField, 0, len(d.Fields)),
+		}},
+		GlobalTags: datahub.GlobalTagsContainer{Value: datahub.GlobalTags{
+			Tags: make([]datahub.TagAssociation, 0, len(d.Tags)),
+		}},
+		GlossaryTerms: datahub.GlossaryTermsContainer{Value: datahub.GlossaryTerms{
+			Terms:      termAssociations(d.Terms),
+			AuditStamp: stamp,
+		}},
+		EditableSchemaMetadata: datahub.EditableSchemaMetadataContainer{Value: datahub.EditableSchemaMetadata{
+			EditableSchemaFieldInfo: []datahub.EditableSchemaFieldInfo{},
+		}},
+	}
+
+	for _, t := range d.Tags {
+		ds.GlobalTags.Value.Tags = append(ds.GlobalTags.Value.Tags, datahub.TagAssociation{Tag: t})
+	}
+
+	if d.Description != "" {
+		ds.DatasetProperties = &datahub.DatasetPropertiesContainer{
+			Value: datahub.DatasetProperties{Description: d.Description},
+		}
+	}
+
+	for _, f := range d.Fields {
+		field := datahub.SchemaField{
+			FieldPath:      f.Name,
+			Description:    f.Description,
+			NativeDataType: f.NativeType,
+		}
+		if f.Type == "number" {
+			field.Type.Type.NumberType = &struct{}{}
+		} else {
+			field.Type.Type.StringType = &struct{}{}
+		}
+		if len(f.Terms) > 0 {
+			field.GlossaryTerms = &datahub.FieldGlossaryTermsContainer{
+				Terms:      termAssociations(f.Terms),
+				AuditStamp: stamp,
+			}
+		}
+		ds.SchemaMetadata.Value.Fields = append(ds.SchemaMetadata.Value.Fields, field)
+	}
+
+	return ds
+}
+
+// Prompt describes the spec in a prompt asking the model to generate exactly
+// the datasets specified
+func (s *Spec) Prompt() string {
+	var b strings.Builder
+	b.WriteString("Generate exactly the following datasets. Keep the names, platforms, environments, fields, types, tags and glossary terms as given, and only fill in what is missing.\n")
+	for _, d := range s.Datasets {
+		fmt.Fprintf(&b, "\nDataset %s (platform %s, environment %s)\n", d.Name, d.Platform, d.Env)
+		if d.Description != "" {
+			fmt.Fprintf(&b, "Description: %s\n", d.Description)
+		}
+		if len(d.Tags) > 0 {
+			fmt.Fprintf(&b, "Tags: %s\n", strings.Join(d.Tags, ", "))
+		}
+		if len(d.Terms) > 0 {
+			fmt.Fprintf(&b, "Glossary terms: %s\n", strings.Join(d.Terms, ", "))
+		}
+		b.WriteString("Fields:\n")
+		for _, f := range d.Fields {
+			fmt.Fprintf(&b, "- %s (%s, native type %s)", f.Name, f.Type, f.NativeType)
+			if f.Description != "" {
+				fmt.Fprintf(&b, ": %s", f.Description)
+			}
+			if len(f.Terms) > 0 {
+				fmt.Fprintf(&b, " [glossary terms: %s]", strings.Join(f.Terms, ", "))
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+func termAssociations(terms []string) []datahub.TermAssociation {
+	assocs := make([]datahub.TermAssociation, 0, len(terms))
+	for _, t := range terms {
+		assocs = append(assocs, datahub.TermAssociation{URN: t})
+	}
+	return assocs
+}
+
+// prefixURN returns s as a URN with the given prefix, if it isn't one already
+func prefixURN(prefix, s string) string {
+	if strings.HasPrefix(s, prefix) {
+		return s
+	}
+	return prefix + s
+}
+
+func invalidf(format string, args ...any) error {
+	return &datahub.ValidationError{Msg: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,145 @@
+package spec
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+)
+
+func TestLoadExample(t *testing.T) {
+	s, err := Load("../../tdata/spec.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	datasets := s.ToDatasets()
+	if len(datasets) != 2 {
+		t.Fatalf("got %d datasets, want 2", len(datasets))
+	}
+
+	customers := datasets[0]
+	if customers.URN != "urn:li:dataset:(urn:li:dataPlatform:mysql,shop.customers,PROD)" {
+		t.Errorf("URN = %q", customers.URN)
+	}
+	if customers.Key.Value != (datahub.DatasetKey{Platform: "urn:li:dataPlatform:mysql", Name: "shop.customers", Origin: "PROD"}) {
+		t.Errorf("datasetKey = %+v", customers.Key.Value)
+	}
+	schema := customers.SchemaMetadata.Value
+	if schema.SchemaName != "shop.customers" || schema.Platform != "urn:li:dataPlatform:mysql" || len(schema.Fields) != 2 {
+		t.Fatalf("schemaMetadata = %+v", schema)
+	}
+
+	id, email := schema.Fields[0], schema.Fields[1]
+	if id.FieldPath != "id" || id.Type.Type.Name() != "number" || id.NativeDataType != "BIGINT" || id.Description != "Customer identifier" {
+		t.Errorf("id field = %+v", id)
+	}
+	if email.FieldPath != "email" || email.Type.Type.Name() != "string" || email.NativeDataType != "VARCHAR(255)" {
+		t.Errorf("email field = %+v", email)
+	}
+	if email.GlossaryTerms == nil || len(email.GlossaryTerms.Terms) != 1 || email.GlossaryTerms.Terms[0].URN != "urn:li:glossaryTerm:Test.PersonalData" {
+		t.Errorf("email terms = %+v", email.GlossaryTerms)
+	}
+
+	if len(customers.GlobalTags.Value.Tags) != 1 || customers.GlobalTags.Value.Tags[0].Tag != "urn:li:tag:PII" {
+		t.Errorf("tags = %+v", customers.GlobalTags.Value.Tags)
+	}
+	terms := customers.GlossaryTerms.Value
+	if len(terms.Terms) != 1 || terms.Terms[0].URN != "urn:li:glossaryTerm:Shop.Customer" || terms.AuditStamp.Actor != datahub.DefaultActor {
+		t.Errorf("glossaryTerms = %+v", terms)
+	}
+	if customers.DatasetProperties == nil || customers.DatasetProperties.Value.Description != "Registered customers" {
+		t.Errorf("datasetProperties = %+v", customers.DatasetProperties)
+	}
+
+	// The shared platform and env apply to every dataset
+	if datasets[1].URN != "urn:li:dataset:(urn:li:dataPlatform:mysql,shop.orders,PROD)" || datasets[1].DatasetProperties != nil {
+		t.Errorf("orders = %+v", datasets[1])
+	}
+
+	// The datasets are valid DataHub JSON
+	data, err := json.Marshal(datasets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dups := datahub.DuplicateURNs(datasets); dups != nil {
+		t.Errorf("duplicate URNs %v", dups)
+	}
+	if counts := datahub.CountFieldTypes(datasets); counts.Total() != 4 || counts.Numbers != 3 {
+		t.Errorf("field types = %s", counts)
+	}
+	if !strings.Contains(string(data), `"com.linkedin.schema.NumberType":{}`) {
+		t.Errorf("the field types are not encoded: %s", data)
+	}
+}
+
+func TestParseSingleDataset(t *testing.T) {
+	s, err := Parse([]byte(`
+name: events
+platform: urn:li:dataPlatform:kafka
+env: dev
+fields:
+  - name: payload
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Datasets) != 1 {
+		t.Fatalf("got %d datasets", len(s.Datasets))
+	}
+	d := s.Datasets[0]
+	if d.Platform != "urn:li:dataPlatform:kafka" || d.Env != "DEV" {
+		t.Errorf("dataset = %+v", d)
+	}
+	// Fields are strings by default, with the same native type
+	if f := d.Fields[0]; f.Type != "string" || f.NativeType != "string" {
+		t.Errorf("field = %+v", f)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		``:                                     "empty spec",
+		`name: x`:                              "platform is required",
+		`platform: mysql`:                      "name is required",
+		"name: x\nplatform: mysql":             "at least one field is required",
+		"name: x\nplatform: mysql\nunknown: 1": "invalid spec",
+		"name: x\nplatform: mysql\nenv: nope\nfields: [{name: a}]":               `invalid DataHub environment "NOPE"`,
+		"name: x\nplatform: mysql\nfields: [{name: a, type: date}]":              `invalid type "date"`,
+		"name: x\nplatform: mysql\nfields: [{name: a}, {name: a}]":               "field a: duplicated",
+		"name: x\nplatform: mysql\nfields: [{type: string}]":                     "field 1: name is required",
+		"name: x\nplatform: mysql\ndatasets: [{name: y, fields: [{name: a}]}]":   "only platform and env can be set next to datasets",
+		"platform: mysql\ndatasets: [{name: y, fields: [{name: a}]}, {name: z}]": "dataset 2: at least one field is required",
+	}
+	for data, want := range tests {
+		_, err := Parse([]byte(data))
+		if !errors.Is(err, datahub.ErrValidation) {
+			t.Errorf("Parse(%q) error = %v, want a validation error", data, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Parse(%q) error = %v, want %q", data, err, want)
+		}
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	s, err := Load("../../tdata/spec.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prompt := s.Prompt()
+	for _, want := range []string{
+		"Dataset shop.customers (platform urn:li:dataPlatform:mysql, environment PROD)\n",
+		"Description: Registered customers\n",
+		"Tags: urn:li:tag:PII\n",
+		"Glossary terms: urn:li:glossaryTerm:Shop.Customer\n",
+		"- email (string, native type VARCHAR(255)): Contact email [glossary terms: urn:li:glossaryTerm:Test.PersonalData]\n",
+		"Dataset shop.orders (platform urn:li:dataPlatform:mysql, environment PROD)\n",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt is missing %q:\n%s", want, prompt)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// gzipMagic is the header of gzip compressed data
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// compress gzips s. The result is stored as a BLOB.
+func compress(s string) ([]byte, error) {
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := zw.Write([]byte(s)); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompress returns the plain text of a column value, which may or may not
+// be compressed
+func decompress(data []byte) (string, error) {
+	if !bytes.HasPrefix(data, gzipMagic) {
+		return string(data), nil
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress: %w", err)
+	}
+	defer zr.Close()
+
+	plain, err := io.ReadAll(zr)
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress: %w", err)
+	}
+	return string(plain), nil
+}
+
+// encode returns the value stored for a text column, compressed if enabled
+func (s *SQLiteStorage) encode(text string) (interface{}, error) {
+	if !s.compress {
+		return text, nil
+	}
+	data, err := compress(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress: %w", err)
+	}
+	return data, nil
+}
+
+// CompressHistory compresses the responses stored uncompressed.
+// It returns the number of responses compressed.
+func (s *SQLiteStorage) CompressHistory() (int, error) {
+	rows, err := s.db.Query("SELECT id, prompt, response, rendered_prompt FROM responses")
+	if err != nil {
+		return 0, fmt.Errorf("failed to query responses: %w", err)
+	}
+
+	type row struct {
+		id                               int64
+		prompt, response, renderedPrompt []byte
+	}
+	var pending []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.id, &r.prompt, &r.response, &r.renderedPrompt); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan response: %w", err)
+		}
+		if bytes.HasPrefix(r.response, gzipMagic) && bytes.HasPrefix(r.prompt, gzipMagic) {
+			continue
+		}
+		pending = append(pending, r)
+	}
+	rows.Close()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, r := range pending {
+		var values [][]byte
+		for _, col := range [][]byte{r.prompt, r.response, r.renderedPrompt} {
+			text, err := decompress(col)
+			if err != nil {
+				return 0, err
+			}
+			data, err := compress(text)
+			if err != nil {
+				return 0, fmt.Errorf("failed to compress: %w", err)
+			}
+			values = append(values, data)
+		}
+		_, err := tx.Exec("UPDATE responses SET prompt = ?, response = ?, rendered_prompt = ? WHERE id = ?", values[0], values[1], values[2], r.id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to update response: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit: %w", err)
+	}
+
+	return len(pending), nil
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+// largeResponse returns a dataset JSON array like the ones saved to history
+func largeResponse() string {
+	field := `{"fieldPath": "field", "description": "a field", "type": {"type": {"com.linkedin.schema.StringType": {}}}, "nativeDataType": "string"}`
+	return `[{"schemaMetadata": {"value": {"fields": [` + strings.Repeat(field+",", 200) + field + `]}}}]`
+}
+
+func TestCompressRoundTrip(t *testing.T) {
+	for _, text := range []string{"", "plain text", largeResponse()} {
+		data, err := compress(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := decompress(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != text {
+			t.Errorf("round trip of %q returned %q", text, got)
+		}
+	}
+
+	// Rows saved without compression are read as is
+	if got, err := decompress([]byte("plain text")); err != nil || got != "plain text" {
+		t.Errorf("decompress of plain text = %q, %v", got, err)
+	}
+}
+
+func TestCompressedStorage(t *testing.T) {
+	response := largeResponse()
+	plain := newTestStorage(t)
+	compressed := newTestStorage(t, WithCompression(true))
+
+	for _, s := range []*SQLiteStorage{plain, compressed} {
+		id, err := s.SaveResponse(&Response{Prompt: "a table", Response: response, RenderedPrompt: "system\n\na table"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := s.GetResponse(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Prompt != "a table" || got.Response != response || got.RenderedPrompt != "system\n\na table" {
+			t.Errorf("compress=%t: unexpected response %+v", s.compress, got)
+		}
+	}
+
+	plainSize, compressedSize := columnSize(t, plain), columnSize(t, compressed)
+	if compressedSize*10 > plainSize {
+		t.Errorf("compressed response is %d bytes, plain %d bytes", compressedSize, plainSize)
+	}
+}
+
+func TestCompressHistory(t *testing.T) {
+	s := newTestStorage(t)
+	response := largeResponse()
+	id, err := s.SaveResponse(&Response{Prompt: "a table", Response: response})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := columnSize(t, s)
+
+	n, err := s.CompressHistory()
+	if err != nil || n != 1 {
+		t.Fatalf("compressed %d responses, err = %v", n, err)
+	}
+	if after := columnSize(t, s); after >= before {
+		t.Errorf("response column is %d bytes after compressing, %d before", after, before)
+	}
+
+	got, err := s.GetResponse(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Response != response || got.Prompt != "a table" {
+		t.Errorf("unexpected response after compressing %+v", got)
+	}
+
+	// Compressed responses are skipped
+	if n, err := s.CompressHistory(); err != nil || n != 0 {
+		t.Errorf("compressed %d responses again, err = %v", n, err)
+	}
+}
+
+// columnSize returns the size of the stored response column of all responses
+func columnSize(t *testing.T, s *SQLiteStorage) int {
+	t.Helper()
+	var size int
+	if err := s.db.QueryRow("SELECT SUM(LENGTH(response)) FROM responses").Scan(&size); err != nil {
+		t.Fatal(err)
+	}
+	return size
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// PromptEmbedding is the embedding vector of a stored prompt
+type PromptEmbedding struct {
+	ID     int64
+	Vector []float32
+}
+
+// SetEmbedding stores the embedding vector of the prompt of a response
+func (s *SQLiteStorage) SetEmbedding(id int64, vector []float32) error {
+	_, err := s.db.Exec("UPDATE responses SET embedding = ? WHERE id = ?", encodeVector(vector), id)
+	if err != nil {
+		return fmt.Errorf("failed to save embedding: %w", err)
+	}
+	return nil
+}
+
+// ListEmbeddings returns the embeddings of all the prompts that have one
+func (s *SQLiteStorage) ListEmbeddings() ([]PromptEmbedding, error) {
+	rows, err := s.db.Query("SELECT id, embedding FROM responses WHERE embedding IS NOT NULL ORDER BY id DESC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query embeddings: %w", err)
+	}
+	defer rows.Close()
+
+	var embeddings []PromptEmbedding
+	for rows.Next() {
+		var e PromptEmbedding
+		var data []byte
+		if err := rows.Scan(&e.ID, &data); err != nil {
+			return nil, fmt.Errorf("failed to scan embedding: %w", err)
+		}
+		e.Vector = decodeVector(data)
+		embeddings = append(embeddings, e)
+	}
+
+	return embeddings, rows.Err()
+}
+
+// encodeVector encodes v as little endian float32 values
+func encodeVector(v []float32) []byte {
+	data := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(data[4*i:], math.Float32bits(f))
+	}
+	return data
+}
+
+func decodeVector(data []byte) []float32 {
+	v := make([]float32, len(data)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+	}
+	return v
+}
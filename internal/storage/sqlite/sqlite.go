@@ -2,15 +2,79 @@ package storage
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
-var defaultDataDir = filepath.Join(os.Getenv("HOME"), ".local", "share", "dsg")
+var defaultDataDir = xdgDataDir()
+
+// noHome is true when no home directory was found and defaultDataDir
+// points to a temporary location
+var noHome bool
+
+// noHomeWarnOnce prints the noHome warning once, as several storages can
+// be opened by a command
+var noHomeWarnOnce sync.Once
+
+// xdgDataDir returns $XDG_DATA_HOME/dsg, or ~/.local/share/dsg when
+// XDG_DATA_HOME is not set. Relative paths are ignored, as required by the
+// XDG base directory spec.
+func xdgDataDir() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "dsg")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		// No usable HOME, common in CI and containers. The temporary
+		// directory is shared, so the user ID keeps it per user.
+		noHome = true
+		return filepath.Join(os.TempDir(), fmt.Sprintf("dsg-%d", os.Getuid()))
+	}
+	noHome = false
+	return filepath.Join(home, ".local", "share", "dsg")
+}
+
+// privateDir creates dir only accessible by the user, failing if it already
+// exists and other users can access it or it's not a directory, as it could
+// have been created by someone else in a shared location
+func privateDir(dir string) error {
+	if err := os.Mkdir(dir, 0700); err != nil && !errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to check data directory: %w", err)
+	}
+	if !info.IsDir() || info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("data directory %s is not a private directory, use --data-dir to choose another one", dir)
+	}
+	return nil
+}
+
+// SetDefaultDataDir changes the data directory used when WithDataDir
+// is not given. An empty path restores the default.
+func SetDefaultDataDir(path string) {
+	if path == "" {
+		defaultDataDir = xdgDataDir()
+		return
+	}
+	defaultDataDir = path
+	noHome = false
+}
+
+// Response statuses
+const (
+	StatusGenerated = "generated"
+	StatusPosted    = "posted"
+	StatusFailed    = "failed"
+)
 
 // Response represents a stored OpenAI response
 type Response struct {
@@ -21,13 +85,31 @@ type Response struct {
 	SchemaURN   string
 	CreatedAt   time.Time
 	DatasetName string
+	// RenderedPrompt is the full prompt sent to the model
+	RenderedPrompt string
+	// PromptTemplate identifies the template used to render the prompt
+	PromptTemplate string
+	// Status tells whether the response has been posted to DataHub
+	Status string
+	// Seed is the OpenAI seed used to generate the response, if any
+	Seed *int64
+	// BatchID identifies the batch the response was generated in, if any
+	BatchID string
+	// Tokens is the number of OpenAI tokens used to generate the response
+	Tokens int
+	// Model is the OpenAI model that generated the response
+	Model string
 }
 
 // SQLiteStorage handles storing responses in SQLite
 type SQLiteStorage struct {
-	db      *sql.DB
-	dataDir string
-	dbPath  string
+	db       *sql.DB
+	dataDir  string
+	dbPath   string
+	compress bool
+
+	closeOnce sync.Once
+	closeErr  error
 }
 
 // Option defines a functional option for configuring SQLiteStorage
@@ -41,6 +123,14 @@ func WithDataDir(path string) Option {
 	}
 }
 
+// WithCompression gzips the prompts and responses saved to the database.
+// Compressed and plain rows are read transparently.
+func WithCompression(enabled bool) Option {
+	return func(s *SQLiteStorage) {
+		s.compress = enabled
+	}
+}
+
 // NewSQLiteStorage creates a new SQLite storage
 func NewSQLiteStorage(opts ...Option) (*SQLiteStorage, error) {
 	s := &SQLiteStorage{
@@ -53,19 +143,99 @@ func NewSQLiteStorage(opts ...Option) (*SQLiteStorage, error) {
 		opt(s)
 	}
 
+	if noHome && s.dataDir == defaultDataDir {
+		noHomeWarnOnce.Do(func() {
+			fmt.Fprintf(os.Stderr, "Warning: HOME is not set, storing the history in %s. Use --data-dir to choose another directory.\n", s.dataDir)
+		})
+		if err := privateDir(s.dataDir); err != nil {
+			return nil, err
+		}
+	}
+
 	// Create directory if it doesn't exist
 	if err := os.MkdirAll(s.dataDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create data directory: %w", err)
 	}
 
-	db, err := sql.Open("sqlite3", s.dbPath)
+	db, err := openShared(s.dbPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		return nil, err
 	}
 	s.db = db
 
+	return s, nil
+}
+
+// sharedDB is a database connection pool shared by the storages using the
+// same database file
+type sharedDB struct {
+	db   *sql.DB
+	refs int
+}
+
+var (
+	sharedMu sync.Mutex
+	shared   = map[string]*sharedDB{}
+)
+
+// openShared returns the connection pool of the database at path, opening
+// and initializing it on first use. Concurrent callers wait for the
+// initialization instead of racing to create the tables.
+func openShared(path string) (*sql.DB, error) {
+	key, err := filepath.Abs(path)
+	if err != nil {
+		key = path
+	}
+
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+
+	if sdb, ok := shared[key]; ok {
+		sdb.refs++
+		return sdb.db, nil
+	}
+
+	// Wait for the locks held by other dsg processes instead of failing
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err := initSchema(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	shared[key] = &sharedDB{db: db, refs: 1}
+	return db, nil
+}
+
+// releaseShared closes the connection pool of the database at path once
+// no storage uses it anymore
+func releaseShared(path string, db *sql.DB) error {
+	key, err := filepath.Abs(path)
+	if err != nil {
+		key = path
+	}
+
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+
+	sdb, ok := shared[key]
+	if !ok || sdb.db != db {
+		return nil
+	}
+	sdb.refs--
+	if sdb.refs > 0 {
+		return nil
+	}
+	delete(shared, key)
+	return db.Close()
+}
+
+// initSchema creates the tables and migrates the ones created by older versions
+func initSchema(db *sql.DB) error {
 	// Create table if it doesn't exist
-	_, err = db.Exec(`
+	_, err := db.Exec(`
 		CREATE TABLE IF NOT EXISTS responses (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			prompt TEXT NOT NULL,
@@ -77,30 +247,140 @@ func NewSQLiteStorage(opts ...Option) (*SQLiteStorage, error) {
 		)
 	`)
 	if err != nil {
-		db.Close()
-		return nil, fmt.Errorf("failed to create table: %w", err)
+		return fmt.Errorf("failed to create table: %w", err)
 	}
 
-	return s, nil
+	if err := migrate(db); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(createSyncTable); err != nil {
+		return fmt.Errorf("failed to create sync table: %w", err)
+	}
+
+	return nil
+}
+
+// migrations lists the columns added to the responses table after its creation
+var migrations = []struct {
+	column     string
+	definition string
+}{
+	{"rendered_prompt", "TEXT NOT NULL DEFAULT ''"},
+	{"prompt_template", "TEXT NOT NULL DEFAULT ''"},
+	{"status", "TEXT NOT NULL DEFAULT 'generated'"},
+	{"seed", "INTEGER"},
+	{"batch_id", "TEXT NOT NULL DEFAULT ''"},
+	{"embedding", "BLOB"},
+	{"tokens", "INTEGER NOT NULL DEFAULT 0"},
+	{"model", "TEXT NOT NULL DEFAULT ''"},
+}
+
+// migrate adds the columns missing from databases created by older versions
+func migrate(db *sql.DB) error {
+	rows, err := db.Query("PRAGMA table_info(responses)")
+	if err != nil {
+		return fmt.Errorf("failed to read table info: %w", err)
+	}
+
+	columns := map[string]bool{}
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			ctype     string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &ctype, &notNull, &dfltValue, &pk); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan table info: %w", err)
+		}
+		columns[name] = true
+	}
+	rows.Close()
+
+	for _, m := range migrations {
+		if columns[m.column] {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE responses ADD COLUMN %s %s", m.column, m.definition)); err != nil {
+			return fmt.Errorf("failed to add column %s: %w", m.column, err)
+		}
+	}
+
+	return nil
 }
 
-// Close closes the database connection
+// Close releases the database connection, closing it when no other
+// storage shares it. Closing twice is a no-op.
 func (s *SQLiteStorage) Close() error {
-	return s.db.Close()
+	s.closeOnce.Do(func() {
+		s.closeErr = releaseShared(s.dbPath, s.db)
+	})
+	return s.closeErr
+}
+
+// responseColumns are the columns selected to scan a Response
+const responseColumns = "id, prompt, response, schema_name, schema_urn, dataset_name, created_at, rendered_prompt, prompt_template, status, seed, batch_id, tokens, model"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanResponse scans a row selected with responseColumns
+func scanResponse(row scanner) (*Response, error) {
+	var resp Response
+	var createdAt time.Time
+	var seed sql.NullInt64
+	var prompt, response, renderedPrompt []byte
+	err := row.Scan(&resp.ID, &prompt, &response, &resp.SchemaName, &resp.SchemaURN, &resp.DatasetName, &createdAt, &renderedPrompt, &resp.PromptTemplate, &resp.Status, &seed, &resp.BatchID, &resp.Tokens, &resp.Model)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Prompt, err = decompress(prompt); err != nil {
+		return nil, err
+	}
+	if resp.Response, err = decompress(response); err != nil {
+		return nil, err
+	}
+	if resp.RenderedPrompt, err = decompress(renderedPrompt); err != nil {
+		return nil, err
+	}
+	if seed.Valid {
+		resp.Seed = &seed.Int64
+	}
+	resp.CreatedAt = createdAt
+
+	return &resp, nil
 }
 
 // SaveResponse stores a response in the database
-func (s *SQLiteStorage) SaveResponse(prompt, response, schemaName, schemaURN, datasetName string) (int64, error) {
+func (s *SQLiteStorage) SaveResponse(resp *Response) (int64, error) {
 	stmt, err := s.db.Prepare(`
-		INSERT INTO responses (prompt, response, schema_name, schema_urn, dataset_name)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO responses (prompt, response, schema_name, schema_urn, dataset_name, rendered_prompt, prompt_template, seed, batch_id, tokens, model)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`)
 	if err != nil {
 		return 0, fmt.Errorf("failed to prepare statement: %w", err)
 	}
 	defer stmt.Close()
 
-	result, err := stmt.Exec(prompt, response, schemaName, schemaURN, datasetName)
+	prompt, err := s.encode(resp.Prompt)
+	if err != nil {
+		return 0, err
+	}
+	response, err := s.encode(resp.Response)
+	if err != nil {
+		return 0, err
+	}
+	renderedPrompt, err := s.encode(resp.RenderedPrompt)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := stmt.Exec(prompt, response, resp.SchemaName, resp.SchemaURN, resp.DatasetName, renderedPrompt, resp.PromptTemplate, resp.Seed, resp.BatchID, resp.Tokens, resp.Model)
 	if err != nil {
 		return 0, fmt.Errorf("failed to insert response: %w", err)
 	}
@@ -116,13 +396,11 @@ func (s *SQLiteStorage) SaveResponse(prompt, response, schemaName, schemaURN, da
 // GetResponse retrieves a response by ID
 func (s *SQLiteStorage) GetResponse(id int64) (*Response, error) {
 	row := s.db.QueryRow(`
-		SELECT id, prompt, response, schema_name, schema_urn, dataset_name, created_at
+		SELECT `+responseColumns+`
 		FROM responses WHERE id = ?
 	`, id)
 
-	var resp Response
-	var createdAt time.Time
-	err := row.Scan(&resp.ID, &resp.Prompt, &resp.Response, &resp.SchemaName, &resp.SchemaURN, &resp.DatasetName, &createdAt)
+	resp, err := scanResponse(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("no response found with ID %d", id)
@@ -130,14 +408,14 @@ func (s *SQLiteStorage) GetResponse(id int64) (*Response, error) {
 		return nil, fmt.Errorf("failed to scan response: %w", err)
 	}
 
-	return &resp, nil
+	return resp, nil
 }
 
 // ListResponses retrieves all responses, with optional limit and offset
 func (s *SQLiteStorage) ListResponses(limit, offset int) ([]*Response, error) {
 	rows, err := s.db.Query(`
-		SELECT id, prompt, response, schema_name, schema_urn, dataset_name, created_at
-		FROM responses ORDER BY created_at DESC LIMIT ? OFFSET ?
+		SELECT `+responseColumns+`
+		FROM responses ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?
 	`, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query responses: %w", err)
@@ -146,19 +424,154 @@ func (s *SQLiteStorage) ListResponses(limit, offset int) ([]*Response, error) {
 
 	var responses []*Response
 	for rows.Next() {
-		var resp Response
-		var createdAt time.Time
-		err := rows.Scan(&resp.ID, &resp.Prompt, &resp.Response, &resp.SchemaName, &resp.SchemaURN, &resp.DatasetName, &createdAt)
+		resp, err := scanResponse(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan response: %w", err)
+		}
+
+		responses = append(responses, resp)
+	}
+
+	return responses, nil
+}
+
+// ListResponsesBefore retrieves up to limit responses with an ID lower than
+// beforeID, newest first. Unlike offsets, the pages stay stable when new
+// responses are saved while paginating.
+func (s *SQLiteStorage) ListResponsesBefore(beforeID int64, limit int) ([]*Response, error) {
+	rows, err := s.db.Query(`
+		SELECT `+responseColumns+`
+		FROM responses WHERE id < ? ORDER BY id DESC LIMIT ?
+	`, beforeID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query responses: %w", err)
+	}
+	defer rows.Close()
+
+	var responses []*Response
+	for rows.Next() {
+		resp, err := scanResponse(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan response: %w", err)
+		}
+
+		responses = append(responses, resp)
+	}
+
+	return responses, nil
+}
+
+// ListResponsesCreatedSince retrieves the responses created at or after
+// since, oldest first
+func (s *SQLiteStorage) ListResponsesCreatedSince(since time.Time) ([]*Response, error) {
+	rows, err := s.db.Query(`
+		SELECT `+responseColumns+`
+		FROM responses WHERE created_at >= ? ORDER BY created_at ASC, id ASC
+	`, since.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query responses: %w", err)
+	}
+	defer rows.Close()
+
+	var responses []*Response
+	for rows.Next() {
+		resp, err := scanResponse(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan response: %w", err)
+		}
+
+		responses = append(responses, resp)
+	}
+
+	return responses, nil
+}
+
+// ListResponsesSince retrieves up to limit responses with an ID greater than
+// id, oldest first. A limit of 0 returns all of them.
+func (s *SQLiteStorage) ListResponsesSince(id int64, limit int) ([]*Response, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := s.db.Query(`
+		SELECT `+responseColumns+`
+		FROM responses WHERE id > ? ORDER BY id ASC LIMIT ?
+	`, id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query responses: %w", err)
+	}
+	defer rows.Close()
+
+	var responses []*Response
+	for rows.Next() {
+		resp, err := scanResponse(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan response: %w", err)
+		}
+
+		responses = append(responses, resp)
+	}
+
+	return responses, nil
+}
+
+// ListPostedResponses retrieves the most recent responses successfully posted to DataHub
+func (s *SQLiteStorage) ListPostedResponses(limit int) ([]*Response, error) {
+	rows, err := s.db.Query(`
+		SELECT `+responseColumns+`
+		FROM responses WHERE status = ? ORDER BY created_at DESC, id DESC LIMIT ?
+	`, StatusPosted, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query responses: %w", err)
+	}
+	defer rows.Close()
+
+	var responses []*Response
+	for rows.Next() {
+		resp, err := scanResponse(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan response: %w", err)
 		}
 
-		responses = append(responses, &resp)
+		responses = append(responses, resp)
 	}
 
 	return responses, nil
 }
 
+// EachResponse calls fn for every response, oldest first, without loading
+// the whole history in memory. Iteration stops at the first error.
+func (s *SQLiteStorage) EachResponse(fn func(*Response) error) error {
+	rows, err := s.db.Query(`
+		SELECT ` + responseColumns + `
+		FROM responses ORDER BY id ASC
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query responses: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		resp, err := scanResponse(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan response: %w", err)
+		}
+		if err := fn(resp); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// SetStatus updates the status of a response
+func (s *SQLiteStorage) SetStatus(id int64, status string) error {
+	_, err := s.db.Exec("UPDATE responses SET status = ? WHERE id = ?", status, id)
+	if err != nil {
+		return fmt.Errorf("failed to update response status: %w", err)
+	}
+	return nil
+}
+
 // DeleteResponse deletes a response by ID
 func (s *SQLiteStorage) DeleteResponse(id int64) error {
 	_, err := s.db.Exec("DELETE FROM responses WHERE id = ?", id)
@@ -168,6 +581,29 @@ func (s *SQLiteStorage) DeleteResponse(id int64) error {
 	return nil
 }
 
+// DeleteResponsesOlderThan deletes the responses created before t, only the
+// ones with the given statuses if any. It returns the number of responses deleted.
+func (s *SQLiteStorage) DeleteResponsesOlderThan(t time.Time, statuses ...string) (int64, error) {
+	query := "DELETE FROM responses WHERE created_at < ?"
+	args := []any{t.UTC().Format("2006-01-02 15:04:05")}
+	if len(statuses) > 0 {
+		query += " AND status IN (?" + strings.Repeat(", ?", len(statuses)-1) + ")"
+		for _, status := range statuses {
+			args = append(args, status)
+		}
+	}
+
+	res, err := s.db.Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete responses: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count deleted responses: %w", err)
+	}
+	return n, nil
+}
+
 // ClearHistory deletes all response history
 func (s *SQLiteStorage) ClearHistory() error {
 	_, err := s.db.Exec("DELETE FROM responses")
@@ -176,3 +612,31 @@ func (s *SQLiteStorage) ClearHistory() error {
 	}
 	return nil
 }
+
+// Vacuum checkpoints the write-ahead log (if any) and rebuilds the database
+// file to reclaim the space left by deleted responses
+func (s *SQLiteStorage) Vacuum() error {
+	if _, err := s.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return fmt.Errorf("failed to checkpoint WAL: %w", err)
+	}
+	if _, err := s.db.Exec("VACUUM"); err != nil {
+		return fmt.Errorf("failed to vacuum database: %w", err)
+	}
+	return nil
+}
+
+// Size returns the size in bytes of the database files
+func (s *SQLiteStorage) Size() (int64, error) {
+	var size int64
+	for _, path := range []string{s.dbPath, s.dbPath + "-wal"} {
+		fi, err := os.Stat(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return 0, fmt.Errorf("failed to stat database: %w", err)
+		}
+		size += fi.Size()
+	}
+	return size, nil
+}
@@ -0,0 +1,449 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestStorage returns a storage in a temporary directory
+func newTestStorage(t *testing.T, opts ...Option) *SQLiteStorage {
+	t.Helper()
+	s, err := NewSQLiteStorage(append([]Option{WithDataDir(t.TempDir())}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// save saves n responses to s and returns their IDs
+func save(t *testing.T, s *SQLiteStorage, n int) []int64 {
+	t.Helper()
+	var ids []int64
+	for i := 0; i < n; i++ {
+		id, err := s.SaveResponse(&Response{Prompt: fmt.Sprintf("prompt %d", i), Response: "[]"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func responseIDs(responses []*Response) []int64 {
+	var ids []int64
+	for _, r := range responses {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+func TestKeysetMatchesOffset(t *testing.T) {
+	s := newTestStorage(t)
+	save(t, s, 7)
+
+	var offsetIDs, keysetIDs []int64
+	for offset := 0; ; offset += 3 {
+		page, err := s.ListResponses(3, offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		offsetIDs = append(offsetIDs, responseIDs(page)...)
+	}
+
+	before := int64(1 << 62)
+	for {
+		page, err := s.ListResponsesBefore(before, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		keysetIDs = append(keysetIDs, responseIDs(page)...)
+		before = page[len(page)-1].ID
+	}
+
+	if want := []int64{7, 6, 5, 4, 3, 2, 1}; !slices.Equal(offsetIDs, want) || !slices.Equal(keysetIDs, want) {
+		t.Errorf("offset pages = %v, keyset pages = %v, want %v", offsetIDs, keysetIDs, want)
+	}
+}
+
+func TestKeysetStableUnderInserts(t *testing.T) {
+	s := newTestStorage(t)
+	existing := save(t, s, 20)
+
+	// Save new responses while paginating
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			if _, err := s.SaveResponse(&Response{Prompt: "new", Response: "[]"}); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	page, err := s.ListResponses(4, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := page[len(page)-1].ID
+	seen := responseIDs(page)
+	for {
+		page, err := s.ListResponsesBefore(before, 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		seen = append(seen, responseIDs(page)...)
+		before = page[len(page)-1].ID
+	}
+	wg.Wait()
+
+	// Every response older than the first page is listed once, in order
+	for i := 1; i < len(seen); i++ {
+		if seen[i] >= seen[i-1] {
+			t.Fatalf("responses listed out of order or twice: %v", seen)
+		}
+	}
+	for _, id := range existing {
+		if seen[0] >= id && !slices.Contains(seen, id) {
+			t.Errorf("response %d was skipped: %v", id, seen)
+		}
+	}
+}
+
+func TestVacuum(t *testing.T) {
+	s := newTestStorage(t, WithCompression(false))
+	ids := save(t, s, 50)
+	for _, id := range ids[:40] {
+		if err := s.DeleteResponse(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.Vacuum(); err != nil {
+		t.Fatal(err)
+	}
+	if size, err := s.Size(); err != nil || size == 0 {
+		t.Errorf("size = %d, err = %v", size, err)
+	}
+
+	// The database is still usable
+	responses, err := s.ListResponses(100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 10 {
+		t.Errorf("listed %d responses after vacuum, want 10", len(responses))
+	}
+	id, err := s.SaveResponse(&Response{Prompt: "after vacuum", Response: "[]"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := s.GetResponse(id); err != nil || resp.Prompt != "after vacuum" {
+		t.Errorf("response = %+v, err = %v", resp, err)
+	}
+}
+
+// keepNoHome restores noHome, changed by xdgDataDir, when the test ends
+func keepNoHome(t *testing.T) {
+	saved := noHome
+	t.Cleanup(func() { noHome = saved })
+}
+
+func TestXDGDataDir(t *testing.T) {
+	keepNoHome(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	t.Setenv("XDG_DATA_HOME", "/xdg/data")
+	if got := xdgDataDir(); got != filepath.Join("/xdg/data", "dsg") {
+		t.Errorf("with XDG_DATA_HOME: %q", got)
+	}
+
+	for _, xdg := range []string{"", "relative/data"} {
+		t.Setenv("XDG_DATA_HOME", xdg)
+		if got := xdgDataDir(); got != filepath.Join(home, ".local", "share", "dsg") {
+			t.Errorf("with XDG_DATA_HOME=%q: %q", xdg, got)
+		}
+	}
+	if noHome {
+		t.Error("noHome is set with a HOME")
+	}
+}
+
+func TestNoHome(t *testing.T) {
+	keepNoHome(t)
+	t.Setenv("HOME", "")
+	t.Setenv("XDG_DATA_HOME", "")
+
+	want := filepath.Join(os.TempDir(), fmt.Sprintf("dsg-%d", os.Getuid()))
+	if got := xdgDataDir(); got != want || !noHome {
+		t.Errorf("xdgDataDir = %q, noHome = %v, want %q", got, noHome, want)
+	}
+
+	// The default data directory is created private
+	savedDir := defaultDataDir
+	t.Cleanup(func() { defaultDataDir = savedDir })
+	defaultDataDir = filepath.Join(t.TempDir(), "dsg")
+	noHomeWarnOnce = sync.Once{}
+	stderr := captureStderr(t, func() {
+		for range 2 {
+			s, err := NewSQLiteStorage()
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.Close()
+		}
+	})
+	if n := strings.Count(stderr, "HOME is not set"); n != 1 {
+		t.Errorf("warned %d times, want 1: %q", n, stderr)
+	}
+	if info, err := os.Stat(defaultDataDir); err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("data directory info = %v, err = %v", info, err)
+	}
+}
+
+// captureStderr returns what f writes to stderr
+func captureStderr(t *testing.T, f func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	out := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- string(data)
+	}()
+
+	f()
+	w.Close()
+
+	return <-out
+}
+
+func TestPrivateDir(t *testing.T) {
+	base := t.TempDir()
+
+	dir := filepath.Join(base, "new")
+	if err := privateDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	// Existing private directories are reused
+	if err := privateDir(dir); err != nil {
+		t.Errorf("existing private directory: %v", err)
+	}
+
+	shared := filepath.Join(base, "shared")
+	os.Mkdir(shared, 0755)
+	os.Chmod(shared, 0755)
+	if err := privateDir(shared); err == nil {
+		t.Error("a directory accessible by other users was accepted")
+	}
+
+	file := filepath.Join(base, "file")
+	os.WriteFile(file, nil, 0600)
+	if err := privateDir(file); err == nil {
+		t.Error("a file was accepted")
+	}
+
+	link := filepath.Join(base, "link")
+	os.Symlink(dir, link)
+	if err := privateDir(link); err == nil {
+		t.Error("a symlink was accepted")
+	}
+}
+
+func TestEmbeddings(t *testing.T) {
+	s := newTestStorage(t)
+	ids := save(t, s, 3)
+
+	vector := []float32{0.5, -1.25, 3e-8, 0}
+	if err := s.SetEmbedding(ids[1], vector); err != nil {
+		t.Fatal(err)
+	}
+	embeddings, err := s.ListEmbeddings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(embeddings) != 1 || embeddings[0].ID != ids[1] || !slices.Equal(embeddings[0].Vector, vector) {
+		t.Errorf("embeddings = %+v", embeddings)
+	}
+}
+
+func TestListResponsesSince(t *testing.T) {
+	s := newTestStorage(t)
+	ids := save(t, s, 5)
+
+	tests := []struct {
+		after int64
+		limit int
+		want  []int64
+	}{
+		{after: ids[1], limit: 0, want: ids[2:]},
+		{after: ids[1], limit: 2, want: ids[2:4]},
+		{after: 0, limit: 0, want: ids},
+		{after: ids[4], limit: 0, want: nil},
+	}
+	for _, tt := range tests {
+		responses, err := s.ListResponsesSince(tt.after, tt.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := responseIDs(responses); !slices.Equal(got, tt.want) {
+			t.Errorf("ListResponsesSince(%d, %d) = %v, want %v", tt.after, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestLastSyncedID(t *testing.T) {
+	s := newTestStorage(t)
+
+	if id, err := s.LastSyncedID("http://a"); err != nil || id != 0 {
+		t.Errorf("LastSyncedID before syncing = %d, %v", id, err)
+	}
+	for _, id := range []int64{3, 7} {
+		if err := s.SetLastSyncedID("http://a", id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.SetLastSyncedID("http://b", 1); err != nil {
+		t.Fatal(err)
+	}
+	if id, err := s.LastSyncedID("http://a"); err != nil || id != 7 {
+		t.Errorf("LastSyncedID(a) = %d, %v, want 7", id, err)
+	}
+	if id, err := s.LastSyncedID("http://b"); err != nil || id != 1 {
+		t.Errorf("LastSyncedID(b) = %d, %v, want 1", id, err)
+	}
+}
+
+func TestConcurrentNewSQLiteStorage(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nested", "data")
+
+	const n = 20
+	storages := make([]*SQLiteStorage, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s, err := NewSQLiteStorage(WithDataDir(dir))
+			if err == nil {
+				_, err = s.SaveResponse(&Response{Prompt: fmt.Sprintf("prompt %d", i), Response: "[]"})
+			}
+			storages[i], errs[i] = s, err
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("storage %d: %v", i, err)
+		}
+	}
+	for _, s := range storages[1:] {
+		if s.db != storages[0].db {
+			t.Fatal("the storages don't share the database connection")
+		}
+	}
+
+	// Closing some storages leaves the shared connection usable
+	for _, s := range storages[1:] {
+		if err := s.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	storages[1].Close()
+	s := storages[0]
+	responses, err := s.ListResponses(n+1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != n {
+		t.Errorf("listed %d responses, want %d", len(responses), n)
+	}
+
+	// The last one closes it, and a new storage opens it again
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.db.Ping(); err == nil {
+		t.Error("the database connection is still open")
+	}
+	reopened := newTestStorage(t, WithDataDir(dir))
+	if listed, err := reopened.ListResponses(n+1, 0); err != nil || len(listed) != n {
+		t.Errorf("listed %d responses after reopening, err = %v", len(listed), err)
+	}
+}
+
+// setCreatedAt backdates the creation time of the response id
+func setCreatedAt(t *testing.T, s *SQLiteStorage, id int64, createdAt time.Time) {
+	t.Helper()
+	if _, err := s.db.Exec("UPDATE responses SET created_at = ? WHERE id = ?", createdAt.UTC().Format("2006-01-02 15:04:05"), id); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeleteResponsesOlderThan(t *testing.T) {
+	s := newTestStorage(t)
+	ids := save(t, s, 6)
+	now := time.Now()
+	ages := []time.Duration{200 * 24 * time.Hour, 100 * 24 * time.Hour, 91 * 24 * time.Hour, 89 * 24 * time.Hour, time.Hour, 0}
+	for i, age := range ages {
+		setCreatedAt(t, s, ids[i], now.Add(-age))
+	}
+	s.SetStatus(ids[0], StatusPosted)
+	s.SetStatus(ids[2], StatusFailed)
+
+	cutoff := now.Add(-90 * 24 * time.Hour)
+
+	// Only the old entries with the given statuses
+	n, err := s.DeleteResponsesOlderThan(cutoff, StatusPosted, StatusGenerated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("deleted %d responses, want 2", n)
+	}
+	remaining, _ := s.ListResponses(10, 0)
+	if got, want := responseIDs(remaining), []int64{ids[5], ids[4], ids[3], ids[2]}; !slices.Equal(got, want) {
+		t.Errorf("remaining responses = %v, want %v", got, want)
+	}
+
+	// Any status
+	if n, err := s.DeleteResponsesOlderThan(cutoff); err != nil || n != 1 {
+		t.Errorf("deleted %d responses, err = %v, want 1", n, err)
+	}
+	remaining, _ = s.ListResponses(10, 0)
+	if got, want := responseIDs(remaining), []int64{ids[5], ids[4], ids[3]}; !slices.Equal(got, want) {
+		t.Errorf("remaining responses = %v, want %v", got, want)
+	}
+
+	if n, err := s.DeleteResponsesOlderThan(cutoff); err != nil || n != 0 {
+		t.Errorf("deleted %d responses, err = %v, want none", n, err)
+	}
+}
@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// createSyncTable creates the table keeping the last response synced to each
+// DataHub instance
+const createSyncTable = `
+	CREATE TABLE IF NOT EXISTS sync_state (
+		target TEXT PRIMARY KEY,
+		last_id INTEGER NOT NULL
+	)
+`
+
+// LastSyncedID returns the ID of the last response synced to target, or 0
+// if nothing has been synced yet
+func (s *SQLiteStorage) LastSyncedID(target string) (int64, error) {
+	var id int64
+	err := s.db.QueryRow("SELECT last_id FROM sync_state WHERE target = ?", target).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read sync state: %w", err)
+	}
+	return id, nil
+}
+
+// SetLastSyncedID records id as the last response synced to target
+func (s *SQLiteStorage) SetLastSyncedID(target string, id int64) error {
+	_, err := s.db.Exec(`
+		INSERT INTO sync_state (target, last_id) VALUES (?, ?)
+		ON CONFLICT(target) DO UPDATE SET last_id = excluded.last_id
+	`, target, id)
+	if err != nil {
+		return fmt.Errorf("failed to save sync state: %w", err)
+	}
+	return nil
+}
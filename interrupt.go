@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+var (
+	cleanupMu sync.Mutex
+	cleanups  []func()
+)
+
+// atInterrupt registers f to be called if the command is interrupted.
+// Cleanup functions run in reverse registration order.
+func atInterrupt(f func()) {
+	cleanupMu.Lock()
+	defer cleanupMu.Unlock()
+	cleanups = append(cleanups, f)
+}
+
+// runCleanups runs and unregisters the cleanup functions
+func runCleanups() {
+	cleanupMu.Lock()
+	defer cleanupMu.Unlock()
+	for i := len(cleanups) - 1; i >= 0; i-- {
+		cleanups[i]()
+	}
+	cleanups = nil
+}
+
+// handleInterrupts cancels the command context, runs the cleanup functions
+// and exits when SIGINT or SIGTERM is received
+func handleInterrupts(cancel context.CancelFunc) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-ch
+		fmt.Fprintln(os.Stderr, "\nInterrupted, cleaning up...")
+		cancel()
+		runCleanups()
+		os.Exit(130)
+	}()
+}
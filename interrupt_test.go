@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	storage "github.com/rubiojr/dsg/internal/storage/sqlite"
+)
+
+func TestRunCleanups(t *testing.T) {
+	// Forget the cleanups registered by the other tests
+	cleanupMu.Lock()
+	cleanups = nil
+	cleanupMu.Unlock()
+
+	dir := t.TempDir()
+	db, err := storage.NewSQLiteStorage(storage.WithDataDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prompt := filepath.Join(dir, "prompt")
+	response := prompt + ".response.json"
+	for _, path := range []string{prompt, response} {
+		if err := os.WriteFile(path, []byte("{}"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var order []string
+	atInterrupt(func() { order = append(order, "db"); db.Close() })
+	atInterrupt(func() { order = append(order, "prompt"); os.Remove(prompt) })
+	atInterrupt(func() { order = append(order, "response"); os.Remove(response) })
+
+	runCleanups()
+
+	if want := []string{"response", "prompt", "db"}; !slices.Equal(order, want) {
+		t.Errorf("cleanups ran in order %v, want %v", order, want)
+	}
+	for _, path := range []string{prompt, response} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s was not removed", path)
+		}
+	}
+	if _, err := db.ListResponses(1, 0); err == nil {
+		t.Error("the database is still open")
+	}
+
+	// The database is consistent and can be opened again
+	db, err = storage.NewSQLiteStorage(storage.WithDataDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.ListResponses(1, 0); err != nil {
+		t.Error(err)
+	}
+
+	// Cleanups run once
+	order = nil
+	runCleanups()
+	if len(order) != 0 {
+		t.Errorf("cleanups ran again: %v", order)
+	}
+}
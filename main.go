@@ -3,20 +3,27 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"text/template"
 
 	_ "embed"
 
 	"time"
 
 	"github.com/rubiojr/dsg/internal/datahub"
+	"github.com/rubiojr/dsg/internal/datahub/graphql"
 	"github.com/rubiojr/dsg/internal/log"
+	"github.com/rubiojr/dsg/internal/metrics"
+	"github.com/rubiojr/dsg/internal/spec"
 	storage "github.com/rubiojr/dsg/internal/storage/sqlite"
 	"github.com/sashabaranov/go-openai"
 	"github.com/urfave/cli/v2"
@@ -25,16 +32,136 @@ import (
 //go:embed tdata/schema.json
 var trainingDataset string
 
-func main() {
-	app := &cli.App{
+// newApp returns the dsg command line application
+func newApp() *cli.App {
+	var start time.Time
+
+	return &cli.App{
 		Name:  "dsg",
 		Usage: "AI assisted DataHub dataset generator",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "log-format",
+				EnvVars: []string{"DSG_LOG_FORMAT"},
+				Usage:   "Log format (text or json)",
+				Value:   log.FormatText,
+			},
+			&cli.StringFlag{
+				Name:    "data-dir",
+				EnvVars: []string{"DSG_DATA_DIR"},
+				Usage:   "Directory where the history database is stored (defaults to $XDG_DATA_HOME/dsg or ~/.local/share/dsg)",
+			},
+			&cli.IntFlag{
+				Name:    "rate-limit",
+				EnvVars: []string{"DSG_RATE_LIMIT"},
+				Usage:   "Maximum number of entities posted to DataHub per second (0 for no limit)",
+			},
+			&cli.BoolFlag{
+				Name:  "no-color",
+				Usage: "Disable the progress spinner and other terminal decorations (or set NO_COLOR)",
+			},
+			&cli.DurationFlag{
+				Name:    "timeout",
+				EnvVars: []string{"DSG_TIMEOUT"},
+				Usage:   "Maximum duration of the whole command, e.g. 5m, including the AI and DataHub requests (0 for no limit)",
+			},
+			&cli.DurationFlag{
+				Name:    "datahub-timeout",
+				EnvVars: []string{"DATAHUB_TIMEOUT"},
+				Usage:   "Timeout of every DataHub request, e.g. 30s (0 for no timeout)",
+			},
+			&cli.IntFlag{
+				Name:    "datahub-max-retries",
+				EnvVars: []string{"DATAHUB_MAX_RETRIES"},
+				Usage:   "Number of times a rate limited (429) DataHub request is retried",
+				Value:   datahub.DefaultMaxRetries,
+			},
+			&cli.IntFlag{
+				Name:    "datahub-page-retries",
+				EnvVars: []string{"DATAHUB_PAGE_RETRIES"},
+				Usage:   "Number of times a DataHub listing page failing with a transient error is retried",
+				Value:   datahub.DefaultPageRetries,
+			},
+			&cli.BoolFlag{
+				Name:    "gzip",
+				EnvVars: []string{"DATAHUB_GZIP"},
+				Usage:   "Compress the entities posted to DataHub with gzip (the server must accept gzip encoded requests)",
+			},
+			&cli.StringFlag{
+				Name:    "proxy",
+				EnvVars: []string{"DSG_PROXY"},
+				Usage:   "Proxy URL for the DataHub and OpenAI requests (defaults to HTTP_PROXY and HTTPS_PROXY)",
+			},
+			&cli.BoolFlag{
+				Name:    "insecure-skip-verify",
+				EnvVars: []string{"DATAHUB_INSECURE_SKIP_VERIFY"},
+				Usage:   "Do not verify the TLS certificate of DataHub (insecure, prefer --ca-cert)",
+			},
+			&cli.StringFlag{
+				Name:    "ca-cert",
+				EnvVars: []string{"DATAHUB_CA_CERT"},
+				Usage:   "PEM file with the CA certificates trusted to verify the TLS certificate of DataHub",
+			},
+			&cli.BoolFlag{
+				Name:    "metrics",
+				EnvVars: []string{"DSG_METRICS"},
+				Usage:   "Print the count and duration of the DataHub and OpenAI requests to stderr at exit",
+			},
+			&cli.StringFlag{
+				Name:    "metrics-file",
+				EnvVars: []string{"DSG_METRICS_FILE"},
+				Usage:   "Write the count and duration of the DataHub and OpenAI requests to this file in Prometheus text format at exit",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			start = time.Now()
+			if err := log.SetFormat(c.String("log-format")); err != nil {
+				return err
+			}
+			storage.SetDefaultDataDir(c.String("data-dir"))
+			datahubRateLimit = c.Int("rate-limit")
+			noColor = c.Bool("no-color") || os.Getenv("NO_COLOR") != ""
+			if commandTimeout = c.Duration("timeout"); commandTimeout > 0 {
+				// Commands inherit the context, cancelling their requests
+				c.Context, cancelTimeout = context.WithTimeout(c.Context, commandTimeout)
+				timeoutContext = c.Context
+			}
+			datahubTimeout = c.Duration("datahub-timeout")
+			datahubMaxRetries = c.Int("datahub-max-retries")
+			datahubPageRetries = c.Int("datahub-page-retries")
+			datahubGzip = c.Bool("gzip")
+			var err error
+			if proxy := c.String("proxy"); proxy != "" {
+				if httpProxy, err = parseProxy(proxy); err != nil {
+					return err
+				}
+			}
+			datahubTLS, err = datahub.NewTLSConfig(c.Bool("insecure-skip-verify"), c.String("ca-cert"))
+			if err != nil {
+				return err
+			}
+			if c.Bool("metrics") || c.String("metrics-file") != "" {
+				metricsCollector = metrics.New()
+			}
+			log.AddField("command", c.Args().First())
+			return nil
+		},
+		After: func(c *cli.Context) error {
+			log.DebugWith(log.Fields{"duration": time.Since(start).String()}, "command finished")
+			return writeMetrics(c)
+		},
 		Commands: []*cli.Command{
 			{
 				Name:   "add-term",
 				Usage:  "Add a glossary term to DataHub",
 				Action: runAddGlossaryTerm,
 				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "api",
+						EnvVars: []string{"DATAHUB_API"},
+						Usage:   "DataHub API used to send the entities (openapi or graphql)",
+						Value:   apiOpenAPI,
+					},
 					&cli.StringFlag{
 						Name:    "datahub-gms-url",
 						EnvVars: []string{"DATAHUB_GMS_URL"},
@@ -46,6 +173,10 @@ func main() {
 						EnvVars: []string{"DATAHUB_GMS_TOKEN"},
 						Usage:   "DataHub token",
 					},
+					&cli.BoolFlag{
+						Name:  "strict-instances",
+						Usage: "With several --datahub-gms-url, stop at the first failing instance instead of posting to the rest",
+					},
 					&cli.StringFlag{
 						Name:     "name",
 						Usage:    "Glossary Term name",
@@ -60,6 +191,58 @@ func main() {
 						Usage:    "Glossary Term definition",
 						Required: false,
 					},
+					&cli.StringFlag{
+						Name:  "term-source",
+						Usage: "Glossary Term source (" + strings.Join(datahub.TermSources, ", ") + ")",
+						Value: "INTERNAL",
+					},
+					&cli.StringFlag{
+						Name:  "actor",
+						Usage: "Actor URN recorded as the creator and owner of the term, e.g. urn:li:corpuser:jdoe",
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print the post report as JSON",
+					},
+				},
+			},
+			{
+				Name:   "update-term",
+				Usage:  "Update the definition of a DataHub glossary term",
+				Action: runUpdateGlossaryTerm,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "api",
+						EnvVars: []string{"DATAHUB_API"},
+						Usage:   "DataHub API used to send the entities (openapi or graphql)",
+						Value:   apiOpenAPI,
+					},
+					&cli.StringFlag{
+						Name:    "datahub-gms-url",
+						EnvVars: []string{"DATAHUB_GMS_URL"},
+						Usage:   "DataHub URL",
+						Value:   "https://api.datahub.io",
+					},
+					&cli.StringFlag{
+						Name:    "datahub-gms-token",
+						EnvVars: []string{"DATAHUB_GMS_TOKEN"},
+						Usage:   "DataHub token",
+					},
+					&cli.StringFlag{
+						Name:     "urn",
+						Usage:    "Glossary Term URN",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "definition",
+						Usage:    "Glossary Term definition",
+						Required: true,
+					},
+					&cli.BoolFlag{
+						Name:  "append-definition",
+						Usage: "Append to the current definition instead of replacing it",
+						Value: false,
+					},
 				},
 			},
 			{
@@ -79,14 +262,37 @@ func main() {
 						EnvVars: []string{"DATAHUB_GMS_TOKEN"},
 						Usage:   "DataHub token",
 					},
+					&cli.BoolFlag{
+						Name:  "strict-instances",
+						Usage: "With several --datahub-gms-url, stop at the first failing instance instead of posting to the rest",
+					},
+					&cli.BoolFlag{
+						Name:  "continue-on-error",
+						Usage: "Keep posting the remaining entities when one fails",
+						Value: false,
+					},
+					&cli.BoolFlag{
+						Name:  "strict",
+						Usage: "Fail instead of warning when entities share a URN",
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print the post report as JSON",
+					},
 				},
 			},
 			{
 				Name:      "from-json",
-				Usage:     "Create a dataset from a JSON file",
-				ArgsUsage: "FILE",
+				Usage:     "Create a dataset from a JSON file (or JSON piped to stdin)",
+				ArgsUsage: "[FILE]",
 				Action:    runFromJSON,
 				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "api",
+						EnvVars: []string{"DATAHUB_API"},
+						Usage:   "DataHub API used to send the entities (openapi or graphql)",
+						Value:   apiOpenAPI,
+					},
 					&cli.StringFlag{
 						Name:    "datahub-gms-url",
 						EnvVars: []string{"DATAHUB_GMS_URL"},
@@ -98,11 +304,152 @@ func main() {
 						EnvVars: []string{"DATAHUB_GMS_TOKEN"},
 						Usage:   "DataHub token",
 					},
+					&cli.BoolFlag{
+						Name:  "strict-instances",
+						Usage: "With several --datahub-gms-url, stop at the first failing instance instead of posting to the rest",
+					},
+					&cli.BoolFlag{
+						Name:  "continue-on-error",
+						Usage: "Keep posting the remaining entities when one fails",
+						Value: false,
+					},
+					&cli.BoolFlag{
+						Name:  "strict",
+						Usage: "Fail instead of warning when entities share a URN",
+					},
+					&cli.BoolFlag{
+						Name:  "disallow-unknown-fields",
+						Usage: "Reject entities with fields unknown to dsg",
+					},
+					&cli.StringFlag{
+						Name:  "entity-type",
+						Usage: "Entity type to send (dataset, glossaryTerm or tag), detected from the JSON if not set",
+					},
+					&cli.StringFlag{
+						Name:  "merge-into",
+						Usage: "Add the fields of the dataset to this existing dataset URN instead of creating it",
+					},
+					&cli.StringFlag{
+						Name:  "output-dir",
+						Usage: "Also write each dataset to DIR/<schema name>.json",
+					},
+					&cli.StringFlag{
+						Name:  "output-urns-file",
+						Usage: "Write the URNs created to FILE, one per line or as a JSON array if FILE ends in .json",
+					},
+					&cli.BoolFlag{
+						Name:  "pretty",
+						Usage: "Indent the JSON payload before posting it",
+					},
+					&cli.BoolFlag{
+						Name:  "compact",
+						Usage: "Post the JSON payload as-is (default)",
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print the post report as JSON",
+					},
+				},
+			},
+			{
+				Name:   "from-sql",
+				Usage:  "Create datasets from the CREATE TABLE statements of a SQL file (or SQL piped to stdin)",
+				Action: runFromSQL,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "datahub-gms-url",
+						EnvVars: []string{"DATAHUB_GMS_URL"},
+						Usage:   "DataHub URL",
+						Value:   "https://api.datahub.io",
+					},
 					&cli.StringFlag{
-						Name:     "entity-type",
-						Usage:    "Entity type to send (dataset, glossaryTerm, tag, etc)",
+						Name:    "datahub-gms-token",
+						EnvVars: []string{"DATAHUB_GMS_TOKEN"},
+						Usage:   "DataHub token",
+					},
+					&cli.BoolFlag{
+						Name:  "strict-instances",
+						Usage: "With several --datahub-gms-url, stop at the first failing instance instead of posting to the rest",
+					},
+					&cli.StringFlag{
+						Name:     "file",
+						Usage:    "SQL file with the CREATE TABLE statements, - reads stdin",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:  "platform",
+						Usage: "DataHub platform of the datasets (name or URN)",
+						Value: "mysql",
+					},
+					&cli.StringFlag{
+						Name:    "datahub-env",
+						EnvVars: []string{"DATAHUB_ENV"},
+						Usage:   "DataHub environment (origin) of the datasets (" + strings.Join(datahub.Fabrics, ", ") + ")",
+						Value:   "PROD",
+					},
+					&cli.BoolFlag{
+						Name:  "stdout",
+						Usage: "Print the datasets instead of posting them",
+					},
+					&cli.BoolFlag{
+						Name:  "continue-on-error",
+						Usage: "Keep posting the remaining entities when one fails",
+						Value: false,
+					},
+				},
+			},
+			{
+				Name:   "from-schema",
+				Usage:  "Create a dataset from an Avro schema or a JSON Schema",
+				Action: runFromSchema,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "datahub-gms-url",
+						EnvVars: []string{"DATAHUB_GMS_URL"},
+						Usage:   "DataHub URL",
+						Value:   "https://api.datahub.io",
+					},
+					&cli.StringFlag{
+						Name:    "datahub-gms-token",
+						EnvVars: []string{"DATAHUB_GMS_TOKEN"},
+						Usage:   "DataHub token",
+					},
+					&cli.BoolFlag{
+						Name:  "strict-instances",
+						Usage: "With several --datahub-gms-url, stop at the first failing instance instead of posting to the rest",
+					},
+					&cli.StringFlag{
+						Name:  "avro",
+						Usage: "Avro schema file (.avsc) with a record",
+					},
+					&cli.StringFlag{
+						Name:  "json-schema",
+						Usage: "JSON Schema file describing an object",
+					},
+					&cli.StringFlag{
+						Name:  "name",
+						Usage: "Dataset name, defaults to the record full name or the JSON Schema title",
+					},
+					&cli.StringFlag{
+						Name:     "platform",
+						Usage:    "DataHub platform of the dataset (name or URN)",
 						Required: true,
 					},
+					&cli.StringFlag{
+						Name:    "datahub-env",
+						EnvVars: []string{"DATAHUB_ENV"},
+						Usage:   "DataHub environment (origin) of the dataset (" + strings.Join(datahub.Fabrics, ", ") + ")",
+						Value:   "PROD",
+					},
+					&cli.BoolFlag{
+						Name:  "stdout",
+						Usage: "Print the dataset instead of posting it",
+					},
+					&cli.BoolFlag{
+						Name:  "continue-on-error",
+						Usage: "Keep posting the remaining entities when one fails",
+						Value: false,
+					},
 				},
 			},
 			{
@@ -110,6 +457,59 @@ func main() {
 				Usage:     "Post a previously saved response to DataHub",
 				ArgsUsage: "HISTORY_ID",
 				Action:    runPostHistory,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "pretty",
+						Usage: "Indent the JSON payload before posting it",
+					},
+					&cli.BoolFlag{
+						Name:  "compact",
+						Usage: "Post the JSON payload as-is (default)",
+					},
+					&cli.StringFlag{
+						Name:    "datahub-ui-url",
+						EnvVars: []string{"DATAHUB_UI_URL"},
+						Usage:   "DataHub UI URL (derived from the DataHub URL if not set)",
+					},
+					&cli.StringFlag{
+						Name:    "datahub-gms-url",
+						EnvVars: []string{"DATAHUB_GMS_URL"},
+						Usage:   "DataHub URL",
+						Value:   "https://api.datahub.io",
+					},
+					&cli.StringFlag{
+						Name:    "datahub-gms-token",
+						EnvVars: []string{"DATAHUB_GMS_TOKEN"},
+						Usage:   "DataHub token",
+					},
+					&cli.BoolFlag{
+						Name:  "strict-instances",
+						Usage: "With several --datahub-gms-url, stop at the first failing instance instead of posting to the rest",
+					},
+					&cli.BoolFlag{
+						Name:  "continue-on-error",
+						Usage: "Keep posting the remaining entities when one fails",
+						Value: false,
+					},
+					&cli.BoolFlag{
+						Name:  "strict",
+						Usage: "Fail instead of warning when entities share a URN",
+					},
+					&cli.StringFlag{
+						Name:  "output-urns-file",
+						Usage: "Write the URNs created to FILE, one per line or as a JSON array if FILE ends in .json",
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print the post report as JSON",
+					},
+				},
+			},
+			{
+				Name:      "browse",
+				Usage:     "Browse DataHub datasets by browse path",
+				ArgsUsage: "[PATH]",
+				Action:    runBrowse,
 				Flags: []cli.Flag{
 					&cli.StringFlag{
 						Name:    "datahub-gms-url",
@@ -125,45 +525,299 @@ func main() {
 				},
 			},
 			{
-				Name:   "generate",
-				Usage:  "Generate a new dataset",
-				Action: runGenerate,
+				Name:   "delete-entity",
+				Usage:  "Delete the DataHub datasets of a platform or with a name prefix",
+				Action: runDeleteEntity,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "datahub-gms-url",
+						EnvVars: []string{"DATAHUB_GMS_URL"},
+						Usage:   "DataHub URL",
+						Value:   "https://api.datahub.io",
+					},
+					&cli.StringFlag{
+						Name:    "datahub-gms-token",
+						EnvVars: []string{"DATAHUB_GMS_TOKEN"},
+						Usage:   "DataHub token",
+					},
+					&cli.StringFlag{
+						Name:  "platform",
+						Usage: "Only delete the datasets of this platform (name or URN)",
+					},
+					&cli.StringFlag{
+						Name:  "prefix",
+						Usage: "Only delete the datasets whose name starts with this prefix",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "List the datasets that would be deleted without deleting them",
+					},
+					&cli.BoolFlag{
+						Name:    "force",
+						Aliases: []string{"f"},
+						Usage:   "Delete without confirmation",
+					},
+					&cli.IntFlag{
+						Name:  "concurrency",
+						Usage: "Number of datasets deleted in parallel",
+						Value: 4,
+					},
+				},
+			},
+			{
+				Name:      "ensure-terms",
+				Usage:     "Create the glossary terms referenced by the datasets in a JSON file that don't exist in DataHub",
+				ArgsUsage: "[FILE]",
+				Action:    runEnsureTerms,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "datahub-gms-url",
+						EnvVars: []string{"DATAHUB_GMS_URL"},
+						Usage:   "DataHub URL",
+						Value:   "https://api.datahub.io",
+					},
+					&cli.StringFlag{
+						Name:    "datahub-gms-token",
+						EnvVars: []string{"DATAHUB_GMS_TOKEN"},
+						Usage:   "DataHub token",
+					},
+					&cli.StringFlag{
+						Name:  "definition",
+						Usage: "Definition of the glossary terms created",
+						Value: defaultTermDefinition,
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Show the glossary terms that would be created without creating them",
+					},
+				},
+			},
+			{
+				Name:   "audit-terms",
+				Usage:  "Report the DataHub datasets associated with glossary terms that don't exist",
+				Action: runAuditTerms,
 				Flags: []cli.Flag{
 					&cli.StringFlag{
-						Name:     "api-key",
-						EnvVars:  []string{"OPENAI_API_KEY"},
-						Usage:    "OpenAI API key",
+						Name:    "datahub-gms-url",
+						EnvVars: []string{"DATAHUB_GMS_URL"},
+						Usage:   "DataHub URL",
+						Value:   "https://api.datahub.io",
+					},
+					&cli.StringFlag{
+						Name:    "datahub-gms-token",
+						EnvVars: []string{"DATAHUB_GMS_TOKEN"},
+						Usage:   "DataHub token",
+					},
+					&cli.StringFlag{
+						Name:  "platform",
+						Usage: "Only audit the datasets of this platform (name or URN)",
+					},
+					&cli.BoolFlag{
+						Name:  "fix",
+						Usage: "Remove the associations with the missing glossary terms",
+					},
+				},
+			},
+			{
+				Name:   "touch",
+				Usage:  "Bump the schema version and last modified time of a DataHub dataset without changing it",
+				Action: runTouch,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "datahub-gms-url",
+						EnvVars: []string{"DATAHUB_GMS_URL"},
+						Usage:   "DataHub URL",
+						Value:   "https://api.datahub.io",
+					},
+					&cli.StringFlag{
+						Name:    "datahub-gms-token",
+						EnvVars: []string{"DATAHUB_GMS_TOKEN"},
+						Usage:   "DataHub token",
+					},
+					&cli.StringFlag{
+						Name:     "urn",
+						Usage:    "Dataset URN",
 						Required: true,
 					},
 					&cli.StringFlag{
-						Name:    "api-base",
-						EnvVars: []string{"OPENAI_API_BASE"},
-						Usage:   "OpenAI API base URL (for Azure OpenAI)",
-						Value:   "https://api.openai.com/v1",
+						Name:  "actor",
+						Usage: "Actor URN of the last modified audit stamp (default: " + datahub.DefaultActor + ")",
+					},
+				},
+			},
+			{
+				Name:   "inspect",
+				Usage:  "Show the aspects stored in DataHub for a dataset",
+				Action: runInspect,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "datahub-gms-url",
+						EnvVars: []string{"DATAHUB_GMS_URL"},
+						Usage:   "DataHub URL",
+						Value:   "https://api.datahub.io",
 					},
 					&cli.StringFlag{
-						Name:    "model",
-						EnvVars: []string{"OPENAI_MODEL"},
-						Usage:   "OpenAI model to use",
-						Value:   "gpt-4o",
+						Name:    "datahub-gms-token",
+						EnvVars: []string{"DATAHUB_GMS_TOKEN"},
+						Usage:   "DataHub token",
+					},
+					&cli.StringFlag{
+						Name:     "urn",
+						Usage:    "Dataset URN",
+						Required: true,
+					},
+					&cli.StringSliceFlag{
+						Name:  "aspects",
+						Usage: "Aspects to show, comma separated or repeated (default: " + strings.Join(defaultInspectAspects, ",") + ")",
 					},
 					&cli.BoolFlag{
-						Name:    "azure",
-						EnvVars: []string{"OPENAI_USE_AZURE"},
-						Usage:   "Use Azure OpenAI",
-						Value:   false,
+						Name:  "json",
+						Usage: "Print the aspects as JSON",
+					},
+				},
+			},
+			{
+				Name:  "datasets",
+				Usage: "Manage DataHub datasets",
+				Subcommands: []*cli.Command{
+					{
+						Name:   "list",
+						Usage:  "List the datasets in DataHub",
+						Action: runListDatasets,
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "datahub-gms-url",
+								EnvVars: []string{"DATAHUB_GMS_URL"},
+								Usage:   "DataHub URL",
+								Value:   "https://api.datahub.io",
+							},
+							&cli.StringFlag{
+								Name:    "datahub-gms-token",
+								EnvVars: []string{"DATAHUB_GMS_TOKEN"},
+								Usage:   "DataHub token",
+							},
+							&cli.IntFlag{
+								Name:  "per-page",
+								Usage: "Number of datasets fetched per request",
+								Value: 100,
+							},
+							&cli.BoolFlag{
+								Name:  "include-soft-deleted",
+								Usage: "Include soft-deleted datasets",
+								Value: false,
+							},
+							&cli.IntFlag{
+								Name:  "limit",
+								Usage: "Maximum number of datasets to list (0 lists all)",
+								Value: 0,
+							},
+							&cli.BoolFlag{
+								Name:  "cache",
+								Usage: "Cache the listing on disk and only download the pages that changed",
+								Value: false,
+							},
+							&cli.StringFlag{
+								Name:  "scroll-id",
+								Usage: "Resume a previous listing from this scroll ID",
+							},
+							&cli.StringFlag{
+								Name:  "checkpoint-file",
+								Usage: "Save the scroll ID to this file after every page and resume from it",
+							},
+							&cli.Int64Flag{
+								Name:  "max-body-size",
+								Usage: "Maximum size in bytes of each DataHub response page",
+								Value: datahub.DefaultMaxBodySize,
+							},
+							&cli.StringFlag{
+								Name:  "platform",
+								Usage: "Only list the datasets of this platform (name or URN)",
+							},
+							&cli.BoolFlag{
+								Name:  "count-only",
+								Usage: "Print the number of datasets instead of listing them",
+							},
+						},
+					},
+				},
+			},
+			{
+				Name:  "terms",
+				Usage: "Manage DataHub glossary terms",
+				Subcommands: []*cli.Command{
+					{
+						Name:   "list",
+						Usage:  "List the glossary terms in DataHub",
+						Action: runListTerms,
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "datahub-gms-url",
+								EnvVars: []string{"DATAHUB_GMS_URL"},
+								Usage:   "DataHub URL",
+								Value:   "https://api.datahub.io",
+							},
+							&cli.StringFlag{
+								Name:    "datahub-gms-token",
+								EnvVars: []string{"DATAHUB_GMS_TOKEN"},
+								Usage:   "DataHub token",
+							},
+							&cli.IntFlag{
+								Name:  "per-page",
+								Usage: "Number of glossary terms fetched per request",
+								Value: 100,
+							},
+							&cli.BoolFlag{
+								Name:  "include-soft-deleted",
+								Usage: "Include soft-deleted glossary terms",
+								Value: false,
+							},
+							&cli.IntFlag{
+								Name:  "limit",
+								Usage: "Maximum number of glossary terms to list (0 lists all)",
+								Value: 0,
+							},
+						},
+					},
+				},
+			},
+			{
+				Name:   "set-owner",
+				Usage:  "Set the owners of a DataHub dataset",
+				Action: runSetOwner,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "datahub-gms-url",
+						EnvVars: []string{"DATAHUB_GMS_URL"},
+						Usage:   "DataHub URL",
+						Value:   "https://api.datahub.io",
 					},
 					&cli.StringFlag{
-						Name:    "azure-deployment",
-						EnvVars: []string{"AZURE_OPENAI_DEPLOYMENT"},
-						Usage:   "Azure OpenAI deployment name (required when using Azure)",
+						Name:    "datahub-gms-token",
+						EnvVars: []string{"DATAHUB_GMS_TOKEN"},
+						Usage:   "DataHub token",
 					},
 					&cli.StringFlag{
-						Name:    "azure-api-version",
-						EnvVars: []string{"AZURE_OPENAI_API_VERSION"},
-						Usage:   "Azure OpenAI API version",
-						Value:   "2023-05-15",
+						Name:     "dataset-urn",
+						Usage:    "Dataset URN",
+						Required: true,
+					},
+					&cli.StringSliceFlag{
+						Name:     "owner",
+						Usage:    "Owner URN (urn:li:corpuser:... or urn:li:corpGroup:...), can be repeated",
+						Required: true,
 					},
+					&cli.StringFlag{
+						Name:  "type",
+						Usage: "Ownership type (" + strings.Join(datahub.OwnershipTypes, ", ") + ")",
+						Value: "TECHNICAL_OWNER",
+					},
+				},
+			},
+			{
+				Name:   "assign-domain",
+				Usage:  "Assign a DataHub dataset to a domain",
+				Action: runAssignDomain,
+				Flags: []cli.Flag{
 					&cli.StringFlag{
 						Name:    "datahub-gms-url",
 						EnvVars: []string{"DATAHUB_GMS_URL"},
@@ -175,20 +829,191 @@ func main() {
 						EnvVars: []string{"DATAHUB_GMS_TOKEN"},
 						Usage:   "DataHub token",
 					},
+					&cli.StringFlag{
+						Name:     "dataset-urn",
+						Usage:    "Dataset URN",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "domain",
+						Usage:    "Domain URN (urn:li:domain:...)",
+						Required: true,
+					},
+					&cli.BoolFlag{
+						Name:  "create-if-missing",
+						Usage: "Create the domain if it does not exist",
+						Value: false,
+					},
+				},
+			},
+			{
+				Name:   "add-lineage",
+				Usage:  "Add upstream lineage to a DataHub dataset",
+				Action: runAddLineage,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "datahub-gms-url",
+						EnvVars: []string{"DATAHUB_GMS_URL"},
+						Usage:   "DataHub URL",
+						Value:   "https://api.datahub.io",
+					},
+					&cli.StringFlag{
+						Name:    "datahub-gms-token",
+						EnvVars: []string{"DATAHUB_GMS_TOKEN"},
+						Usage:   "DataHub token",
+					},
+					&cli.StringFlag{
+						Name:     "dataset-urn",
+						Usage:    "Dataset URN",
+						Required: true,
+					},
+					&cli.StringSliceFlag{
+						Name:     "upstream",
+						Usage:    "Upstream dataset URN, can be repeated",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:  "type",
+						Usage: "Lineage type (" + strings.Join(datahub.LineageTypes, ", ") + ")",
+						Value: "TRANSFORMED",
+					},
+				},
+			},
+			{
+				Name:   "generate",
+				Usage:  "Generate a new dataset",
+				Action: runGenerate,
+				Flags: append(generateFlags(),
+					&cli.StringFlag{
+						Name:    "datahub-ui-url",
+						EnvVars: []string{"DATAHUB_UI_URL"},
+						Usage:   "DataHub UI URL (derived from the DataHub URL if not set)",
+					},
 					&cli.BoolFlag{
 						Name:  "stdout",
 						Usage: "Write the generated datasets to stdout",
 					},
 					&cli.BoolFlag{
-						Name:  "skip-post",
-						Usage: "Do not post the datasets to DataHub",
-						Value: false,
+						Name:  "no-save-history",
+						Usage: "Do not save the generation to the history database",
+					},
+					&cli.BoolFlag{
+						Name:  "keep-temp",
+						Usage: "Keep the temporary prompt and response files for debugging and print their paths",
+					},
+					&cli.BoolFlag{
+						Name:  "suggest",
+						Usage: "Suggest similar prompts from the history (costs an extra embeddings request)",
+					},
+					&cli.StringFlag{
+						Name:    "embedding-model",
+						EnvVars: []string{"OPENAI_EMBEDDING_MODEL"},
+						Usage:   "OpenAI model used to compute prompt embeddings for --suggest",
+						Value:   string(openai.SmallEmbedding3),
 					},
 					&cli.IntFlag{
 						Name:  "prompt-from",
 						Usage: "Post using the prompt from history",
 						Value: -1,
 					},
+					&cli.StringFlag{
+						Name:  "spec-file",
+						Usage: "YAML dataset spec converted into the datasets without the AI (see docs/spec.schema.json)",
+					},
+					&cli.BoolFlag{
+						Name:  "spec-prompt",
+						Usage: "Send a prompt built from --spec-file to the AI instead of converting the spec",
+					},
+					&cli.StringFlag{
+						Name:  "merge-into",
+						Usage: "Add the fields of the dataset to this existing dataset URN instead of creating it",
+					},
+					&cli.StringFlag{
+						Name:  "output-dir",
+						Usage: "Also write each dataset to DIR/<schema name>.json",
+					},
+					&cli.StringFlag{
+						Name:  "output-urns-file",
+						Usage: "Write the URNs created to FILE, one per line or as a JSON array if FILE ends in .json",
+					},
+					&cli.StringFlag{
+						Name:  "response-file",
+						Usage: "Use the AI response saved in FILE instead of calling the API, saving it there first if FILE doesn't exist",
+					},
+					&cli.BoolFlag{
+						Name:  "diff-existing",
+						Usage: "Show the field changes against the datasets already in DataHub and confirm before posting",
+					},
+					&cli.StringFlag{
+						Name:  "summary",
+						Usage: "Print a summary of the results to stdout in the given format (json)",
+					},
+					&cli.BoolFlag{
+						Name:  "prompt-only",
+						Usage: "Print the prompt that would be sent to OpenAI and exit",
+						Value: false,
+					},
+				),
+			},
+			{
+				Name:   "batch-generate",
+				Usage:  "Generate datasets from a file with multiple prompts",
+				Action: runBatchGenerate,
+				Flags: append(generateFlags(),
+					&cli.StringFlag{
+						Name:     "prompts-file",
+						Usage:    "File with one prompt per line, or prompts separated by --- lines",
+						Required: true,
+					},
+					&cli.IntFlag{
+						Name:  "concurrency",
+						Usage: "Maximum number of concurrent OpenAI requests",
+						Value: 1,
+					},
+				),
+			},
+			{
+				Name:   "replay",
+				Usage:  "Post all the history entries to a DataHub instance",
+				Action: runReplay,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "datahub-gms-url",
+						EnvVars: []string{"DATAHUB_GMS_URL"},
+						Usage:   "DataHub URL",
+						Value:   "https://api.datahub.io",
+					},
+					&cli.StringFlag{
+						Name:    "datahub-gms-token",
+						EnvVars: []string{"DATAHUB_GMS_TOKEN"},
+						Usage:   "DataHub token",
+					},
+					&cli.BoolFlag{
+						Name:  "continue-on-error",
+						Usage: "Keep posting the remaining entities when one fails",
+						Value: false,
+					},
+					&cli.BoolFlag{
+						Name:  "strict",
+						Usage: "Fail instead of warning when entities share a URN",
+					},
+					&cli.StringFlag{
+						Name:  "since",
+						Usage: "Only replay entries created on or after this date (YYYY-MM-DD)",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Show what would be posted without posting anything",
+						Value: false,
+					},
+					&cli.Int64Flag{
+						Name:  "since-id",
+						Usage: "Only replay entries with an ID greater than this one",
+					},
+					&cli.BoolFlag{
+						Name:  "incremental",
+						Usage: "Only replay the entries created since the last incremental replay to the same DataHub instance",
+					},
 				},
 			},
 			{
@@ -208,6 +1033,33 @@ func main() {
 						Usage:   "Offset for pagination",
 						Value:   0,
 					},
+					&cli.BoolFlag{
+						Name:    "relative",
+						EnvVars: []string{"DSG_HISTORY_RELATIVE"},
+						Usage:   "Show relative dates (e.g. 3 hours ago) for entries newer than a week",
+						Value:   false,
+					},
+					&cli.Int64Flag{
+						Name:  "before",
+						Usage: "Only list entries with an ID lower than this one (stable pagination)",
+					},
+					&cli.Int64Flag{
+						Name:  "since-id",
+						Usage: "Only list entries with an ID greater than this one",
+					},
+					&cli.BoolFlag{
+						Name:    "full",
+						Aliases: []string{"show-prompt"},
+						Usage:   "Add the prompt to the table",
+					},
+					&cli.BoolFlag{
+						Name:  "no-truncate",
+						Usage: "Do not truncate long values in the table",
+					},
+					&cli.StringFlag{
+						Name:  "template",
+						Usage: "Go text/template executed for every entry (e.g. '{{.ID}} {{.SchemaName}}')",
+					},
 					&cli.BoolFlag{
 						Name:    "json",
 						Aliases: []string{"j"},
@@ -216,12 +1068,49 @@ func main() {
 					},
 				},
 			},
+			{
+				Name:   "export",
+				Usage:  "Export the generation history",
+				Action: runExport,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "format",
+						Usage: "Export format (csv or json)",
+						Value: "csv",
+					},
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Usage:   "File to write the export to (defaults to stdout)",
+					},
+				},
+			},
 			{
 				Name:      "show",
 				Usage:     "Show details of a specific history entry",
 				ArgsUsage: "HISTORY_ID",
 				Action:    runShowHistory,
 				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "datahub-gms-url",
+						EnvVars: []string{"DATAHUB_GMS_URL"},
+						Usage:   "DataHub URL",
+						Value:   "https://api.datahub.io",
+					},
+					&cli.StringFlag{
+						Name:    "datahub-ui-url",
+						EnvVars: []string{"DATAHUB_UI_URL"},
+						Usage:   "DataHub UI URL (derived from the DataHub URL if not set)",
+					},
+					&cli.BoolFlag{
+						Name:  "open",
+						Usage: "Open the dataset in the DataHub UI",
+						Value: false,
+					},
+					&cli.StringFlag{
+						Name:  "template",
+						Usage: "Go text/template executed for every entry (e.g. '{{.ID}} {{.SchemaName}}')",
+					},
 					&cli.BoolFlag{
 						Name:    "json",
 						Aliases: []string{"j"},
@@ -236,6 +1125,18 @@ func main() {
 				ArgsUsage: "HISTORY_ID",
 				Action:    runDeleteHistory,
 			},
+			{
+				Name:   "vacuum",
+				Usage:  "Reclaim unused space in the history database",
+				Action: runVacuum,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "compress",
+						Usage: "Compress the uncompressed history entries before vacuuming",
+						Value: false,
+					},
+				},
+			},
 			{
 				Name:   "clear",
 				Usage:  "Clear all history entries",
@@ -249,12 +1150,47 @@ func main() {
 					},
 				},
 			},
+			{
+				Name:   "prune",
+				Usage:  "Delete the history entries older than a cutoff",
+				Action: runPrune,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "older-than",
+						Usage:    "Delete the entries older than this age (e.g. 90d, 2w or 36h)",
+						Required: true,
+					},
+					&cli.StringSliceFlag{
+						Name:  "status",
+						Usage: "Only delete the entries with this status (generated, posted or failed), can be repeated",
+					},
+					&cli.BoolFlag{
+						Name:    "force",
+						Aliases: []string{"f"},
+						Usage:   "Skip confirmation",
+						Value:   false,
+					},
+				},
+			},
 		},
 	}
+}
+
+func main() {
+	app := newApp()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handleInterrupts(cancel)
 
-	if err := app.Run(os.Args); err != nil {
+	err := app.RunContext(ctx, os.Args)
+	if cancelTimeout != nil {
+		cancelTimeout()
+	}
+	if err != nil {
+		err = checkTimeout(err)
 		fmt.Println("Error:", err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
 
@@ -272,45 +1208,79 @@ func getResponse(id int64) (*storage.Response, error) {
 	return resp, nil
 }
 
-func runGenerate(c *cli.Context) error {
-	apiKey := c.String("api-key")
-	apiBase := c.String("api-base")
-	model := c.String("model")
-	useAzure := c.Bool("azure")
-	azureDeployment := c.String("azure-deployment")
-	azureAPIVersion := c.String("azure-api-version")
-	datahubURL := c.String("datahub-gms-url")
-	datahubToken := c.String("datahub-gms-token")
+func runGenerate(c *cli.Context) (err error) {
 	toStdout := c.Bool("stdout")
 	skipPost := c.Bool("skip-post")
 	fromHistory := c.Int64("prompt-from")
+	mergeInto := c.String("merge-into")
+	if err := validateMergeInto(mergeInto); err != nil {
+		return err
+	}
+	// Both read the history database, which must not be created
+	if c.Bool("no-save-history") {
+		if c.Int("few-shot") > 0 {
+			return usagef("--few-shot uses the history, it can't be used with --no-save-history")
+		}
+		if c.Bool("suggest") {
+			return usagef("--suggest uses the history, it can't be used with --no-save-history")
+		}
+	}
 
-	// Validate Azure arguments
-	if useAzure && azureDeployment == "" {
-		return fmt.Errorf("azure-deployment is required when using Azure OpenAI")
+	// With a JSON summary, stdout is reserved for it
+	out := io.Writer(os.Stdout)
+	summary := newGenerateSummary()
+	switch c.String("summary") {
+	case "":
+	case "json":
+		out = os.Stderr
+		defer func() {
+			if !c.Bool("prompt-only") {
+				summary.print(os.Stdout, err)
+			}
+		}()
+	default:
+		return fmt.Errorf("unsupported summary format: %s", c.String("summary"))
 	}
 
-	// Create a temporary file for the prompt
-	tmpfile, err := os.CreateTemp("", "XXXXXprompt")
+	g, err := newGenerator(c)
 	if err != nil {
-		return fmt.Errorf("error creating temporary file: %w", err)
+		return err
 	}
-	defer os.Remove(tmpfile.Name())
-
-	log.Debugf("Writing temp prompt file to %s...\n", tmpfile.Name())
+	g.out = out
 
 	var userInput string
-	if fromHistory > -1 {
-		fmt.Println("Loading prompt from history...")
+	var sp *spec.Spec
+	var specSource string
+	if specFile := c.String("spec-file"); specFile != "" {
+		if fromHistory > -1 {
+			return usagef("--spec-file and --prompt-from are mutually exclusive")
+		}
+		data, err := os.ReadFile(specFile)
+		if err != nil {
+			return fmt.Errorf("error reading spec file: %w", err)
+		}
+		sp, err = spec.Parse(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", specFile, err)
+		}
+		specSource = string(data)
+		// The prompt sent to the AI with --spec-prompt
+		userInput = sp.Prompt()
+	} else if fromHistory > -1 {
+		fmt.Fprintln(out, "Loading prompt from history...")
 		resp, err := getResponse(fromHistory)
 		if err != nil {
 			return fmt.Errorf("error getting response from history: %w", err)
 		}
 		userInput = resp.Prompt
-		fmt.Println("\n>> " + strings.TrimSpace(userInput))
+		if !c.IsSet("seed") && resp.Seed != nil {
+			stored := int(*resp.Seed)
+			g.seed = &stored
+		}
+		fmt.Fprintln(out, "\n>> "+strings.TrimSpace(userInput))
 	} else {
-		fmt.Println("Write the input for AI, hit Enter+Ctrl-D when finished:")
-		fmt.Println()
+		fmt.Fprintln(out, "Write the input for AI, hit Enter+Ctrl-D when finished:")
+		fmt.Fprintln(out)
 		userInput, err = readUserInput()
 		if err != nil {
 			return fmt.Errorf("error reading user input: %w", err)
@@ -318,127 +1288,210 @@ func runGenerate(c *cli.Context) error {
 	}
 
 	// Construct the prompt
-	prompt := fmt.Sprintf(`Given a reference json schema like:
-
-%s
+	_, fullPrompt, err := g.render(userInput)
+	if err != nil {
+		return err
+	}
 
-Give me another schema taking into account:
+	if c.Bool("prompt-only") {
+		fmt.Println()
+		fmt.Println(fullPrompt)
+		return nil
+	}
 
-%s
+	// Create a temporary file for the prompt
+	tmpfile, err := os.CreateTemp("", "XXXXXprompt")
+	if err != nil {
+		return fmt.Errorf("error creating temporary file: %w", err)
+	}
+	keepTemp := c.Bool("keep-temp")
+	if keepTemp {
+		fmt.Fprintf(out, "Keeping the prompt file %s\n", tmpfile.Name())
+	} else {
+		defer os.Remove(tmpfile.Name())
+		atInterrupt(func() { os.Remove(tmpfile.Name()) })
+	}
 
-If a schema name is provided, set schemaName to the name provided. If not, replace @@@REPLACE_ME@@@ with %d.
-Do not explain anything. Return only the required JSON. Do not format the response as markdown.`, trainingDataset, userInput, time.Now().UnixMilli())
+	log.Debugf("Writing temp prompt file to %s...\n", tmpfile.Name())
 
 	// Write the prompt to the temp file
-	if _, err := tmpfile.WriteString(prompt); err != nil {
+	if _, err := tmpfile.WriteString(fullPrompt); err != nil {
 		return fmt.Errorf("error writing to temp file: %w", err)
 	}
 	if err := tmpfile.Close(); err != nil {
 		return fmt.Errorf("error closing temp file: %w", err)
 	}
 
-	fmt.Println()
-	fmt.Println("Understood! generating DataHub datasets...")
-	fmt.Println("Processing input and generating the dataset (may take a while)...")
-
-	// Initialize the OpenAI client
-	var client *openai.Client
-	if useAzure {
-		config := openai.DefaultAzureConfig(apiKey, azureDeployment)
-		config.APIVersion = azureAPIVersion
-		config.BaseURL = apiBase
-		client = openai.NewClientWithConfig(config)
-	} else {
-		config := openai.DefaultConfig(apiKey)
-		config.BaseURL = apiBase
-		client = openai.NewClientWithConfig(config)
+	// Specs are converted without the AI unless --spec-prompt is set
+	specOnly := sp != nil && !c.Bool("spec-prompt")
+
+	var embedding []float32
+	switch {
+	case !c.Bool("suggest") || specOnly:
+	case g.client == nil:
+		// The response comes from --response-file, no OpenAI client
+		fmt.Fprintln(out, "Warning: not looking for similar prompts, the response is read from --response-file")
+	default:
+		embedding, err = suggestSimilarPrompts(c.Context, out, g.client, c.String("embedding-model"), userInput)
+		if err != nil {
+			fmt.Fprintf(out, "Warning: Failed to look for similar prompts: %v\n", err)
+		}
 	}
 
-	// Create chat completion request
-	responseFile := tmpfile.Name() + ".response.json"
-	responseData, err := sendOpenAIRequest(client, model, prompt)
+	var gen *generation
+	if specOnly {
+		fmt.Fprintln(out, "Converting the spec into DataHub datasets...")
+		gen, err = g.generateFromSpec(sp, specSource)
+	} else {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Understood! generating DataHub datasets...")
+		fmt.Fprintln(out, "Processing input and generating the dataset (may take a while)...")
+		g.progress = newProgress(out, "Waiting for "+g.model)
+		gen, err = g.generate(c.Context, userInput)
+		g.progress.Stop()
+	}
 	if err != nil {
-		return fmt.Errorf("error sending request to OpenAI: %w", err)
+		return err
 	}
+	responseData := gen.Response
+	schemaName := gen.SchemaName
+	schemaURN := gen.SchemaURN
+	summary.setGeneration(gen)
 
 	// Write the response to a file
-	if err := os.WriteFile(responseFile, []byte(responseData), 0644); err != nil {
-		return fmt.Errorf("error writing response to file: %w", err)
+	responseFile := tmpfile.Name() + ".response.json"
+	if !keepTemp {
+		atInterrupt(func() { os.Remove(responseFile) })
 	}
-	defer os.Remove(responseFile)
-
-	// Parse the JSON response
-	var jsonResponse []map[string]interface{}
-	if err := json.Unmarshal([]byte(responseData), &jsonResponse); err != nil {
-		return fmt.Errorf("error parsing JSON response: %w", err)
+	// Only readable by the user, like the prompt file
+	if err := os.WriteFile(responseFile, []byte(responseData), 0600); err != nil {
+		return fmt.Errorf("error writing response to file: %w", err)
 	}
-
-	// Extract schema information
-	var schemaName, schemaURN, datasetName string
-	if len(jsonResponse) > 0 {
-		if metadata, ok := jsonResponse[0]["schemaMetadata"].(map[string]interface{}); ok {
-			if value, ok := metadata["value"].(map[string]interface{}); ok {
-				if name, ok := value["schemaName"].(string); ok {
-					schemaName = name
-				}
-			}
-		}
-		if urn, ok := jsonResponse[0]["urn"].(string); ok {
-			schemaURN = urn
-		}
-		if datasetKey, ok := jsonResponse[0]["datasetKey"].(map[string]interface{}); ok {
-			if value, ok := datasetKey["value"].(map[string]interface{}); ok {
-				if name, ok := value["name"].(string); ok {
-					datasetName = name
-				}
-			}
-		}
+	if keepTemp {
+		fmt.Fprintf(out, "Keeping the response file %s\n", responseFile)
+	} else {
+		defer os.Remove(responseFile)
 	}
 
 	// Save to history database
-	db, err := storage.NewSQLiteStorage()
-	if err != nil {
-		fmt.Printf("Warning: Failed to initialize history database: %v\n", err)
+	var historyID int64 = -1
+	var db *storage.SQLiteStorage
+	if c.Bool("no-save-history") {
+		log.Debug("not saving the generation to history")
+	} else if db, err = storage.NewSQLiteStorage(storage.WithCompression(c.Bool("compress-history"))); err != nil {
+		fmt.Fprintf(out, "Warning: Failed to initialize history database: %v\n", err)
 	} else {
 		defer db.Close()
-		id, err := db.SaveResponse(userInput, responseData, schemaName, schemaURN, datasetName)
+		atInterrupt(func() { db.Close() })
+		id, err := saveGeneration(db, g, gen, "")
 		if err != nil {
-			fmt.Printf("Warning: Failed to save to history: %v\n", err)
+			fmt.Fprintf(out, "Warning: Failed to save to history: %v\n", err)
 		} else {
+			historyID = id
+			summary.HistoryID = id
 			log.Debugf("Response saved to history with ID: %d\n", id)
+			if embedding != nil {
+				if err := db.SetEmbedding(id, embedding); err != nil {
+					fmt.Fprintf(out, "Warning: Failed to save the prompt embedding: %v\n", err)
+				}
+			}
 		}
 	}
 
 	if toStdout {
-		fmt.Println("Generated JSON:")
-		fmt.Println()
-		fmt.Println(responseData)
-		fmt.Println()
+		fmt.Fprintln(out, "Generated JSON:")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, responseData)
+		fmt.Fprintln(out)
+	}
+
+	if dir := c.String("output-dir"); dir != "" {
+		paths, err := writeDatasetFiles(dir, responseData)
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			fmt.Fprintf(out, "Wrote %s\n", path)
+		}
+	}
+
+	if c.Bool("diff-existing") {
+		if err := printExistingDiffs(c, responseData, out); err != nil {
+			return err
+		}
+		if !skipPost && !confirmPost(out) {
+			fmt.Fprintln(out, "Not posting the datasets.")
+			return nil
+		}
 	}
 
 	if skipPost {
 		return nil
 	}
 
+	if err := checkDatasetDuplicates(responseData, c.Bool("strict")); err != nil {
+		if historyID > -1 {
+			updateHistoryStatus(db, historyID, err)
+		}
+		return err
+	}
+
+	if mergeInto != "" {
+		dh, err := newClientFromContext(c)
+		if err != nil {
+			return err
+		}
+		err = mergeDatasetFields(dh, mergeInto, responseData, out)
+		if historyID > -1 {
+			updateHistoryStatus(db, historyID, err)
+		}
+		if err != nil {
+			return err
+		}
+		summary.Posted = true
+		return writeURNsFile(c, []string{mergeInto})
+	}
+
+	if err := ensureDatasetTags(c, g.defaults.Tags, out); err != nil {
+		return err
+	}
+
+	dh, err := newPosterFromContext(c)
+	if err != nil {
+		return err
+	}
+
 	// Execute post-dataset command
 	log.Debug("posting the dataset")
-	dh := datahub.NewClient(datahubURL, datahubToken)
-	count, err := dh.PostEntity("dataset", responseData)
+	count, err := dh.PostEntity("dataset", responseData, &datahub.PostOptions{ContinueOnError: c.Bool("continue-on-error")})
+	if historyID > -1 {
+		updateHistoryStatus(db, historyID, err)
+	}
 	if err != nil {
+		if count > 0 {
+			fmt.Fprintf(out, "%d datasets created before the errors\n", count)
+		}
 		return fmt.Errorf("error posting datasets: %w", err)
 	}
+	summary.Posted = true
+	if err := writeURNsFile(c, gen.URNs); err != nil {
+		return err
+	}
 
-	fmt.Println("🤖 finished!")
+	fmt.Fprintln(out, "🤖 finished!")
 	if count > 1 {
-		fmt.Printf("%d datasets created! ☑", count)
+		fmt.Fprintf(out, "%d datasets created! ☑\n", count)
 	} else {
-		fmt.Println()
-		fmt.Println("Dataset info")
-		fmt.Println("-------------")
-		fmt.Printf("Schema URN: %s\n", schemaURN)
-		fmt.Printf("Schema Name: %s\n", schemaName)
-		fmt.Println()
-		fmt.Println("Dataset created! ☑")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Dataset info")
+		fmt.Fprintln(out, "-------------")
+		fmt.Fprintf(out, "Schema URN: %s\n", schemaURN)
+		fmt.Fprintf(out, "Schema Name: %s\n", schemaName)
+		if uiURL, err := datasetUIURL(c, schemaURN); err == nil && schemaURN != "" {
+			fmt.Fprintf(out, "URL: %s\n", uiURL)
+		}
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Dataset created! ☑")
 	}
 
 	return nil
@@ -449,13 +1502,37 @@ func runListHistory(c *cli.Context) error {
 	offset := c.Int("offset")
 	outputJSON := c.Bool("json")
 
+	var tmpl *template.Template
+	if c.IsSet("template") {
+		if outputJSON {
+			return usagef("--json and --template are mutually exclusive")
+		}
+		var err error
+		tmpl, err = parseOutputTemplate(c.String("template"))
+		if err != nil {
+			return err
+		}
+	}
+
 	db, err := storage.NewSQLiteStorage()
 	if err != nil {
 		return fmt.Errorf("failed to initialize history database: %w", err)
 	}
 	defer db.Close()
 
-	responses, err := db.ListResponses(limit, offset)
+	var responses []*storage.Response
+	switch {
+	case c.IsSet("before") && c.IsSet("since-id"):
+		return usagef("--before and --since-id are mutually exclusive")
+	case c.IsSet("before"):
+		responses, err = db.ListResponsesBefore(c.Int64("before"), limit)
+	case c.IsSet("since-id"):
+		responses, err = db.ListResponsesSince(c.Int64("since-id"), limit)
+		// Newest first, like the rest of the history listings
+		slices.Reverse(responses)
+	default:
+		responses, err = db.ListResponses(limit, offset)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to list history: %w", err)
 	}
@@ -469,19 +1546,59 @@ func runListHistory(c *cli.Context) error {
 		return nil
 	}
 
+	if tmpl != nil {
+		return printTemplate(os.Stdout, tmpl, responses...)
+	}
+
 	if len(responses) == 0 {
 		fmt.Println("No history entries found.")
 		return nil
 	}
 
-	fmt.Printf("%-6s %-20s %-40s %-30s\n", "ID", "DATE", "SCHEMA NAME", "DATASET NAME")
-	fmt.Println(strings.Repeat("-", 100))
+	full := c.Bool("full")
+	truncate := func(s string, maxLen int) string {
+		if c.Bool("no-truncate") {
+			return s
+		}
+		return truncateString(s, maxLen)
+	}
+
+	header := fmt.Sprintf("%-6s %-20s %-40s %-30s", "ID", "DATE", "SCHEMA NAME", "DATASET NAME")
+	width := 100
+	if full {
+		header += " PROMPT"
+		width += 50
+	}
+	fmt.Println(header)
+	fmt.Println(strings.Repeat("-", width))
+	now := time.Now()
 	for _, resp := range responses {
-		fmt.Printf("%-6d %-20s %-40s %-30s\n",
+		date := resp.CreatedAt.Format("2006-01-02 15:04:05")
+		if c.Bool("relative") {
+			date = relativeTime(resp.CreatedAt, now)
+		}
+		schemaName := resp.SchemaName
+		if n := countDatasets(resp.Response); n > 1 {
+			// The first dataset is the one stored, flag the others
+			suffix := fmt.Sprintf(" (+%d)", n-1)
+			schemaName = truncate(schemaName, 38-len(suffix)) + suffix
+		}
+		line := fmt.Sprintf("%-6d %-20s %-40s %-30s",
 			resp.ID,
-			resp.CreatedAt.Format("2006-01-02 15:04:05"),
-			truncateString(resp.SchemaName, 38),
-			truncateString(resp.DatasetName, 28))
+			date,
+			truncate(schemaName, 38),
+			truncate(resp.DatasetName, 28))
+		if full {
+			// Keep one entry per line
+			prompt := strings.Join(strings.Fields(resp.Prompt), " ")
+			line += " " + truncate(prompt, 48)
+		}
+		fmt.Println(line)
+	}
+
+	if c.IsSet("before") && len(responses) == limit {
+		fmt.Println()
+		fmt.Printf("Next page: dsg history --before %d\n", responses[len(responses)-1].ID)
 	}
 
 	return nil
@@ -489,16 +1606,27 @@ func runListHistory(c *cli.Context) error {
 
 func runShowHistory(c *cli.Context) error {
 	if c.NArg() == 0 {
-		return fmt.Errorf("history ID is required")
+		return usagef("history ID is required")
 	}
 
 	id, err := strconv.ParseInt(c.Args().Get(0), 10, 64)
 	if err != nil {
-		return fmt.Errorf("invalid history ID: %w", err)
+		return usagef("invalid history ID: %v", err)
 	}
 
 	outputJSON := c.Bool("json")
 
+	var tmpl *template.Template
+	if c.IsSet("template") {
+		if outputJSON {
+			return usagef("--json and --template are mutually exclusive")
+		}
+		tmpl, err = parseOutputTemplate(c.String("template"))
+		if err != nil {
+			return err
+		}
+	}
+
 	db, err := storage.NewSQLiteStorage()
 	if err != nil {
 		return fmt.Errorf("failed to initialize history database: %w", err)
@@ -521,6 +1649,24 @@ func runShowHistory(c *cli.Context) error {
 		Datasets: datasets,
 	}
 
+	if c.Bool("open") {
+		if resp.SchemaURN == "" {
+			return fmt.Errorf("history entry %d has no dataset URN", id)
+		}
+		uiURL, err := datasetUIURL(c, resp.SchemaURN)
+		if err != nil {
+			return err
+		}
+		if err := openBrowser(uiURL); err != nil {
+			fmt.Printf("Could not open the browser, visit %s\n", uiURL)
+		}
+		return nil
+	}
+
+	if tmpl != nil {
+		return printTemplate(os.Stdout, tmpl, resp)
+	}
+
 	if outputJSON {
 		jsonData, err := json.MarshalIndent(item, "", "  ")
 		if err != nil {
@@ -537,6 +1683,22 @@ func runShowHistory(c *cli.Context) error {
 	fmt.Printf("Schema Name: %s\n", resp.SchemaName)
 	fmt.Printf("Schema URN:  %s\n", resp.SchemaURN)
 	fmt.Printf("Dataset:     %s\n", resp.DatasetName)
+	if len(datasets) > 1 {
+		fmt.Printf("Datasets:    %d\n", len(datasets))
+		for i, ds := range datasets {
+			fmt.Printf("  %d. %s (%s)\n", i+1, ds.SchemaMetadata.Value.SchemaName, ds.URN)
+		}
+	}
+	fmt.Printf("Status:      %s\n", resp.Status)
+	if resp.Seed != nil {
+		fmt.Printf("Seed:        %d\n", *resp.Seed)
+	}
+	if resp.PromptTemplate != "" {
+		fmt.Printf("Template:    %s\n", resp.PromptTemplate)
+	}
+	if resp.Model != "" {
+		fmt.Printf("Model:       %s\n", resp.Model)
+	}
 	fmt.Println()
 	fmt.Println("Prompt:")
 	fmt.Println("-------")
@@ -544,6 +1706,19 @@ func runShowHistory(c *cli.Context) error {
 	fmt.Println()
 	fmt.Println("Response:")
 	fmt.Println("---------")
+	if len(datasets) > 1 {
+		for _, ds := range datasets {
+			name := ds.SchemaMetadata.Value.SchemaName
+			if name == "" {
+				name = ds.URN
+			}
+			fmt.Printf("%s: %s\n", name, datahub.CountFieldTypes([]datahub.Dataset{ds}))
+		}
+		fmt.Printf("Total: %s\n", datahub.CountFieldTypes(datasets))
+	} else {
+		fmt.Println(datahub.CountFieldTypes(datasets))
+	}
+	fmt.Println()
 
 	// Try to pretty print the JSON response
 	var prettyJSON bytes.Buffer
@@ -558,12 +1733,12 @@ func runShowHistory(c *cli.Context) error {
 
 func runDeleteHistory(c *cli.Context) error {
 	if c.NArg() == 0 {
-		return fmt.Errorf("history ID is required")
+		return usagef("history ID is required")
 	}
 
 	id, err := strconv.ParseInt(c.Args().Get(0), 10, 64)
 	if err != nil {
-		return fmt.Errorf("invalid history ID: %w", err)
+		return usagef("invalid history ID: %v", err)
 	}
 
 	db, err := storage.NewSQLiteStorage()
@@ -633,27 +1808,206 @@ func runClearHistory(c *cli.Context) error {
 	return nil
 }
 
+// saveGeneration saves a generation to the history database
+func saveGeneration(db *storage.SQLiteStorage, g *generator, gen *generation, batchID string) (int64, error) {
+	return db.SaveResponse(&storage.Response{
+		Prompt:         gen.UserInput,
+		Response:       gen.Response,
+		SchemaName:     gen.SchemaName,
+		SchemaURN:      gen.SchemaURN,
+		DatasetName:    gen.DatasetName,
+		RenderedPrompt: gen.FullPrompt,
+		PromptTemplate: g.template.Version,
+		Seed:           historySeed(gen.Seed),
+		BatchID:        batchID,
+		Tokens:         gen.Tokens,
+		Model:          gen.Model,
+	})
+}
+
+// historySeed converts the OpenAI seed to the type stored in history
+func historySeed(seed *int) *int64 {
+	if seed == nil {
+		return nil
+	}
+	s := int64(*seed)
+	return &s
+}
+
+// datasetUIURL returns the DataHub UI URL of a dataset, using --datahub-ui-url
+// or deriving the UI URL from --datahub-gms-url
+func datasetUIURL(c *cli.Context, urn string) (string, error) {
+	uiURL := c.String("datahub-ui-url")
+	if uiURL == "" {
+		var err error
+		uiURL, err = datahub.UIURL(c.String("datahub-gms-url"))
+		if err != nil {
+			return "", err
+		}
+	}
+	return datahub.DatasetUIURL(uiURL, urn), nil
+}
+
+// writeMetrics prints the metrics summary and writes the metrics file, if enabled
+func writeMetrics(c *cli.Context) error {
+	if metricsCollector == nil {
+		return nil
+	}
+
+	if c.Bool("metrics") {
+		fmt.Fprintln(os.Stderr)
+		if err := metricsCollector.WriteSummary(os.Stderr); err != nil {
+			return fmt.Errorf("error writing metrics: %w", err)
+		}
+	}
+
+	if path := c.String("metrics-file"); path != "" {
+		var buf bytes.Buffer
+		if err := metricsCollector.WritePrometheus(&buf); err != nil {
+			return fmt.Errorf("error writing metrics: %w", err)
+		}
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("error writing metrics file: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// updateHistoryStatus records whether posting a history entry succeeded
+func updateHistoryStatus(db *storage.SQLiteStorage, id int64, postErr error) {
+	status := storage.StatusPosted
+	if postErr != nil {
+		status = storage.StatusFailed
+	}
+	if err := db.SetStatus(id, status); err != nil {
+		fmt.Printf("Warning: Failed to update history status: %v\n", err)
+	}
+}
+
+// loadFewShotExamples loads up to n posted history entries to use as examples
+func loadFewShotExamples(n, maxTokens int) ([]fewShotExample, error) {
+	db, err := storage.NewSQLiteStorage()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize history database: %w", err)
+	}
+	defer db.Close()
+
+	// Fetch a few extra entries in case some don't fit in the token budget
+	responses, err := db.ListPostedResponses(n * 3)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load few-shot examples: %w", err)
+	}
+
+	return selectFewShotExamples(responses, n, maxTokens), nil
+}
+
+func runVacuum(c *cli.Context) error {
+	db, err := storage.NewSQLiteStorage()
+	if err != nil {
+		return fmt.Errorf("failed to initialize history database: %w", err)
+	}
+	defer db.Close()
+
+	before, err := db.Size()
+	if err != nil {
+		return err
+	}
+
+	if c.Bool("compress") {
+		count, err := db.CompressHistory()
+		if err != nil {
+			return fmt.Errorf("failed to compress history: %w", err)
+		}
+		fmt.Printf("%d history entries compressed.\n", count)
+	}
+
+	if err := db.Vacuum(); err != nil {
+		return err
+	}
+
+	after, err := db.Size()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("History database vacuumed: %s -> %s\n", formatBytes(before), formatBytes(after))
+	return nil
+}
+
+// relativeTime formats t relative to now (e.g. 3 hours ago), falling back to
+// the absolute date for times older than a week
+func relativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s ago", unit)
+		}
+		return fmt.Sprintf("%d %ss ago", n, unit)
+	}
+
+	switch {
+	case d < 0:
+		return t.Format("2006-01-02 15:04:05")
+	case d < 10*time.Second:
+		return "just now"
+	case d < time.Minute:
+		return plural(int(d/time.Second), "second")
+	case d < time.Hour:
+		return plural(int(d/time.Minute), "minute")
+	case d < 24*time.Hour:
+		return plural(int(d/time.Hour), "hour")
+	case d < 7*24*time.Hour:
+		return plural(int(d/(24*time.Hour)), "day")
+	default:
+		return t.Format("2006-01-02 15:04:05")
+	}
+}
+
+// formatBytes formats a size in bytes for display
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// countDatasets returns the number of datasets in a history response, or 0
+// if it can't be parsed
+func countDatasets(response string) int {
+	var datasets []json.RawMessage
+	if err := json.Unmarshal([]byte(response), &datasets); err != nil {
+		return 0
+	}
+	return len(datasets)
+}
+
 // Helper function to truncate strings for display
 func truncateString(s string, maxLen int) string {
-	if len(s) <= maxLen {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
 		return s
 	}
-	return s[:maxLen-3] + "..."
+	return string(runes[:maxLen-3]) + "..."
 }
 
 func runPostHistory(c *cli.Context) error {
 	if c.NArg() == 0 {
-		return fmt.Errorf("history ID is required")
+		return usagef("history ID is required")
 	}
 
 	id, err := strconv.ParseInt(c.Args().Get(0), 10, 64)
 	if err != nil {
-		return fmt.Errorf("invalid history ID: %w", err)
+		return usagef("invalid history ID: %v", err)
 	}
 
-	datahubURL := c.String("datahub-gms-url")
-	datahubToken := c.String("datahub-gms-token")
-
 	db, err := storage.NewSQLiteStorage()
 	if err != nil {
 		return fmt.Errorf("failed to initialize history database: %w", err)
@@ -665,25 +2019,40 @@ func runPostHistory(c *cli.Context) error {
 		return fmt.Errorf("failed to get history entry: %w", err)
 	}
 
-	fmt.Printf("Sending datasets (ID: %d) to DataHub...\n", resp.ID)
+	if !c.Bool("json") {
+		fmt.Printf("Sending datasets (ID: %d) to DataHub...\n", resp.ID)
+	}
 
 	// Execute post-dataset command
-	dh := datahub.NewClient(datahubURL, datahubToken)
-	count, err := dh.PostEntity("dataset", resp.Response)
+	dh, err := newPosterFromContext(c)
+	if err != nil {
+		return err
+	}
+	payload, err := formatPayload(c, []byte(resp.Response))
 	if err != nil {
-		return fmt.Errorf("error posting dataset: %w", err)
+		return err
+	}
+	if err := checkDatasetDuplicates(payload, c.Bool("strict")); err != nil {
+		return err
+	}
+	count, err := dh.PostEntity("dataset", payload, &datahub.PostOptions{ContinueOnError: c.Bool("continue-on-error")})
+	updateHistoryStatus(db, resp.ID, err)
+	if err != nil || count > 1 || c.Bool("json") {
+		return reportPost(c, "dataset", payload, count, err)
+	}
+	if err := writeURNsFile(c, newPostReport("dataset", payload, count, nil).URNs); err != nil {
+		return err
 	}
 
-	if count > 1 {
-		fmt.Printf("%d datasets successfully sent to DataHub!\n", count)
-	} else {
-		fmt.Println("Dataset successfully sent to DataHub!")
-		fmt.Println()
-		fmt.Println("Dataset info")
-		fmt.Println("-------------")
-		fmt.Printf("Schema URN: %s\n", resp.SchemaURN)
-		fmt.Printf("Schema Name: %s\n", resp.SchemaName)
-		fmt.Printf("Dataset Name: %s\n", resp.DatasetName)
+	fmt.Println("Dataset successfully sent to DataHub!")
+	fmt.Println()
+	fmt.Println("Dataset info")
+	fmt.Println("-------------")
+	fmt.Printf("Schema URN: %s\n", resp.SchemaURN)
+	fmt.Printf("Schema Name: %s\n", resp.SchemaName)
+	fmt.Printf("Dataset Name: %s\n", resp.DatasetName)
+	if uiURL, err := datasetUIURL(c, resp.SchemaURN); err == nil && resp.SchemaURN != "" {
+		fmt.Printf("URL: %s\n", uiURL)
 	}
 
 	return nil
@@ -714,21 +2083,39 @@ func runAddGlossaryTerm(c *cli.Context) error {
 		urn = "urn:li:glossaryTerm:" + name
 	}
 	definition := c.String("definition")
+	source := strings.ToUpper(c.String("term-source"))
+	if err := datahub.ValidateTermSource(source); err != nil {
+		return err
+	}
+	actor := c.String("actor")
 
 	datahubURL := c.String("datahub-gms-url")
 	datahubToken := c.String("datahub-gms-token")
 
-	dh := datahub.NewClient(datahubURL, datahubToken)
+	graphQL, err := useGraphQL(c)
+	if err != nil {
+		return err
+	}
+	if graphQL && actor != "" {
+		return usagef("--actor is not supported with the GraphQL API")
+	}
+	if graphQL && source != "INTERNAL" {
+		return usagef("--term-source %s is not supported with the GraphQL API", source)
+	}
+
 	gTerm := datahub.GlossaryTerm{
 		URN: urn,
 		Info: datahub.GlossaryTermInfo{
 			Value: datahub.GlossaryTermValue{
 				Name:       name,
 				Definition: definition,
-				Source:     "INTERNAL",
+				Source:     source,
 			},
 		},
 	}
+	if actor != "" {
+		gTerm.SetCreator(actor)
+	}
 
 	terms := []datahub.GlossaryTerm{gTerm}
 	payload, err := json.Marshal(terms)
@@ -736,7 +2123,31 @@ func runAddGlossaryTerm(c *cli.Context) error {
 		return fmt.Errorf("error encoding glossary term to JSON: %w", err)
 	}
 
-	_, err = dh.PostEntity("glossaryTerm", string(payload))
+	if graphQL {
+		gql, err := newGraphQLClient(c.Context, datahubURL, datahubToken)
+		if err != nil {
+			return err
+		}
+		_, err = gql.CreateGlossaryTerm(gTerm)
+		if c.Bool("json") {
+			return reportPost(c, "glossaryTerm", string(payload), 0, err)
+		}
+		if err != nil {
+			return fmt.Errorf("error adding glossary term: %w", err)
+		}
+		fmt.Println("Glossary term successfully added to DataHub!")
+		return nil
+	}
+
+	dh, err := newPosterFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	count, err := dh.PostEntity("glossaryTerm", string(payload), nil)
+	if c.Bool("json") {
+		return reportPost(c, "glossaryTerm", string(payload), count, err)
+	}
 	if err != nil {
 		return fmt.Errorf("error adding glossary term: %w", err)
 	}
@@ -745,31 +2156,72 @@ func runAddGlossaryTerm(c *cli.Context) error {
 	return nil
 }
 
+func runUpdateGlossaryTerm(c *cli.Context) error {
+	urn := c.String("urn")
+	definition := c.String("definition")
+
+	datahubURL := c.String("datahub-gms-url")
+	datahubToken := c.String("datahub-gms-token")
+
+	graphQL, err := useGraphQL(c)
+	if err != nil {
+		return err
+	}
+
+	type termUpdater interface {
+		UpdateGlossaryTermDefinition(urn, definition string, appendDef bool) error
+	}
+	var updater termUpdater
+	if graphQL {
+		updater, err = newGraphQLClient(c.Context, datahubURL, datahubToken)
+	} else {
+		updater, err = newClientFromContext(c)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := updater.UpdateGlossaryTermDefinition(urn, definition, c.Bool("append-definition")); err != nil {
+		return fmt.Errorf("error updating glossary term: %w", err)
+	}
+
+	fmt.Println("Glossary term successfully updated in DataHub!")
+	return nil
+}
+
 func runFromJSON(c *cli.Context) error {
 	filePath := c.Args().First()
 	entityType := c.String("entity-type")
-
-	if filePath == "" {
-		return errors.New("file path is required")
+	mergeInto := c.String("merge-into")
+	if err := validateMergeInto(mergeInto); err != nil {
+		return err
 	}
 
-	data, err := os.ReadFile(filePath)
+	data, err := readInputFile(filePath, os.Stdin)
 	if err != nil {
-		return fmt.Errorf("error reading file: %w", err)
+		return err
+	}
+
+	if entityType == "" {
+		entityType, err = datahub.DetectEntityType(data)
+		if err != nil {
+			return err
+		}
+		log.Debugf("Detected entity type %s\n", entityType)
 	}
 
 	// if entity-type is dataset it'll be an array of Dataset objects
 	var datasets []datahub.Dataset
 	var glossaryTerms []datahub.GlossaryTerm
-	var entities interface{}
 
+	disallowUnknown := c.Bool("disallow-unknown-fields")
 	switch entityType {
 	case "dataset":
-		err = json.Unmarshal(data, &datasets)
-		entities = datasets
+		datasets, err = datahub.DecodeEntities[datahub.Dataset](data, disallowUnknown)
 	case "glossaryTerm":
-		err = json.Unmarshal(data, &glossaryTerms)
-		entities = glossaryTerms
+		glossaryTerms, err = datahub.DecodeEntities[datahub.GlossaryTerm](data, disallowUnknown)
+	case "tag":
+		_, err = datahub.DecodeEntities[map[string]json.RawMessage](data, false)
 	default:
 		return fmt.Errorf("unsupported entity type: %s", entityType)
 	}
@@ -778,24 +2230,170 @@ func runFromJSON(c *cli.Context) error {
 		return fmt.Errorf("error decoding JSON: %w", err)
 	}
 
+	dups := datahub.DuplicateURNs(datasets)
+	if entityType == "glossaryTerm" {
+		dups = datahub.DuplicateURNs(glossaryTerms)
+	}
+	if err := checkDuplicateURNs(dups, c.Bool("strict")); err != nil {
+		return err
+	}
+
+	if dir := c.String("output-dir"); dir != "" {
+		if entityType != "dataset" {
+			return usagef("--output-dir only supports datasets, got %s", entityType)
+		}
+		paths, err := writeDatasetFiles(dir, string(data))
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			fmt.Printf("Wrote %s\n", path)
+		}
+	}
+
 	datahubURL := c.String("datahub-gms-url")
 	datahubToken := c.String("datahub-gms-token")
 
-	dh := datahub.NewClient(datahubURL, datahubToken)
-	jblob, err := json.MarshalIndent(entities, "", "  ")
+	graphQL, err := useGraphQL(c)
 	if err != nil {
-		return fmt.Errorf("error encoding datasets to JSON: %w", err)
+		return err
+	}
+
+	if mergeInto != "" {
+		if entityType != "dataset" {
+			return usagef("--merge-into only supports datasets, got %s", entityType)
+		}
+		if graphQL {
+			return usagef("--merge-into is not supported with the GraphQL API")
+		}
+		dh, err := newClientFromContext(c)
+		if err != nil {
+			return err
+		}
+		if err := mergeDatasetFields(dh, mergeInto, string(data), os.Stdout); err != nil {
+			return err
+		}
+		return writeURNsFile(c, []string{mergeInto})
+	}
+
+	var count int
+	if graphQL {
+		var gql *graphql.Client
+		if gql, err = newGraphQLClient(c.Context, datahubURL, datahubToken); err != nil {
+			return err
+		}
+		count, err = postGraphQL(gql, entityType, datasets, glossaryTerms, c.Bool("continue-on-error"))
+	} else {
+		var dh entityPoster
+		if dh, err = newPosterFromContext(c); err != nil {
+			return err
+		}
+		var payload string
+		if payload, err = formatPayload(c, data); err != nil {
+			return err
+		}
+		count, err = dh.PostEntity(entityType, payload, &datahub.PostOptions{ContinueOnError: c.Bool("continue-on-error")})
+	}
+	return reportPost(c, entityType, string(data), count, err)
+}
+
+// checkDuplicateURNs warns about entities sharing a URN, since DataHub only
+// keeps one of them. It fails instead when strict is set.
+func checkDuplicateURNs(dups []string, strict bool) error {
+	if len(dups) == 0 {
+		return nil
+	}
+	if strict {
+		return fmt.Errorf("duplicate URNs found: %s", strings.Join(dups, ", "))
+	}
+	fmt.Fprintf(os.Stderr, "Warning: duplicate URNs found, DataHub will only keep one of each: %s\n", strings.Join(dups, ", "))
+	return nil
+}
+
+// validateMergeInto returns an error if the --merge-into value is set and
+// isn't a dataset URN
+func validateMergeInto(urn string) error {
+	if urn != "" && !strings.HasPrefix(urn, "urn:li:dataset:") {
+		return usagef("invalid --merge-into dataset URN: %s", urn)
+	}
+	return nil
+}
+
+// mergeDatasetFields adds the fields of the dataset in payload to the
+// existing dataset urn, warning about the fields redefined with another type
+func mergeDatasetFields(dh *datahub.Client, urn, payload string, out io.Writer) error {
+	var datasets []datahub.Dataset
+	if err := json.Unmarshal([]byte(payload), &datasets); err != nil {
+		return fmt.Errorf("error decoding JSON: %w", err)
+	}
+	if len(datasets) != 1 {
+		return usagef("--merge-into needs a single dataset, got %d", len(datasets))
 	}
 
-	count, err := dh.PostEntity(entityType, string(jblob))
+	added, conflicts, err := dh.MergeSchemaFields(urn, datasets[0].SchemaMetadata.Value.Fields)
+	for _, conflict := range conflicts {
+		fmt.Fprintf(os.Stderr, "Warning: field %s, keeping the existing field\n", conflict)
+	}
 	if err != nil {
-		return fmt.Errorf("error adding datasets: %w", err)
+		return fmt.Errorf("error merging the fields into %s: %w", urn, err)
 	}
 
-	fmt.Printf("%d entities successfully created in DataHub!\n", count)
+	fmt.Fprintf(out, "%d fields added to %s\n", added, urn)
 	return nil
 }
 
+// checkDatasetDuplicates runs checkDuplicateURNs on a JSON array of datasets
+func checkDatasetDuplicates(payload string, strict bool) error {
+	var datasets []datahub.Dataset
+	if err := json.Unmarshal([]byte(payload), &datasets); err != nil {
+		return fmt.Errorf("error decoding JSON: %w", err)
+	}
+	return checkDuplicateURNs(datahub.DuplicateURNs(datasets), strict)
+}
+
+// formatPayload returns the JSON payload to post. The original bytes are
+// passed through unless --pretty is set, so fields unknown to dsg are kept.
+func formatPayload(c *cli.Context, data []byte) (string, error) {
+	if c.Bool("pretty") && c.Bool("compact") {
+		return "", fmt.Errorf("--pretty and --compact are mutually exclusive")
+	}
+	if !c.Bool("pretty") {
+		return string(data), nil
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return "", fmt.Errorf("error indenting JSON: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// readInputFile reads the file at path, or stdin when path is empty or "-"
+// and stdin is piped
+func readInputFile(path string, stdin *os.File) ([]byte, error) {
+	if path != "" && path != "-" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading file: %w", err)
+		}
+		return data, nil
+	}
+
+	fi, err := stdin.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("error reading stdin: %w", err)
+	}
+	if path == "" && fi.Mode()&os.ModeCharDevice != 0 {
+		return nil, usagef("file path is required (or pipe the JSON to stdin)")
+	}
+
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return nil, fmt.Errorf("error reading stdin: %w", err)
+	}
+	return data, nil
+}
+
 type HistoryItem struct {
 	Prompt   string            `json:"prompt"`
 	Datasets []datahub.Dataset `json:"datasets"`
@@ -805,7 +2403,7 @@ func runFromHistoryFile(c *cli.Context) error {
 	filePath := c.Args().First()
 
 	if filePath == "" {
-		return errors.New("file path is required")
+		return usagef("file path is required")
 	}
 
 	data, err := os.ReadFile(filePath)
@@ -813,28 +2411,310 @@ func runFromHistoryFile(c *cli.Context) error {
 		return fmt.Errorf("error reading file: %w", err)
 	}
 
-	// if entity-type is dataset it'll be an array of Dataset objects
-	var item HistoryItem
-
-	err = json.Unmarshal(data, &item)
+	// History files have the datasets of a HistoryItem, or the raw response
+	// of an exported history entry
+	var item struct {
+		Datasets json.RawMessage `json:"datasets"`
+		Response string          `json:"response"`
+	}
+	if err := json.Unmarshal(data, &item); err != nil {
+		return fmt.Errorf("error decoding history file %s: %w", filePath, err)
+	}
+	raw := item.Datasets
+	if len(raw) == 0 || string(raw) == "null" {
+		raw = json.RawMessage(item.Response)
+	}
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return &datahub.ValidationError{Msg: fmt.Sprintf("history file %s has no datasets or response", filePath)}
+	}
+	datasets, err := datahub.DecodeEntities[datahub.Dataset](raw, false)
 	if err != nil {
-		return fmt.Errorf("error decoding JSON: %w", err)
+		return fmt.Errorf("invalid datasets in history file %s: %w", filePath, err)
 	}
 
-	datahubURL := c.String("datahub-gms-url")
-	datahubToken := c.String("datahub-gms-token")
+	if err := checkDuplicateURNs(datahub.DuplicateURNs(datasets), c.Bool("strict")); err != nil {
+		return err
+	}
 
-	dh := datahub.NewClient(datahubURL, datahubToken)
-	jblob, err := json.MarshalIndent(item.Datasets, "", "  ")
+	dh, err := newPosterFromContext(c)
+	if err != nil {
+		return err
+	}
+	jblob, err := json.MarshalIndent(datasets, "", "  ")
 	if err != nil {
 		return fmt.Errorf("error encoding datasets to JSON: %w", err)
 	}
 
-	count, err := dh.PostEntity("dataset", string(jblob))
+	count, err := dh.PostEntity("dataset", string(jblob), &datahub.PostOptions{ContinueOnError: c.Bool("continue-on-error")})
+	if c.Bool("json") {
+		return reportPost(c, "dataset", string(jblob), count, err)
+	}
 	if err != nil {
+		if count > 0 {
+			fmt.Printf("%d entities successfully created in DataHub before the errors\n", count)
+		}
 		return fmt.Errorf("error adding datasets: %w", err)
 	}
 
 	fmt.Printf("%d entities successfully created in DataHub!\n", count)
+	for _, urn := range newPostReport("dataset", string(jblob), count, nil).URNs {
+		fmt.Printf("  %s\n", urn)
+	}
+	return nil
+}
+
+func runBrowse(c *cli.Context) error {
+	path := c.Args().First()
+
+	dh, err := newClientFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	result, err := dh.Browse(path)
+	if err != nil {
+		return fmt.Errorf("error browsing %s: %w", path, err)
+	}
+
+	if len(result.Groups) == 0 && len(result.Entities) == 0 {
+		fmt.Printf("Nothing found at %s\n", result.Path)
+		return nil
+	}
+
+	fmt.Printf("Path: %s\n", result.Path)
+
+	if len(result.Groups) > 0 {
+		fmt.Println()
+		fmt.Printf("%-50s %-10s\n", "PATH", "DATASETS")
+		fmt.Println(strings.Repeat("-", 61))
+		for _, group := range result.Groups {
+			fmt.Printf("%-50s %-10d\n", truncateString(strings.TrimRight(result.Path, "/")+"/"+group.Name, 48), group.Count)
+		}
+	}
+
+	if len(result.Entities) > 0 {
+		fmt.Println()
+		fmt.Printf("%-30s %-70s\n", "NAME", "URN")
+		fmt.Println(strings.Repeat("-", 101))
+		for _, entity := range result.Entities {
+			fmt.Printf("%-30s %-70s\n", truncateString(entity.Name, 28), entity.URN)
+		}
+	}
+
+	return nil
+}
+
+func runListDatasets(c *cli.Context) error {
+	checkpointFile := c.String("checkpoint-file")
+
+	dh, err := newClientFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	dh.MaxBodySize = c.Int64("max-body-size")
+	if c.Bool("cache") {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return fmt.Errorf("error finding the cache directory: %w", err)
+		}
+		dh.Cache, err = datahub.NewDiskCache(filepath.Join(cacheDir, "dsg", "datasets"))
+		if err != nil {
+			return err
+		}
+	}
+
+	opts := &datahub.ListOptions{
+		PerPage:            c.Int("per-page"),
+		IncludeSoftDeleted: c.Bool("include-soft-deleted"),
+		ScrollID:           c.String("scroll-id"),
+		MaxResults:         c.Int("limit"),
+		Platform:           platformURN(c.String("platform")),
+	}
+
+	if c.Bool("count-only") {
+		total, err := dh.CountDatasets(opts)
+		if err != nil {
+			return fmt.Errorf("error counting datasets: %w", err)
+		}
+		fmt.Println(total)
+		return nil
+	}
+
+	// Resume from the checkpoint file if no scroll ID was given
+	if opts.ScrollID == "" && checkpointFile != "" {
+		data, err := os.ReadFile(checkpointFile)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("error reading checkpoint file: %w", err)
+		}
+		opts.ScrollID = strings.TrimSpace(string(data))
+		if opts.ScrollID != "" {
+			log.Debugf("Resuming dataset listing from scroll ID %s\n", opts.ScrollID)
+		}
+	}
+
+	fmt.Printf("%-80s %-30s %-8s\n", "URN", "SCHEMA NAME", "DELETED")
+	fmt.Println(strings.Repeat("-", 120))
+	it := dh.NewDatasetIterator(opts)
+	for {
+		datasets, err := it.Next()
+		if err != nil {
+			if it.ScrollID() != "" {
+				fmt.Printf("Resume the listing with --scroll-id %s\n", it.ScrollID())
+			}
+			return fmt.Errorf("error listing datasets: %w", err)
+		}
+		if datasets == nil {
+			break
+		}
+
+		for _, ds := range datasets {
+			deleted := ""
+			if ds.SoftDeleted() {
+				deleted = "yes"
+			}
+			fmt.Printf("%-80s %-30s %-8s\n",
+				truncateString(ds.URN, 78),
+				truncateString(ds.SchemaMetadata.Value.SchemaName, 28),
+				deleted)
+		}
+
+		log.Debugf("Next scroll ID: %s\n", it.ScrollID())
+		if checkpointFile != "" {
+			if err := os.WriteFile(checkpointFile, []byte(it.ScrollID()), 0644); err != nil {
+				return fmt.Errorf("error writing checkpoint file: %w", err)
+			}
+		}
+	}
+
+	// The listing finished, nothing to resume
+	if checkpointFile != "" {
+		if err := os.Remove(checkpointFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("error removing checkpoint file: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func runListTerms(c *cli.Context) error {
+	dh, err := newClientFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	opts := &datahub.ListOptions{
+		PerPage:            c.Int("per-page"),
+		IncludeSoftDeleted: c.Bool("include-soft-deleted"),
+		MaxResults:         c.Int("limit"),
+	}
+
+	fmt.Printf("%-60s %-30s %-8s\n", "URN", "NAME", "DELETED")
+	fmt.Println(strings.Repeat("-", 100))
+	err = dh.GetGlossaryTerms(func(terms []*datahub.GlossaryTerm) error {
+		for _, term := range terms {
+			deleted := ""
+			if term.SoftDeleted() {
+				deleted = "yes"
+			}
+			fmt.Printf("%-60s %-30s %-8s\n",
+				truncateString(term.URN, 58),
+				truncateString(term.Info.Value.Name, 28),
+				deleted)
+		}
+		return nil
+	}, opts)
+	if err != nil {
+		return fmt.Errorf("error listing glossary terms: %w", err)
+	}
+
+	return nil
+}
+
+func runSetOwner(c *cli.Context) error {
+	datasetURN := c.String("dataset-urn")
+	ownerType := strings.ToUpper(c.String("type"))
+
+	ownership := datahub.Ownership{
+		LastModified: datahub.NewAuditStamp(""),
+	}
+	for _, owner := range c.StringSlice("owner") {
+		ownership.Owners = append(ownership.Owners, datahub.Owner{
+			Owner: owner,
+			Type:  ownerType,
+		})
+	}
+
+	dh, err := newClientFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	if err := dh.SetOwnership(datasetURN, ownership); err != nil {
+		return fmt.Errorf("error setting dataset owners: %w", err)
+	}
+
+	fmt.Println("Dataset owners successfully updated in DataHub!")
+	return nil
+}
+
+func runAssignDomain(c *cli.Context) error {
+	datasetURN := c.String("dataset-urn")
+	domainURN := c.String("domain")
+
+	if err := datahub.ValidateDomainURN(domainURN); err != nil {
+		return err
+	}
+
+	dh, err := newClientFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	if c.Bool("create-if-missing") {
+		exists, err := dh.EntityExists("domain", domainURN)
+		if err != nil {
+			return fmt.Errorf("error checking domain: %w", err)
+		}
+		if !exists {
+			if err := dh.CreateDomain(domainURN); err != nil {
+				return fmt.Errorf("error creating domain: %w", err)
+			}
+			fmt.Printf("Domain %s created.\n", domainURN)
+		}
+	}
+
+	if err := dh.SetDomains(datasetURN, domainURN); err != nil {
+		return fmt.Errorf("error assigning domain: %w", err)
+	}
+
+	fmt.Println("Dataset domain successfully updated in DataHub!")
+	return nil
+}
+
+func runAddLineage(c *cli.Context) error {
+	datasetURN := c.String("dataset-urn")
+	lineageType := strings.ToUpper(c.String("type"))
+
+	stamp := datahub.NewAuditStamp("")
+	var upstreams []datahub.Upstream
+	for _, upstream := range c.StringSlice("upstream") {
+		upstreams = append(upstreams, datahub.Upstream{
+			AuditStamp: stamp,
+			Dataset:    upstream,
+			Type:       lineageType,
+		})
+	}
+
+	dh, err := newClientFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	if err := dh.AddUpstreams(datasetURN, upstreams...); err != nil {
+		return fmt.Errorf("error adding lineage: %w", err)
+	}
+
+	fmt.Printf("%d upstreams successfully added to the dataset lineage!\n", len(upstreams))
 	return nil
 }
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+	storage "github.com/rubiojr/dsg/internal/storage/sqlite"
+)
+
+// runApp runs dsg with args and returns what it printed to stdout
+func runApp(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	out := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- string(data)
+	}()
+
+	err = newApp().RunContext(context.Background(), append([]string{"dsg"}, args...))
+	w.Close()
+
+	return <-out, err
+}
+
+// withStdin makes the commands run by the test read input from stdin
+func withStdin(t *testing.T, input string) {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(input)
+	f.Seek(0, io.SeekStart)
+
+	stdin := os.Stdin
+	os.Stdin = f
+	t.Cleanup(func() {
+		os.Stdin = stdin
+		f.Close()
+	})
+}
+
+// testDataDir stores the history of the commands run by the test in a
+// temporary directory, returned
+func testDataDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("DSG_DATA_DIR", dir)
+	return dir
+}
+
+// seedHistory saves responses to the history database in dataDir and
+// returns their IDs
+func seedHistory(t *testing.T, dataDir string, responses ...*storage.Response) []int64 {
+	t.Helper()
+	db, err := storage.NewSQLiteStorage(storage.WithDataDir(dataDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var ids []int64
+	for _, resp := range responses {
+		id, err := db.SaveResponse(resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// datasetJSON returns a JSON array with a minimal MySQL dataset per name
+func datasetJSON(names ...string) string {
+	var datasets []string
+	for _, name := range names {
+		datasets = append(datasets, fmt.Sprintf(`{
+  "urn": "urn:li:dataset:(urn:li:dataPlatform:mysql,%[1]s,PROD)",
+  "datasetKey": {"value": {"platform": "urn:li:dataPlatform:mysql", "name": %[1]q, "origin": "PROD"}},
+  "schemaMetadata": {"value": {
+    "schemaName": %[1]q,
+    "platform": "urn:li:dataPlatform:mysql",
+    "version": 0,
+    "hash": "",
+    "platformSchema": {"com.linkedin.schema.MySqlDDL": {"tableSchema": ""}},
+    "fields": [{"fieldPath": "id", "type": {"type": {"com.linkedin.schema.NumberType": {}}}, "nativeDataType": "int"}]
+  }}
+}`, name))
+	}
+	return "[" + strings.Join(datasets, ",") + "]"
+}
+
+// datasetURN returns the URN of the datasetJSON dataset called name
+func datasetURN(name string) string {
+	return "urn:li:dataset:(urn:li:dataPlatform:mysql," + name + ",PROD)"
+}
+
+// datahubStub stubs the DataHub entity endpoints, recording the posted
+// entities. Entities are not found unless set in entities.
+type datahubStub struct {
+	*httptest.Server
+	mu     sync.Mutex
+	posted []map[string]json.RawMessage
+	// bodies holds the raw bodies of the posts
+	bodies   []string
+	entities map[string]string
+	// fail makes the posts of the entities it returns true for fail
+	fail func(urn string) bool
+}
+
+func newDataHubStub(t *testing.T) *datahubStub {
+	s := &datahubStub{entities: map[string]string{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if r.Method == http.MethodGet {
+			urn := strings.TrimPrefix(r.URL.Path, "/openapi/v3/entity/")
+			if i := strings.Index(urn, "/"); i >= 0 {
+				urn = urn[i+1:]
+			}
+			entity, ok := s.entities[urn]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			io.WriteString(w, entity)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		s.bodies = append(s.bodies, string(body))
+		var items []map[string]json.RawMessage
+		if err := json.Unmarshal(body, &items); err != nil {
+			t.Errorf("unexpected request body: %v", err)
+			return
+		}
+		for _, item := range items {
+			var urn string
+			json.Unmarshal(item["urn"], &urn)
+			if s.fail != nil && s.fail(urn) {
+				http.Error(w, "rejected", http.StatusBadRequest)
+				return
+			}
+			s.posted = append(s.posted, item)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// postedURNs returns the URNs of the posted entities
+func (s *datahubStub) postedURNs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var urns []string
+	for _, item := range s.posted {
+		var urn string
+		json.Unmarshal(item["urn"], &urn)
+		urns = append(urns, urn)
+	}
+	return urns
+}
+
+func TestBrowseCommand(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"value": datahub.BrowseResult{
+			Groups:      []datahub.BrowseGroup{{Name: "db1", Count: 3}},
+			Entities:    []datahub.BrowseEntity{{Name: "users", URN: "urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)"}},
+			NumEntities: 1,
+		}})
+	}))
+	defer srv.Close()
+
+	out, err := runApp(t, "browse", "--datahub-gms-url", srv.URL, "/prod/mysql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Path: /prod/mysql",
+		"/prod/mysql/db1",
+		"users",
+		"urn:li:dataset:(urn:li:dataPlatform:mysql,users,PROD)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output is missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestListTermsCommand(t *testing.T) {
+	var mu sync.Mutex
+	var scrollIDs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		scrollIDs = append(scrollIDs, r.URL.Query().Get("scrollId"))
+		mu.Unlock()
+		if r.URL.Query().Get("scrollId") == "" {
+			io.WriteString(w, `{"scrollId": "page2", "entities": [{"urn": "urn:li:glossaryTerm:email", "glossaryTermInfo": {"value": {"name": "Email"}}}]}`)
+			return
+		}
+		io.WriteString(w, `{"entities": [{"urn": "urn:li:glossaryTerm:ssn", "glossaryTermInfo": {"value": {"name": "SSN"}}, "status": {"value": {"removed": true}}}]}`)
+	}))
+	defer srv.Close()
+
+	out, err := runApp(t, "terms", "list", "--datahub-gms-url", srv.URL, "--per-page", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "URN") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+	if fields := strings.Fields(lines[2]); len(fields) != 2 || fields[0] != "urn:li:glossaryTerm:email" || fields[1] != "Email" {
+		t.Errorf("first term = %q", lines[2])
+	}
+	if fields := strings.Fields(lines[3]); len(fields) != 3 || fields[0] != "urn:li:glossaryTerm:ssn" || fields[2] != "yes" {
+		t.Errorf("soft deleted term = %q", lines[3])
+	}
+	if len(scrollIDs) != 2 || scrollIDs[1] != "page2" {
+		t.Errorf("scroll IDs = %q", scrollIDs)
+	}
+}
+
+func TestAddTermSourceAndActor(t *testing.T) {
+	dh := newDataHubStub(t)
+
+	if _, err := runApp(t, "add-term", "--datahub-gms-url", dh.URL, "--name", "Email"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := runApp(t, "add-term", "--datahub-gms-url", dh.URL, "--name", "SSN",
+		"--urn", "urn:li:glossaryTerm:ssn", "--definition", "A social security number",
+		"--term-source", "external", "--actor", "urn:li:corpuser:alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dh.posted) != 2 {
+		t.Fatalf("posted %d entities, want 2", len(dh.posted))
+	}
+
+	var term datahub.GlossaryTerm
+	json.Unmarshal(mustMarshal(t, dh.posted[0]), &term)
+	if term.URN != "urn:li:glossaryTerm:Email" || term.Info.Value.Source != "INTERNAL" || term.Ownership != nil {
+		t.Errorf("default term = %+v", term)
+	}
+
+	term = datahub.GlossaryTerm{}
+	json.Unmarshal(mustMarshal(t, dh.posted[1]), &term)
+	want := datahub.GlossaryTermValue{Name: "SSN", Definition: "A social security number", Source: "EXTERNAL"}
+	if term.URN != "urn:li:glossaryTerm:ssn" || term.Info.Value != want {
+		t.Errorf("term = %+v", term)
+	}
+	if term.Ownership == nil || term.Ownership.Value.Owners[0].Owner != "urn:li:corpuser:alice" || term.Ownership.Value.LastModified.Actor != "urn:li:corpuser:alice" {
+		t.Errorf("ownership = %+v", term.Ownership)
+	}
+
+	_, err = runApp(t, "add-term", "--datahub-gms-url", dh.URL, "--name", "Bad", "--term-source", "manual")
+	if exitCode(err) != exitValidation {
+		t.Errorf("err = %v, want a validation error", err)
+	}
+	for _, flags := range [][]string{{"--actor", "urn:li:corpuser:alice"}, {"--term-source", "EXTERNAL"}} {
+		args := append([]string{"add-term", "--api", "graphql", "--datahub-gms-url", dh.URL, "--name", "Bad"}, flags...)
+		if _, err := runApp(t, args...); exitCode(err) != exitUsage {
+			t.Errorf("%q with GraphQL: err = %v, want a usage error", flags, err)
+		}
+	}
+	if len(dh.posted) != 2 {
+		t.Errorf("posted %d entities, want 2", len(dh.posted))
+	}
+}
+
+// mustMarshal encodes v as JSON
+func mustMarshal(t *testing.T, v any) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
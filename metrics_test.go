@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMetricsFile(t *testing.T) {
+	t.Cleanup(func() { metricsCollector = nil })
+	dh := newDataHubStub(t)
+	input := filepath.Join(t.TempDir(), "datasets.json")
+	os.WriteFile(input, []byte(datasetJSON("alpha", "beta")), 0644)
+	path := filepath.Join(t.TempDir(), "metrics.prom")
+
+	if _, err := runApp(t, "--metrics-file", path, "from-json", "--datahub-gms-url", dh.URL, input); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// One post per dataset
+	if !strings.Contains(string(data), `dsg_http_requests_total{service="datahub",method="POST",status="200"} 2`+"\n") {
+		t.Errorf("metrics file:\n%s", data)
+	}
+	if strings.Contains(string(data), `service="openai"`) {
+		t.Errorf("OpenAI requests recorded:\n%s", data)
+	}
+}
+
+func TestWithMetricsDisabled(t *testing.T) {
+	metricsCollector = nil
+	client := &http.Client{}
+	if withMetrics(client, "datahub") != client {
+		t.Error("withMetrics wrapped the client with metrics disabled")
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+	"github.com/urfave/cli/v2"
+)
+
+// entityPoster posts entities to DataHub
+type entityPoster interface {
+	PostEntity(entityType, payload string, opts *datahub.PostOptions) (int, error)
+}
+
+// newPosterFromContext returns the poster of the DataHub instances in the
+// comma separated --datahub-gms-url. A single URL returns its client, see
+// newClientFromContext.
+func newPosterFromContext(c *cli.Context) (entityPoster, error) {
+	urls, tokens, err := datahubInstances(c)
+	if err != nil {
+		return nil, err
+	}
+	if len(urls) == 1 {
+		return newDataHubClient(c.Context, urls[0], tokens[0])
+	}
+
+	m := &multiPoster{strict: c.Bool("strict-instances"), out: os.Stderr}
+	for i, u := range urls {
+		dh, err := newDataHubClient(c.Context, u, tokens[i])
+		if err != nil {
+			return nil, err
+		}
+		m.clients = append(m.clients, dh)
+	}
+	return m, nil
+}
+
+// datahubInstances splits the comma separated --datahub-gms-url and
+// --datahub-gms-token values. A single token is shared by all the URLs.
+func datahubInstances(c *cli.Context) ([]string, []string, error) {
+	urls := splitList(c.String("datahub-gms-url"))
+	if len(urls) == 0 {
+		return nil, nil, usagef("--datahub-gms-url is required")
+	}
+	tokens := splitList(c.String("datahub-gms-token"))
+	switch len(tokens) {
+	case len(urls):
+	case 0, 1:
+		token := c.String("datahub-gms-token")
+		tokens = make([]string, len(urls))
+		for i := range tokens {
+			tokens[i] = strings.TrimSpace(token)
+		}
+	default:
+		return nil, nil, usagef("got %d DataHub tokens for %d URLs, pass one token or one per URL", len(tokens), len(urls))
+	}
+	return urls, tokens, nil
+}
+
+// splitList splits a comma separated list, dropping empty items
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// partialPostError is returned when the entities were posted to some of the
+// DataHub instances only. It wraps the errors of the failing instances.
+type partialPostError struct {
+	failed int
+	total  int
+	err    error
+}
+
+func (e *partialPostError) Error() string {
+	return fmt.Sprintf("%d of %d DataHub instances failed: %v", e.failed, e.total, e.err)
+}
+
+func (e *partialPostError) Unwrap() error {
+	return e.err
+}
+
+// multiPoster posts the same entities to several DataHub instances,
+// reporting the outcome of each one. A failing instance doesn't stop the
+// post to the rest, unless strict is set (--strict-instances).
+type multiPoster struct {
+	clients []*datahub.Client
+	strict  bool
+	out     io.Writer
+}
+
+// PostEntity posts the entities to every instance. When some of them fail,
+// it returns the number of entities posted to the instances that succeeded
+// and a *partialPostError. When all of them fail, or one fails with strict
+// set, it returns the fewest entities an instance got and the errors.
+func (m *multiPoster) PostEntity(entityType, payload string, opts *datahub.PostOptions) (int, error) {
+	var errs []error
+	// posted is the number of entities every instance got, succeeded the
+	// number of entities posted to an instance without errors
+	posted, succeeded := -1, -1
+	for _, dh := range m.clients {
+		url := redactURL(dh.URL)
+		count, err := dh.PostEntity(entityType, payload, opts)
+		if err != nil {
+			fmt.Fprintf(m.out, "Warning: %s: %d entities posted before the error: %v\n", url, count, err)
+			errs = append(errs, fmt.Errorf("%s: %w", url, err))
+		} else {
+			fmt.Fprintf(m.out, "%s: %d entities posted\n", url, count)
+			succeeded = count
+		}
+		if posted == -1 || count < posted {
+			posted = count
+		}
+		if err != nil && m.strict {
+			break
+		}
+	}
+
+	if len(errs) == 0 {
+		return posted, nil
+	}
+	if succeeded == -1 || m.strict {
+		return posted, errors.Join(errs...)
+	}
+	return succeeded, &partialPostError{failed: len(errs), total: len(m.clients), err: errors.Join(errs...)}
+}
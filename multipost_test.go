@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+	storage "github.com/rubiojr/dsg/internal/storage/sqlite"
+	"github.com/sashabaranov/go-openai"
+	"github.com/urfave/cli/v2"
+)
+
+func TestDataHubInstances(t *testing.T) {
+	tests := []struct {
+		urls, tokens string
+		wantURLs     []string
+		wantTokens   []string
+	}{
+		{"http://a", "", []string{"http://a"}, []string{""}},
+		{"http://a, http://b,", "secret", []string{"http://a", "http://b"}, []string{"secret", "secret"}},
+		{"http://a,http://b", "ta, tb", []string{"http://a", "http://b"}, []string{"ta", "tb"}},
+	}
+	for _, tt := range tests {
+		set := flag.NewFlagSet("test", flag.ContinueOnError)
+		set.String("datahub-gms-url", tt.urls, "")
+		set.String("datahub-gms-token", tt.tokens, "")
+		urls, tokens, err := datahubInstances(cli.NewContext(nil, set, nil))
+		if err != nil || !slices.Equal(urls, tt.wantURLs) || !slices.Equal(tokens, tt.wantTokens) {
+			t.Errorf("datahubInstances(%q, %q) = %q, %q, %v", tt.urls, tt.tokens, urls, tokens, err)
+		}
+	}
+
+	for _, tt := range [][2]string{{"", ""}, {" , ", "t"}, {"http://a,http://b,http://c", "ta,tb"}} {
+		set := flag.NewFlagSet("test", flag.ContinueOnError)
+		set.String("datahub-gms-url", tt[0], "")
+		set.String("datahub-gms-token", tt[1], "")
+		if _, _, err := datahubInstances(cli.NewContext(nil, set, nil)); exitCode(err) != exitUsage {
+			t.Errorf("datahubInstances(%q, %q) error = %v, want a usage error", tt[0], tt[1], err)
+		}
+	}
+}
+
+func TestMultiPoster(t *testing.T) {
+	staging, prod := newDataHubStub(t), newDataHubStub(t)
+	prod.fail = func(urn string) bool { return urn == datasetURN("beta") }
+	payload := datasetJSON("alpha", "beta")
+	all := []string{datasetURN("alpha"), datasetURN("beta")}
+
+	var out strings.Builder
+	m := &multiPoster{
+		clients: []*datahub.Client{datahub.NewClient(prod.URL, ""), datahub.NewClient(staging.URL, "")},
+		out:     &out,
+	}
+	count, err := m.PostEntity("dataset", payload, nil)
+	var partialErr *partialPostError
+	if !errors.As(err, &partialErr) || partialErr.failed != 1 || partialErr.total != 2 || !strings.Contains(err.Error(), prod.URL) || count != 2 {
+		t.Errorf("count = %d, err = %v, want a partial error", count, err)
+	}
+	// The instance errors are kept for the exit code
+	if exitCode(err) != exitRejected {
+		t.Errorf("exit code = %d, want %d", exitCode(err), exitRejected)
+	}
+	if urns := staging.postedURNs(); !slices.Equal(urns, all) {
+		t.Errorf("staging got %v", urns)
+	}
+	if urns := prod.postedURNs(); !slices.Equal(urns, []string{datasetURN("alpha")}) {
+		t.Errorf("prod got %v", urns)
+	}
+	for _, want := range []string{staging.URL + ": 2 entities posted", "Warning: " + prod.URL + ": 1 entities posted before the error"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output is missing %q:\n%s", want, out.String())
+		}
+	}
+
+	// Strict stops at the first failing instance
+	staging.posted, prod.posted = nil, nil
+	m.strict = true
+	count, err = m.PostEntity("dataset", payload, nil)
+	if err == nil || errors.As(err, &partialErr) || !strings.Contains(err.Error(), prod.URL) || count != 1 {
+		t.Errorf("strict: count = %d, err = %v", count, err)
+	}
+	if urns := staging.postedURNs(); len(urns) != 0 {
+		t.Errorf("strict: staging got %v", urns)
+	}
+
+	// Failing everywhere isn't a partial failure
+	staging.fail = prod.fail
+	m.strict = false
+	_, err = m.PostEntity("dataset", payload, nil)
+	if err == nil || errors.As(err, &partialErr) || !strings.Contains(err.Error(), staging.URL) || !strings.Contains(err.Error(), prod.URL) {
+		t.Errorf("err = %v, want the errors of both instances", err)
+	}
+}
+
+func TestFromJSONMultipleInstances(t *testing.T) {
+	staging, prod := newDataHubStub(t), newDataHubStub(t)
+	input := filepath.Join(t.TempDir(), "datasets.json")
+	os.WriteFile(input, []byte(datasetJSON("alpha", "beta")), 0644)
+
+	if _, err := runApp(t, "from-json", "--datahub-gms-url", staging.URL+","+prod.URL, input); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{datasetURN("alpha"), datasetURN("beta")}
+	for _, dh := range []*datahubStub{staging, prod} {
+		if urns := dh.postedURNs(); !slices.Equal(urns, want) {
+			t.Errorf("%s got %v", dh.URL, urns)
+		}
+		if len(dh.bodies) != 2 || !slices.Equal(dh.bodies, staging.bodies) {
+			t.Errorf("%s got bodies %q", dh.URL, dh.bodies)
+		}
+	}
+}
+
+func TestPartialPostFails(t *testing.T) {
+	dataDir := testDataDir(t)
+	ids := seedHistory(t, dataDir, &storage.Response{Prompt: "alpha beta", Response: datasetJSON("alpha", "beta")})
+	staging, prod := newDataHubStub(t), newDataHubStub(t)
+	prod.fail = func(urn string) bool { return urn == datasetURN("beta") }
+	urnsFile := filepath.Join(t.TempDir(), "urns.txt")
+
+	out, err := runApp(t, "post", "--output-urns-file", urnsFile, "--datahub-gms-url", staging.URL+","+prod.URL, strconv.FormatInt(ids[0], 10))
+	if err == nil || err.Error() != "1 of 2 DataHub instances failed" {
+		t.Errorf("err = %v, want the partial failure", err)
+	}
+	if !strings.Contains(out, "2 posted, 0 failed, 0 skipped") {
+		t.Errorf("unexpected report:\n%s", out)
+	}
+	if _, err := os.Stat(urnsFile); !os.IsNotExist(err) {
+		t.Errorf("the URNs file was written: %v", err)
+	}
+
+	db, err := storage.NewSQLiteStorage(storage.WithDataDir(dataDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if resp, err := db.GetResponse(ids[0]); err != nil || resp.Status != storage.StatusFailed {
+		t.Errorf("history entry = %+v, err = %v, want it failed", resp, err)
+	}
+}
+
+func TestBatchGenerateMultipleInstances(t *testing.T) {
+	testDataDir(t)
+	staging, prod := newDataHubStub(t), newDataHubStub(t)
+	apiBase, _ := openAIStub(t, func(req openai.ChatCompletionRequest) string {
+		return datasetJSON(userInput(req))
+	})
+	promptsFile := filepath.Join(t.TempDir(), "prompts.txt")
+	os.WriteFile(promptsFile, []byte("alpha\nbeta\n"), 0644)
+
+	_, err := runApp(t, "batch-generate",
+		"--prompts-file", promptsFile,
+		"--api-key", "test",
+		"--api-base", apiBase,
+		"--model", "m",
+		"--datahub-gms-url", staging.URL+","+prod.URL,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The prompts run concurrently
+	want := []string{datasetURN("alpha"), datasetURN("beta")}
+	for _, dh := range []*datahubStub{staging, prod} {
+		urns := dh.postedURNs()
+		slices.Sort(urns)
+		if !slices.Equal(urns, want) {
+			t.Errorf("%s got %v", dh.URL, urns)
+		}
+	}
+}
+
+func TestSingleInstanceCommands(t *testing.T) {
+	testDataDir(t)
+	input := filepath.Join(t.TempDir(), "datasets.json")
+	os.WriteFile(input, []byte(datasetJSON("alpha")), 0644)
+	urls := "http://staging:8080,http://prod:8080"
+
+	for _, args := range [][]string{
+		{"replay", "--datahub-gms-url", urls},
+		{"from-json", "--api", "graphql", "--datahub-gms-url", urls, input},
+		{"from-json", "--merge-into", datasetURN("alpha"), "--datahub-gms-url", urls, input},
+	} {
+		if _, err := runApp(t, args...); exitCode(err) != exitUsage {
+			t.Errorf("%q: err = %v, want a usage error", args, err)
+		}
+	}
+}
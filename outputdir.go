@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+)
+
+// unsafeFileChars matches the characters replaced in dataset file names
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// datasetFileName returns a file name (without extension) safe to use on
+// any platform for a schema name
+func datasetFileName(schemaName string) string {
+	name := unsafeFileChars.ReplaceAllString(schemaName, "_")
+	name = strings.Trim(name, "._")
+	if name == "" {
+		return "dataset"
+	}
+	return name
+}
+
+// writeDatasetFiles writes every dataset in the JSON array payload to
+// dir/<schemaName>.json, as a one element array that from-json can post.
+// Datasets sharing a name in payload get a numeric suffix, files from
+// previous runs are overwritten. It returns the paths of the files written.
+func writeDatasetFiles(dir, payload string) ([]string, error) {
+	var datasets []json.RawMessage
+	if err := json.Unmarshal([]byte(payload), &datasets); err != nil {
+		return nil, fmt.Errorf("error parsing dataset array: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating output directory: %w", err)
+	}
+
+	used := map[string]bool{}
+	paths := make([]string, 0, len(datasets))
+	for i, raw := range datasets {
+		var ds struct {
+			SchemaMetadata struct {
+				Value struct {
+					SchemaName string `json:"schemaName"`
+				} `json:"value"`
+			} `json:"schemaMetadata"`
+		}
+		if err := json.Unmarshal(raw, &ds); err != nil {
+			return paths, fmt.Errorf("error decoding dataset %d: %w", i+1, err)
+		}
+
+		base := datasetFileName(ds.SchemaMetadata.Value.SchemaName)
+		name := base
+		for n := 2; used[strings.ToLower(name)]; n++ {
+			name = fmt.Sprintf("%s-%d", base, n)
+		}
+		// Case-insensitive file systems would collide too
+		used[strings.ToLower(name)] = true
+
+		var buf bytes.Buffer
+		buf.WriteString("[")
+		buf.Write(raw)
+		buf.WriteString("]")
+		out, err := datahub.CanonicalJSON(buf.Bytes())
+		if err != nil {
+			return paths, fmt.Errorf("error formatting dataset %d: %w", i+1, err)
+		}
+		out = append(out, '\n')
+
+		path := filepath.Join(dir, name+".json")
+		if err := os.WriteFile(path, out, 0644); err != nil {
+			return paths, fmt.Errorf("error writing dataset file: %w", err)
+		}
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDatasetFileName(t *testing.T) {
+	tests := map[string]string{
+		"users":               "users",
+		"sales.orders":        "sales.orders",
+		"db/schema/table":     "db_schema_table",
+		"My Table (v2)":       "My_Table_v2",
+		"../../etc/passwd":    "etc_passwd",
+		"注文":                  "dataset",
+		"":                    "dataset",
+		"  user-events_2024 ": "user-events_2024",
+	}
+	for in, want := range tests {
+		if got := datasetFileName(in); got != want {
+			t.Errorf("datasetFileName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestWriteDatasetFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "datasets")
+	var datasets []json.RawMessage
+	json.Unmarshal([]byte(datasetJSON("users", "orders", "users", "Users", "a/b")), &datasets)
+	payload, _ := json.Marshal(datasets)
+
+	paths, err := writeDatasetFiles(dir, string(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, path := range paths {
+		names = append(names, filepath.Base(path))
+	}
+	want := []string{"users.json", "orders.json", "users-2.json", "Users-3.json", "a_b.json"}
+	if !slices.Equal(names, want) {
+		t.Fatalf("wrote %v, want %v", names, want)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != len(want) {
+		t.Errorf("%s has %d files, want %d", dir, len(entries), len(want))
+	}
+
+	for i, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var written []json.RawMessage
+		if err := json.Unmarshal(data, &written); err != nil {
+			t.Fatalf("%s is not a JSON array: %v", path, err)
+		}
+		if len(written) != 1 || !jsonEqual(t, written[0], datasets[i]) {
+			t.Errorf("%s = %s, want dataset %d", path, data, i+1)
+		}
+		if !strings.HasSuffix(string(data), "\n") {
+			t.Errorf("%s doesn't end in a new line", path)
+		}
+	}
+
+	if _, err := writeDatasetFiles(dir, "not json"); err == nil {
+		t.Error("expected an error for an invalid payload")
+	}
+}
+
+func TestFromJSONOutputDir(t *testing.T) {
+	dh := newDataHubStub(t)
+	dir := t.TempDir()
+	input := filepath.Join(t.TempDir(), "datasets.json")
+	os.WriteFile(input, []byte(datasetJSON("alpha", "beta")), 0644)
+
+	out, err := runApp(t, "from-json", "--output-dir", dir, "--datahub-gms-url", dh.URL, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"alpha", "beta"} {
+		path := filepath.Join(dir, name+".json")
+		if !strings.Contains(out, "Wrote "+path) {
+			t.Errorf("output is missing %s:\n%s", path, out)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !jsonEqual(t, data, []byte(datasetJSON(name))) {
+			t.Errorf("%s = %s", path, data)
+		}
+	}
+	if urns := dh.postedURNs(); len(urns) != 2 {
+		t.Errorf("posted %v", urns)
+	}
+}
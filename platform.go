@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+)
+
+// defaultPlatformKeywords maps keywords found in prompts to DataHub platform URNs
+var defaultPlatformKeywords = map[string]string{
+	"postgres":      "urn:li:dataPlatform:postgres",
+	"postgresql":    "urn:li:dataPlatform:postgres",
+	"mysql":         "urn:li:dataPlatform:mysql",
+	"mariadb":       "urn:li:dataPlatform:mariadb",
+	"snowflake":     "urn:li:dataPlatform:snowflake",
+	"bigquery":      "urn:li:dataPlatform:bigquery",
+	"redshift":      "urn:li:dataPlatform:redshift",
+	"hive":          "urn:li:dataPlatform:hive",
+	"kafka":         "urn:li:dataPlatform:kafka",
+	"oracle":        "urn:li:dataPlatform:oracle",
+	"sql server":    "urn:li:dataPlatform:mssql",
+	"mssql":         "urn:li:dataPlatform:mssql",
+	"mongodb":       "urn:li:dataPlatform:mongodb",
+	"mongo":         "urn:li:dataPlatform:mongodb",
+	"clickhouse":    "urn:li:dataPlatform:clickhouse",
+	"databricks":    "urn:li:dataPlatform:databricks",
+	"s3":            "urn:li:dataPlatform:s3",
+	"trino":         "urn:li:dataPlatform:trino",
+	"presto":        "urn:li:dataPlatform:presto",
+	"elasticsearch": "urn:li:dataPlatform:elasticsearch",
+	"dynamodb":      "urn:li:dataPlatform:dynamodb",
+	"cassandra":     "urn:li:dataPlatform:cassandra",
+	"sqlite":        "urn:li:dataPlatform:sqlite",
+}
+
+// loadPlatformKeywords loads a JSON object mapping keywords to platform URNs
+// from path, or returns the default keywords if path is empty
+func loadPlatformKeywords(path string) (map[string]string, error) {
+	if path == "" {
+		return defaultPlatformKeywords, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading platform keywords: %w", err)
+	}
+
+	var keywords map[string]string
+	if err := json.Unmarshal(data, &keywords); err != nil {
+		return nil, fmt.Errorf("error decoding platform keywords: %w", err)
+	}
+
+	return keywords, nil
+}
+
+// loadPlatformMap loads a JSON object mapping bare platform names to platform
+// URNs from path, or returns the default platform keywords if path is empty
+func loadPlatformMap(path string) (map[string]string, error) {
+	if path == "" {
+		return defaultPlatformKeywords, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading platform map: %w", err)
+	}
+
+	var platforms map[string]string
+	if err := json.Unmarshal(data, &platforms); err != nil {
+		return nil, fmt.Errorf("error decoding platform map: %w", err)
+	}
+
+	// Names are matched case-insensitively
+	normalized := make(map[string]string, len(platforms))
+	for name, urn := range platforms {
+		if !strings.HasPrefix(urn, datahub.PlatformURNPrefix) {
+			return nil, fmt.Errorf("invalid platform URN %q for %s in the platform map", urn, name)
+		}
+		normalized[strings.ToLower(name)] = urn
+	}
+
+	return normalized, nil
+}
+
+// platformURN returns the platform URN for a platform name or URN
+func platformURN(platform string) string {
+	if platform == "" || strings.HasPrefix(platform, "urn:li:dataPlatform:") {
+		return platform
+	}
+	return "urn:li:dataPlatform:" + strings.ToLower(platform)
+}
+
+// detectPlatform returns the platform URN of the keyword found first in input,
+// or an empty string if no keyword is found
+func detectPlatform(input string, keywords map[string]string) string {
+	input = strings.ToLower(input)
+
+	best, bestPos := "", -1
+	for keyword := range keywords {
+		re := regexp.MustCompile(`\b` + regexp.QuoteMeta(strings.ToLower(keyword)) + `\b`)
+		loc := re.FindStringIndex(input)
+		if loc == nil {
+			continue
+		}
+		// Prefer the earliest match, then the longest keyword
+		if bestPos == -1 || loc[0] < bestPos || (loc[0] == bestPos && len(keyword) > len(best)) {
+			best, bestPos = keyword, loc[0]
+		}
+	}
+
+	if best == "" {
+		return ""
+	}
+	return platformURN(keywords[best])
+}
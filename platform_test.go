@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectPlatform(t *testing.T) {
+	tests := map[string]string{
+		"a Postgres table for orders":               "urn:li:dataPlatform:postgres",
+		"a PostgreSQL table for orders":             "urn:li:dataPlatform:postgres",
+		"a mysql table":                             "urn:li:dataPlatform:mysql",
+		"orders in Snowflake":                       "urn:li:dataPlatform:snowflake",
+		"a SQL Server table":                        "urn:li:dataPlatform:mssql",
+		"a MongoDB collection":                      "urn:li:dataPlatform:mongodb",
+		"a kafka topic copied to bigquery":          "urn:li:dataPlatform:kafka",
+		"a table of mysqldump files":                "",
+		"a table of users":                          "",
+		"events landing in s3 from mysql databases": "urn:li:dataPlatform:s3",
+	}
+	for input, want := range tests {
+		if got := detectPlatform(input, defaultPlatformKeywords); got != want {
+			t.Errorf("detectPlatform(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestPlatformKeywordsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keywords.json")
+	os.WriteFile(path, []byte(`{"warehouse": "urn:li:dataPlatform:snowflake", "lake": "iceberg"}`), 0644)
+
+	keywords, err := loadPlatformKeywords(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := detectPlatform("a warehouse table", keywords); got != "urn:li:dataPlatform:snowflake" {
+		t.Errorf("detectPlatform = %q", got)
+	}
+	if got := detectPlatform("a lake table", keywords); got != "urn:li:dataPlatform:iceberg" {
+		t.Errorf("detectPlatform with a bare platform name = %q", got)
+	}
+	if got := detectPlatform("a postgres table", keywords); got != "" {
+		t.Errorf("the default keywords are still used: %q", got)
+	}
+
+	keywords, err = loadPlatformKeywords("")
+	if err != nil || keywords["postgres"] == "" {
+		t.Errorf("default keywords = %v, err = %v", keywords, err)
+	}
+}
+
+func TestPlatformInstruction(t *testing.T) {
+	tmpl, err := loadPromptTemplate("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &generator{template: tmpl, keywords: defaultPlatformKeywords}
+
+	prompt, _, err := g.render("a Postgres table for orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(prompt, "Use urn:li:dataPlatform:postgres as the dataset platform, in the platform fields and in the dataset URN.") {
+		t.Errorf("prompt is missing the detected platform:\n%s", prompt)
+	}
+
+	// An explicit platform wins
+	g.platform = "urn:li:dataPlatform:mysql"
+	prompt, _, err = g.render("a Postgres table for orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(prompt, "Use urn:li:dataPlatform:mysql as the dataset platform, in the platform fields and in the dataset URN.") {
+		t.Errorf("prompt is missing the --platform:\n%s", prompt)
+	}
+}
+
+func TestLoadPlatformMap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "platforms.json")
+	os.WriteFile(path, []byte(`{"PG": "urn:li:dataPlatform:postgres"}`), 0644)
+
+	platforms, err := loadPlatformMap(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(platforms) != 1 || platforms["pg"] != "urn:li:dataPlatform:postgres" {
+		t.Errorf("platforms = %v", platforms)
+	}
+
+	if platforms, err := loadPlatformMap(""); err != nil || platforms["mysql"] != "urn:li:dataPlatform:mysql" {
+		t.Errorf("default platforms = %v, err = %v", platforms, err)
+	}
+
+	os.WriteFile(path, []byte(`{"pg": "postgres"}`), 0644)
+	if _, err := loadPlatformMap(path); err == nil || !strings.Contains(err.Error(), "invalid platform URN") {
+		t.Errorf("err = %v, want an invalid platform URN error", err)
+	}
+	if _, err := loadPlatformMap(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected an error for a missing platform map")
+	}
+}
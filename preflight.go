@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// validateAPIBase checks that apiBase is an absolute http(s) URL
+func validateAPIBase(apiBase string) error {
+	u, err := url.Parse(apiBase)
+	if err != nil {
+		return usagef("invalid api-base %q: %v", apiBase, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return usagef("invalid api-base %q: the URL must start with http:// or https://", apiBase)
+	}
+	if u.Host == "" {
+		return usagef("invalid api-base %q: missing host", apiBase)
+	}
+	return nil
+}
+
+// checkAI lists the models available to confirm the API is reachable and the
+// key is valid before spending tokens
+func checkAI(ctx context.Context, client *openai.Client, apiBase string) error {
+	_, err := client.ListModels(ctx)
+	if err == nil {
+		return nil
+	}
+
+	status := 0
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.HTTPStatusCode
+	case errors.As(err, &reqErr):
+		status = reqErr.HTTPStatusCode
+	default:
+		return fmt.Errorf("cannot reach the OpenAI API at %s: %w", apiBase, err)
+	}
+
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("the OpenAI API at %s rejected the API key, check --api-key: %w", apiBase, err)
+	case http.StatusNotFound:
+		return usagef("the OpenAI API was not found at %s (404), check --api-base (missing /v1?)", apiBase)
+	default:
+		return fmt.Errorf("OpenAI API check failed (%d): %w", status, err)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestValidateAPIBase(t *testing.T) {
+	for _, base := range []string{"https://api.openai.com/v1", "http://localhost:11434/v1"} {
+		if err := validateAPIBase(base); err != nil {
+			t.Errorf("validateAPIBase(%q): %v", base, err)
+		}
+	}
+	for _, base := range []string{"api.openai.com/v1", "ftp://api.openai.com", "https:///v1", "http://[::1"} {
+		if err := validateAPIBase(base); exitCode(err) != exitUsage {
+			t.Errorf("validateAPIBase(%q) = %v, want a usage error", base, err)
+		}
+	}
+}
+
+// modelsServer stubs the OpenAI models endpoint, replying with status
+func modelsServer(t *testing.T, status int) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]string{"message": http.StatusText(status)}})
+			return
+		}
+		json.NewEncoder(w).Encode(openai.ModelsList{Models: []openai.Model{{ID: "m"}}})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCheckAI(t *testing.T) {
+	tests := []struct {
+		status int
+		want   string
+	}{
+		{http.StatusOK, ""},
+		{http.StatusUnauthorized, "rejected the API key"},
+		{http.StatusForbidden, "rejected the API key"},
+		{http.StatusNotFound, "not found"},
+		{http.StatusInternalServerError, "OpenAI API check failed (500)"},
+	}
+	for _, tt := range tests {
+		srv := modelsServer(t, tt.status)
+		config := openai.DefaultConfig("test")
+		config.BaseURL = srv.URL + "/v1"
+
+		err := checkAI(context.Background(), openai.NewClientWithConfig(config), config.BaseURL)
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("%d: %v", tt.status, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%d: err = %v, want %q", tt.status, err, tt.want)
+		}
+		if tt.status == http.StatusNotFound && exitCode(err) != exitUsage {
+			t.Errorf("404 is not a usage error: %v", err)
+		}
+	}
+
+	// Connection errors
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	config := openai.DefaultConfig("test")
+	config.BaseURL = srv.URL + "/v1"
+	err := checkAI(context.Background(), openai.NewClientWithConfig(config), config.BaseURL)
+	if err == nil || !strings.Contains(err.Error(), "cannot reach the OpenAI API") {
+		t.Errorf("closed server: err = %v", err)
+	}
+}
+
+func TestGenerateCheckAI(t *testing.T) {
+	testDataDir(t)
+	srv := modelsServer(t, http.StatusUnauthorized)
+	withStdin(t, "alpha\n")
+
+	_, err := runApp(t, "generate", "--check-ai", "--api-key", "bad", "--api-base", srv.URL+"/v1", "--model", "m")
+	if err == nil || !strings.Contains(err.Error(), "rejected the API key") {
+		t.Errorf("err = %v", err)
+	}
+}
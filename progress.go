@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// noColor is the --no-color flag, disabling the spinner and other terminal
+// decorations
+var noColor bool
+
+// progress reports the progress of a long running operation
+type progress interface {
+	// Update replaces the message shown
+	Update(msg string)
+	// Stop stops reporting and clears the progress
+	Stop()
+}
+
+// newProgress starts reporting progress with msg on w. Progress is only
+// shown on terminals, unless --no-color is set.
+func newProgress(w io.Writer, msg string) progress {
+	f, ok := w.(*os.File)
+	if !ok || noColor || !isTerminal(f) {
+		return noProgress{}
+	}
+	return startSpinner(w, msg, 100*time.Millisecond)
+}
+
+// isTerminal returns true if f is a terminal
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// noProgress doesn't report anything
+type noProgress struct{}
+
+func (noProgress) Update(string) {}
+func (noProgress) Stop()         {}
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// spinner draws a spinner with the message and the elapsed time
+type spinner struct {
+	w     io.Writer
+	start time.Time
+	done  chan struct{}
+	wg    sync.WaitGroup
+
+	mu  sync.Mutex
+	msg string
+}
+
+func startSpinner(w io.Writer, msg string, interval time.Duration) *spinner {
+	s := &spinner{w: w, msg: msg, start: time.Now(), done: make(chan struct{})}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for frame := 0; ; frame++ {
+			s.draw(spinnerFrames[frame%len(spinnerFrames)])
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return s
+}
+
+func (s *spinner) draw(frame string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elapsed := time.Since(s.start).Truncate(time.Second)
+	fmt.Fprintf(s.w, "\r\033[K%s %s (%s)", frame, s.msg, elapsed)
+}
+
+// Update clears the line right away, so anything printed before the next
+// frame starts on a clean line
+func (s *spinner) Update(msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.msg = msg
+	fmt.Fprint(s.w, "\r\033[K")
+}
+
+func (s *spinner) Stop() {
+	close(s.done)
+	s.wg.Wait()
+	fmt.Fprint(s.w, "\r\033[K")
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// syncBuffer is a strings.Builder safe for concurrent use
+type syncBuffer struct {
+	mu sync.Mutex
+	b  strings.Builder
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.String()
+}
+
+func TestNewProgress(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	if p := newProgress(w, "waiting"); p != (noProgress{}) {
+		t.Errorf("progress on a pipe = %T, want noProgress", p)
+	}
+	if p := newProgress(&syncBuffer{}, "waiting"); p != (noProgress{}) {
+		t.Errorf("progress on a buffer = %T, want noProgress", p)
+	}
+	if isTerminal(w) {
+		t.Error("a pipe is a terminal")
+	}
+}
+
+func TestSpinner(t *testing.T) {
+	var out syncBuffer
+	s := startSpinner(&out, "Waiting for m", time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	s.Update("Waiting for fallback")
+	time.Sleep(10 * time.Millisecond)
+	s.Stop()
+
+	got := out.String()
+	for _, want := range []string{spinnerFrames[0] + " Waiting for m (0s)", "Waiting for fallback (0s)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output is missing %q: %q", want, got)
+		}
+	}
+	if !strings.HasSuffix(got, "\r\033[K") {
+		t.Errorf("the spinner wasn't cleared: %q", got)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if stopped := out.String(); len(stopped) != len(got) {
+		t.Error("the spinner was drawn after stopping")
+	}
+}
+
+func TestGenerateNoSpinner(t *testing.T) {
+	testDataDir(t)
+	dh := newDataHubStub(t)
+	apiBase, _ := openAIStub(t, func(req openai.ChatCompletionRequest) string {
+		time.Sleep(150 * time.Millisecond)
+		return datasetJSON(userInput(req))
+	})
+	withStdin(t, "alpha\n")
+
+	// stdout is a pipe in the tests
+	out, err := runApp(t, "generate",
+		"--api-key", "test",
+		"--api-base", apiBase,
+		"--model", "m",
+		"--datahub-gms-url", dh.URL,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, "\r") || strings.Contains(out, "Waiting for m") {
+		t.Errorf("the spinner was drawn on a pipe:\n%q", out)
+	}
+}
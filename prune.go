@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	storage "github.com/rubiojr/dsg/internal/storage/sqlite"
+	"github.com/urfave/cli/v2"
+)
+
+// historyStatuses are the statuses of the history entries
+var historyStatuses = []string{storage.StatusGenerated, storage.StatusPosted, storage.StatusFailed}
+
+// parseAge parses an age like 90d, 2w or any time.ParseDuration duration (e.g. 36h)
+func parseAge(s string) (time.Duration, error) {
+	units := map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	}
+	for suffix, unit := range units {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			days, err := strconv.Atoi(n)
+			if err != nil || days < 0 {
+				return 0, fmt.Errorf("invalid age %q", s)
+			}
+			return time.Duration(days) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return d, nil
+}
+
+func runPrune(c *cli.Context) error {
+	age, err := parseAge(c.String("older-than"))
+	if err != nil {
+		return usagef("%v: use e.g. 90d, 2w or 36h", err)
+	}
+	cutoff := time.Now().Add(-age)
+
+	statuses := c.StringSlice("status")
+	for _, status := range statuses {
+		if !slices.Contains(historyStatuses, status) {
+			return usagef("invalid status %q: must be one of %s", status, strings.Join(historyStatuses, ", "))
+		}
+	}
+
+	db, err := storage.NewSQLiteStorage()
+	if err != nil {
+		return fmt.Errorf("failed to initialize history database: %w", err)
+	}
+	defer db.Close()
+
+	if !c.Bool("force") {
+		what := "history entries"
+		if len(statuses) > 0 {
+			what = strings.Join(statuses, " or ") + " " + what
+		}
+		fmt.Printf("Are you sure you want to delete the %s created before %s? This action cannot be undone. (y/N): ", what, cutoff.Format("2006-01-02 15:04:05"))
+		reader := bufio.NewReader(os.Stdin)
+		confirm, err := reader.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("failed to read confirmation: %w", err)
+		}
+
+		confirm = strings.TrimSpace(strings.ToLower(confirm))
+		if confirm != "y" && confirm != "yes" {
+			fmt.Println("Prune operation cancelled.")
+			return nil
+		}
+	}
+
+	n, err := db.DeleteResponsesOlderThan(cutoff, statuses...)
+	if err != nil {
+		return fmt.Errorf("failed to prune history: %w", err)
+	}
+
+	fmt.Printf("%d history entries deleted.\n", n)
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	storage "github.com/rubiojr/dsg/internal/storage/sqlite"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := map[string]time.Duration{
+		"90d": 90 * 24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+		"36h": 36 * time.Hour,
+		"0d":  0,
+	}
+	for s, want := range tests {
+		if got, err := parseAge(s); err != nil || got != want {
+			t.Errorf("parseAge(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "d", "-1d", "1.5d", "90days", "-2h"} {
+		if _, err := parseAge(s); err == nil {
+			t.Errorf("parseAge(%q) succeeded", s)
+		}
+	}
+}
+
+func TestPruneCommand(t *testing.T) {
+	dataDir := testDataDir(t)
+	seedHistory(t, dataDir, &storage.Response{Prompt: "a", Response: "[]"}, &storage.Response{Prompt: "b", Response: "[]"})
+
+	// Cancelled
+	withStdin(t, "n\n")
+	out, err := runApp(t, "prune", "--older-than", "0d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "Are you sure") || !strings.Contains(out, "Prune operation cancelled.") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	// Entries created now are newer than a day
+	out, err = runApp(t, "prune", "--older-than", "1d", "--status", "generated", "--force")
+	if err != nil || !strings.Contains(out, "0 history entries deleted.") {
+		t.Errorf("err = %v, output:\n%s", err, out)
+	}
+
+	for _, args := range [][]string{
+		{"prune", "--older-than", "soon", "--force"},
+		{"prune", "--older-than", "1d", "--status", "pending", "--force"},
+	} {
+		if _, err := runApp(t, args...); exitCode(err) != exitUsage {
+			t.Errorf("%q: err = %v, want a usage error", args, err)
+		}
+	}
+
+	db, err := storage.NewSQLiteStorage(storage.WithDataDir(dataDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if history, _ := db.ListResponses(10, 0); len(history) != 2 {
+		t.Errorf("%d history entries left, want 2", len(history))
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+	storage "github.com/rubiojr/dsg/internal/storage/sqlite"
+	"github.com/urfave/cli/v2"
+)
+
+// replayableDatasets returns the number of datasets in a history response,
+// or an error if the response doesn't contain valid datasets
+func replayableDatasets(resp *storage.Response) (int, error) {
+	var datasets []datahub.Dataset
+	if err := json.Unmarshal([]byte(resp.Response), &datasets); err != nil {
+		return 0, fmt.Errorf("invalid dataset JSON: %w", err)
+	}
+	if len(datasets) == 0 {
+		return 0, fmt.Errorf("no datasets found")
+	}
+	return len(datasets), nil
+}
+
+// createdSince returns the responses created at or after since
+func createdSince(responses []*storage.Response, since time.Time) []*storage.Response {
+	if since.IsZero() {
+		return responses
+	}
+	var filtered []*storage.Response
+	for _, resp := range responses {
+		if !resp.CreatedAt.Before(since) {
+			filtered = append(filtered, resp)
+		}
+	}
+	return filtered
+}
+
+func runReplay(c *cli.Context) error {
+	dryRun := c.Bool("dry-run")
+
+	var since time.Time
+	if s := c.String("since"); s != "" {
+		var err error
+		since, err = time.Parse("2006-01-02", s)
+		if err != nil {
+			return fmt.Errorf("invalid --since date (expected YYYY-MM-DD): %w", err)
+		}
+	}
+
+	db, err := storage.NewSQLiteStorage()
+	if err != nil {
+		return fmt.Errorf("failed to initialize history database: %w", err)
+	}
+	defer db.Close()
+
+	dh, err := newClientFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	incremental := c.Bool("incremental")
+	sinceID := c.Int64("since-id")
+	if incremental && !c.IsSet("since-id") {
+		sinceID, err = db.LastSyncedID(dh.URL)
+		if err != nil {
+			return err
+		}
+		if sinceID > 0 {
+			fmt.Printf("Resuming after history entry %d, the last one synced to %s\n", sinceID, dh.URL)
+		}
+	}
+
+	var responses []*storage.Response
+	if sinceID > 0 {
+		responses, err = db.ListResponsesSince(sinceID, 0)
+		responses = createdSince(responses, since)
+	} else {
+		responses, err = db.ListResponsesCreatedSince(since)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list history: %w", err)
+	}
+
+	if len(responses) == 0 {
+		fmt.Println("No history entries found.")
+		return nil
+	}
+	opts := &datahub.PostOptions{ContinueOnError: c.Bool("continue-on-error")}
+
+	if dryRun {
+		fmt.Printf("Dry run, nothing will be posted to %s\n\n", dh.URL)
+	} else {
+		fmt.Printf("Replaying history to %s\n\n", dh.URL)
+	}
+
+	var posted, skipped, failed int
+	// lastSynced only moves forward while no entry fails, so failed
+	// entries are retried by the next incremental replay
+	lastSynced := sinceID
+	fmt.Printf("%-6s %-20s %-10s %s\n", "ID", "DATE", "RESULT", "DETAILS")
+	fmt.Println(strings.Repeat("-", 100))
+	for _, resp := range responses {
+		date := resp.CreatedAt.Format("2006-01-02 15:04:05")
+
+		count, err := replayableDatasets(resp)
+		if err == nil {
+			err = checkDatasetDuplicates(resp.Response, c.Bool("strict"))
+		}
+		if err != nil {
+			skipped++
+			if failed == 0 {
+				lastSynced = resp.ID
+			}
+			fmt.Printf("%-6d %-20s %-10s %s\n", resp.ID, date, "skipped", err)
+			continue
+		}
+
+		if dryRun {
+			posted++
+			fmt.Printf("%-6d %-20s %-10s %d datasets would be posted\n", resp.ID, date, "dry-run", count)
+			continue
+		}
+
+		count, err = dh.PostEntity("dataset", resp.Response, opts)
+		if err != nil {
+			failed++
+			fmt.Printf("%-6d %-20s %-10s %s\n", resp.ID, date, "failed", err)
+			continue
+		}
+
+		posted++
+		if failed == 0 {
+			lastSynced = resp.ID
+		}
+		fmt.Printf("%-6d %-20s %-10s %d datasets posted\n", resp.ID, date, "ok", count)
+	}
+
+	if incremental && !dryRun && lastSynced > sinceID {
+		if err := db.SetLastSyncedID(dh.URL, lastSynced); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf("%d entries replayed, %d skipped, %d failed\n", posted, skipped, failed)
+
+	if failed > 0 {
+		return fmt.Errorf("%d history entries failed to replay", failed)
+	}
+
+	return nil
+}
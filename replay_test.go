@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	storage "github.com/rubiojr/dsg/internal/storage/sqlite"
+)
+
+func TestReplay(t *testing.T) {
+	dataDir := testDataDir(t)
+	seedHistory(t, dataDir,
+		&storage.Response{Prompt: "alpha", Response: datasetJSON("alpha")},
+		&storage.Response{Prompt: "invalid", Response: "not json"},
+		&storage.Response{Prompt: "empty", Response: "[]"},
+		&storage.Response{Prompt: "beta gamma", Response: datasetJSON("beta", "gamma")},
+	)
+	dh := newDataHubStub(t)
+
+	out, err := runApp(t, "replay", "--datahub-gms-url", dh.URL, "--dry-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dh.postedURNs()) != 0 {
+		t.Errorf("dry run posted %v", dh.postedURNs())
+	}
+	if !strings.Contains(out, "2 entries replayed, 2 skipped, 0 failed") {
+		t.Errorf("unexpected dry run output:\n%s", out)
+	}
+
+	out, err = runApp(t, "replay", "--datahub-gms-url", dh.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{datasetURN("alpha"), datasetURN("beta"), datasetURN("gamma")}
+	if urns := dh.postedURNs(); !slices.Equal(urns, want) {
+		t.Errorf("posted %v, want %v", urns, want)
+	}
+	if !strings.Contains(out, "2 entries replayed, 2 skipped, 0 failed") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestReplayFailure(t *testing.T) {
+	dataDir := testDataDir(t)
+	seedHistory(t, dataDir,
+		&storage.Response{Prompt: "alpha", Response: datasetJSON("alpha")},
+		&storage.Response{Prompt: "beta", Response: datasetJSON("beta")},
+	)
+	dh := newDataHubStub(t)
+	dh.fail = func(urn string) bool { return urn == datasetURN("alpha") }
+
+	out, err := runApp(t, "replay", "--datahub-gms-url", dh.URL)
+	if err == nil {
+		t.Fatal("expected an error when an entry fails")
+	}
+	if urns := dh.postedURNs(); !slices.Equal(urns, []string{datasetURN("beta")}) {
+		t.Errorf("posted %v", urns)
+	}
+	if !strings.Contains(out, "1 entries replayed, 0 skipped, 1 failed") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestReplaySinceID(t *testing.T) {
+	dataDir := testDataDir(t)
+	ids := seedHistory(t, dataDir,
+		&storage.Response{Prompt: "alpha", SchemaName: "alpha", Response: datasetJSON("alpha")},
+		&storage.Response{Prompt: "beta", SchemaName: "beta", Response: datasetJSON("beta")},
+		&storage.Response{Prompt: "gamma", SchemaName: "gamma", Response: datasetJSON("gamma")},
+	)
+	dh := newDataHubStub(t)
+
+	if _, err := runApp(t, "replay", "--datahub-gms-url", dh.URL, "--since-id", fmt.Sprint(ids[0])); err != nil {
+		t.Fatal(err)
+	}
+	if urns := dh.postedURNs(); !slices.Equal(urns, []string{datasetURN("beta"), datasetURN("gamma")}) {
+		t.Errorf("posted %v", urns)
+	}
+
+	out, err := runApp(t, "history", "--since-id", fmt.Sprint(ids[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "gamma") || strings.Contains(out, "alpha") || strings.Contains(out, "beta") {
+		t.Errorf("history --since-id lists older entries:\n%s", out)
+	}
+}
+
+func TestReplayIncremental(t *testing.T) {
+	dataDir := testDataDir(t)
+	seedHistory(t, dataDir,
+		&storage.Response{Prompt: "alpha", Response: datasetJSON("alpha")},
+		&storage.Response{Prompt: "beta", Response: datasetJSON("beta")},
+	)
+	dh := newDataHubStub(t)
+	dh.fail = func(urn string) bool { return urn == datasetURN("beta") }
+
+	// beta fails, so it is retried by the next sync
+	if _, err := runApp(t, "replay", "--datahub-gms-url", dh.URL, "--incremental"); err == nil {
+		t.Fatal("expected an error when an entry fails")
+	}
+	if urns := dh.postedURNs(); !slices.Equal(urns, []string{datasetURN("alpha")}) {
+		t.Errorf("posted %v", urns)
+	}
+
+	dh.fail = nil
+	seedHistory(t, dataDir, &storage.Response{Prompt: "gamma", Response: datasetJSON("gamma")})
+	if _, err := runApp(t, "replay", "--datahub-gms-url", dh.URL, "--incremental"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{datasetURN("alpha"), datasetURN("beta"), datasetURN("gamma")}
+	if urns := dh.postedURNs(); !slices.Equal(urns, want) {
+		t.Errorf("posted %v, want %v", urns, want)
+	}
+
+	// Nothing new to sync
+	out, err := runApp(t, "replay", "--datahub-gms-url", dh.URL, "--incremental")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "No history entries found.") || len(dh.postedURNs()) != 3 {
+		t.Errorf("replayed synced entries:\n%s", out)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+	"github.com/urfave/cli/v2"
+)
+
+// Statuses of the entities in a post report
+const (
+	postStatusPosted  = "posted"
+	postStatusFailed  = "failed"
+	postStatusSkipped = "skipped"
+)
+
+// postResult is the outcome of posting a single entity
+type postResult struct {
+	Index  int    `json:"index"`
+	URN    string `json:"urn,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// postReport summarizes the outcome of posting a batch of entities. Count
+// and URNs are the entities created, so scripts don't need to go through
+// the results.
+type postReport struct {
+	EntityType string       `json:"entity_type"`
+	Count      int          `json:"count"`
+	URNs       []string     `json:"urns"`
+	Total      int          `json:"total"`
+	Posted     int          `json:"posted"`
+	Failed     int          `json:"failed"`
+	Skipped    int          `json:"skipped"`
+	Results    []postResult `json:"results"`
+}
+
+// newPostReport builds the report of posting the JSON array of entities of
+// entityType in payload, given the number of entities posted and the error returned.
+// Entities are posted in order, so without a BatchError the entity after
+// the posted ones failed and the rest were skipped.
+func newPostReport(entityType, payload string, posted int, err error) *postReport {
+	var entities []struct {
+		URN string `json:"urn"`
+	}
+	if json.Unmarshal([]byte(payload), &entities) != nil {
+		return &postReport{EntityType: entityType, URNs: []string{}, Results: []postResult{}}
+	}
+
+	failures := map[int]string{}
+	var batchErr *datahub.BatchError
+	if errors.As(err, &batchErr) {
+		for _, f := range batchErr.Failures {
+			failures[f.Index] = f.Err.Error()
+		}
+	} else if err != nil && posted < len(entities) {
+		failures[posted] = err.Error()
+	}
+
+	r := &postReport{
+		EntityType: entityType,
+		URNs:       []string{},
+		Total:      len(entities),
+		Results:    make([]postResult, 0, len(entities)),
+	}
+	for i, e := range entities {
+		result := postResult{Index: i + 1, URN: e.URN, Status: postStatusPosted}
+		switch msg, failed := failures[i]; {
+		case failed:
+			result.Status = postStatusFailed
+			result.Error = msg
+			r.Failed++
+		case batchErr == nil && err != nil && i > posted:
+			result.Status = postStatusSkipped
+			r.Skipped++
+		default:
+			r.Posted++
+			if e.URN != "" {
+				r.URNs = append(r.URNs, e.URN)
+			}
+		}
+		r.Results = append(r.Results, result)
+	}
+	r.Count = r.Posted
+	return r
+}
+
+// print writes the report as a table followed by the totals
+func (r *postReport) print(w io.Writer) {
+	fmt.Fprintf(w, "%-4s %-8s %-70s %s\n", "#", "STATUS", "URN", "ERROR")
+	fmt.Fprintln(w, strings.Repeat("-", 100))
+	for _, result := range r.Results {
+		urn := result.URN
+		if urn == "" {
+			urn = "-"
+		}
+		fmt.Fprintf(w, "%-4d %-8s %-70s %s\n", result.Index, result.Status, truncateString(urn, 68), result.Error)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%d posted, %d failed, %d skipped\n", r.Posted, r.Failed, r.Skipped)
+}
+
+// reportedError is returned once the failures have been reported, keeping
+// the original error for the exit code without repeating it
+type reportedError struct {
+	msg string
+	err error
+}
+
+func (e *reportedError) Error() string {
+	return e.msg
+}
+
+func (e *reportedError) Unwrap() error {
+	return e.err
+}
+
+// reportPost prints the report of posting the entities of entityType in
+// payload, as JSON with --json, and returns an error if any of them failed,
+// or if some of the DataHub instances failed. Otherwise the URNs posted go
+// to --output-urns-file.
+func reportPost(c *cli.Context, entityType, payload string, posted int, err error) error {
+	reportErr := err
+	var partialErr *partialPostError
+	if errors.As(err, &partialErr) {
+		// The entities are those of the instances that succeeded, the
+		// failing instances were already reported
+		reportErr = nil
+	}
+
+	r := newPostReport(entityType, payload, posted, reportErr)
+	if len(r.Results) == 0 {
+		// Nothing was posted, the payload couldn't be read
+		if err != nil {
+			return fmt.Errorf("error posting entities: %w", err)
+		}
+		return nil
+	}
+
+	if c.Bool("json") {
+		data, jerr := json.MarshalIndent(r, "", "  ")
+		if jerr != nil {
+			return fmt.Errorf("error encoding report: %w", jerr)
+		}
+		fmt.Println(string(data))
+	} else {
+		r.print(os.Stdout)
+	}
+
+	if partialErr != nil {
+		return &reportedError{msg: fmt.Sprintf("%d of %d DataHub instances failed", partialErr.failed, partialErr.total), err: err}
+	}
+	if err != nil {
+		return &reportedError{msg: fmt.Sprintf("%d of %d entities failed", r.Failed, r.Total), err: err}
+	}
+	return writeURNsFile(c, r.URNs)
+}
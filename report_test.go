@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+	storage "github.com/rubiojr/dsg/internal/storage/sqlite"
+)
+
+func TestNewPostReport(t *testing.T) {
+	payload := datasetJSON("alpha", "beta", "gamma", "delta")
+
+	// With --continue-on-error, every entity is posted
+	batchErr := &datahub.BatchError{Total: 4, Failures: []*datahub.ItemError{
+		{Index: 1, Err: errors.New("rejected")},
+		{Index: 3, Err: errors.New("timeout")},
+	}}
+	r := newPostReport("dataset", payload, 2, batchErr)
+	if r.Total != 4 || r.Posted != 2 || r.Failed != 2 || r.Skipped != 0 || r.Count != 2 {
+		t.Errorf("totals = %+v", r)
+	}
+	want := []postResult{
+		{Index: 1, URN: datasetURN("alpha"), Status: postStatusPosted},
+		{Index: 2, URN: datasetURN("beta"), Status: postStatusFailed, Error: "rejected"},
+		{Index: 3, URN: datasetURN("gamma"), Status: postStatusPosted},
+		{Index: 4, URN: datasetURN("delta"), Status: postStatusFailed, Error: "timeout"},
+	}
+	for i, result := range r.Results {
+		if result != want[i] {
+			t.Errorf("result %d = %+v, want %+v", i+1, result, want[i])
+		}
+	}
+	if len(r.URNs) != 2 || r.URNs[0] != datasetURN("alpha") || r.URNs[1] != datasetURN("gamma") {
+		t.Errorf("URNs = %v", r.URNs)
+	}
+
+	// Otherwise posting stops at the first failure
+	r = newPostReport("dataset", payload, 1, errors.New("rejected"))
+	statuses := []string{}
+	for _, result := range r.Results {
+		statuses = append(statuses, result.Status)
+	}
+	if strings.Join(statuses, ",") != "posted,failed,skipped,skipped" || r.Posted != 1 || r.Failed != 1 || r.Skipped != 2 {
+		t.Errorf("statuses = %v, totals = %+v", statuses, r)
+	}
+
+	r = newPostReport("dataset", `[{"name": "no urn"}]`, 1, nil)
+	if r.Posted != 1 || r.Results[0].URN != "" || len(r.URNs) != 0 {
+		t.Errorf("report without URNs = %+v", r)
+	}
+
+	if r := newPostReport("dataset", "not json", 0, nil); len(r.Results) != 0 || r.URNs == nil {
+		t.Errorf("report of an invalid payload = %+v", r)
+	}
+}
+
+func TestPostReportPrint(t *testing.T) {
+	r := newPostReport("dataset", `[{"urn": "urn:a"}, {}]`, 1, errors.New("rejected"))
+
+	var out strings.Builder
+	r.print(&out)
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("unexpected report:\n%s", out.String())
+	}
+	if fields := strings.Fields(lines[2]); strings.Join(fields, " ") != "1 posted urn:a" {
+		t.Errorf("first row = %q", lines[2])
+	}
+	if fields := strings.Fields(lines[3]); strings.Join(fields, " ") != "2 failed - rejected" {
+		t.Errorf("second row = %q", lines[3])
+	}
+	if lines[5] != "1 posted, 1 failed, 0 skipped" {
+		t.Errorf("totals = %q", lines[5])
+	}
+}
+
+func TestFromJSONReport(t *testing.T) {
+	dh := newDataHubStub(t)
+	dh.fail = func(urn string) bool { return urn == datasetURN("beta") }
+	input := filepath.Join(t.TempDir(), "datasets.json")
+	os.WriteFile(input, []byte(datasetJSON("alpha", "beta", "gamma")), 0644)
+
+	out, err := runApp(t, "from-json", "--continue-on-error", "--datahub-gms-url", dh.URL, input)
+	if err == nil || err.Error() != "1 of 3 entities failed" || exitCode(err) != exitRejected {
+		t.Errorf("err = %v (exit code %d)", err, exitCode(err))
+	}
+	for _, want := range []string{"2 posted, 1 failed, 0 skipped", datasetURN("gamma")} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output is missing %q:\n%s", want, out)
+		}
+	}
+
+	out, err = runApp(t, "from-json", "--continue-on-error", "--json", "--datahub-gms-url", dh.URL, input)
+	if err == nil {
+		t.Error("expected an error with a failed entity")
+	}
+	var r postReport
+	if err := json.Unmarshal([]byte(out), &r); err != nil {
+		t.Fatalf("invalid JSON report: %v\n%s", err, out)
+	}
+	if r.EntityType != "dataset" || r.Total != 3 || r.Posted != 2 || r.Failed != 1 || len(r.Results) != 3 {
+		t.Errorf("report = %+v", r)
+	}
+	if failed := r.Results[1]; failed.URN != datasetURN("beta") || failed.Status != postStatusFailed || failed.Error == "" {
+		t.Errorf("failed result = %+v", failed)
+	}
+}
+
+// decodeReport decodes the --json report printed in out
+func decodeReport(t *testing.T, out string) postReport {
+	t.Helper()
+	var r postReport
+	if err := json.Unmarshal([]byte(out), &r); err != nil {
+		t.Fatalf("invalid JSON report: %v\n%s", err, out)
+	}
+	return r
+}
+
+func TestPostJSON(t *testing.T) {
+	dataDir := testDataDir(t)
+	dh := newDataHubStub(t)
+
+	out, err := runApp(t, "add-term", "--json", "--datahub-gms-url", dh.URL, "--name", "Email")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := decodeReport(t, out)
+	if r.EntityType != "glossaryTerm" || r.Count != 1 || !slices.Equal(r.URNs, []string{"urn:li:glossaryTerm:Email"}) {
+		t.Errorf("add-term report = %+v", r)
+	}
+
+	input := filepath.Join(t.TempDir(), "datasets.json")
+	os.WriteFile(input, []byte(datasetJSON("alpha", "beta")), 0644)
+	out, err = runApp(t, "from-json", "--json", "--datahub-gms-url", dh.URL, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r = decodeReport(t, out)
+	if r.EntityType != "dataset" || r.Count != 2 || !slices.Equal(r.URNs, []string{datasetURN("alpha"), datasetURN("beta")}) {
+		t.Errorf("from-json report = %+v", r)
+	}
+
+	ids := seedHistory(t, dataDir, &storage.Response{Prompt: "gamma", Response: datasetJSON("gamma")})
+	out, err = runApp(t, "post", "--json", "--datahub-gms-url", dh.URL, strconv.FormatInt(ids[0], 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r = decodeReport(t, out)
+	if r.EntityType != "dataset" || r.Count != 1 || !slices.Equal(r.URNs, []string{datasetURN("gamma")}) {
+		t.Errorf("post report = %+v", r)
+	}
+
+	historyFile := filepath.Join(t.TempDir(), "history.json")
+	os.WriteFile(historyFile, []byte(`{"datasets": `+datasetJSON("delta")+`}`), 0644)
+	out, err = runApp(t, "post-history-file", "--json", "--datahub-gms-url", dh.URL, historyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r = decodeReport(t, out)
+	if r.EntityType != "dataset" || r.Count != 1 || !slices.Equal(r.URNs, []string{datasetURN("delta")}) {
+		t.Errorf("post-history-file report = %+v", r)
+	}
+
+	want := []string{"urn:li:glossaryTerm:Email", datasetURN("alpha"), datasetURN("beta"), datasetURN("gamma"), datasetURN("delta")}
+	if urns := dh.postedURNs(); !slices.Equal(urns, want) {
+		t.Errorf("posted %v", urns)
+	}
+}
+
+func TestPostHistoryFilePlatformSchema(t *testing.T) {
+	dh := newDataHubStub(t)
+	kafka := `{"com.linkedin.schema.KafkaSchema": {"documentSchema": "{\"type\": \"record\", \"name\": \"User\"}", "documentSchemaType": "AVRO"}}`
+	datasets := strings.Replace(datasetJSON("users"), `{"com.linkedin.schema.MySqlDDL": {"tableSchema": ""}}`, kafka, 1)
+	historyFile := filepath.Join(t.TempDir(), "history.json")
+	os.WriteFile(historyFile, []byte(`{"datasets": `+datasets+`}`), 0644)
+
+	if _, err := runApp(t, "post-history-file", "--datahub-gms-url", dh.URL, historyFile); err != nil {
+		t.Fatal(err)
+	}
+	if len(dh.posted) != 1 {
+		t.Fatalf("posted %d entities, want 1", len(dh.posted))
+	}
+	var schema struct {
+		Value struct {
+			PlatformSchema json.RawMessage `json:"platformSchema"`
+		} `json:"value"`
+	}
+	json.Unmarshal(dh.posted[0]["schemaMetadata"], &schema)
+	if !jsonEqual(t, schema.Value.PlatformSchema, []byte(kafka)) {
+		t.Errorf("posted platformSchema %s, want %s", schema.Value.PlatformSchema, kafka)
+	}
+}
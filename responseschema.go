@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+	"github.com/sashabaranov/go-openai"
+)
+
+// datasetsSchemaName names the JSON Schema sent with --strict-schema
+const datasetsSchemaName = "datahub_datasets"
+
+// datasetsResponseFormat returns the structured outputs response format
+// constraining the model to the layout of tdata/schema.json. Structured
+// outputs need an object at the root, so the datasets are wrapped in a
+// datasets property, see unwrapDatasets.
+func datasetsResponseFormat() *openai.ChatCompletionResponseFormat {
+	auditStamp := objectSchema(map[string]any{
+		"time":  map[string]any{"type": "integer"},
+		"actor": map[string]any{"type": "string"},
+	})
+	terms := objectSchema(map[string]any{
+		"terms": arraySchema(objectSchema(map[string]any{
+			"urn": map[string]any{"type": "string"},
+		})),
+		"auditStamp": auditStamp,
+	})
+	field := objectSchema(map[string]any{
+		"fieldPath":      map[string]any{"type": "string"},
+		"description":    map[string]any{"type": "string"},
+		"type":           objectSchema(map[string]any{"type": fieldTypeSchema()}),
+		"nativeDataType": map[string]any{"type": "string"},
+		"recursive":      map[string]any{"type": "boolean"},
+		"glossaryTerms":  terms,
+	})
+	dataset := objectSchema(map[string]any{
+		"urn": map[string]any{"type": "string"},
+		"datasetKey": valueSchema(objectSchema(map[string]any{
+			"platform": map[string]any{"type": "string"},
+			"name":     map[string]any{"type": "string"},
+			"origin":   map[string]any{"type": "string"},
+		})),
+		"schemaMetadata": valueSchema(objectSchema(map[string]any{
+			"schemaName": map[string]any{"type": "string"},
+			"platform":   map[string]any{"type": "string"},
+			"version":    map[string]any{"type": "integer"},
+			"hash":       map[string]any{"type": "string"},
+			"platformSchema": objectSchema(map[string]any{
+				"com.linkedin.schema.MySqlDDL": objectSchema(map[string]any{
+					"tableSchema": map[string]any{"type": "string"},
+				}),
+			}),
+			"fields": arraySchema(field),
+		})),
+		"globalTags": valueSchema(objectSchema(map[string]any{
+			"tags": arraySchema(objectSchema(map[string]any{
+				"tag": map[string]any{"type": "string"},
+			})),
+		})),
+		"glossaryTerms": valueSchema(terms),
+	})
+
+	schema, _ := json.Marshal(objectSchema(map[string]any{"datasets": arraySchema(dataset)}))
+	return &openai.ChatCompletionResponseFormat{
+		Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+		JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+			Name:   datasetsSchemaName,
+			Schema: json.RawMessage(schema),
+			Strict: true,
+		},
+	}
+}
+
+// fieldTypeSchema allows exactly one of the datahub.FieldType types
+func fieldTypeSchema() map[string]any {
+	var types []any
+	t := reflect.TypeOf(datahub.FieldType{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		types = append(types, objectSchema(map[string]any{name: objectSchema(map[string]any{})}))
+	}
+	return map[string]any{"anyOf": types}
+}
+
+// objectSchema returns the schema of an object with properties. Strict
+// structured outputs need every property to be required and no others.
+func objectSchema(properties map[string]any) map[string]any {
+	required := make([]string, 0, len(properties))
+	for name := range properties {
+		required = append(required, name)
+	}
+	slices.Sort(required)
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+func arraySchema(items map[string]any) map[string]any {
+	return map[string]any{"type": "array", "items": items}
+}
+
+// valueSchema wraps an aspect schema in a value property
+func valueSchema(aspect map[string]any) map[string]any {
+	return objectSchema(map[string]any{"value": aspect})
+}
+
+// unwrapDatasets returns the datasets array of a response constrained by
+// datasetsResponseFormat, other responses are unchanged
+func unwrapDatasets(content string) string {
+	var wrapped map[string]json.RawMessage
+	if json.Unmarshal([]byte(content), &wrapped) != nil || len(wrapped) != 1 {
+		return content
+	}
+	datasets, ok := wrapped["datasets"]
+	if !ok || !strings.HasPrefix(strings.TrimSpace(string(datasets)), "[") {
+		return content
+	}
+	return string(datasets)
+}
+
+// isResponseFormatUnsupported returns true if err tells that the model
+// doesn't support the JSON Schema response format
+func isResponseFormatUnsupported(err error) bool {
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != http.StatusBadRequest {
+		return false
+	}
+	if apiErr.Param != nil && *apiErr.Param == "response_format" {
+		return true
+	}
+	return strings.Contains(apiErr.Message, "response_format") || strings.Contains(apiErr.Message, "json_schema")
+}
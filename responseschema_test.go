@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+	"github.com/sashabaranov/go-openai"
+)
+
+// checkStrictSchema fails if an object of schema allows properties that
+// aren't required, which strict structured outputs reject
+func checkStrictSchema(t *testing.T, path string, schema map[string]any) {
+	t.Helper()
+	if schema["type"] == "object" {
+		properties, _ := schema["properties"].(map[string]any)
+		var names []string
+		for name := range properties {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		var required []string
+		for _, name := range schema["required"].([]any) {
+			required = append(required, name.(string))
+		}
+		if !slices.Equal(names, required) || schema["additionalProperties"] != false {
+			t.Errorf("%s: properties %v, required %v, additionalProperties %v", path, names, required, schema["additionalProperties"])
+		}
+		for name, property := range properties {
+			checkStrictSchema(t, path+"."+name, property.(map[string]any))
+		}
+	}
+	if items, ok := schema["items"].(map[string]any); ok {
+		checkStrictSchema(t, path+"[]", items)
+	}
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		for _, s := range anyOf {
+			checkStrictSchema(t, path, s.(map[string]any))
+		}
+	}
+}
+
+func TestDatasetsResponseFormat(t *testing.T) {
+	format := datasetsResponseFormat()
+	if format.Type != openai.ChatCompletionResponseFormatTypeJSONSchema || format.JSONSchema == nil {
+		t.Fatalf("format = %+v", format)
+	}
+	if format.JSONSchema.Name != datasetsSchemaName || !format.JSONSchema.Strict {
+		t.Errorf("JSON schema = %+v", format.JSONSchema)
+	}
+
+	data, err := format.JSONSchema.Schema.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+	checkStrictSchema(t, "$", schema)
+
+	dataset := schema["properties"].(map[string]any)["datasets"].(map[string]any)["items"].(map[string]any)
+	for _, aspect := range []string{"urn", "datasetKey", "schemaMetadata", "globalTags", "glossaryTerms"} {
+		if _, ok := dataset["properties"].(map[string]any)[aspect]; !ok {
+			t.Errorf("the dataset schema is missing %s", aspect)
+		}
+	}
+	if types := fieldTypeSchema()["anyOf"].([]any); len(types) != reflect.TypeOf(datahub.FieldType{}).NumField() {
+		t.Errorf("%d field types, want one per datahub.FieldType field", len(types))
+	}
+}
+
+func TestUnwrapDatasets(t *testing.T) {
+	tests := map[string]string{
+		`{"datasets": [{"urn": "x"}]}`:             `[{"urn": "x"}]`,
+		`{"datasets": []}`:                         `[]`,
+		`[{"urn": "x"}]`:                           `[{"urn": "x"}]`,
+		`{"datasets": {"urn": "x"}}`:               `{"datasets": {"urn": "x"}}`,
+		`{"datasets": [], "other": 1}`:             `{"datasets": [], "other": 1}`,
+		"```json\n{\"datasets\": []}\n```":         "```json\n{\"datasets\": []}\n```",
+		`{"urn": "urn:li:dataset:(mysql,x,PROD)"}`: `{"urn": "urn:li:dataset:(mysql,x,PROD)"}`,
+	}
+	for in, want := range tests {
+		if got := unwrapDatasets(in); got != want {
+			t.Errorf("unwrapDatasets(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestIsResponseFormatUnsupported(t *testing.T) {
+	param := "response_format"
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&openai.APIError{HTTPStatusCode: http.StatusBadRequest, Param: &param, Message: "invalid"}, true},
+		{&openai.APIError{HTTPStatusCode: http.StatusBadRequest, Message: "'json_schema' is not supported with this model"}, true},
+		{&openai.APIError{HTTPStatusCode: http.StatusBadRequest, Message: "context length exceeded"}, false},
+		{&openai.APIError{HTTPStatusCode: http.StatusInternalServerError, Param: &param}, false},
+		{io.EOF, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := isResponseFormatUnsupported(tt.err); got != tt.want {
+			t.Errorf("isResponseFormatUnsupported(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+// strictSchemaServer stubs the OpenAI chat completions endpoint, answering
+// with the datasets wrapped as structured outputs do. Requests with a
+// response format are rejected unless supported is true. It returns the
+// API base URL and the response formats of the requests received.
+func strictSchemaServer(t *testing.T, supported bool) (string, *[]json.RawMessage) {
+	var mu sync.Mutex
+	var formats []json.RawMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The schema can't be decoded in an openai.ChatCompletionRequest
+		var req struct {
+			ResponseFormat json.RawMessage                `json:"response_format"`
+			Messages       []openai.ChatCompletionMessage `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("error decoding the chat completion request: %v", err)
+			return
+		}
+		mu.Lock()
+		formats = append(formats, req.ResponseFormat)
+		mu.Unlock()
+
+		content := datasetJSON(userInput(openai.ChatCompletionRequest{Messages: req.Messages}))
+		if req.ResponseFormat != nil {
+			if !supported {
+				w.WriteHeader(http.StatusBadRequest)
+				io.WriteString(w, `{"error": {"message": "Invalid parameter: 'response_format' of type 'json_schema' is not supported with this model.", "type": "invalid_request_error", "param": "response_format"}}`)
+				return
+			}
+			content = `{"datasets": ` + content + `}`
+		}
+		json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{
+				Message: openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: content},
+			}},
+			Usage: openai.Usage{TotalTokens: 10},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL + "/v1", &formats
+}
+
+func TestGenerateStrictSchema(t *testing.T) {
+	testDataDir(t)
+	dh := newDataHubStub(t)
+	apiBase, formats := strictSchemaServer(t, true)
+	withStdin(t, "alpha\n")
+
+	_, err := runApp(t, "generate",
+		"--strict-schema",
+		"--api-key", "test",
+		"--api-base", apiBase,
+		"--model", "m",
+		"--datahub-gms-url", dh.URL,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if urns := dh.postedURNs(); !slices.Equal(urns, []string{datasetURN("alpha")}) {
+		t.Errorf("posted %v", urns)
+	}
+
+	if len(*formats) != 1 {
+		t.Fatalf("sent %d requests, want 1", len(*formats))
+	}
+	var format struct {
+		Type       string `json:"type"`
+		JSONSchema struct {
+			Name   string          `json:"name"`
+			Strict bool            `json:"strict"`
+			Schema json.RawMessage `json:"schema"`
+		} `json:"json_schema"`
+	}
+	if err := json.Unmarshal((*formats)[0], &format); err != nil {
+		t.Fatalf("invalid response format %s: %v", (*formats)[0], err)
+	}
+	want, _ := datasetsResponseFormat().JSONSchema.Schema.MarshalJSON()
+	if format.Type != "json_schema" || format.JSONSchema.Name != datasetsSchemaName || !format.JSONSchema.Strict {
+		t.Errorf("response format = %+v", format)
+	}
+	if !jsonEqual(t, format.JSONSchema.Schema, want) {
+		t.Errorf("sent schema %s", format.JSONSchema.Schema)
+	}
+}
+
+func TestStrictSchemaUnsupported(t *testing.T) {
+	apiBase, formats := strictSchemaServer(t, false)
+	config := openai.DefaultConfig("test")
+	config.BaseURL = apiBase
+
+	content, _, err := sendOpenAIRequest(context.Background(), openai.NewClientWithConfig(config), generationRequest{
+		Model:        "m",
+		Prompt:       "<user_input>\nalpha\n</user_input>",
+		StrictSchema: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !jsonEqual(t, []byte(content), []byte(datasetJSON("alpha"))) {
+		t.Errorf("content = %s", content)
+	}
+	if len(*formats) != 2 || (*formats)[0] == nil || (*formats)[1] != nil {
+		t.Errorf("response formats sent: %q", *formats)
+	}
+
+	// Without --strict-schema no response format is sent
+	*formats = nil
+	if _, _, err := sendOpenAIRequest(context.Background(), openai.NewClientWithConfig(config), generationRequest{Model: "m", Prompt: "alpha"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(*formats) != 1 || (*formats)[0] != nil {
+		t.Errorf("response formats sent: %q", *formats)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	storage "github.com/rubiojr/dsg/internal/storage/sqlite"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	// similarPromptThreshold is the minimum cosine similarity for a stored
+	// prompt to be suggested
+	similarPromptThreshold = 0.85
+	// maxSimilarPrompts is the maximum number of suggested prompts
+	maxSimilarPrompts = 3
+)
+
+// similarPrompt is a stored prompt similar to the one being generated
+type similarPrompt struct {
+	ID         int64
+	Similarity float64
+}
+
+// embedPrompt returns the embedding vector of prompt
+func embedPrompt(ctx context.Context, client *openai.Client, model, prompt string) ([]float32, error) {
+	resp, err := client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		// Newlines degrade the embeddings quality
+		Input: []string{strings.Join(strings.Fields(prompt), " ")},
+		Model: openai.EmbeddingModel(model),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating embedding: %w", err)
+	}
+	if len(resp.Data) == 0 {
+		return nil, fmt.Errorf("no embedding returned by OpenAI")
+	}
+	return resp.Data[0].Embedding, nil
+}
+
+// cosineSimilarity returns the cosine similarity of a and b, or 0 if they
+// have different dimensions or one of them is zero
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// rankSimilarPrompts returns up to n stored prompts with a similarity of at
+// least threshold to query, most similar first
+func rankSimilarPrompts(query []float32, stored []storage.PromptEmbedding, n int, threshold float64) []similarPrompt {
+	var similar []similarPrompt
+	for _, e := range stored {
+		sim := cosineSimilarity(query, e.Vector)
+		if sim >= threshold {
+			similar = append(similar, similarPrompt{ID: e.ID, Similarity: sim})
+		}
+	}
+	sort.SliceStable(similar, func(i, j int) bool {
+		return similar[i].Similarity > similar[j].Similarity
+	})
+	if len(similar) > n {
+		similar = similar[:n]
+	}
+	return similar
+}
+
+// suggestSimilarPrompts prints the stored prompts similar to prompt and returns
+// the prompt embedding so it can be saved with the generation
+func suggestSimilarPrompts(ctx context.Context, out io.Writer, client *openai.Client, model, prompt string) ([]float32, error) {
+	embedding, err := embedPrompt(ctx, client, model, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := storage.NewSQLiteStorage()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize history database: %w", err)
+	}
+	defer db.Close()
+
+	stored, err := db.ListEmbeddings()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range rankSimilarPrompts(embedding, stored, maxSimilarPrompts, similarPromptThreshold) {
+		fmt.Fprintf(out, "You generated something similar before (ID %d, %.0f%% similar), see dsg show %d\n", s.ID, s.Similarity*100, s.ID)
+	}
+
+	return embedding, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	storage "github.com/rubiojr/dsg/internal/storage/sqlite"
+)
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b []float32
+		want float64
+	}{
+		{[]float32{1, 0}, []float32{2, 0}, 1},
+		{[]float32{1, 0}, []float32{0, 3}, 0},
+		{[]float32{1, 0}, []float32{-1, 0}, -1},
+		{[]float32{1, 1}, []float32{1, 0}, 1 / math.Sqrt2},
+		// Zero vectors and different dimensions
+		{[]float32{0, 0}, []float32{1, 0}, 0},
+		{[]float32{1, 0}, []float32{1, 0, 0}, 0},
+	}
+	for _, tt := range tests {
+		if got := cosineSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("cosineSimilarity(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestRankSimilarPrompts(t *testing.T) {
+	query := []float32{1, 0, 0}
+	stored := []storage.PromptEmbedding{
+		{ID: 1, Vector: []float32{0, 1, 0}},     // 0
+		{ID: 2, Vector: []float32{1, 0.1, 0}},   // 0.995
+		{ID: 3, Vector: []float32{1, 0.5, 0}},   // 0.894
+		{ID: 4, Vector: []float32{2, 0, 0}},     // 1
+		{ID: 5, Vector: []float32{1, 0.3, 0.3}}, // 0.921
+		{ID: 6, Vector: []float32{1, 0}},        // different dimensions
+		{ID: 7, Vector: []float32{1, 1, 0}},     // 0.707
+	}
+
+	ids := func(similar []similarPrompt) []int64 {
+		var ids []int64
+		for _, s := range similar {
+			ids = append(ids, s.ID)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		n         int
+		threshold float64
+		want      []int64
+	}{
+		{n: 10, threshold: 0.85, want: []int64{4, 2, 5, 3}},
+		{n: 2, threshold: 0.85, want: []int64{4, 2}},
+		{n: 10, threshold: 0.95, want: []int64{4, 2}},
+		{n: 10, threshold: 0.5, want: []int64{4, 2, 5, 3, 7}},
+		{n: 10, threshold: 1.1, want: nil},
+	}
+	for _, tt := range tests {
+		got := ids(rankSimilarPrompts(query, stored, tt.n, tt.threshold))
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("rankSimilarPrompts(n=%d, threshold=%v) = %v, want %v", tt.n, tt.threshold, got, tt.want)
+		}
+	}
+
+	similar := rankSimilarPrompts(query, stored, 1, similarPromptThreshold)
+	if len(similar) != 1 || math.Abs(similar[0].Similarity-1) > 1e-9 {
+		t.Errorf("most similar = %+v", similar)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+)
+
+// generateSummary is the machine readable result of generate --summary json
+type generateSummary struct {
+	HistoryID  int64    `json:"history_id"`
+	URNs       []string `json:"urns"`
+	Count      int      `json:"count"`
+	SchemaName string   `json:"schema_name"`
+	Tokens     int      `json:"tokens"`
+	Posted     bool     `json:"posted"`
+	Errors     []string `json:"errors"`
+}
+
+func newGenerateSummary() *generateSummary {
+	return &generateSummary{
+		HistoryID: -1,
+		URNs:      []string{},
+		Errors:    []string{},
+	}
+}
+
+// setGeneration fills the summary with the generation results
+func (s *generateSummary) setGeneration(gen *generation) {
+	s.URNs = gen.URNs
+	s.Count = len(gen.URNs)
+	s.SchemaName = gen.SchemaName
+	s.Tokens = gen.Tokens
+}
+
+// print writes the summary as a single line JSON object, including err
+// (one entry per failed entity if posting failed) in the errors field
+func (s *generateSummary) print(w io.Writer, err error) {
+	if err != nil {
+		var batchErr *datahub.BatchError
+		if errors.As(err, &batchErr) {
+			for _, f := range batchErr.Failures {
+				s.Errors = append(s.Errors, f.Error())
+			}
+		} else {
+			s.Errors = append(s.Errors, err.Error())
+		}
+	}
+
+	data, jerr := json.Marshal(s)
+	if jerr != nil {
+		fmt.Fprintf(w, `{"errors":[%q]}`+"\n", jerr.Error())
+		return
+	}
+	fmt.Fprintln(w, string(data))
+}
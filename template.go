@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	storage "github.com/rubiojr/dsg/internal/storage/sqlite"
+)
+
+// outputTemplateFuncs are the functions available to the --template flag
+var outputTemplateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"truncate": truncateString,
+	"upper":    strings.ToUpper,
+	"lower":    strings.ToLower,
+}
+
+// parseOutputTemplate parses a --template flag value, a Go text/template
+// executed for every history entry (e.g. '{{.ID}} {{.SchemaName}}').
+// The template is checked against an empty entry so that unknown fields
+// are reported before anything is printed.
+func parseOutputTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("output").Funcs(outputTemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, usagef("invalid --template: %v", err)
+	}
+	if err := tmpl.Execute(io.Discard, &storage.Response{}); err != nil {
+		return nil, usagef("invalid --template: %v", err)
+	}
+	return tmpl, nil
+}
+
+// printTemplate executes tmpl for each response, one entry per line
+func printTemplate(w io.Writer, tmpl *template.Template, responses ...*storage.Response) error {
+	for _, resp := range responses {
+		if err := tmpl.Execute(w, resp); err != nil {
+			return fmt.Errorf("error executing the template for entry %d: %w", resp.ID, err)
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	storage "github.com/rubiojr/dsg/internal/storage/sqlite"
+)
+
+func TestOutputTemplate(t *testing.T) {
+	dataDir := testDataDir(t)
+	ids := seedHistory(t, dataDir,
+		&storage.Response{Prompt: "p1", Response: "[]", SchemaName: "alpha", Tokens: 10},
+		&storage.Response{Prompt: "p2", Response: "[]", SchemaName: "beta", Tokens: 20},
+	)
+
+	out, err := runApp(t, "history", "--template", "{{.ID}} {{.SchemaName}} {{.Tokens}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := fmt.Sprintf("%d beta 20\n%d alpha 10\n", ids[1], ids[0]); out != want {
+		t.Errorf("history --template printed %q, want %q", out, want)
+	}
+
+	out, err = runApp(t, "show", "--template", `{{upper .SchemaName}}|{{.Status}}|{{json .Prompt}}`, fmt.Sprint(ids[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != `ALPHA|generated|"p1"`+"\n" {
+		t.Errorf("show --template printed %q", out)
+	}
+}
+
+func TestOutputTemplateErrors(t *testing.T) {
+	testDataDir(t)
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"history", "--template", "{{.ID"}, "invalid --template"},
+		{[]string{"history", "--template", "{{.NoSuchField}}"}, "invalid --template"},
+		{[]string{"history", "--template", "{{.ID}}", "--json"}, "mutually exclusive"},
+		{[]string{"show", "--template", "{{.ID", "1"}, "invalid --template"},
+	}
+	for _, tt := range tests {
+		out, err := runApp(t, tt.args...)
+		if exitCode(err) != exitUsage || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: err = %v, want a usage error with %q", tt.args, err, tt.want)
+		}
+		if out != "" {
+			t.Errorf("%q printed %q", tt.args, out)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+	"github.com/urfave/cli/v2"
+)
+
+func runTouch(c *cli.Context) error {
+	urn := c.String("urn")
+	if _, _, _, err := datahub.ParseDatasetURN(urn); err != nil {
+		return err
+	}
+
+	dh, err := newClientFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	version, err := dh.TouchDataset(urn, c.String("actor"))
+	if err != nil {
+		return fmt.Errorf("error touching %s: %w", urn, err)
+	}
+
+	fmt.Printf("Touched %s (schema version %d)\n", urn, version)
+	return nil
+}
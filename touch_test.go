@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rubiojr/dsg/internal/datahub"
+)
+
+func TestTouchCommand(t *testing.T) {
+	dh := newDataHubStub(t)
+	urn := datasetURN("users")
+	dh.entities[urn] = `{"urn": "` + urn + `", "schemaMetadata": {"value": {"schemaName": "users", "version": 1, "fields": []}}}`
+
+	out, err := runApp(t, "touch", "--datahub-gms-url", dh.URL, "--urn", urn, "--actor", "urn:li:corpuser:alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "Touched "+urn+" (schema version 2)") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	if len(dh.posted) != 1 {
+		t.Fatalf("posted %d entities, want 1", len(dh.posted))
+	}
+	var aspect struct {
+		Value struct {
+			Version      int                `json:"version"`
+			LastModified datahub.AuditStamp `json:"lastModified"`
+		} `json:"value"`
+	}
+	json.Unmarshal(dh.posted[0]["schemaMetadata"], &aspect)
+	if aspect.Value.Version != 2 || aspect.Value.LastModified.Actor != "urn:li:corpuser:alice" {
+		t.Errorf("posted schemaMetadata %s", dh.posted[0]["schemaMetadata"])
+	}
+
+	// Missing datasets aren't created
+	_, err = runApp(t, "touch", "--datahub-gms-url", dh.URL, "--urn", datasetURN("missing"))
+	if !errors.Is(err, datahub.ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+	if _, err := runApp(t, "touch", "--datahub-gms-url", dh.URL, "--urn", "urn:li:dataset:bad"); err == nil {
+		t.Error("expected an error for an invalid URN")
+	}
+	if len(dh.posted) != 1 {
+		t.Errorf("posted %d entities, want 1", len(dh.posted))
+	}
+}
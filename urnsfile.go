@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// writeURNsFile writes urns to the --output-urns-file path, if set, one per
+// line or as a JSON array when the file name ends in .json. Commands call it
+// only once everything was posted, so later pipeline steps never see a
+// partial list.
+func writeURNsFile(c *cli.Context, urns []string) error {
+	path := c.String("output-urns-file")
+	if path == "" {
+		return nil
+	}
+
+	var data []byte
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		if urns == nil {
+			urns = []string{}
+		}
+		var err error
+		if data, err = json.MarshalIndent(urns, "", "  "); err != nil {
+			return fmt.Errorf("error encoding URNs: %w", err)
+		}
+		data = append(data, '\n')
+	} else {
+		for _, urn := range urns {
+			data = append(data, urn+"\n"...)
+		}
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing URNs file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+
+	storage "github.com/rubiojr/dsg/internal/storage/sqlite"
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestOutputURNsFile(t *testing.T) {
+	dataDir := testDataDir(t)
+	dh := newDataHubStub(t)
+	dir := t.TempDir()
+	input := filepath.Join(dir, "datasets.json")
+	os.WriteFile(input, []byte(datasetJSON("alpha", "beta")), 0644)
+
+	urnsFile := filepath.Join(dir, "urns.txt")
+	if _, err := runApp(t, "from-json", "--output-urns-file", urnsFile, "--datahub-gms-url", dh.URL, input); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(urnsFile)
+	if want := datasetURN("alpha") + "\n" + datasetURN("beta") + "\n"; string(data) != want {
+		t.Errorf("URNs file = %q, want %q", data, want)
+	}
+
+	// A .json file gets a JSON array
+	jsonFile := filepath.Join(dir, "urns.json")
+	ids := seedHistory(t, dataDir, &storage.Response{Prompt: "gamma", Response: datasetJSON("gamma")})
+	if _, err := runApp(t, "post", "--output-urns-file", jsonFile, "--datahub-gms-url", dh.URL, strconv.FormatInt(ids[0], 10)); err != nil {
+		t.Fatal(err)
+	}
+	var urns []string
+	data, _ = os.ReadFile(jsonFile)
+	if err := json.Unmarshal(data, &urns); err != nil || !slices.Equal(urns, []string{datasetURN("gamma")}) {
+		t.Errorf("URNs file = %s, err = %v", data, err)
+	}
+
+	apiBase, _ := openAIStub(t, func(req openai.ChatCompletionRequest) string { return datasetJSON(userInput(req)) })
+	withStdin(t, "delta\n")
+	if _, err := runApp(t, "generate", "--output-urns-file", urnsFile, "--api-key", "test", "--api-base", apiBase,
+		"--model", "m", "--datahub-gms-url", dh.URL); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(urnsFile); string(data) != datasetURN("delta")+"\n" {
+		t.Errorf("URNs file = %q", data)
+	}
+}
+
+func TestOutputURNsFileFailure(t *testing.T) {
+	dh := newDataHubStub(t)
+	dh.fail = func(urn string) bool { return urn == datasetURN("beta") }
+	dir := t.TempDir()
+	input := filepath.Join(dir, "datasets.json")
+	os.WriteFile(input, []byte(datasetJSON("alpha", "beta")), 0644)
+	urnsFile := filepath.Join(dir, "urns.txt")
+
+	for _, flags := range [][]string{nil, {"--continue-on-error"}} {
+		args := append(append([]string{"from-json", "--output-urns-file", urnsFile, "--datahub-gms-url", dh.URL}, flags...), input)
+		if _, err := runApp(t, args...); err == nil {
+			t.Errorf("%q: expected an error", flags)
+		}
+		if _, err := os.Stat(urnsFile); !os.IsNotExist(err) {
+			t.Errorf("%q: the URNs file was written after a failure: %v", flags, err)
+		}
+	}
+}